@@ -5,6 +5,7 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
 	"os"
@@ -17,18 +18,42 @@ import (
 	"github.com/rsned/spacemolt-crafting-server/internal/crafting/engine"
 	"github.com/rsned/spacemolt-crafting-server/internal/crafting/mcp"
 	"github.com/rsned/spacemolt-crafting-server/internal/crafting/sync"
+	"github.com/rsned/spacemolt-crafting-server/internal/version"
 )
 
 func main() {
 	// Parse flags
 	dbPath := flag.String("db", "data/crafting/crafting.db", "Path to SQLite database")
 	httpAddr := flag.String("http", "", "Start HTTP server on specified address (e.g., ':8080')")
-	importItems := flag.String("import-items", "", "Import items from JSON file")
-	importRecipes := flag.String("import-recipes", "", "Import recipes from JSON file")
-	importSkills := flag.String("import-skills", "", "Import skills from JSON file")
-	importMarket := flag.String("import-market", "", "Import market data from JSON file")
+	transport := flag.String("transport", string(mcp.TransportNewline), "MCP message framing: 'newline' or 'content-length'")
+	toolTimeout := flag.Duration("tool-timeout", 5*time.Second, "Maximum time a single MCP tool call may run (0 disables)")
+	importItems := flag.String("import-items", "", "Import items from JSON file ('-' for stdin)")
+	importRecipes := flag.String("import-recipes", "", "Import recipes from JSON file ('-' for stdin)")
+	importSkills := flag.String("import-skills", "", "Import skills from JSON file ('-' for stdin)")
+	importMarket := flag.String("import-market", "", "Import market data from JSON file ('-' for stdin)")
+	importAcquisition := flag.String("import-acquisition", "", "Import non-market acquisition sources from JSON file ('-' for stdin)")
+	exportRecipes := flag.String("export-recipes", "", "Export all recipes to a JSON file in the import format ('-' for stdout)")
+	exportSkills := flag.String("export-skills", "", "Export all skills to a JSON file in the import format ('-' for stdout)")
+	exportMarket := flag.String("export-market", "", "Export recorded market prices to a JSON file in the import format ('-' for stdout)")
+	dryRun := flag.Bool("dry-run", false, "Report what the imports would change, and any validation problems, without writing them")
+	deleteRecipe := flag.String("delete-recipe", "", "Delete a single recipe by ID, e.g. one removed from the game")
+	deleteSkill := flag.String("delete-skill", "", "Delete a single skill by ID, e.g. one removed from the game")
+	strictMarket := flag.Bool("strict-market", false, "Fail -import-market on any row with a negative price or volume, or a sell price below its buy price, instead of skipping it")
+	incrementalMarket := flag.Bool("incremental-market", false, "Only refresh price summaries for markets touched by -import-market")
+	trendWindow := flag.Duration("trend-window", 24*time.Hour, "Prices newer than this count as recent when computing price trends")
+	trendThreshold := flag.Float64("trend-threshold", 0.05, "Fractional price change needed to report a rising or falling trend")
+	trendMidpoint := flag.Bool("trend-midpoint", false, "Compute price trends by splitting each market's data at its midpoint instead of -trend-window")
+	warmup := flag.Bool("warmup", true, "Load the recipe catalog at startup so the first MCP query is fast")
+	metrics := flag.Bool("metrics", false, "Collect tool call counts and latencies in memory and expose them through the metrics tool")
+	staleAfter := flag.Duration("stale-after", engine.DefaultStaleDataAge, "Warn when market data behind a profit figure is older than this (0 disables)")
+	slowQuery := flag.Duration("slow-query", engine.DefaultSlowQueryThreshold, "Log a warning with per-phase timings for queries slower than this (0 disables)")
+	maxResults := flag.Int("max-results", engine.DefaultMaxResults, "Most results per list a query may return, whatever limit it asks for")
 	gameVersion := flag.String("game-version", "", "Game server version (e.g., 'v0.142.7')")
-	showVersion := flag.Bool("version", false, "Show database version information and exit")
+	validate := flag.Bool("validate", false, "Check imported data for broken skill references and non-cumulative XP thresholds and exit")
+	readOnly := flag.Bool("read-only", false, "Open an existing, up-to-date database without write access; imports and maintenance are rejected")
+	optimize := flag.Bool("optimize", false, "Refresh query planner statistics and checkpoint the WAL, then exit")
+	vacuum := flag.Bool("vacuum", false, "With -optimize, also VACUUM the database to reclaim free space")
+	showVersion := flag.Bool("version", false, "Show server and database version information and exit")
 	verbose := flag.Bool("verbose", false, "Enable verbose logging")
 	flag.Parse()
 
@@ -55,7 +80,15 @@ func main() {
 	}()
 
 	// Open database
-	database, err := db.OpenAndInit(ctx, *dbPath)
+	var (
+		database *db.DB
+		err      error
+	)
+	if *readOnly {
+		database, err = db.OpenReadOnly(ctx, *dbPath)
+	} else {
+		database, err = db.OpenAndInit(ctx, *dbPath)
+	}
 	if err != nil {
 		logger.Error("failed to open database", "error", err)
 		os.Exit(1)
@@ -64,66 +97,151 @@ func main() {
 
 	// Handle version query
 	if *showVersion {
-		version, err := database.GetVersion(ctx)
+		fmt.Printf("Server Version: %s\n", version.String())
+
+		schemaVersion, err := db.SchemaVersion(ctx, database)
+		if err != nil {
+			logger.Error("failed to get schema version", "error", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Schema Version: %d\n", schemaVersion)
+
+		dbVersion, err := database.GetVersion(ctx)
 		if err != nil {
 			logger.Error("failed to get version", "error", err)
 			os.Exit(1)
 		}
-		if version == nil {
+		if dbVersion == nil {
 			fmt.Println("No version information available in database")
 			os.Exit(0)
 		}
-		fmt.Printf("Game Version: %s\n", version.GameVersion)
-		fmt.Printf("Imported At: %s\n", version.ImportedAt.Format("2006-01-02 15:04:05 MST"))
-		fmt.Printf("Updated At:  %s\n", version.UpdatedAt.Format("2006-01-02 15:04:05 MST"))
+		fmt.Printf("Game Version: %s\n", dbVersion.GameVersion)
+		fmt.Printf("Imported At: %s\n", dbVersion.ImportedAt.Format("2006-01-02 15:04:05 MST"))
+		fmt.Printf("Updated At:  %s\n", dbVersion.UpdatedAt.Format("2006-01-02 15:04:05 MST"))
 		os.Exit(0)
 	}
 
-	// Handle import commands
-	if *importItems != "" || *importRecipes != "" || *importSkills != "" || *importMarket != "" {
+	exporting := *exportRecipes != "" || *exportSkills != "" || *exportMarket != ""
+
+	// Handle import and delete commands
+	if *importItems != "" || *importRecipes != "" || *importSkills != "" || *importMarket != "" || *importAcquisition != "" ||
+		*deleteRecipe != "" || *deleteSkill != "" {
+		if *dryRun && (*deleteRecipe != "" || *deleteSkill != "") {
+			logger.Error("-dry-run applies to imports only and cannot be combined with -delete-recipe or -delete-skill")
+			os.Exit(1)
+		}
+
 		syncer := sync.NewSyncer(database)
+		syncer.SetDryRun(*dryRun)
 
-		// Track if any imports happened
+		// Track if any data changed
 		imported := false
 
 		if *importItems != "" {
 			logger.Info("importing items", "file", *importItems)
-			if err := syncer.ImportItemsFromFile(ctx, *importItems); err != nil {
+			var result sync.ImportResult
+			if err := importFrom(*importItems, func(r io.Reader) (err error) { result, err = syncer.ImportItems(ctx, r); return err }); err != nil {
 				logger.Error("failed to import items", "error", err)
 				os.Exit(1)
 			}
-			logger.Info("items imported successfully")
-			imported = true
+			logImportResult(logger, "items", result)
+			imported = !*dryRun
 		}
 
 		if *importRecipes != "" {
 			logger.Info("importing recipes", "file", *importRecipes)
-			if err := syncer.ImportRecipesFromFile(ctx, *importRecipes); err != nil {
+			var result sync.ImportResult
+			if err := importFrom(*importRecipes, func(r io.Reader) (err error) { result, err = syncer.ImportRecipes(ctx, r); return err }); err != nil {
 				logger.Error("failed to import recipes", "error", err)
 				os.Exit(1)
 			}
-			logger.Info("recipes imported successfully")
-			imported = true
+			logImportResult(logger, "recipes", result)
+			imported = !*dryRun
 		}
 
 		if *importSkills != "" {
 			logger.Info("importing skills", "file", *importSkills)
-			if err := syncer.ImportSkillsFromFile(ctx, *importSkills); err != nil {
+			var result sync.ImportResult
+			if err := importFrom(*importSkills, func(r io.Reader) (err error) { result, err = syncer.ImportSkills(ctx, r); return err }); err != nil {
 				logger.Error("failed to import skills", "error", err)
 				os.Exit(1)
 			}
-			logger.Info("skills imported successfully")
-			imported = true
+			logImportResult(logger, "skills", result)
+			imported = !*dryRun
 		}
 
 		if *importMarket != "" {
-			logger.Info("importing market data", "file", *importMarket)
-			if err := syncer.ImportMarketDataFromFile(ctx, *importMarket); err != nil {
+			logger.Info("importing market data", "file", *importMarket, "incremental", *incrementalMarket, "strict", *strictMarket)
+			marketOpts := sync.MarketImportOptions{
+				Incremental: *incrementalMarket,
+				Strict:      *strictMarket,
+				Trend: db.TrendConfig{
+					RecentWindow:     *trendWindow,
+					SplitAtMidpoint:  *trendMidpoint,
+					RisingThreshold:  *trendThreshold,
+					FallingThreshold: *trendThreshold,
+				},
+			}
+			var result sync.MarketImportResult
+			err := importFrom(*importMarket, func(r io.Reader) (err error) {
+				result, err = syncer.ImportMarketDataWithOptions(ctx, r, marketOpts)
+				return err
+			})
+			for _, row := range result.Rejected {
+				logger.Warn("rejected market row", "row", row)
+			}
+			if err != nil {
 				logger.Error("failed to import market data", "error", err)
 				os.Exit(1)
 			}
-			logger.Info("market data imported successfully")
-			imported = true
+			if *dryRun {
+				logger.Info("dry run: market data import is valid, nothing written", "rows", result.Imported, "rejected", len(result.Rejected))
+			} else {
+				logger.Info("market data imported successfully", "rows", result.Imported, "rejected", len(result.Rejected))
+				imported = true
+			}
+		}
+
+		if *importAcquisition != "" {
+			logger.Info("importing acquisition sources", "file", *importAcquisition)
+			if err := importFrom(*importAcquisition, func(r io.Reader) error { return syncer.ImportAcquisitionSources(ctx, r) }); err != nil {
+				logger.Error("failed to import acquisition sources", "error", err)
+				os.Exit(1)
+			}
+			if *dryRun {
+				logger.Info("dry run: acquisition sources import is valid, nothing written")
+			} else {
+				logger.Info("acquisition sources imported successfully")
+				imported = true
+			}
+		}
+
+		if *deleteRecipe != "" {
+			deleted, err := syncer.DeleteRecipe(ctx, *deleteRecipe)
+			if err != nil {
+				logger.Error("failed to delete recipe", "recipe_id", *deleteRecipe, "error", err)
+				os.Exit(1)
+			}
+			if deleted {
+				logger.Info("recipe deleted", "recipe_id", *deleteRecipe)
+				imported = true
+			} else {
+				logger.Warn("recipe not found", "recipe_id", *deleteRecipe)
+			}
+		}
+
+		if *deleteSkill != "" {
+			deleted, err := syncer.DeleteSkill(ctx, *deleteSkill)
+			if err != nil {
+				logger.Error("failed to delete skill", "skill_id", *deleteSkill, "error", err)
+				os.Exit(1)
+			}
+			if deleted {
+				logger.Info("skill deleted", "skill_id", *deleteSkill)
+				imported = true
+			} else {
+				logger.Warn("skill not found", "skill_id", *deleteSkill)
+			}
 		}
 
 		// Update version info if game-version was provided
@@ -142,14 +260,88 @@ func main() {
 			}
 		}
 
-		// If only doing imports, exit
-		if flag.NArg() == 0 {
+		// If only doing imports or deletes, exit
+		if flag.NArg() == 0 && !*validate && !*optimize && !exporting {
 			return
 		}
 	}
 
+	// Handle exports (runs after any imports so they show what landed)
+	if exporting {
+		syncer := sync.NewSyncer(database)
+		exports := []struct {
+			name string
+			path string
+			fn   func(w io.Writer) error
+		}{
+			{"recipes", *exportRecipes, func(w io.Writer) error { return syncer.ExportRecipes(ctx, w) }},
+			{"skills", *exportSkills, func(w io.Writer) error { return syncer.ExportSkills(ctx, w) }},
+			{"market data", *exportMarket, func(w io.Writer) error { return syncer.ExportMarketData(ctx, w) }},
+		}
+		for _, exp := range exports {
+			if exp.path == "" {
+				continue
+			}
+			logger.Info("exporting "+exp.name, "file", exp.path)
+			if err := exportTo(exp.path, exp.fn); err != nil {
+				logger.Error("failed to export "+exp.name, "error", err)
+				os.Exit(1)
+			}
+		}
+		if !*validate && !*optimize {
+			return
+		}
+	}
+
+	// Handle maintenance (runs after any imports so their freed pages are reclaimed)
+	if *optimize {
+		logger.Info("optimizing database", "vacuum", *vacuum)
+		if err := database.Optimize(ctx, *vacuum); err != nil {
+			logger.Error("failed to optimize database", "error", err)
+			os.Exit(1)
+		}
+		logger.Info("database optimized")
+		if !*validate {
+			return
+		}
+	}
+
+	// Handle validation (runs after any imports so freshly imported data is checked)
+	if *validate {
+		syncer := sync.NewSyncer(database)
+		report, err := syncer.ValidateReferences(ctx)
+		if err != nil {
+			logger.Error("failed to validate data", "error", err)
+			os.Exit(1)
+		}
+		for _, ref := range report.MissingSkillReferences {
+			logger.Warn("skill prerequisite references missing skill",
+				"skill_id", ref.SkillID,
+				"missing_skill_id", ref.MissingSkillID,
+				"level_required", ref.LevelRequired)
+		}
+		for _, th := range report.NonIncreasingThresholds {
+			logger.Warn("skill XP threshold is not cumulative",
+				"skill_id", th.SkillID,
+				"level", th.Level,
+				"xp_required", th.XPRequired,
+				"previous_xp", th.PreviousXP)
+		}
+		if report.HasProblems() {
+			logger.Error("validation failed",
+				"missing_skill_references", len(report.MissingSkillReferences),
+				"non_increasing_thresholds", len(report.NonIncreasingThresholds))
+			os.Exit(1)
+		}
+		logger.Info("validation passed")
+		return
+	}
+
 	// Create engine and server
-	eng := engine.New(database)
+	eng := engine.New(database, logger)
+	eng.SetStaleDataAge(*staleAfter)
+	eng.SetSlowQueryThreshold(*slowQuery)
+	eng.SetMaxResults(*maxResults)
 
 	// Choose server mode based on flags
 	if *httpAddr != "" {
@@ -169,6 +361,25 @@ func main() {
 	} else {
 		// MCP server mode (default)
 		server := mcp.NewServer(eng, logger)
+		mode, err := mcp.ParseTransport(*transport)
+		if err != nil {
+			logger.Error("invalid transport", "error", err)
+			os.Exit(1)
+		}
+		server.SetTransport(mode)
+		server.SetToolTimeout(*toolTimeout)
+		if *metrics {
+			server.SetMetrics(mcp.NewInMemoryMetrics())
+		}
+		if *warmup {
+			start := time.Now()
+			if n, err := eng.Warmup(ctx); err != nil {
+				// Not fatal: recipes still load on the first query
+				logger.Warn("failed to warm up recipe catalog", "error", err)
+			} else {
+				logger.Info("recipe catalog loaded", "recipes", n, "elapsed", time.Since(start))
+			}
+		}
 
 		logger.Info("starting MCP server", "db", *dbPath)
 		if err := server.Run(ctx); err != nil && ctx.Err() == nil {
@@ -179,3 +390,63 @@ func main() {
 
 	fmt.Fprintln(os.Stderr, "server stopped")
 }
+
+// logImportResult logs the counts from an import, along with any problems
+// a dry run found in the data it would have written.
+func logImportResult(logger *slog.Logger, name string, result sync.ImportResult) {
+	if !result.DryRun {
+		logger.Info(name+" imported successfully", "result", result)
+		return
+	}
+
+	logger.Info("dry run: "+name+" import would make these changes, nothing written", "result", result)
+	if result.Validation == nil {
+		return
+	}
+	for _, ref := range result.Validation.MissingSkillReferences {
+		logger.Warn("skill prerequisite would reference missing skill",
+			"skill_id", ref.SkillID,
+			"missing_skill_id", ref.MissingSkillID,
+			"level_required", ref.LevelRequired)
+	}
+	for _, th := range result.Validation.NonIncreasingThresholds {
+		logger.Warn("skill XP threshold would not be cumulative",
+			"skill_id", th.SkillID,
+			"level", th.Level,
+			"xp_required", th.XPRequired,
+			"previous_xp", th.PreviousXP)
+	}
+}
+
+// importFrom opens path and passes it to fn. A path of "-" reads from stdin
+// so data can be piped straight in from another command.
+func importFrom(path string, fn func(r io.Reader) error) error {
+	if path == "-" {
+		return fn(os.Stdin)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("opening file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	return fn(f)
+}
+
+// exportTo calls fn with a writer for path, or stdout when path is "-".
+func exportTo(path string, fn func(w io.Writer) error) error {
+	if path == "-" {
+		return fn(os.Stdout)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("creating file: %w", err)
+	}
+	if err := fn(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -72,7 +72,7 @@ func main() {
 	}()
 
 	// Initialize engine
-	eng := engine.New(database)
+	eng := engine.New(database, log)
 
 	// Run all tests
 	results := runAllTests(ctx, eng, log, *verbose)
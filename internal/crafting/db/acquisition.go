@@ -0,0 +1,82 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// AcquisitionSource records one non-market way an item can be obtained,
+// such as mining it at a belt or looting it from a particular NPC.
+type AcquisitionSource struct {
+	ItemID string
+	Method string // e.g. "mine", "loot", "mission", "vendor"
+	Detail string // free-form location or source, may be empty
+}
+
+// ItemAcquisitionStore handles acquisition source operations.
+type ItemAcquisitionStore struct {
+	db *DB
+}
+
+// NewItemAcquisitionStore creates a new acquisition source store.
+func NewItemAcquisitionStore(db *DB) *ItemAcquisitionStore {
+	return &ItemAcquisitionStore{db: db}
+}
+
+// GetSources returns every known acquisition source for an item, ordered by
+// method and detail. Items with no recorded sources return an empty slice.
+func (s *ItemAcquisitionStore) GetSources(ctx context.Context, itemID string) ([]AcquisitionSource, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT item_id, method, detail
+		FROM acquisition_sources
+		WHERE item_id = ?
+		ORDER BY method, detail
+	`, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("querying acquisition sources: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var sources []AcquisitionSource
+	for rows.Next() {
+		var src AcquisitionSource
+		if err := rows.Scan(&src.ItemID, &src.Method, &src.Detail); err != nil {
+			return nil, fmt.Errorf("scanning acquisition source: %w", err)
+		}
+		sources = append(sources, src)
+	}
+	return sources, rows.Err()
+}
+
+// ReplaceSources replaces the full set of acquisition sources in a single
+// transaction. Duplicate entries are collapsed.
+func (s *ItemAcquisitionStore) ReplaceSources(ctx context.Context, sources []AcquisitionSource) error {
+	return s.db.InTransaction(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM acquisition_sources`); err != nil {
+			return fmt.Errorf("clearing acquisition sources: %w", err)
+		}
+
+		stmt, err := tx.PrepareContext(ctx, `
+			INSERT OR IGNORE INTO acquisition_sources (item_id, method, detail)
+			VALUES (?, ?, ?)
+		`)
+		if err != nil {
+			return fmt.Errorf("preparing insert statement: %w", err)
+		}
+		defer func() { _ = stmt.Close() }()
+
+		for _, src := range sources {
+			if _, err := stmt.ExecContext(ctx, src.ItemID, src.Method, src.Detail); err != nil {
+				return fmt.Errorf("inserting acquisition source for %s: %w", src.ItemID, err)
+			}
+		}
+		return nil
+	})
+}
+
+// ClearSources removes all acquisition source data.
+func (s *ItemAcquisitionStore) ClearSources(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM acquisition_sources`)
+	return err
+}
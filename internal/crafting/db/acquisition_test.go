@@ -0,0 +1,56 @@
+package db
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestItemAcquisitionStore_ReplaceSources(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	store := NewItemAcquisitionStore(db)
+
+	err := store.ReplaceSources(ctx, []AcquisitionSource{
+		{ItemID: "ore_iron", Method: "mine", Detail: "Sol belt"},
+		{ItemID: "ore_iron", Method: "loot", Detail: "Drones"},
+		{ItemID: "ore_iron", Method: "mine", Detail: "Sol belt"}, // duplicate
+		{ItemID: "insignia", Method: "mission"},
+	})
+	if err != nil {
+		t.Fatalf("ReplaceSources: %v", err)
+	}
+
+	got, err := store.GetSources(ctx, "ore_iron")
+	if err != nil {
+		t.Fatalf("GetSources: %v", err)
+	}
+	want := []AcquisitionSource{
+		{ItemID: "ore_iron", Method: "loot", Detail: "Drones"},
+		{ItemID: "ore_iron", Method: "mine", Detail: "Sol belt"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("sources = %+v, want %+v", got, want)
+	}
+
+	// A second import replaces the first rather than merging with it.
+	if err := store.ReplaceSources(ctx, []AcquisitionSource{{ItemID: "insignia", Method: "vendor"}}); err != nil {
+		t.Fatalf("ReplaceSources: %v", err)
+	}
+	got, err = store.GetSources(ctx, "ore_iron")
+	if err != nil {
+		t.Fatalf("GetSources: %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("expected stale sources to be removed, got %+v", got)
+	}
+	got, err = store.GetSources(ctx, "insignia")
+	if err != nil {
+		t.Fatalf("GetSources: %v", err)
+	}
+	if want := []AcquisitionSource{{ItemID: "insignia", Method: "vendor"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("sources = %+v, want %+v", got, want)
+	}
+}
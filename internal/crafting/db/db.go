@@ -3,28 +3,107 @@ package db
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	_ "modernc.org/sqlite"
 )
 
+// ErrReadOnly reports an attempt to modify a database opened with
+// OpenReadOnly.
+var ErrReadOnly = errors.New("database is read-only")
+
 // DB wraps a sql.DB with crafting-specific methods.
 type DB struct {
 	*sql.DB
-	catPri *CategoryPriorityStore
+	catPri   *CategoryPriorityStore
+	readOnly bool
+
+	// recipeGen increments whenever recipe data is written, so caches
+	// built on top of any RecipeStore can tell when they are stale.
+	recipeGen atomic.Uint64
+
+	// Prepared statements for the GetRecipe hot path, created on first use
+	stmtMu      sync.Mutex
+	recipeStmts *recipeStmts
 }
 
-// Open opens a SQLite database at the given path.
+// Close releases the prepared statements and closes the database.
+func (db *DB) Close() error {
+	db.stmtMu.Lock()
+	if db.recipeStmts != nil {
+		db.recipeStmts.close()
+		db.recipeStmts = nil
+	}
+	db.stmtMu.Unlock()
+	return db.DB.Close()
+}
+
+// RecipeGeneration returns a counter that changes whenever recipe data is
+// written through a RecipeStore.
+func (db *DB) RecipeGeneration() uint64 {
+	return db.recipeGen.Load()
+}
+
+// PoolConfig controls the connection pool and lock waiting behaviour of a
+// database opened with OpenWithConfig.
+type PoolConfig struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+
+	// BusyTimeout is how long a connection waits on a locked database
+	// before failing with "database is locked".
+	BusyTimeout time.Duration
+}
+
+// DefaultPoolConfig returns pool settings suited to the read-heavy MCP
+// workload: a handful of connections so WAL readers can run alongside a
+// writer, kept open for reuse, with a few seconds of lock waiting.
+func DefaultPoolConfig() PoolConfig {
+	return PoolConfig{
+		MaxOpenConns:    8,
+		MaxIdleConns:    8,
+		ConnMaxLifetime: time.Hour,
+		BusyTimeout:     5 * time.Second,
+	}
+}
+
+// Open opens a SQLite database at the given path using DefaultPoolConfig.
 // If the path is ":memory:", an in-memory database is created.
 func Open(path string) (*DB, error) {
-	// Enable foreign keys and WAL mode for better concurrency
-	dsn := fmt.Sprintf("%s?_foreign_keys=on&_journal_mode=WAL", path)
+	return OpenWithConfig(path, DefaultPoolConfig())
+}
+
+// OpenWithConfig opens a SQLite database at the given path with the given
+// pool settings. An in-memory database is limited to a single connection,
+// since each connection to ":memory:" would otherwise see its own empty
+// database.
+func OpenWithConfig(path string, cfg PoolConfig) (*DB, error) {
+	// WAL mode lets readers proceed while a write is in progress.
+	// Pragmas are applied to every connection the pool opens.
+	dsn := fmt.Sprintf("%s?_pragma=journal_mode(WAL)&_pragma=busy_timeout(%d)",
+		path, cfg.BusyTimeout.Milliseconds())
 
 	sqlDB, err := sql.Open("sqlite", dsn)
 	if err != nil {
 		return nil, fmt.Errorf("opening database: %w", err)
 	}
 
+	if path == ":memory:" {
+		cfg.MaxOpenConns = 1
+		cfg.MaxIdleConns = 1
+		cfg.ConnMaxLifetime = 0
+	}
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+
 	// Verify connection
 	if err := sqlDB.Ping(); err != nil {
 		_ = sqlDB.Close()
@@ -56,14 +135,58 @@ func OpenAndInit(ctx context.Context, path string) (*DB, error) {
 	}
 
 	// Apply pending migrations for existing databases
-	if err := ApplyMigration008(ctx, db); err != nil {
+	if err := ApplyMigrations(ctx, db); err != nil {
 		_ = db.Close()
-		return nil, fmt.Errorf("applying migration 008: %w", err)
+		return nil, err
 	}
 
 	return db, nil
 }
 
+// OpenReadOnly opens an existing database without write access. The schema
+// is neither created nor migrated, so the file must already be at
+// LatestSchemaVersion; open it once normally to bring it up to date.
+// Several read-only servers can safely share one database file.
+func OpenReadOnly(ctx context.Context, path string) (*DB, error) {
+	cfg := DefaultPoolConfig()
+	dsn := fmt.Sprintf("file:%s?mode=ro&_pragma=busy_timeout(%d)",
+		path, cfg.BusyTimeout.Milliseconds())
+
+	sqlDB, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("opening database: %w", err)
+	}
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+
+	if err := sqlDB.PingContext(ctx); err != nil {
+		_ = sqlDB.Close()
+		return nil, fmt.Errorf("pinging database: %w", err)
+	}
+
+	db := &DB{DB: sqlDB, readOnly: true}
+	db.catPri = NewCategoryPriorityStore(db)
+
+	version, err := SchemaVersion(ctx, db)
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("reading schema version: %w", err)
+	}
+	if version < LatestSchemaVersion() {
+		_ = db.Close()
+		return nil, fmt.Errorf("schema version %d is older than %d; open the database without read-only mode to migrate it",
+			version, LatestSchemaVersion())
+	}
+
+	return db, nil
+}
+
+// ReadOnly reports whether the database was opened with OpenReadOnly.
+func (db *DB) ReadOnly() bool {
+	return db.readOnly
+}
+
 // CategoryPriorities returns the category priority store.
 func (db *DB) CategoryPriorities() *CategoryPriorityStore {
 	return db.catPri
@@ -92,6 +215,62 @@ func (db *DB) InTransaction(ctx context.Context, fn func(tx *sql.Tx) error) erro
 	return nil
 }
 
+// Optimize runs routine maintenance on a long-lived database. It refreshes
+// the query planner statistics and checkpoints the WAL back into the main
+// file. With vacuum set it also rebuilds the file to reclaim space freed by
+// pruning or re-syncing market data; this rewrites the whole database, so
+// it is best done while the server is otherwise idle.
+func (db *DB) Optimize(ctx context.Context, vacuum bool) error {
+	if db.readOnly {
+		return ErrReadOnly
+	}
+
+	if _, err := db.ExecContext(ctx, `PRAGMA optimize`); err != nil {
+		return fmt.Errorf("optimizing database: %w", err)
+	}
+
+	if vacuum {
+		if _, err := db.ExecContext(ctx, `VACUUM`); err != nil {
+			return fmt.Errorf("vacuuming database: %w", err)
+		}
+	}
+
+	if _, err := db.ExecContext(ctx, `PRAGMA wal_checkpoint(TRUNCATE)`); err != nil {
+		return fmt.Errorf("checkpointing WAL: %w", err)
+	}
+
+	return nil
+}
+
+// ScratchCopy copies the database into a temporary file and opens the copy
+// for writing, so changes can be tried out and thrown away without touching
+// the original. It works on read-only databases too. The returned function
+// closes the copy and removes its file.
+func (db *DB) ScratchCopy(ctx context.Context) (*DB, func(), error) {
+	dir, err := os.MkdirTemp("", "crafting-scratch-")
+	if err != nil {
+		return nil, nil, fmt.Errorf("creating scratch directory: %w", err)
+	}
+	path := filepath.Join(dir, "crafting.db")
+
+	if _, err := db.ExecContext(ctx, `VACUUM INTO ?`, path); err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, nil, fmt.Errorf("copying database: %w", err)
+	}
+
+	scratch, err := Open(path)
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, nil, err
+	}
+
+	cleanup := func() {
+		_ = scratch.Close()
+		_ = os.RemoveAll(dir)
+	}
+	return scratch, cleanup, nil
+}
+
 // InsertOrderBookEntry inserts a single order into the market_order_book table.
 func (db *DB) InsertOrderBookEntry(ctx context.Context, batchID, itemID, stationID, orderType string, price, volume int, source, recordedAt string) error {
 	_, err := db.ExecContext(ctx, `
@@ -0,0 +1,191 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestOptimizeReclaimsFreePages(t *testing.T) {
+	ctx := context.Background()
+	database, err := OpenAndInit(ctx, t.TempDir()+"/crafting.db")
+	if err != nil {
+		t.Fatalf("OpenAndInit: %v", err)
+	}
+	defer func() { _ = database.Close() }()
+
+	for i := 0; i < 500; i++ {
+		if _, err := database.ExecContext(ctx,
+			`INSERT INTO items (id, name, description, base_value, category) VALUES (?, ?, ?, 1, 'ore')`,
+			fmt.Sprintf("item_%d", i), fmt.Sprintf("Item %d", i), fmt.Sprintf("%0512d", i)); err != nil {
+			t.Fatalf("inserting item: %v", err)
+		}
+	}
+	if _, err := database.ExecContext(ctx, `DELETE FROM items`); err != nil {
+		t.Fatalf("deleting items: %v", err)
+	}
+
+	freePages := func() int {
+		var n int
+		if err := database.QueryRowContext(ctx, `PRAGMA freelist_count`).Scan(&n); err != nil {
+			t.Fatalf("reading freelist_count: %v", err)
+		}
+		return n
+	}
+	if freePages() == 0 {
+		t.Fatal("expected free pages after delete")
+	}
+
+	if err := database.Optimize(ctx, false); err != nil {
+		t.Fatalf("Optimize: %v", err)
+	}
+	if freePages() == 0 {
+		t.Error("Optimize without vacuum should leave free pages in place")
+	}
+
+	if err := database.Optimize(ctx, true); err != nil {
+		t.Fatalf("Optimize with vacuum: %v", err)
+	}
+	if n := freePages(); n != 0 {
+		t.Errorf("expected no free pages after vacuum, got %d", n)
+	}
+}
+
+func TestOpenAppliesPragmas(t *testing.T) {
+	ctx := context.Background()
+	database, err := OpenWithConfig(t.TempDir()+"/crafting.db", PoolConfig{
+		MaxOpenConns: 4,
+		MaxIdleConns: 4,
+		BusyTimeout:  2500 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("OpenWithConfig: %v", err)
+	}
+	defer func() { _ = database.Close() }()
+
+	// Hold one connection so the pragma checks below run on another.
+	conn, err := database.Conn(ctx)
+	if err != nil {
+		t.Fatalf("Conn: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	var mode string
+	if err := database.QueryRowContext(ctx, `PRAGMA journal_mode`).Scan(&mode); err != nil {
+		t.Fatalf("reading journal_mode: %v", err)
+	}
+	if mode != "wal" {
+		t.Errorf("journal_mode = %q, want wal", mode)
+	}
+
+	var timeout int
+	if err := database.QueryRowContext(ctx, `PRAGMA busy_timeout`).Scan(&timeout); err != nil {
+		t.Fatalf("reading busy_timeout: %v", err)
+	}
+	if timeout != 2500 {
+		t.Errorf("busy_timeout = %d, want 2500", timeout)
+	}
+
+	if got := database.Stats().MaxOpenConnections; got != 4 {
+		t.Errorf("MaxOpenConnections = %d, want 4", got)
+	}
+}
+
+func TestOpenMemorySingleConnection(t *testing.T) {
+	database, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer func() { _ = database.Close() }()
+
+	if got := database.Stats().MaxOpenConnections; got != 1 {
+		t.Errorf("MaxOpenConnections = %d, want 1 for an in-memory database", got)
+	}
+}
+
+func TestOpenReadOnly(t *testing.T) {
+	ctx := context.Background()
+	path := t.TempDir() + "/crafting.db"
+
+	rw, err := OpenAndInit(ctx, path)
+	if err != nil {
+		t.Fatalf("OpenAndInit: %v", err)
+	}
+	_ = rw.Close()
+
+	ro, err := OpenReadOnly(ctx, path)
+	if err != nil {
+		t.Fatalf("OpenReadOnly: %v", err)
+	}
+	defer func() { _ = ro.Close() }()
+
+	if !ro.ReadOnly() {
+		t.Error("expected ReadOnly() to be true")
+	}
+	if _, err := ro.ExecContext(ctx, `INSERT INTO items (id, name) VALUES ('x', 'X')`); err == nil {
+		t.Error("expected write to a read-only database to fail")
+	}
+	if err := ro.Optimize(ctx, false); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Optimize error = %v, want ErrReadOnly", err)
+	}
+}
+
+func TestOpenReadOnlyRequiresCurrentSchema(t *testing.T) {
+	ctx := context.Background()
+	path := t.TempDir() + "/crafting.db"
+
+	rw, err := OpenAndInit(ctx, path)
+	if err != nil {
+		t.Fatalf("OpenAndInit: %v", err)
+	}
+	latest := migrationSteps[len(migrationSteps)-1]
+	if _, err := rw.ExecContext(ctx, `DELETE FROM schema_migrations WHERE migration_id = ?`, latest.ID); err != nil {
+		t.Fatalf("forgetting migration: %v", err)
+	}
+	_ = rw.Close()
+
+	if ro, err := OpenReadOnly(ctx, path); err == nil {
+		_ = ro.Close()
+		t.Fatal("expected OpenReadOnly to reject an out-of-date schema")
+	}
+}
+
+func TestScratchCopyLeavesOriginalUntouched(t *testing.T) {
+	ctx := context.Background()
+	database, err := OpenAndInit(ctx, ":memory:")
+	if err != nil {
+		t.Fatalf("OpenAndInit: %v", err)
+	}
+	defer func() { _ = database.Close() }()
+
+	if _, err := database.ExecContext(ctx,
+		`INSERT INTO items (id, name, base_value, category) VALUES ('ore_iron', 'Iron Ore', 1, 'ore')`); err != nil {
+		t.Fatalf("inserting item: %v", err)
+	}
+
+	scratch, cleanup, err := database.ScratchCopy(ctx)
+	if err != nil {
+		t.Fatalf("ScratchCopy: %v", err)
+	}
+	defer cleanup()
+
+	if _, err := scratch.ExecContext(ctx, `DELETE FROM items`); err != nil {
+		t.Fatalf("deleting from copy: %v", err)
+	}
+
+	count := func(d *DB) int {
+		var n int
+		if err := d.QueryRowContext(ctx, `SELECT COUNT(*) FROM items`).Scan(&n); err != nil {
+			t.Fatalf("counting items: %v", err)
+		}
+		return n
+	}
+	if n := count(scratch); n != 0 {
+		t.Errorf("copy has %d items after delete, want 0", n)
+	}
+	if n := count(database); n != 1 {
+		t.Errorf("original has %d items, want 1", n)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 
 	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
 )
@@ -18,6 +19,97 @@ func NewItemStore(db *DB) *ItemStore {
 	return &ItemStore{db: db}
 }
 
+// GetItem retrieves an item by ID. Returns nil if not found.
+func (s *ItemStore) GetItem(ctx context.Context, id string) (*crafting.Item, error) {
+	var item crafting.Item
+	var description, category, rarity sql.NullString
+	err := s.db.QueryRowContext(ctx, `
+		SELECT id, name, description, category, rarity, size, base_value, stackable, tradeable
+		FROM items
+		WHERE id = ?
+	`, id).Scan(
+		&item.ID, &item.Name, &description, &category, &rarity,
+		&item.Size, &item.BaseValue, &item.Stackable, &item.Tradeable,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("querying item: %w", err)
+	}
+	item.Description = description.String
+	item.Category = category.String
+	item.Rarity = rarity.String
+	return &item, nil
+}
+
+// GetAllItems retrieves every item, ordered by ID.
+func (s *ItemStore) GetAllItems(ctx context.Context) ([]crafting.Item, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, name, description, category, rarity, size, base_value, stackable, tradeable
+		FROM items
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("querying all items: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var items []crafting.Item
+	for rows.Next() {
+		var item crafting.Item
+		var description, category, rarity sql.NullString
+		if err := rows.Scan(
+			&item.ID, &item.Name, &description, &category, &rarity,
+			&item.Size, &item.BaseValue, &item.Stackable, &item.Tradeable,
+		); err != nil {
+			return nil, fmt.Errorf("scanning item: %w", err)
+		}
+		item.Description = description.String
+		item.Category = category.String
+		item.Rarity = rarity.String
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// GetItemNames returns the display names for the given item IDs. IDs with no
+// item record map to themselves so callers always get a usable label.
+func (s *ItemStore) GetItemNames(ctx context.Context, ids []string) (map[string]string, error) {
+	names := make(map[string]string, len(ids))
+	if len(ids) == 0 {
+		return names, nil
+	}
+
+	placeholders := make([]string, len(ids))
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		placeholders[i] = "?"
+		args[i] = id
+		names[id] = id
+	}
+
+	rows, err := s.db.QueryContext(ctx,
+		fmt.Sprintf(`SELECT id, name FROM items WHERE id IN (%s)`, strings.Join(placeholders, ",")),
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("querying item names: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var id, name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, fmt.Errorf("scanning item name: %w", err)
+		}
+		if name != "" {
+			names[id] = name
+		}
+	}
+	return names, rows.Err()
+}
+
 // BulkInsertItems inserts multiple items in a transaction.
 func (s *ItemStore) BulkInsertItems(ctx context.Context, items []crafting.Item) error {
 	return s.db.InTransaction(ctx, func(tx *sql.Tx) error {
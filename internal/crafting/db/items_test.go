@@ -0,0 +1,51 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
+)
+
+func TestItemStoreLookups(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	store := NewItemStore(db)
+
+	items := []crafting.Item{
+		{ID: "ore_iron", Name: "Iron Ore", Category: "ore", BaseValue: 5},
+		{ID: "comp_steel", Name: "Steel Plate", Category: "component", BaseValue: 100},
+	}
+	if err := store.BulkInsertItems(ctx, items); err != nil {
+		t.Fatalf("BulkInsertItems failed: %v", err)
+	}
+
+	item, err := store.GetItem(ctx, "ore_iron")
+	if err != nil {
+		t.Fatalf("GetItem failed: %v", err)
+	}
+	if item == nil || item.Name != "Iron Ore" || item.BaseValue != 5 {
+		t.Errorf("unexpected item: %+v", item)
+	}
+
+	item, err = store.GetItem(ctx, "missing")
+	if err != nil {
+		t.Fatalf("GetItem failed: %v", err)
+	}
+	if item != nil {
+		t.Errorf("expected nil for missing item, got %+v", item)
+	}
+
+	names, err := store.GetItemNames(ctx, []string{"ore_iron", "comp_steel", "missing"})
+	if err != nil {
+		t.Fatalf("GetItemNames failed: %v", err)
+	}
+	want := map[string]string{"ore_iron": "Iron Ore", "comp_steel": "Steel Plate", "missing": "missing"}
+	for id, name := range want {
+		if names[id] != name {
+			t.Errorf("name for %s: expected %q, got %q", id, name, names[id])
+		}
+	}
+}
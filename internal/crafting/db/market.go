@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
@@ -31,41 +32,68 @@ type MarketDataPoint struct {
 
 // GetPriceSummary retrieves the price summary for an item at a station.
 func (s *MarketStore) GetPriceSummary(ctx context.Context, itemID, stationID string) (*crafting.MarketPriceSummary, *crafting.MarketPriceSummary, error) {
-	var buySummary, sellSummary *crafting.MarketPriceSummary
+	buySummary, err := s.getPriceSummary(ctx, itemID, stationID, "buy")
+	if err != nil {
+		return nil, nil, err
+	}
+	sellSummary, err := s.getPriceSummary(ctx, itemID, stationID, "sell")
+	if err != nil {
+		return nil, nil, err
+	}
+	return buySummary, sellSummary, nil
+}
 
-	// Get buy summary
-	var buy crafting.MarketPriceSummary
+// getPriceSummary reads one side of an item's price summary, or nil if there
+// is none. PriceAsOf is the newest observation behind the summary, falling
+// back to the time the summary was refreshed.
+func (s *MarketStore) getPriceSummary(ctx context.Context, itemID, stationID, priceType string) (*crafting.MarketPriceSummary, error) {
+	var summary crafting.MarketPriceSummary
+	var asOf string
 	err := s.db.QueryRowContext(ctx, `
-		SELECT item_id, station_id, price_type, avg_price_7d, min_price_7d, max_price_7d, price_trend
-		FROM market_price_summary
-		WHERE item_id = ? AND station_id = ? AND price_type = 'buy'
-	`, itemID, stationID).Scan(
-		&buy.ItemID, &buy.StationID, &buy.PriceType,
-		&buy.AvgPrice7d, &buy.MinPrice7d, &buy.MaxPrice7d, &buy.PriceTrend,
+		SELECT item_id, station_id, price_type, avg_price_7d, min_price_7d, max_price_7d, price_trend,
+		       COALESCE(median_price_7d, avg_price_7d), COALESCE(volatility_7d, 0),
+		       COALESCE((SELECT MAX(p.recorded_at) FROM market_prices p
+		                 WHERE p.item_id = s.item_id AND p.station_id = s.station_id
+		                   AND p.price_type = s.price_type), s.last_updated, '')
+		FROM market_price_summary s
+		WHERE item_id = ? AND station_id = ? AND price_type = ?
+	`, itemID, stationID, priceType).Scan(
+		&summary.ItemID, &summary.StationID, &summary.PriceType,
+		&summary.AvgPrice7d, &summary.MinPrice7d, &summary.MaxPrice7d, &summary.PriceTrend,
+		&summary.Median7d, &summary.Volatility, &asOf,
 	)
-	if err == nil {
-		buySummary = &buy
-	} else if err != sql.ErrNoRows {
-		return nil, nil, fmt.Errorf("querying buy summary: %w", err)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("querying %s summary: %w", priceType, err)
 	}
 
-	// Get sell summary
-	var sell crafting.MarketPriceSummary
-	err = s.db.QueryRowContext(ctx, `
-		SELECT item_id, station_id, price_type, avg_price_7d, min_price_7d, max_price_7d, price_trend
-		FROM market_price_summary
-		WHERE item_id = ? AND station_id = ? AND price_type = 'sell'
-	`, itemID, stationID).Scan(
-		&sell.ItemID, &sell.StationID, &sell.PriceType,
-		&sell.AvgPrice7d, &sell.MinPrice7d, &sell.MaxPrice7d, &sell.PriceTrend,
-	)
-	if err == nil {
-		sellSummary = &sell
-	} else if err != sql.ErrNoRows {
-		return nil, nil, fmt.Errorf("querying sell summary: %w", err)
+	if t, ok := parseTimestamp(asOf); ok {
+		summary.PriceAsOf = t
+		summary.DataAgeHours = AgeHours(t, time.Now())
 	}
+	return &summary, nil
+}
 
-	return buySummary, sellSummary, nil
+// parseTimestamp parses a stored timestamp, which is RFC 3339 when written
+// from Go and "YYYY-MM-DD HH:MM:SS" (UTC) when written by SQLite's datetime().
+func parseTimestamp(s string) (time.Time, bool) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, true
+	}
+	if t, err := time.Parse(time.DateTime, s); err == nil {
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+// AgeHours returns the whole hours between asOf and now, never negative.
+func AgeHours(asOf, now time.Time) int {
+	if !now.After(asOf) {
+		return 0
+	}
+	return int(now.Sub(asOf).Hours())
 }
 
 // GetSellPrice retrieves the current sell price for an item at a station.
@@ -122,24 +150,246 @@ func (s *MarketStore) GetPriceTrend(ctx context.Context, itemID, stationID strin
 }
 
 // GetVolume24h retrieves the 24h trading volume for an item.
+// Each price snapshot reports a rolling 24h volume, and imports record the
+// same volume on both the buy and sell rows, so summing would double count.
+// Instead this returns the largest volume reported on either side within the
+// last 24 hours. Returns 0 if there are no recent snapshots.
 func (s *MarketStore) GetVolume24h(ctx context.Context, itemID, stationID string) (int, error) {
 	var volume int
 	err := s.db.QueryRowContext(ctx, `
-		SELECT COALESCE(volume_24h, 0)
+		SELECT COALESCE(MAX(volume_24h), 0)
 		FROM market_prices
-		WHERE item_id = ? AND station_id = ? AND price_type = 'sell'
-		ORDER BY recorded_at DESC
-		LIMIT 1
+		WHERE item_id = ? AND station_id = ?
+		  AND datetime(recorded_at) > datetime('now', '-1 day')
 	`, itemID, stationID).Scan(&volume)
-	if err == sql.ErrNoRows {
-		return 0, nil
-	}
 	if err != nil {
 		return 0, fmt.Errorf("querying volume: %w", err)
 	}
 	return volume, nil
 }
 
+// PriceHistoryPoint is a single recorded price observation.
+type PriceHistoryPoint struct {
+	Price      int
+	Volume24h  int
+	RecordedAt time.Time
+}
+
+// GetPriceHistory returns raw price observations for an item at a station,
+// newest first. Points recorded before since are excluded unless since is
+// the zero time. At most limit points are returned when limit > 0.
+func (s *MarketStore) GetPriceHistory(ctx context.Context, itemID, stationID, priceType string, since time.Time, limit int) ([]PriceHistoryPoint, error) {
+	query := `
+		SELECT price, COALESCE(volume_24h, 0), recorded_at
+		FROM market_prices
+		WHERE item_id = ? AND station_id = ? AND price_type = ?
+	`
+	args := []any{itemID, stationID, priceType}
+	if !since.IsZero() {
+		query += ` AND datetime(recorded_at) >= datetime(?)`
+		args = append(args, since.UTC().Format(time.RFC3339))
+	}
+	query += ` ORDER BY datetime(recorded_at) DESC`
+	if limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, limit)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("querying price history: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var points []PriceHistoryPoint
+	for rows.Next() {
+		var p PriceHistoryPoint
+		var recordedAt string
+		if err := rows.Scan(&p.Price, &p.Volume24h, &recordedAt); err != nil {
+			return nil, fmt.Errorf("scanning price history: %w", err)
+		}
+		p.RecordedAt, err = time.Parse(time.RFC3339, recordedAt)
+		if err != nil {
+			return nil, fmt.Errorf("parsing recorded_at %q: %w", recordedAt, err)
+		}
+		points = append(points, p)
+	}
+	return points, rows.Err()
+}
+
+// MarketStation is a station that appears in market price data.
+type MarketStation struct {
+	StationID string
+	Name      string // Empty if the station is not in the stations table
+	Empire    string
+	ItemCount int    // Distinct items priced at the station
+}
+
+// ListStations returns the distinct stations that have market prices, with
+// the number of items priced at each. If itemID is non-empty, only stations
+// with a price for that item are returned.
+func (s *MarketStore) ListStations(ctx context.Context, itemID string) ([]MarketStation, error) {
+	query := `
+		SELECT mp.station_id, COALESCE(st.name, ''), COALESCE(st.empire, ''), COUNT(DISTINCT mp.item_id)
+		FROM market_prices mp
+		LEFT JOIN stations st ON st.id = mp.station_id
+	`
+	var args []any
+	if itemID != "" {
+		query += ` WHERE mp.station_id IN (SELECT station_id FROM market_prices WHERE item_id = ?)`
+		args = append(args, itemID)
+	}
+	query += ` GROUP BY mp.station_id ORDER BY mp.station_id`
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("listing market stations: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var stations []MarketStation
+	for rows.Next() {
+		var st MarketStation
+		if err := rows.Scan(&st.StationID, &st.Name, &st.Empire, &st.ItemCount); err != nil {
+			return nil, fmt.Errorf("scanning market station: %w", err)
+		}
+		stations = append(stations, st)
+	}
+	return stations, rows.Err()
+}
+
+// StationPrice is an item's buy price at one station.
+type StationPrice struct {
+	StationID   string
+	StationName string // Empty if the station is not in the stations table
+	Price       int
+}
+
+// FindCheapestStation returns the station with the lowest 7-day average buy
+// price for an item, or nil if no station has one. Prices are truncated to
+// whole credits, so an average under one credit counts as no price. Ties
+// go to the lowest station ID.
+func (s *MarketStore) FindCheapestStation(ctx context.Context, itemID string) (*StationPrice, error) {
+	var sp StationPrice
+	err := s.db.QueryRowContext(ctx, `
+		SELECT ps.station_id, COALESCE(st.name, ''), CAST(ps.avg_price_7d AS INTEGER)
+		FROM market_price_summary ps
+		LEFT JOIN stations st ON st.id = ps.station_id
+		WHERE ps.item_id = ? AND ps.price_type = 'buy' AND CAST(ps.avg_price_7d AS INTEGER) > 0
+		ORDER BY ps.avg_price_7d, ps.station_id
+		LIMIT 1
+	`, itemID).Scan(&sp.StationID, &sp.StationName, &sp.Price)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("finding cheapest station: %w", err)
+	}
+	return &sp, nil
+}
+
+// PriceSpread pairs an item's buy price at one station with a higher sell
+// price at another.
+type PriceSpread struct {
+	ItemID        string
+	BuyStationID  string
+	BuyPrice      int
+	SellStationID string
+	SellPrice     int
+}
+
+// FindPriceSpreads returns every pair of stations where an item's 7-day
+// average sell price at one exceeds its average buy price at the other,
+// widest spread first, then by item and stations. Prices are truncated to
+// whole credits, and buy prices under one credit count as unpriced.
+func (s *MarketStore) FindPriceSpreads(ctx context.Context) ([]PriceSpread, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT b.item_id, b.station_id, CAST(b.avg_price_7d AS INTEGER),
+		       s.station_id, CAST(s.avg_price_7d AS INTEGER)
+		FROM market_price_summary b
+		JOIN market_price_summary s
+		  ON s.item_id = b.item_id AND s.station_id != b.station_id AND s.price_type = 'sell'
+		WHERE b.price_type = 'buy' AND CAST(b.avg_price_7d AS INTEGER) > 0
+		  AND CAST(s.avg_price_7d AS INTEGER) > CAST(b.avg_price_7d AS INTEGER)
+		ORDER BY CAST(s.avg_price_7d AS INTEGER) - CAST(b.avg_price_7d AS INTEGER) DESC,
+		         b.item_id, b.station_id, s.station_id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("finding price spreads: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var spreads []PriceSpread
+	for rows.Next() {
+		var sp PriceSpread
+		if err := rows.Scan(&sp.ItemID, &sp.BuyStationID, &sp.BuyPrice, &sp.SellStationID, &sp.SellPrice); err != nil {
+			return nil, fmt.Errorf("scanning price spread: %w", err)
+		}
+		spreads = append(spreads, sp)
+	}
+	return spreads, rows.Err()
+}
+
+// PriceStatsStations returns the stations with price statistics for an
+// item, in either order type, sorted by station ID.
+func (s *MarketStore) PriceStatsStations(ctx context.Context, itemID string) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT DISTINCT station_id
+		FROM market_price_stats
+		WHERE item_id = ?
+		ORDER BY station_id
+	`, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("listing price stats stations: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var stations []string
+	for rows.Next() {
+		var stationID string
+		if err := rows.Scan(&stationID); err != nil {
+			return nil, fmt.Errorf("scanning price stats station: %w", err)
+		}
+		stations = append(stations, stationID)
+	}
+	return stations, rows.Err()
+}
+
+// GetAllMarketData returns every recorded price as data points, pairing the
+// buy and sell prices recorded for a market at the same time. Points are
+// ordered by time, then item and station.
+func (s *MarketStore) GetAllMarketData(ctx context.Context) ([]MarketDataPoint, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT item_id, station_id, recorded_at,
+			COALESCE(MAX(CASE WHEN price_type = 'buy' THEN price END), 0),
+			COALESCE(MAX(CASE WHEN price_type = 'sell' THEN price END), 0),
+			COALESCE(MAX(volume_24h), 0)
+		FROM market_prices
+		GROUP BY item_id, station_id, recorded_at
+		ORDER BY recorded_at, item_id, station_id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("querying market prices: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var points []MarketDataPoint
+	for rows.Next() {
+		var p MarketDataPoint
+		var recordedAt string
+		if err := rows.Scan(&p.ItemID, &p.StationID, &recordedAt, &p.BuyPrice, &p.SellPrice, &p.Volume24h); err != nil {
+			return nil, fmt.Errorf("scanning market price: %w", err)
+		}
+		ts, ok := parseTimestamp(recordedAt)
+		if !ok {
+			return nil, fmt.Errorf("parsing recorded_at %q for %s at %s", recordedAt, p.ItemID, p.StationID)
+		}
+		p.Timestamp = ts
+		points = append(points, p)
+	}
+	return points, rows.Err()
+}
+
 // ImportMarketData imports market price data points.
 func (s *MarketStore) ImportMarketData(ctx context.Context, data []MarketDataPoint) error {
 	return s.db.InTransaction(ctx, func(tx *sql.Tx) error {
@@ -181,38 +431,224 @@ func (s *MarketStore) ImportMarketData(ctx context.Context, data []MarketDataPoi
 	})
 }
 
-// RefreshPriceSummaries recalculates the price summary table from raw data.
-func (s *MarketStore) RefreshPriceSummaries(ctx context.Context) error {
-	_, err := s.db.ExecContext(ctx, `
-		INSERT OR REPLACE INTO market_price_summary
-		(item_id, station_id, price_type, avg_price_7d, min_price_7d, max_price_7d, price_trend, last_updated)
+// TrendConfig controls how the rising/falling/stable price trend is derived
+// when summaries are refreshed, and which period the summaries cover. The
+// zero value uses the defaults.
+type TrendConfig struct {
+	// RecentWindow is how far back a price counts as "recent" when it is
+	// compared against older prices. Defaults to 24 hours.
+	RecentWindow time.Duration
+
+	// SplitAtMidpoint compares the newer half of each market's data against
+	// the older half instead of using RecentWindow. This gives a meaningful
+	// trend for sparse markets whose data is not spread across the window.
+	SplitAtMidpoint bool
+
+	// RisingThreshold and FallingThreshold are the fractional change in the
+	// recent average needed to report a rising or falling trend.
+	// Both default to 0.05 (5%).
+	RisingThreshold  float64
+	FallingThreshold float64
+
+	// End is the "now" the summaries are computed as of: later prices are
+	// left out and RecentWindow is measured back from it. Zero means the
+	// current time, with no upper bound on the prices used.
+	End time.Time
+
+	// Start is the earliest price included (exclusive). Zero means
+	// SummaryWindow before End.
+	Start time.Time
+}
+
+// SummaryWindow is how far back price summaries look when no Start is given.
+const SummaryWindow = 7 * 24 * time.Hour
+
+// DefaultTrendConfig returns the trend settings used when none are given.
+func DefaultTrendConfig() TrendConfig {
+	return TrendConfig{
+		RecentWindow:     24 * time.Hour,
+		RisingThreshold:  0.05,
+		FallingThreshold: 0.05,
+	}
+}
+
+// withDefaults fills in any unset fields from DefaultTrendConfig.
+func (c TrendConfig) withDefaults() TrendConfig {
+	def := DefaultTrendConfig()
+	if c.RecentWindow <= 0 {
+		c.RecentWindow = def.RecentWindow
+	}
+	if c.RisingThreshold <= 0 {
+		c.RisingThreshold = def.RisingThreshold
+	}
+	if c.FallingThreshold <= 0 {
+		c.FallingThreshold = def.FallingThreshold
+	}
+	return c
+}
+
+// window returns the condition selecting the prices a refresh summarizes
+// and its parameters. It fails if the period is empty.
+func (c TrendConfig) window() (string, []any, error) {
+	end := c.End
+	if end.IsZero() {
+		end = time.Now()
+	}
+	start := c.Start
+	if start.IsZero() {
+		start = end.Add(-SummaryWindow)
+	}
+	if !start.Before(end) {
+		return "", nil, fmt.Errorf("summary window start %s is not before end %s",
+			start.Format(time.RFC3339), end.Format(time.RFC3339))
+	}
+
+	cond := "julianday(recorded_at) > julianday(?)"
+	args := []any{sqliteTime(start)}
+	if !c.End.IsZero() {
+		cond += " AND julianday(recorded_at) <= julianday(?)"
+		args = append(args, sqliteTime(c.End))
+	}
+	return cond, args, nil
+}
+
+// args returns the trend query parameters in the order they appear in
+// refreshSummariesSQL after the window filter.
+func (c TrendConfig) args() []any {
+	reference := "now"
+	if !c.End.IsZero() {
+		reference = sqliteTime(c.End)
+	}
+	return []any{
+		c.SplitAtMidpoint,
+		reference,
+		fmt.Sprintf("-%d seconds", int64(c.RecentWindow.Seconds())),
+		c.RisingThreshold,
+		c.FallingThreshold,
+	}
+}
+
+// sqliteTime formats t as a UTC time string SQLite's date functions accept.
+func sqliteTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04:05.000")
+}
+
+// refreshSummariesSQL rebuilds market_price_summary rows from the
+// market_prices in a TrendConfig's window, the last 7 days by default. The
+// median uses the middle row (or the mean of the two middle rows) of each
+// market's prices; volatility is the coefficient of variation (population
+// standard deviation divided by the mean). The %s verb is replaced with the
+// WHERE conditions: the window, optionally narrowed to a subset of rows.
+// The condition parameters come first, followed by TrendConfig.args.
+const refreshSummariesSQL = `
+	WITH recent AS (
+		SELECT
+			item_id, station_id, price_type, price, julianday(recorded_at) AS jd,
+			ROW_NUMBER() OVER (PARTITION BY item_id, station_id, price_type ORDER BY price) AS rn,
+			COUNT(*) OVER (PARTITION BY item_id, station_id, price_type) AS cnt
+		FROM market_prices
+		WHERE %s
+	),
+	split AS (
 		SELECT
 			item_id,
 			station_id,
 			price_type,
-			AVG(price) as avg_price_7d,
-			MIN(price) as min_price_7d,
-			MAX(price) as max_price_7d,
-			CASE
-				WHEN AVG(CASE WHEN recorded_at > datetime('now', '-1 day') THEN price END) >
-					 AVG(CASE WHEN recorded_at <= datetime('now', '-1 day') THEN price END) * 1.05
-				THEN 'rising'
-				WHEN AVG(CASE WHEN recorded_at > datetime('now', '-1 day') THEN price END) <
-					 AVG(CASE WHEN recorded_at <= datetime('now', '-1 day') THEN price END) * 0.95
-				THEN 'falling'
-				ELSE 'stable'
-			END as price_trend,
-			datetime('now') as last_updated
-		FROM market_prices
-		WHERE recorded_at > datetime('now', '-7 days')
+			CASE WHEN ? THEN (MIN(jd) + MAX(jd)) / 2.0 ELSE julianday(?, ?) END AS split_jd
+		FROM recent
 		GROUP BY item_id, station_id, price_type
-	`)
+	)
+	INSERT OR REPLACE INTO market_price_summary
+	(item_id, station_id, price_type, avg_price_7d, min_price_7d, max_price_7d, price_trend, last_updated,
+	 median_price_7d, volatility_7d)
+	SELECT
+		r.item_id,
+		r.station_id,
+		r.price_type,
+		AVG(r.price) as avg_price_7d,
+		MIN(r.price) as min_price_7d,
+		MAX(r.price) as max_price_7d,
+		CASE
+			WHEN AVG(CASE WHEN r.jd > s.split_jd THEN r.price END) >
+				 AVG(CASE WHEN r.jd <= s.split_jd THEN r.price END) * (1 + ?)
+			THEN 'rising'
+			WHEN AVG(CASE WHEN r.jd > s.split_jd THEN r.price END) <
+				 AVG(CASE WHEN r.jd <= s.split_jd THEN r.price END) * (1 - ?)
+			THEN 'falling'
+			ELSE 'stable'
+		END as price_trend,
+		datetime('now') as last_updated,
+		AVG(CASE WHEN r.rn IN ((r.cnt + 1) / 2, (r.cnt + 2) / 2) THEN r.price END) as median_price_7d,
+		CASE
+			WHEN AVG(r.price) > 0
+			THEN sqrt(MAX(AVG(r.price * 1.0 * r.price) - AVG(r.price) * AVG(r.price), 0)) / AVG(r.price)
+			ELSE 0
+		END as volatility_7d
+	FROM recent r
+	JOIN split s ON s.item_id = r.item_id AND s.station_id = r.station_id AND s.price_type = r.price_type
+	GROUP BY r.item_id, r.station_id, r.price_type
+`
+
+// RefreshPriceSummaries recalculates the price summary table from raw data.
+func (s *MarketStore) RefreshPriceSummaries(ctx context.Context, trend TrendConfig) error {
+	trend = trend.withDefaults()
+	cond, args, err := trend.window()
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, fmt.Sprintf(refreshSummariesSQL, cond), append(args, trend.args()...)...)
 	if err != nil {
 		return fmt.Errorf("refreshing price summaries: %w", err)
 	}
 	return nil
 }
 
+// ItemStation identifies the market for one item at one station.
+type ItemStation struct {
+	ItemID    string
+	StationID string
+}
+
+// RefreshPriceSummariesFor recalculates price summaries only for the given
+// item/station pairs. This is much cheaper than RefreshPriceSummaries when an
+// import touches a small number of markets in a large price history.
+func (s *MarketStore) RefreshPriceSummariesFor(ctx context.Context, pairs []ItemStation, trend TrendConfig) error {
+	if len(pairs) == 0 {
+		return nil
+	}
+	trend = trend.withDefaults()
+	cond, windowArgs, err := trend.window()
+	if err != nil {
+		return err
+	}
+	trendArgs := trend.args()
+
+	return s.db.InTransaction(ctx, func(tx *sql.Tx) error {
+		stmt, err := tx.PrepareContext(ctx,
+			fmt.Sprintf(refreshSummariesSQL, cond+" AND item_id = ? AND station_id = ?"))
+		if err != nil {
+			return fmt.Errorf("preparing summary refresh: %w", err)
+		}
+		defer func() { _ = stmt.Close() }()
+
+		seen := make(map[ItemStation]bool, len(pairs))
+		for _, p := range pairs {
+			if seen[p] {
+				continue
+			}
+			seen[p] = true
+
+			args := append(slices.Clone(windowArgs), p.ItemID, p.StationID)
+			args = append(args, trendArgs...)
+			if _, err := stmt.ExecContext(ctx, args...); err != nil {
+				return fmt.Errorf("refreshing price summary for %s at %s: %w", p.ItemID, p.StationID, err)
+			}
+		}
+
+		return nil
+	})
+}
+
 // PruneOldPrices removes price records older than the specified days.
 func (s *MarketStore) PruneOldPrices(ctx context.Context, olderThanDays int) (int64, error) {
 	result, err := s.db.ExecContext(ctx, `
@@ -253,16 +689,18 @@ type MarketPriceStats struct {
 	StdDev             *float64 // Nullable
 	ConfidenceScore    float64
 	PriceTrend         *string  // Nullable
+	LastUpdated        time.Time // Zero if the stored value cannot be parsed
 }
 
 // GetPriceStats retrieves market price statistics from the new market_price_stats table.
 // Returns nil if not found.
 func (s *MarketStore) GetPriceStats(ctx context.Context, itemID, stationID, orderType string) (*MarketPriceStats, error) {
 	var stats MarketPriceStats
+	var lastUpdated string
 	err := s.db.QueryRowContext(ctx, `
 		SELECT item_id, station_id, empire_id, order_type,
 		       representative_price, stat_method, sample_count, total_volume,
-		       min_price, max_price, stddev, confidence_score, price_trend
+		       min_price, max_price, stddev, confidence_score, price_trend, last_updated
 		FROM market_price_stats
 		WHERE item_id = ? AND station_id = ? AND order_type = ?
 		ORDER BY empire_id NULLS LAST
@@ -271,6 +709,7 @@ func (s *MarketStore) GetPriceStats(ctx context.Context, itemID, stationID, orde
 		&stats.ItemID, &stats.StationID, &stats.EmpireID, &stats.OrderType,
 		&stats.RepresentativePrice, &stats.StatMethod, &stats.SampleCount, &stats.TotalVolume,
 		&stats.MinPrice, &stats.MaxPrice, &stats.StdDev, &stats.ConfidenceScore, &stats.PriceTrend,
+		&lastUpdated,
 	)
 
 	if err == sql.ErrNoRows {
@@ -279,6 +718,7 @@ func (s *MarketStore) GetPriceStats(ctx context.Context, itemID, stationID, orde
 	if err != nil {
 		return nil, fmt.Errorf("querying price stats: %w", err)
 	}
+	stats.LastUpdated, _ = parseTimestamp(lastUpdated)
 
 	return &stats, nil
 }
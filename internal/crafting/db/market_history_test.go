@@ -0,0 +1,80 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestGetPriceHistory(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	market := NewMarketStore(db)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	points := []MarketDataPoint{
+		{ItemID: "ore_iron", StationID: "station_a", SellPrice: 10, Volume24h: 5, Timestamp: now.Add(-72 * time.Hour)},
+		{ItemID: "ore_iron", StationID: "station_a", SellPrice: 12, Volume24h: 6, Timestamp: now.Add(-48 * time.Hour)},
+		{ItemID: "ore_iron", StationID: "station_a", SellPrice: 14, Volume24h: 7, Timestamp: now.Add(-24 * time.Hour)},
+		{ItemID: "ore_iron", StationID: "station_a", BuyPrice: 9, Timestamp: now},
+	}
+	if err := market.ImportMarketData(ctx, points); err != nil {
+		t.Fatalf("ImportMarketData failed: %v", err)
+	}
+
+	history, err := market.GetPriceHistory(ctx, "ore_iron", "station_a", "sell", time.Time{}, 0)
+	if err != nil {
+		t.Fatalf("GetPriceHistory failed: %v", err)
+	}
+	if len(history) != 3 {
+		t.Fatalf("expected 3 sell points, got %d", len(history))
+	}
+	if history[0].Price != 14 || !history[0].RecordedAt.Equal(now.Add(-24*time.Hour)) {
+		t.Errorf("expected newest point first, got %+v", history[0])
+	}
+
+	history, err = market.GetPriceHistory(ctx, "ore_iron", "station_a", "sell", now.Add(-60*time.Hour), 1)
+	if err != nil {
+		t.Fatalf("GetPriceHistory with since/limit failed: %v", err)
+	}
+	if len(history) != 1 || history[0].Price != 14 {
+		t.Errorf("unexpected limited history: %+v", history)
+	}
+}
+
+func TestGetVolume24h(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	market := NewMarketStore(db)
+
+	now := time.Now().UTC()
+	points := []MarketDataPoint{
+		{ItemID: "ore_iron", StationID: "station_a", SellPrice: 10, Volume24h: 900, Timestamp: now.Add(-48 * time.Hour)},
+		{ItemID: "ore_iron", StationID: "station_a", SellPrice: 10, Volume24h: 40, Timestamp: now.Add(-6 * time.Hour)},
+		// Latest snapshot is buy-only with no reported volume.
+		{ItemID: "ore_iron", StationID: "station_a", BuyPrice: 8, Timestamp: now.Add(-1 * time.Hour)},
+	}
+	if err := market.ImportMarketData(ctx, points); err != nil {
+		t.Fatalf("ImportMarketData failed: %v", err)
+	}
+
+	volume, err := market.GetVolume24h(ctx, "ore_iron", "station_a")
+	if err != nil {
+		t.Fatalf("GetVolume24h failed: %v", err)
+	}
+	if volume != 40 {
+		t.Errorf("expected volume 40 from the last 24h, got %d", volume)
+	}
+
+	volume, err = market.GetVolume24h(ctx, "ore_copper", "station_a")
+	if err != nil {
+		t.Fatalf("GetVolume24h failed: %v", err)
+	}
+	if volume != 0 {
+		t.Errorf("expected volume 0 for unknown item, got %d", volume)
+	}
+}
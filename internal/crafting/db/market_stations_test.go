@@ -0,0 +1,129 @@
+package db
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestListMarketStations(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	market := NewMarketStore(db)
+
+	now := time.Now().UTC()
+	points := []MarketDataPoint{
+		{ItemID: "ore_iron", StationID: "station_a", BuyPrice: 10, SellPrice: 12, Timestamp: now},
+		{ItemID: "ore_copper", StationID: "station_a", BuyPrice: 20, SellPrice: 25, Timestamp: now},
+		{ItemID: "ore_copper", StationID: "station_b", BuyPrice: 21, Timestamp: now},
+	}
+	if err := market.ImportMarketData(ctx, points); err != nil {
+		t.Fatalf("ImportMarketData failed: %v", err)
+	}
+	if err := db.UpsertStation(ctx, Station{ID: "station_a", Name: "Alpha Hub", Empire: "solarian"}); err != nil {
+		t.Fatalf("UpsertStation failed: %v", err)
+	}
+
+	stations, err := market.ListStations(ctx, "")
+	if err != nil {
+		t.Fatalf("ListStations failed: %v", err)
+	}
+	if len(stations) != 2 {
+		t.Fatalf("expected 2 stations, got %d: %+v", len(stations), stations)
+	}
+	want := MarketStation{StationID: "station_a", Name: "Alpha Hub", Empire: "solarian", ItemCount: 2}
+	if stations[0] != want {
+		t.Errorf("unexpected first station: got %+v, want %+v", stations[0], want)
+	}
+	if stations[1].StationID != "station_b" || stations[1].Name != "" || stations[1].ItemCount != 1 {
+		t.Errorf("unexpected second station: %+v", stations[1])
+	}
+
+	stations, err = market.ListStations(ctx, "ore_iron")
+	if err != nil {
+		t.Fatalf("ListStations with filter failed: %v", err)
+	}
+	if len(stations) != 1 || stations[0].StationID != "station_a" || stations[0].ItemCount != 2 {
+		t.Errorf("unexpected filtered stations: %+v", stations)
+	}
+}
+
+func TestFindCheapestStation(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	// station_e's average truncates to 0, which is no price
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO market_price_summary (item_id, station_id, price_type, avg_price_7d) VALUES
+			('ore', 'station_a', 'buy', 12),
+			('ore', 'station_b', 'buy', 9.6),
+			('ore', 'station_c', 'buy', 0),
+			('ore', 'station_e', 'buy', 0.5),
+			('ore', 'station_d', 'sell', 5),
+			('gem', 'station_a', 'sell', 100)
+	`)
+	if err != nil {
+		t.Fatalf("inserting summaries: %v", err)
+	}
+	if err := db.UpsertStation(ctx, Station{ID: "station_b", Name: "Beta Port"}); err != nil {
+		t.Fatalf("UpsertStation failed: %v", err)
+	}
+
+	market := NewMarketStore(db)
+	got, err := market.FindCheapestStation(ctx, "ore")
+	if err != nil {
+		t.Fatalf("FindCheapestStation failed: %v", err)
+	}
+	want := StationPrice{StationID: "station_b", StationName: "Beta Port", Price: 9}
+	if got == nil || *got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	// Only sell prices: nothing to buy from.
+	got, err = market.FindCheapestStation(ctx, "gem")
+	if err != nil {
+		t.Fatalf("FindCheapestStation failed: %v", err)
+	}
+	if got != nil {
+		t.Errorf("expected no station for gem, got %+v", got)
+	}
+}
+
+func TestFindPriceSpreads(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO market_price_summary (item_id, station_id, price_type, avg_price_7d) VALUES
+			('ore', 'station_a', 'buy', 10),
+			('ore', 'station_a', 'sell', 12),
+			('ore', 'station_b', 'buy', 0),
+			('ore', 'station_b', 'sell', 30),
+			('ore', 'station_c', 'sell', 9),
+			('dust', 'station_a', 'buy', 0.4),
+			('dust', 'station_b', 'sell', 3)
+	`)
+	if err != nil {
+		t.Fatalf("inserting prices: %v", err)
+	}
+
+	market := NewMarketStore(db)
+	spreads, err := market.FindPriceSpreads(ctx)
+	if err != nil {
+		t.Fatalf("FindPriceSpreads failed: %v", err)
+	}
+
+	// Same-station and losing pairs, the unpriced buy at station_b and the
+	// dust buy that truncates to 0 are left out.
+	want := []PriceSpread{
+		{ItemID: "ore", BuyStationID: "station_a", BuyPrice: 10, SellStationID: "station_b", SellPrice: 30},
+	}
+	if !reflect.DeepEqual(spreads, want) {
+		t.Errorf("got %+v, want %+v", spreads, want)
+	}
+}
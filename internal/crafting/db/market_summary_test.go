@@ -0,0 +1,219 @@
+package db
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestRefreshPriceSummariesFor(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	market := NewMarketStore(db)
+
+	now := time.Now().UTC()
+	points := []MarketDataPoint{
+		{ItemID: "ore_iron", StationID: "station_a", BuyPrice: 10, SellPrice: 12, Timestamp: now},
+		{ItemID: "ore_copper", StationID: "station_a", BuyPrice: 20, SellPrice: 25, Timestamp: now},
+		{ItemID: "ore_iron", StationID: "station_b", BuyPrice: 11, SellPrice: 13, Timestamp: now},
+	}
+	if err := market.ImportMarketData(ctx, points); err != nil {
+		t.Fatalf("ImportMarketData failed: %v", err)
+	}
+
+	err := market.RefreshPriceSummariesFor(ctx, []ItemStation{
+		{ItemID: "ore_iron", StationID: "station_a"},
+		{ItemID: "ore_iron", StationID: "station_a"}, // duplicates are ignored
+	}, TrendConfig{})
+	if err != nil {
+		t.Fatalf("RefreshPriceSummariesFor failed: %v", err)
+	}
+
+	var count int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM market_price_summary`).Scan(&count); err != nil {
+		t.Fatalf("counting summaries: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("expected 2 summary rows (buy and sell for one market), got %d", count)
+	}
+
+	buy, sell, err := market.GetPriceSummary(ctx, "ore_iron", "station_a")
+	if err != nil {
+		t.Fatalf("GetPriceSummary failed: %v", err)
+	}
+	if buy == nil || sell == nil {
+		t.Fatalf("expected buy and sell summaries, got buy=%v sell=%v", buy, sell)
+	}
+	if sell.AvgPrice7d != 12 {
+		t.Errorf("expected sell avg 12, got %v", sell.AvgPrice7d)
+	}
+
+	// Untouched markets must not be summarized.
+	buy, sell, err = market.GetPriceSummary(ctx, "ore_copper", "station_a")
+	if err != nil {
+		t.Fatalf("GetPriceSummary failed: %v", err)
+	}
+	if buy != nil || sell != nil {
+		t.Errorf("expected no summary for untouched market, got buy=%v sell=%v", buy, sell)
+	}
+}
+
+func TestRefreshPriceSummaries_TrendConfig(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	market := NewMarketStore(db)
+
+	// Sparse data with nothing in the last day, rising 10% halfway through.
+	now := time.Now().UTC()
+	var points []MarketDataPoint
+	for i, price := range []int{100, 100, 110, 110} {
+		points = append(points, MarketDataPoint{
+			ItemID: "ore_iron", StationID: "station_a", SellPrice: price,
+			Timestamp: now.Add(time.Duration(i-6) * 24 * time.Hour),
+		})
+	}
+	if err := market.ImportMarketData(ctx, points); err != nil {
+		t.Fatalf("ImportMarketData failed: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		trend TrendConfig
+		want  string
+	}{
+		{"default window sees no recent data", TrendConfig{}, "stable"},
+		{"wider window", TrendConfig{RecentWindow: 4 * 24 * time.Hour}, "rising"},
+		{"midpoint split", TrendConfig{SplitAtMidpoint: true}, "rising"},
+		{"midpoint with high threshold", TrendConfig{SplitAtMidpoint: true, RisingThreshold: 0.2}, "stable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := market.RefreshPriceSummaries(ctx, tt.trend); err != nil {
+				t.Fatalf("RefreshPriceSummaries failed: %v", err)
+			}
+			trend, err := market.GetPriceTrend(ctx, "ore_iron", "station_a")
+			if err != nil {
+				t.Fatalf("GetPriceTrend failed: %v", err)
+			}
+			if trend != tt.want {
+				t.Errorf("expected trend %q, got %q", tt.want, trend)
+			}
+		})
+	}
+}
+
+func TestRefreshPriceSummaries_MedianAndVolatility(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	market := NewMarketStore(db)
+
+	// One outlier spike drags the average well above the median.
+	now := time.Now().UTC()
+	var points []MarketDataPoint
+	for i, price := range []int{10, 10, 12, 10, 100} {
+		points = append(points, MarketDataPoint{
+			ItemID: "ore_iron", StationID: "station_a", SellPrice: price,
+			Timestamp: now.Add(-time.Duration(i+1) * time.Hour),
+		})
+	}
+	points = append(points, MarketDataPoint{
+		ItemID: "ore_iron", StationID: "station_a", BuyPrice: 8, Timestamp: now,
+	})
+	if err := market.ImportMarketData(ctx, points); err != nil {
+		t.Fatalf("ImportMarketData failed: %v", err)
+	}
+	if err := market.RefreshPriceSummaries(ctx, TrendConfig{}); err != nil {
+		t.Fatalf("RefreshPriceSummaries failed: %v", err)
+	}
+
+	buy, sell, err := market.GetPriceSummary(ctx, "ore_iron", "station_a")
+	if err != nil {
+		t.Fatalf("GetPriceSummary failed: %v", err)
+	}
+	if sell == nil || buy == nil {
+		t.Fatalf("expected buy and sell summaries, got buy=%v sell=%v", buy, sell)
+	}
+
+	if sell.AvgPrice7d != 28.4 {
+		t.Errorf("expected average 28.4, got %v", sell.AvgPrice7d)
+	}
+	if sell.Median7d != 10 {
+		t.Errorf("expected median 10, got %v", sell.Median7d)
+	}
+	// Population stddev of {10,10,12,10,100} is 35.8; divided by the mean 28.4.
+	if math.Abs(sell.Volatility-1.2606) > 0.001 {
+		t.Errorf("expected volatility ~1.2606, got %v", sell.Volatility)
+	}
+
+	if buy.Median7d != 8 || buy.Volatility != 0 {
+		t.Errorf("expected single-point buy median 8 and volatility 0, got %v and %v", buy.Median7d, buy.Volatility)
+	}
+}
+
+func TestRefreshPriceSummaries_HistoricalWindow(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	market := NewMarketStore(db)
+
+	// A week of daily prices in early 2025, then a later price that a
+	// backtest as of January 8th must not see.
+	day := func(d int) time.Time { return time.Date(2025, 1, d, 12, 0, 0, 0, time.UTC) }
+	var points []MarketDataPoint
+	for d, price := range []int{100, 100, 100, 100, 100, 120, 120} {
+		points = append(points, MarketDataPoint{
+			ItemID: "ore_iron", StationID: "station_a", SellPrice: price, Timestamp: day(d + 1),
+		})
+	}
+	points = append(points, MarketDataPoint{
+		ItemID: "ore_iron", StationID: "station_a", SellPrice: 500, Timestamp: day(20),
+	})
+	if err := market.ImportMarketData(ctx, points); err != nil {
+		t.Fatalf("ImportMarketData failed: %v", err)
+	}
+
+	asOf := TrendConfig{End: day(7).Add(time.Hour), RecentWindow: 36 * time.Hour}
+	if err := market.RefreshPriceSummaries(ctx, asOf); err != nil {
+		t.Fatalf("RefreshPriceSummaries failed: %v", err)
+	}
+	_, sell, err := market.GetPriceSummary(ctx, "ore_iron", "station_a")
+	if err != nil {
+		t.Fatalf("GetPriceSummary failed: %v", err)
+	}
+	if sell == nil {
+		t.Fatal("expected a sell summary for the historical window")
+	}
+	// The Jan 20 price is after End and must not count.
+	if sell.MaxPrice7d != 120 || sell.MinPrice7d != 100 || math.Abs(sell.AvgPrice7d-740.0/7) > 0.001 {
+		t.Errorf("expected prices from Jan 1-7 only (avg 105.7, 100-120), got avg %v, %v-%v",
+			sell.AvgPrice7d, sell.MinPrice7d, sell.MaxPrice7d)
+	}
+	if sell.PriceTrend != "rising" {
+		t.Errorf("expected rising trend as of Jan 7, got %q", sell.PriceTrend)
+	}
+
+	// An explicit start narrows the window further, for the For variant too.
+	narrow := TrendConfig{Start: day(5), End: day(7).Add(time.Hour)}
+	if err := market.RefreshPriceSummariesFor(ctx, []ItemStation{{ItemID: "ore_iron", StationID: "station_a"}}, narrow); err != nil {
+		t.Fatalf("RefreshPriceSummariesFor failed: %v", err)
+	}
+	_, sell, err = market.GetPriceSummary(ctx, "ore_iron", "station_a")
+	if err != nil {
+		t.Fatalf("GetPriceSummary failed: %v", err)
+	}
+	if sell.MinPrice7d != 120 {
+		t.Errorf("expected only the Jan 6-7 prices after Jan 5 noon, got min %v", sell.MinPrice7d)
+	}
+
+	if err := market.RefreshPriceSummaries(ctx, TrendConfig{Start: day(7), End: day(6)}); err == nil {
+		t.Error("expected an error for a window that ends before it starts")
+	}
+}
@@ -311,6 +311,225 @@ func ApplyMigration008(ctx context.Context, db *DB) error {
 	})
 }
 
+// GetMigration009 returns the summary statistics migration.
+func GetMigration009() (*Migration, error) {
+	data, err := migrationFS.ReadFile("migrations/009_add_summary_statistics.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migration{
+		ID:    "009_add_summary_statistics",
+		UpSQL: string(data),
+		DownSQL: `
+			ALTER TABLE market_price_summary DROP COLUMN median_price_7d;
+			ALTER TABLE market_price_summary DROP COLUMN volatility_7d;
+		`,
+	}, nil
+}
+
+// ApplyMigration009 applies migration 009 (median and volatility columns on
+// market_price_summary). Fresh databases already have the columns from
+// schema.sql, so each column is only added if missing.
+func ApplyMigration009(ctx context.Context, db *DB) error {
+	tracker := NewMigrationTracker(db)
+	applied, err := tracker.IsApplied(ctx, "009_add_summary_statistics")
+	if err != nil {
+		return err
+	}
+	if applied {
+		return nil
+	}
+
+	return db.InTransaction(ctx, func(tx *sql.Tx) error {
+		for _, col := range []string{"median_price_7d", "volatility_7d"} {
+			if !hasColumn(ctx, tx, "market_price_summary", col) {
+				if _, err := tx.ExecContext(ctx, fmt.Sprintf(`ALTER TABLE market_price_summary ADD COLUMN %s REAL`, col)); err != nil {
+					return err
+				}
+			}
+		}
+
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO schema_migrations (migration_id, applied_at) VALUES (?, datetime('now'))`,
+			"009_add_summary_statistics",
+		)
+		return err
+	})
+}
+
+// GetMigration010 returns the acquisition_sources table migration.
+func GetMigration010() (*Migration, error) {
+	data, err := migrationFS.ReadFile("migrations/010_add_acquisition_sources.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migration{
+		ID:      "010_add_acquisition_sources",
+		UpSQL:   string(data),
+		DownSQL: `DROP TABLE IF EXISTS acquisition_sources;`,
+	}, nil
+}
+
+// ApplyMigration010 applies migration 010 (acquisition_sources table).
+func ApplyMigration010(ctx context.Context, db *DB) error {
+	migration, err := GetMigration010()
+	if err != nil {
+		return err
+	}
+
+	migrator := NewMigrator(db)
+	return migrator.Apply(ctx, migration)
+}
+
+// GetMigration011 returns the recipe_input_alternatives table migration.
+func GetMigration011() (*Migration, error) {
+	data, err := migrationFS.ReadFile("migrations/011_add_recipe_input_alternatives.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migration{
+		ID:      "011_add_recipe_input_alternatives",
+		UpSQL:   string(data),
+		DownSQL: `DROP TABLE IF EXISTS recipe_input_alternatives;`,
+	}, nil
+}
+
+// ApplyMigration011 applies migration 011 (recipe_input_alternatives table).
+func ApplyMigration011(ctx context.Context, db *DB) error {
+	migration, err := GetMigration011()
+	if err != nil {
+		return err
+	}
+
+	migrator := NewMigrator(db)
+	return migrator.Apply(ctx, migration)
+}
+
+// GetMigration012 returns the recipe success_rate migration.
+func GetMigration012() (*Migration, error) {
+	data, err := migrationFS.ReadFile("migrations/012_add_recipe_success_rate.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migration{
+		ID:      "012_add_recipe_success_rate",
+		UpSQL:   string(data),
+		DownSQL: `ALTER TABLE recipes DROP COLUMN success_rate;`,
+	}, nil
+}
+
+// ApplyMigration012 applies migration 012 (success_rate column on recipes).
+// Fresh databases already have the column from schema.sql, so it is only
+// added if missing.
+func ApplyMigration012(ctx context.Context, db *DB) error {
+	tracker := NewMigrationTracker(db)
+	applied, err := tracker.IsApplied(ctx, "012_add_recipe_success_rate")
+	if err != nil {
+		return err
+	}
+	if applied {
+		return nil
+	}
+
+	return db.InTransaction(ctx, func(tx *sql.Tx) error {
+		if !hasColumn(ctx, tx, "recipes", "success_rate") {
+			if _, err := tx.ExecContext(ctx, `ALTER TABLE recipes ADD COLUMN success_rate REAL NOT NULL DEFAULT 1.0`); err != nil {
+				return err
+			}
+		}
+
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO schema_migrations (migration_id, applied_at) VALUES (?, datetime('now'))`,
+			"012_add_recipe_success_rate",
+		)
+		return err
+	})
+}
+
+// GetMigration013 returns the recipe_tags table migration.
+func GetMigration013() (*Migration, error) {
+	data, err := migrationFS.ReadFile("migrations/013_add_recipe_tags.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migration{
+		ID:      "013_add_recipe_tags",
+		UpSQL:   string(data),
+		DownSQL: `DROP TABLE IF EXISTS recipe_tags;`,
+	}, nil
+}
+
+// ApplyMigration013 applies migration 013 (recipe_tags table).
+func ApplyMigration013(ctx context.Context, db *DB) error {
+	migration, err := GetMigration013()
+	if err != nil {
+		return err
+	}
+
+	migrator := NewMigrator(db)
+	return migrator.Apply(ctx, migration)
+}
+
+// migrationStep is one entry in the ordered list of schema migrations.
+type migrationStep struct {
+	Version int
+	ID      string
+	Apply   func(ctx context.Context, db *DB) error
+}
+
+// migrationSteps lists every migration in the order it must be applied.
+// New migrations are appended here with the next version number; each Apply
+// runs in its own transaction and records its ID in schema_migrations, which
+// is what advances SchemaVersion.
+var migrationSteps = []migrationStep{
+	{Version: 5, ID: "005_add_enhanced_market_tables", Apply: ApplyMigration005},
+	{Version: 6, ID: "006_add_stations_table", Apply: ApplyMigration006},
+	{Version: 7, ID: "007_illegal_recipes", Apply: ApplyMigration007},
+	{Version: 8, ID: "008_remove_crafting_gates", Apply: ApplyMigration008},
+	{Version: 9, ID: "009_add_summary_statistics", Apply: ApplyMigration009},
+	{Version: 10, ID: "010_add_acquisition_sources", Apply: ApplyMigration010},
+	{Version: 11, ID: "011_add_recipe_input_alternatives", Apply: ApplyMigration011},
+	{Version: 12, ID: "012_add_recipe_success_rate", Apply: ApplyMigration012},
+	{Version: 13, ID: "013_add_recipe_tags", Apply: ApplyMigration013},
+}
+
+// LatestSchemaVersion is the schema version of a fully migrated database.
+func LatestSchemaVersion() int {
+	return migrationSteps[len(migrationSteps)-1].Version
+}
+
+// ApplyMigrations applies every pending migration in version order. Applied
+// migrations are skipped, so it is safe to call on every startup.
+func ApplyMigrations(ctx context.Context, db *DB) error {
+	for _, step := range migrationSteps {
+		if err := step.Apply(ctx, db); err != nil {
+			return fmt.Errorf("applying migration %s: %w", step.ID, err)
+		}
+	}
+	return nil
+}
+
+// SchemaVersion returns the highest migration version recorded as applied,
+// or 0 for a database that has never been migrated.
+func SchemaVersion(ctx context.Context, db *DB) (int, error) {
+	tracker := NewMigrationTracker(db)
+	for i := len(migrationSteps) - 1; i >= 0; i-- {
+		applied, err := tracker.IsApplied(ctx, migrationSteps[i].ID)
+		if err != nil {
+			return 0, err
+		}
+		if applied {
+			return migrationSteps[i].Version, nil
+		}
+	}
+	return 0, nil
+}
+
 // hasColumn checks if a table has a specific column.
 func hasColumn(ctx context.Context, tx *sql.Tx, table, column string) bool {
 	rows, err := tx.QueryContext(ctx, fmt.Sprintf(`PRAGMA table_info(%s)`, table))
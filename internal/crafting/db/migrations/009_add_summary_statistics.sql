@@ -0,0 +1,8 @@
+-- Migration 009: Add median and volatility to market price summaries
+--
+-- Average/min/max hide skew from outlier spikes. The median is robust to a
+-- few manipulated orders, and volatility (coefficient of variation, i.e.
+-- standard deviation divided by the mean) shows how much prices swing.
+
+ALTER TABLE market_price_summary ADD COLUMN median_price_7d REAL;
+ALTER TABLE market_price_summary ADD COLUMN volatility_7d REAL;
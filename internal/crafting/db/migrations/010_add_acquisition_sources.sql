@@ -0,0 +1,12 @@
+-- Migration 010: Add acquisition_sources table
+-- Records non-market ways an item can be obtained (mining, loot, missions, vendors)
+
+CREATE TABLE IF NOT EXISTS acquisition_sources (
+  item_id TEXT NOT NULL,
+  method TEXT NOT NULL,
+  detail TEXT NOT NULL DEFAULT '',
+  PRIMARY KEY (item_id, method, detail)
+);
+
+CREATE INDEX IF NOT EXISTS idx_acquisition_sources_item
+  ON acquisition_sources(item_id);
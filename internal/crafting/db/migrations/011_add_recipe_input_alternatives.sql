@@ -0,0 +1,13 @@
+-- Migration 011: Add recipe_input_alternatives table
+-- Lists interchangeable items that can stand in for a recipe input
+
+CREATE TABLE IF NOT EXISTS recipe_input_alternatives (
+  recipe_id TEXT NOT NULL,
+  item_id TEXT NOT NULL,
+  alternative_item_id TEXT NOT NULL,
+  PRIMARY KEY (recipe_id, item_id, alternative_item_id),
+  FOREIGN KEY (recipe_id) REFERENCES recipes(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_recipe_input_alternatives_item
+  ON recipe_input_alternatives(alternative_item_id);
@@ -0,0 +1,5 @@
+-- Migration 012: Add success_rate to recipes
+-- The chance (0 < rate <= 1) that one craft run produces its outputs.
+-- Existing recipes default to always succeeding.
+
+ALTER TABLE recipes ADD COLUMN success_rate REAL NOT NULL DEFAULT 1.0;
@@ -0,0 +1,12 @@
+-- Migration 013: Add recipe_tags table
+-- Free-form attributes that classify recipes beyond their single category
+
+CREATE TABLE IF NOT EXISTS recipe_tags (
+  recipe_id TEXT NOT NULL,
+  tag TEXT NOT NULL,
+  PRIMARY KEY (recipe_id, tag),
+  FOREIGN KEY (recipe_id) REFERENCES recipes(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_recipe_tags_tag
+  ON recipe_tags(tag);
@@ -183,3 +183,98 @@ func TestMigration005MarketDataEnhancement(t *testing.T) {
 		t.Errorf("MSRP should be backfilled for ore_iron: got %d, err %v", msrp, err)
 	}
 }
+
+func TestMigration009SummaryStatistics(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("opening database: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	if err := InitSchema(ctx, db.DB); err != nil {
+		t.Fatalf("initializing schema: %v", err)
+	}
+
+	// Simulate a database created before the columns existed
+	_, err = db.ExecContext(ctx, `
+		ALTER TABLE market_price_summary DROP COLUMN median_price_7d;
+		ALTER TABLE market_price_summary DROP COLUMN volatility_7d;
+	`)
+	if err != nil {
+		t.Fatalf("dropping columns: %v", err)
+	}
+
+	// Applying twice must be safe
+	for i := 0; i < 2; i++ {
+		if err := ApplyMigration009(ctx, db); err != nil {
+			t.Fatalf("applying migration 009 (pass %d): %v", i+1, err)
+		}
+	}
+
+	var median, volatility sql.NullFloat64
+	err = db.QueryRowContext(ctx,
+		`SELECT median_price_7d, volatility_7d FROM market_price_summary LIMIT 1`,
+	).Scan(&median, &volatility)
+	if err != nil && err != sql.ErrNoRows {
+		t.Errorf("median_price_7d and volatility_7d columns should exist: %v", err)
+	}
+}
+
+func TestApplyMigrations(t *testing.T) {
+	ctx := context.Background()
+	path := t.TempDir() + "/crafting.db"
+
+	db, err := OpenAndInit(ctx, path)
+	if err != nil {
+		t.Fatalf("OpenAndInit: %v", err)
+	}
+	version, err := SchemaVersion(ctx, db)
+	if err != nil {
+		t.Fatalf("SchemaVersion: %v", err)
+	}
+	if version != LatestSchemaVersion() {
+		t.Errorf("fresh database at version %d, want %d", version, LatestSchemaVersion())
+	}
+
+	// Simulate a database from before the latest migration.
+	latest := migrationSteps[len(migrationSteps)-1]
+	migration, err := GetMigration013()
+	if err != nil {
+		t.Fatalf("GetMigration013: %v", err)
+	}
+	if migration.ID != latest.ID {
+		t.Fatalf("test rolls back %s but the latest migration is %s", migration.ID, latest.ID)
+	}
+	if _, err := db.ExecContext(ctx, migration.DownSQL); err != nil {
+		t.Fatalf("rolling back: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, `DELETE FROM schema_migrations WHERE migration_id = ?`, latest.ID); err != nil {
+		t.Fatalf("forgetting migration: %v", err)
+	}
+	if version, _ := SchemaVersion(ctx, db); version != latest.Version-1 {
+		t.Errorf("expected version %d after rollback, got %d", latest.Version-1, version)
+	}
+	_ = db.Close()
+
+	// Reopening brings it back up to date, and is a no-op the second time.
+	for i := 0; i < 2; i++ {
+		db, err = OpenAndInit(ctx, path)
+		if err != nil {
+			t.Fatalf("reopening (%d): %v", i, err)
+		}
+		version, err = SchemaVersion(ctx, db)
+		if err != nil {
+			t.Fatalf("SchemaVersion: %v", err)
+		}
+		if version != LatestSchemaVersion() {
+			t.Errorf("reopened database at version %d, want %d", version, LatestSchemaVersion())
+		}
+		var n int
+		if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'recipe_tags'`).Scan(&n); err != nil || n != 1 {
+			t.Errorf("recipe_tags missing after migration (n=%d, err=%v)", n, err)
+		}
+		_ = db.Close()
+	}
+}
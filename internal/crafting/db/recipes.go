@@ -14,6 +14,59 @@ type RecipeStore struct {
 	db *DB
 }
 
+// recipeStmts are the prepared statements used by GetRecipe.
+type recipeStmts struct {
+	recipe       *sql.Stmt
+	inputs       *sql.Stmt
+	outputs      *sql.Stmt
+	alternatives *sql.Stmt
+	tags         *sql.Stmt
+}
+
+// close releases the statements, skipping any that were never prepared.
+func (st *recipeStmts) close() {
+	for _, stmt := range []*sql.Stmt{st.recipe, st.inputs, st.outputs, st.alternatives, st.tags} {
+		if stmt != nil {
+			_ = stmt.Close()
+		}
+	}
+}
+
+// recipeStatements returns the GetRecipe statements, preparing them on
+// first use. They are shared by every RecipeStore on the database and
+// closed by Close. A failed prepare is retried on the next call.
+func (db *DB) recipeStatements(ctx context.Context) (*recipeStmts, error) {
+	db.stmtMu.Lock()
+	defer db.stmtMu.Unlock()
+
+	if db.recipeStmts != nil {
+		return db.recipeStmts, nil
+	}
+
+	var stmts recipeStmts
+	queries := []struct {
+		dst   **sql.Stmt
+		query string
+	}{
+		{&stmts.recipe, `SELECT name, description, category, crafting_time, success_rate FROM recipes WHERE id = ?`},
+		{&stmts.inputs, `SELECT item_id, quantity FROM recipe_inputs WHERE recipe_id = ?`},
+		{&stmts.outputs, `SELECT item_id, quantity FROM recipe_outputs WHERE recipe_id = ?`},
+		{&stmts.alternatives, `SELECT item_id, alternative_item_id FROM recipe_input_alternatives WHERE recipe_id = ? ORDER BY item_id, alternative_item_id`},
+		{&stmts.tags, `SELECT tag FROM recipe_tags WHERE recipe_id = ? ORDER BY tag`},
+	}
+	for _, q := range queries {
+		stmt, err := db.PrepareContext(ctx, q.query)
+		if err != nil {
+			stmts.close()
+			return nil, fmt.Errorf("preparing recipe statements: %w", err)
+		}
+		*q.dst = stmt
+	}
+
+	db.recipeStmts = &stmts
+	return db.recipeStmts, nil
+}
+
 // NewRecipeStore creates a new RecipeStore.
 func NewRecipeStore(db *DB) *RecipeStore {
 	return &RecipeStore{db: db}
@@ -21,16 +74,19 @@ func NewRecipeStore(db *DB) *RecipeStore {
 
 // GetRecipe retrieves a single recipe by ID with all its inputs and outputs.
 func (s *RecipeStore) GetRecipe(ctx context.Context, id string) (*crafting.Recipe, error) {
+	stmts, err := s.db.recipeStatements(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	recipe := &crafting.Recipe{ID: id}
 
-	err := s.db.QueryRowContext(ctx, `
-		SELECT name, description, category, crafting_time
-		FROM recipes WHERE id = ?
-	`, id).Scan(
+	err = stmts.recipe.QueryRowContext(ctx, id).Scan(
 		&recipe.Name,
 		&recipe.Description,
 		&recipe.Category,
 		&recipe.CraftingTime,
+		&recipe.SuccessRate,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -40,29 +96,62 @@ func (s *RecipeStore) GetRecipe(ctx context.Context, id string) (*crafting.Recip
 	}
 
 	// Get inputs
-	inputs, err := s.getRecipeInputs(ctx, id)
+	inputs, err := getRecipeInputs(ctx, stmts.inputs, id)
+	if err != nil {
+		return nil, err
+	}
+	alternatives, err := getRecipeAlternatives(ctx, stmts.alternatives, id)
 	if err != nil {
 		return nil, err
 	}
+	attachAlternatives(inputs, alternatives)
 	recipe.Inputs = inputs
 
 	// Get outputs
-	outputs, err := s.getRecipeOutputs(ctx, id)
+	outputs, err := getRecipeOutputs(ctx, stmts.outputs, id)
 	if err != nil {
 		return nil, err
 	}
 	recipe.Outputs = outputs
 
+	tags, err := getRecipeTags(ctx, stmts.tags, id)
+	if err != nil {
+		return nil, err
+	}
+	recipe.Tags = tags
+
 	return recipe, nil
 }
 
+// getRecipeTags retrieves a recipe's tags in sorted order.
+func getRecipeTags(ctx context.Context, stmt *sql.Stmt, recipeID string) ([]string, error) {
+	rows, err := stmt.QueryContext(ctx, recipeID)
+	if err != nil {
+		return nil, fmt.Errorf("querying recipe tags: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var tags []string
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, fmt.Errorf("scanning tag: %w", err)
+		}
+		tags = append(tags, tag)
+	}
+
+	return tags, rows.Err()
+}
+
+// NormalizeTag returns the stored form of a recipe tag: trimmed and lower
+// case, so tag filters are case-insensitive.
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
 // getRecipeInputs retrieves inputs for a recipe.
-func (s *RecipeStore) getRecipeInputs(ctx context.Context, recipeID string) ([]crafting.RecipeInput, error) {
-	rows, err := s.db.QueryContext(ctx, `
-		SELECT item_id, quantity
-		FROM recipe_inputs
-		WHERE recipe_id = ?
-	`, recipeID)
+func getRecipeInputs(ctx context.Context, stmt *sql.Stmt, recipeID string) ([]crafting.RecipeInput, error) {
+	rows, err := stmt.QueryContext(ctx, recipeID)
 	if err != nil {
 		return nil, fmt.Errorf("querying recipe inputs: %w", err)
 	}
@@ -80,13 +169,38 @@ func (s *RecipeStore) getRecipeInputs(ctx context.Context, recipeID string) ([]c
 	return inputs, rows.Err()
 }
 
+// getRecipeAlternatives retrieves the input alternatives for a recipe, keyed
+// by the input item they can replace.
+func getRecipeAlternatives(ctx context.Context, stmt *sql.Stmt, recipeID string) (map[string][]string, error) {
+	rows, err := stmt.QueryContext(ctx, recipeID)
+	if err != nil {
+		return nil, fmt.Errorf("querying recipe input alternatives: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	alternatives := make(map[string][]string)
+	for rows.Next() {
+		var itemID, altID string
+		if err := rows.Scan(&itemID, &altID); err != nil {
+			return nil, fmt.Errorf("scanning input alternative: %w", err)
+		}
+		alternatives[itemID] = append(alternatives[itemID], altID)
+	}
+
+	return alternatives, rows.Err()
+}
+
+// attachAlternatives sets each input's Alternatives from a map keyed by
+// input item ID. Inputs without alternatives are left with a nil slice.
+func attachAlternatives(inputs []crafting.RecipeInput, alternatives map[string][]string) {
+	for i := range inputs {
+		inputs[i].Alternatives = alternatives[inputs[i].ItemID]
+	}
+}
+
 // getRecipeOutputs retrieves outputs for a recipe.
-func (s *RecipeStore) getRecipeOutputs(ctx context.Context, recipeID string) ([]crafting.RecipeOutput, error) {
-	rows, err := s.db.QueryContext(ctx, `
-		SELECT item_id, quantity
-		FROM recipe_outputs
-		WHERE recipe_id = ?
-	`, recipeID)
+func getRecipeOutputs(ctx context.Context, stmt *sql.Stmt, recipeID string) ([]crafting.RecipeOutput, error) {
+	rows, err := stmt.QueryContext(ctx, recipeID)
 	if err != nil {
 		return nil, fmt.Errorf("querying recipe outputs: %w", err)
 	}
@@ -143,6 +257,108 @@ func (s *RecipeStore) FindRecipesByComponents(ctx context.Context, itemIDs []str
 	return recipeIDs, rows.Err()
 }
 
+// FindInputItemsByPrefix returns the distinct item IDs beginning with
+// prefix that some recipe takes as an input or an alternative, sorted. The
+// prefix is matched literally and case-sensitively.
+func (s *RecipeStore) FindInputItemsByPrefix(ctx context.Context, prefix string) ([]string, error) {
+	pattern := escapeLike(prefix) + "%"
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT item_id FROM recipe_inputs WHERE item_id LIKE ? ESCAPE '\'
+		UNION
+		SELECT alternative_item_id FROM recipe_input_alternatives WHERE alternative_item_id LIKE ? ESCAPE '\'
+		ORDER BY 1
+	`, pattern, pattern)
+	if err != nil {
+		return nil, fmt.Errorf("finding input items by prefix: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var itemIDs []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scanning item id: %w", err)
+		}
+		// LIKE ignores ASCII case
+		if strings.HasPrefix(id, prefix) {
+			itemIDs = append(itemIDs, id)
+		}
+	}
+
+	return itemIDs, rows.Err()
+}
+
+// RecipeCoverage reports how many of a recipe's inputs are covered by a
+// supplied set of items.
+type RecipeCoverage struct {
+	RecipeID string
+	Matched  int // Inputs present in the supplied set, or needing no quantity
+	Total    int // All inputs of the recipe
+}
+
+// FindRecipesByComponentCoverage finds recipes that use any of the given
+// items as inputs and whose coverage (Matched/Total) is at least minRatio.
+// Coverage only considers which items are present, not quantities, so it is
+// an upper bound on the quantity-aware match ratio and safe for pre-filtering.
+// Malformed inputs with a non-positive quantity count as covered, matching
+// how the engine treats them.
+func (s *RecipeStore) FindRecipesByComponentCoverage(ctx context.Context, itemIDs []string, minRatio float64) ([]RecipeCoverage, error) {
+	if len(itemIDs) == 0 {
+		return nil, nil
+	}
+
+	// Build placeholders; the item list is bound four times
+	placeholders := make([]string, len(itemIDs))
+	idArgs := make([]interface{}, len(itemIDs))
+	for i, id := range itemIDs {
+		placeholders[i] = "?"
+		idArgs[i] = id
+	}
+	in := strings.Join(placeholders, ",")
+	args := make([]interface{}, 0, 4*len(idArgs)+1)
+	for range 4 {
+		args = append(args, idArgs...)
+	}
+	args = append(args, minRatio)
+
+	// An input also counts as covered when one of its alternatives is supplied.
+	query := fmt.Sprintf(`
+		SELECT i.recipe_id,
+		       SUM(CASE WHEN i.item_id IN (%[1]s) OR i.quantity <= 0 OR EXISTS (
+		           SELECT 1 FROM recipe_input_alternatives a
+		           WHERE a.recipe_id = i.recipe_id AND a.item_id = i.item_id
+		             AND a.alternative_item_id IN (%[1]s)
+		       ) THEN 1 ELSE 0 END) AS matched,
+		       COUNT(*) AS total
+		FROM recipe_inputs i
+		WHERE i.recipe_id IN (
+		    SELECT recipe_id FROM recipe_inputs WHERE item_id IN (%[1]s)
+		    UNION
+		    SELECT recipe_id FROM recipe_input_alternatives WHERE alternative_item_id IN (%[1]s)
+		)
+		GROUP BY i.recipe_id
+		HAVING CAST(matched AS REAL) / COUNT(*) >= ?
+		ORDER BY i.recipe_id
+	`, in)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("finding recipes by input coverage: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var coverage []RecipeCoverage
+	for rows.Next() {
+		var c RecipeCoverage
+		if err := rows.Scan(&c.RecipeID, &c.Matched, &c.Total); err != nil {
+			return nil, fmt.Errorf("scanning recipe coverage: %w", err)
+		}
+		coverage = append(coverage, c)
+	}
+
+	return coverage, rows.Err()
+}
+
 // FindRecipesByOutput finds recipes that produce a given item.
 func (s *RecipeStore) FindRecipesByOutput(ctx context.Context, itemID string) ([]string, error) {
 	rows, err := s.db.QueryContext(ctx, `
@@ -166,13 +382,14 @@ func (s *RecipeStore) FindRecipesByOutput(ctx context.Context, itemID string) ([
 }
 
 // SearchRecipes searches recipes by name (case-insensitive partial match).
+// The term is matched literally: LIKE wildcards in it are escaped.
 func (s *RecipeStore) SearchRecipes(ctx context.Context, term string, limit int) ([]crafting.RecipeSearchHit, error) {
 	rows, err := s.db.QueryContext(ctx, `
 		SELECT id, name, category
 		FROM recipes
-		WHERE name LIKE ?
+		WHERE name LIKE ? ESCAPE '\'
 		LIMIT ?
-	`, "%"+term+"%", limit)
+	`, "%"+escapeLike(term)+"%", limit)
 	if err != nil {
 		return nil, fmt.Errorf("searching recipes: %w", err)
 	}
@@ -190,6 +407,15 @@ func (s *RecipeStore) SearchRecipes(ctx context.Context, term string, limit int)
 	return results, rows.Err()
 }
 
+// likeEscaper escapes the LIKE wildcards and the escape character itself,
+// for patterns used with ESCAPE '\'.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// escapeLike makes term match literally inside a LIKE pattern.
+func escapeLike(term string) string {
+	return likeEscaper.Replace(term)
+}
+
 // ListRecipesByCategory lists all recipes in a category.
 func (s *RecipeStore) ListRecipesByCategory(ctx context.Context, category string) ([]string, error) {
 	rows, err := s.db.QueryContext(ctx, `
@@ -212,6 +438,79 @@ func (s *RecipeStore) ListRecipesByCategory(ctx context.Context, category string
 	return ids, rows.Err()
 }
 
+// ListRecipesByCategories lists all recipes in any of the given categories.
+func (s *RecipeStore) ListRecipesByCategories(ctx context.Context, categories []string) ([]string, error) {
+	if len(categories) == 0 {
+		return nil, nil
+	}
+
+	placeholders := make([]string, len(categories))
+	args := make([]interface{}, len(categories))
+	for i, c := range categories {
+		placeholders[i] = "?"
+		args[i] = c
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id FROM recipes WHERE category IN (%s)
+	`, strings.Join(placeholders, ","))
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("listing recipes by categories: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scanning recipe id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// CategoryCount is a category name with the number of entries in it.
+type CategoryCount struct {
+	Category string
+	Count    int
+}
+
+// ListCategories returns every distinct recipe category with its recipe
+// count, ordered by category. Uncategorized recipes are omitted.
+func (s *RecipeStore) ListCategories(ctx context.Context) ([]CategoryCount, error) {
+	return listCategories(ctx, s.db, "recipes")
+}
+
+// listCategories counts the rows per non-empty category of table.
+func listCategories(ctx context.Context, db *DB, table string) ([]CategoryCount, error) {
+	rows, err := db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT category, COUNT(*)
+		FROM %s
+		WHERE category IS NOT NULL AND category != ''
+		GROUP BY category
+		ORDER BY category
+	`, table))
+	if err != nil {
+		return nil, fmt.Errorf("listing %s categories: %w", table, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var categories []CategoryCount
+	for rows.Next() {
+		var c CategoryCount
+		if err := rows.Scan(&c.Category, &c.Count); err != nil {
+			return nil, fmt.Errorf("scanning category: %w", err)
+		}
+		categories = append(categories, c)
+	}
+
+	return categories, rows.Err()
+}
+
 // GetAllRecipeIDs returns all recipe IDs in the database.
 func (s *RecipeStore) GetAllRecipeIDs(ctx context.Context) ([]string, error) {
 	rows, err := s.db.QueryContext(ctx, `SELECT id FROM recipes`)
@@ -245,7 +544,7 @@ func (s *RecipeStore) CountRecipes(ctx context.Context) (int, error) {
 // GetAllRecipes retrieves all recipes with their inputs and outputs.
 func (s *RecipeStore) GetAllRecipes(ctx context.Context) ([]crafting.Recipe, error) {
 	rows, err := s.db.QueryContext(ctx, `
-		SELECT id, name, description, category, crafting_time
+		SELECT id, name, description, category, crafting_time, success_rate
 		FROM recipes
 	`)
 	if err != nil {
@@ -262,6 +561,7 @@ func (s *RecipeStore) GetAllRecipes(ctx context.Context) ([]crafting.Recipe, err
 			&r.Description,
 			&r.Category,
 			&r.CraftingTime,
+			&r.SuccessRate,
 		); err != nil {
 			return nil, fmt.Errorf("scanning recipe: %w", err)
 		}
@@ -272,22 +572,134 @@ func (s *RecipeStore) GetAllRecipes(ctx context.Context) ([]crafting.Recipe, err
 		return nil, err
 	}
 
-	// Load inputs and outputs for all recipes
+	// Load inputs and outputs for all recipes in one query each rather than
+	// two per recipe
+	inputs, err := s.getAllRecipeInputs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	outputs, err := s.getAllRecipeOutputs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	alternatives, err := s.getAllRecipeAlternatives(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := s.getAllRecipeTags(ctx)
+	if err != nil {
+		return nil, err
+	}
 	for i := range recipes {
-		inputs, err := s.getRecipeInputs(ctx, recipes[i].ID)
-		if err != nil {
-			return nil, fmt.Errorf("loading inputs for %s: %w", recipes[i].ID, err)
+		recipes[i].Inputs = inputs[recipes[i].ID]
+		recipes[i].Outputs = outputs[recipes[i].ID]
+		recipes[i].Tags = tags[recipes[i].ID]
+		attachAlternatives(recipes[i].Inputs, alternatives[recipes[i].ID])
+	}
+
+	return recipes, nil
+}
+
+// getAllRecipeInputs retrieves the inputs of every recipe, keyed by recipe ID.
+func (s *RecipeStore) getAllRecipeInputs(ctx context.Context) (map[string][]crafting.RecipeInput, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT recipe_id, item_id, quantity
+		FROM recipe_inputs
+		ORDER BY recipe_id, item_id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("querying all recipe inputs: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	inputs := make(map[string][]crafting.RecipeInput)
+	for rows.Next() {
+		var recipeID string
+		var inp crafting.RecipeInput
+		if err := rows.Scan(&recipeID, &inp.ItemID, &inp.Quantity); err != nil {
+			return nil, fmt.Errorf("scanning input: %w", err)
 		}
-		recipes[i].Inputs = inputs
+		inputs[recipeID] = append(inputs[recipeID], inp)
+	}
 
-		outputs, err := s.getRecipeOutputs(ctx, recipes[i].ID)
-		if err != nil {
-			return nil, fmt.Errorf("loading outputs for %s: %w", recipes[i].ID, err)
+	return inputs, rows.Err()
+}
+
+// getAllRecipeOutputs retrieves the outputs of every recipe, keyed by recipe ID.
+func (s *RecipeStore) getAllRecipeOutputs(ctx context.Context) (map[string][]crafting.RecipeOutput, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT recipe_id, item_id, quantity
+		FROM recipe_outputs
+		ORDER BY recipe_id, item_id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("querying all recipe outputs: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	outputs := make(map[string][]crafting.RecipeOutput)
+	for rows.Next() {
+		var recipeID string
+		var out crafting.RecipeOutput
+		if err := rows.Scan(&recipeID, &out.ItemID, &out.Quantity); err != nil {
+			return nil, fmt.Errorf("scanning output: %w", err)
 		}
-		recipes[i].Outputs = outputs
+		outputs[recipeID] = append(outputs[recipeID], out)
 	}
 
-	return recipes, nil
+	return outputs, rows.Err()
+}
+
+// getAllRecipeAlternatives retrieves the input alternatives of every recipe,
+// keyed by recipe ID and then by the input item they can replace.
+func (s *RecipeStore) getAllRecipeAlternatives(ctx context.Context) (map[string]map[string][]string, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT recipe_id, item_id, alternative_item_id
+		FROM recipe_input_alternatives
+		ORDER BY recipe_id, item_id, alternative_item_id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("querying all recipe input alternatives: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	alternatives := make(map[string]map[string][]string)
+	for rows.Next() {
+		var recipeID, itemID, altID string
+		if err := rows.Scan(&recipeID, &itemID, &altID); err != nil {
+			return nil, fmt.Errorf("scanning input alternative: %w", err)
+		}
+		if alternatives[recipeID] == nil {
+			alternatives[recipeID] = make(map[string][]string)
+		}
+		alternatives[recipeID][itemID] = append(alternatives[recipeID][itemID], altID)
+	}
+
+	return alternatives, rows.Err()
+}
+
+// getAllRecipeTags retrieves the tags of every recipe, keyed by recipe ID.
+func (s *RecipeStore) getAllRecipeTags(ctx context.Context) (map[string][]string, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT recipe_id, tag
+		FROM recipe_tags
+		ORDER BY recipe_id, tag
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("querying all recipe tags: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	tags := make(map[string][]string)
+	for rows.Next() {
+		var recipeID, tag string
+		if err := rows.Scan(&recipeID, &tag); err != nil {
+			return nil, fmt.Errorf("scanning tag: %w", err)
+		}
+		tags[recipeID] = append(tags[recipeID], tag)
+	}
+
+	return tags, rows.Err()
 }
 
 // GetRecipesUsingOutput finds recipes that use a given item as an input.
@@ -316,6 +728,8 @@ func (s *RecipeStore) GetRecipesUsingOutput(ctx context.Context, itemID string)
 
 // BulkInsertRecipes inserts multiple recipes in a transaction.
 func (s *RecipeStore) BulkInsertRecipes(ctx context.Context, recipes []crafting.Recipe) error {
+	defer s.db.recipeGen.Add(1)
+
 	return s.db.InTransaction(ctx, func(tx *sql.Tx) error {
 		// Remove recipes that are no longer in the import set.
 		importedIDs := make(map[string]struct{}, len(recipes))
@@ -344,8 +758,8 @@ func (s *RecipeStore) BulkInsertRecipes(ctx context.Context, recipes []crafting.
 			return fmt.Errorf("iterating existing recipes: %w", err)
 		}
 
-		// Delete stale recipes and their child rows explicitly
-		// (CASCADE may not fire if foreign_keys pragma is off).
+		// Delete stale recipes and their child rows explicitly, as
+		// DeleteRecipe does (see recipeChildTables).
 		if len(staleIDs) > 0 {
 			delRecipeStmt, err := tx.PrepareContext(ctx, `DELETE FROM recipes WHERE id = ?`)
 			if err != nil {
@@ -353,24 +767,21 @@ func (s *RecipeStore) BulkInsertRecipes(ctx context.Context, recipes []crafting.
 			}
 			defer func() { _ = delRecipeStmt.Close() }()
 
-			delStaleInputs, err := tx.PrepareContext(ctx, `DELETE FROM recipe_inputs WHERE recipe_id = ?`)
-			if err != nil {
-				return fmt.Errorf("preparing delete stale inputs: %w", err)
-			}
-			defer func() { _ = delStaleInputs.Close() }()
-
-			delStaleOutputs, err := tx.PrepareContext(ctx, `DELETE FROM recipe_outputs WHERE recipe_id = ?`)
-			if err != nil {
-				return fmt.Errorf("preparing delete stale outputs: %w", err)
+			childStmts := make([]*sql.Stmt, len(recipeChildTables))
+			for i, table := range recipeChildTables {
+				stmt, err := tx.PrepareContext(ctx, `DELETE FROM `+table+` WHERE recipe_id = ?`)
+				if err != nil {
+					return fmt.Errorf("preparing delete stale %s: %w", table, err)
+				}
+				defer func() { _ = stmt.Close() }()
+				childStmts[i] = stmt
 			}
-			defer func() { _ = delStaleOutputs.Close() }()
 
 			for _, id := range staleIDs {
-				if _, err := delStaleInputs.ExecContext(ctx, id); err != nil {
-					return fmt.Errorf("deleting stale inputs for %s: %w", id, err)
-				}
-				if _, err := delStaleOutputs.ExecContext(ctx, id); err != nil {
-					return fmt.Errorf("deleting stale outputs for %s: %w", id, err)
+				for i, stmt := range childStmts {
+					if _, err := stmt.ExecContext(ctx, id); err != nil {
+						return fmt.Errorf("deleting stale %s for %s: %w", recipeChildTables[i], id, err)
+					}
 				}
 				if _, err := delRecipeStmt.ExecContext(ctx, id); err != nil {
 					return fmt.Errorf("deleting stale recipe %s: %w", id, err)
@@ -381,8 +792,8 @@ func (s *RecipeStore) BulkInsertRecipes(ctx context.Context, recipes []crafting.
 		// Prepare statements
 		recipeStmt, err := tx.PrepareContext(ctx, `
 			INSERT OR REPLACE INTO recipes
-			(id, name, description, category, crafting_time, last_updated_tick)
-			VALUES (?, ?, ?, ?, ?, ?)
+			(id, name, description, category, crafting_time, success_rate, last_updated_tick)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
 		`)
 		if err != nil {
 			return fmt.Errorf("preparing recipe statement: %w", err)
@@ -402,6 +813,18 @@ func (s *RecipeStore) BulkInsertRecipes(ctx context.Context, recipes []crafting.
 		}
 		defer func() { _ = delOutputsStmt.Close() }()
 
+		delAlternativesStmt, err := tx.PrepareContext(ctx, `DELETE FROM recipe_input_alternatives WHERE recipe_id = ?`)
+		if err != nil {
+			return fmt.Errorf("preparing delete alternatives statement: %w", err)
+		}
+		defer func() { _ = delAlternativesStmt.Close() }()
+
+		delTagsStmt, err := tx.PrepareContext(ctx, `DELETE FROM recipe_tags WHERE recipe_id = ?`)
+		if err != nil {
+			return fmt.Errorf("preparing delete tags statement: %w", err)
+		}
+		defer func() { _ = delTagsStmt.Close() }()
+
 		inputStmt, err := tx.PrepareContext(ctx, `
 			INSERT INTO recipe_inputs (recipe_id, item_id, quantity)
 			VALUES (?, ?, ?)
@@ -420,10 +843,29 @@ func (s *RecipeStore) BulkInsertRecipes(ctx context.Context, recipes []crafting.
 		}
 		defer func() { _ = outputStmt.Close() }()
 
+		alternativeStmt, err := tx.PrepareContext(ctx, `
+			INSERT OR IGNORE INTO recipe_input_alternatives (recipe_id, item_id, alternative_item_id)
+			VALUES (?, ?, ?)
+		`)
+		if err != nil {
+			return fmt.Errorf("preparing alternative statement: %w", err)
+		}
+		defer func() { _ = alternativeStmt.Close() }()
+
+		tagStmt, err := tx.PrepareContext(ctx, `
+			INSERT OR IGNORE INTO recipe_tags (recipe_id, tag)
+			VALUES (?, ?)
+		`)
+		if err != nil {
+			return fmt.Errorf("preparing tag statement: %w", err)
+		}
+		defer func() { _ = tagStmt.Close() }()
+
 		for _, r := range recipes {
 			_, err := recipeStmt.ExecContext(ctx,
 				r.ID, r.Name, r.Description, r.Category,
-				r.CraftingTime, 0, // last_updated_tick defaults to 0
+				r.CraftingTime, storedSuccessRate(r.SuccessRate),
+				0, // last_updated_tick defaults to 0
 			)
 			if err != nil {
 				return fmt.Errorf("inserting recipe %s: %w", r.ID, err)
@@ -436,12 +878,26 @@ func (s *RecipeStore) BulkInsertRecipes(ctx context.Context, recipes []crafting.
 			if _, err := delOutputsStmt.ExecContext(ctx, r.ID); err != nil {
 				return fmt.Errorf("clearing outputs for %s: %w", r.ID, err)
 			}
+			if _, err := delAlternativesStmt.ExecContext(ctx, r.ID); err != nil {
+				return fmt.Errorf("clearing alternatives for %s: %w", r.ID, err)
+			}
+			if _, err := delTagsStmt.ExecContext(ctx, r.ID); err != nil {
+				return fmt.Errorf("clearing tags for %s: %w", r.ID, err)
+			}
 
 			for _, inp := range r.Inputs {
 				_, err := inputStmt.ExecContext(ctx, r.ID, inp.ItemID, inp.Quantity)
 				if err != nil {
 					return fmt.Errorf("inserting input for %s: %w", r.ID, err)
 				}
+				for _, alt := range inp.Alternatives {
+					if alt == inp.ItemID {
+						continue
+					}
+					if _, err := alternativeStmt.ExecContext(ctx, r.ID, inp.ItemID, alt); err != nil {
+						return fmt.Errorf("inserting alternative for %s: %w", r.ID, err)
+					}
+				}
 			}
 
 			for _, out := range r.Outputs {
@@ -450,17 +906,74 @@ func (s *RecipeStore) BulkInsertRecipes(ctx context.Context, recipes []crafting.
 					return fmt.Errorf("inserting output for %s: %w", r.ID, err)
 				}
 			}
+
+			for _, tag := range r.Tags {
+				tag = NormalizeTag(tag)
+				if tag == "" {
+					continue
+				}
+				if _, err := tagStmt.ExecContext(ctx, r.ID, tag); err != nil {
+					return fmt.Errorf("inserting tag for %s: %w", r.ID, err)
+				}
+			}
 		}
 
 		return nil
 	})
 }
 
+// storedSuccessRate maps an unset or out-of-range success rate to 1.0 so
+// the column always holds a usable probability.
+func storedSuccessRate(rate float64) float64 {
+	if rate <= 0 || rate > 1 {
+		return 1
+	}
+	return rate
+}
+
+// recipeChildTables lists the tables keyed by recipe_id. Their ON DELETE
+// CASCADE clauses only fire with the foreign_keys pragma on, which this
+// package does not enable, so deletes clear them explicitly.
+var recipeChildTables = []string{"recipe_inputs", "recipe_outputs", "recipe_input_alternatives", "recipe_tags", "illegal_recipes"}
+
 // ClearRecipes removes all recipe data (for re-sync).
 func (s *RecipeStore) ClearRecipes(ctx context.Context) error {
+	defer s.db.recipeGen.Add(1)
+
 	return s.db.InTransaction(ctx, func(tx *sql.Tx) error {
-		// Foreign keys will cascade delete inputs and outputs
+		for _, table := range recipeChildTables {
+			if _, err := tx.ExecContext(ctx, `DELETE FROM `+table); err != nil {
+				return fmt.Errorf("clearing %s: %w", table, err)
+			}
+		}
 		_, err := tx.ExecContext(ctx, `DELETE FROM recipes`)
 		return err
 	})
 }
+
+// DeleteRecipe removes a single recipe along with its inputs, outputs and
+// illegal status. It reports whether the recipe existed.
+func (s *RecipeStore) DeleteRecipe(ctx context.Context, id string) (bool, error) {
+	defer s.db.recipeGen.Add(1)
+
+	var deleted bool
+	err := s.db.InTransaction(ctx, func(tx *sql.Tx) error {
+		for _, table := range recipeChildTables {
+			if _, err := tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE recipe_id = ?`, id); err != nil {
+				return fmt.Errorf("deleting %s for %s: %w", table, id, err)
+			}
+		}
+
+		result, err := tx.ExecContext(ctx, `DELETE FROM recipes WHERE id = ?`, id)
+		if err != nil {
+			return fmt.Errorf("deleting recipe %s: %w", id, err)
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		deleted = n > 0
+		return nil
+	})
+	return deleted, err
+}
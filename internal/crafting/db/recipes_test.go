@@ -0,0 +1,544 @@
+package db
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
+)
+
+func TestGetAllRecipesMatchesGetRecipe(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	store := NewRecipeStore(db)
+
+	recipes := []crafting.Recipe{
+		{
+			ID: "build_hull", Name: "Build Hull", Category: "Ship", CraftingTime: 30,
+			Inputs: []crafting.RecipeInput{
+				{ItemID: "rivet", Quantity: 4},
+				{ItemID: "plate", Quantity: 2},
+			},
+			Outputs: []crafting.RecipeOutput{{ItemID: "hull", Quantity: 1}},
+		},
+		{
+			ID: "refine_gas", Name: "Refine Gas",
+			Inputs: []crafting.RecipeInput{{ItemID: "gas", Quantity: 5}},
+			Outputs: []crafting.RecipeOutput{
+				{ItemID: "fuel", Quantity: 2},
+				{ItemID: "residue", Quantity: 1},
+			},
+		},
+		{ID: "no_parts", Name: "No Parts"},
+	}
+	if err := store.BulkInsertRecipes(ctx, recipes); err != nil {
+		t.Fatalf("BulkInsertRecipes failed: %v", err)
+	}
+
+	all, err := store.GetAllRecipes(ctx)
+	if err != nil {
+		t.Fatalf("GetAllRecipes failed: %v", err)
+	}
+	if len(all) != len(recipes) {
+		t.Fatalf("expected %d recipes, got %d", len(recipes), len(all))
+	}
+
+	for _, got := range all {
+		want, err := store.GetRecipe(ctx, got.ID)
+		if err != nil {
+			t.Fatalf("GetRecipe(%s) failed: %v", got.ID, err)
+		}
+		if !reflect.DeepEqual(&got, want) {
+			t.Errorf("recipe %s: GetAllRecipes returned %+v, GetRecipe returned %+v", got.ID, got, *want)
+		}
+	}
+}
+
+func TestFindRecipesByComponentCoverage(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	store := NewRecipeStore(db)
+
+	recipes := []crafting.Recipe{
+		{ID: "one_of_one", Name: "One of One", Inputs: []crafting.RecipeInput{{ItemID: "ore", Quantity: 1}}},
+		{ID: "one_of_two", Name: "One of Two", Inputs: []crafting.RecipeInput{{ItemID: "ore", Quantity: 1}, {ItemID: "gas", Quantity: 1}}},
+		{ID: "one_of_four", Name: "One of Four", Inputs: []crafting.RecipeInput{
+			{ItemID: "ore", Quantity: 1}, {ItemID: "gas", Quantity: 1},
+			{ItemID: "ice", Quantity: 1}, {ItemID: "gem", Quantity: 1},
+		}},
+		{ID: "unrelated", Name: "Unrelated", Inputs: []crafting.RecipeInput{{ItemID: "gas", Quantity: 1}}},
+	}
+	if err := store.BulkInsertRecipes(ctx, recipes); err != nil {
+		t.Fatalf("BulkInsertRecipes failed: %v", err)
+	}
+
+	tests := []struct {
+		minRatio float64
+		want     []RecipeCoverage
+	}{
+		{0.25, []RecipeCoverage{
+			{RecipeID: "one_of_four", Matched: 1, Total: 4},
+			{RecipeID: "one_of_one", Matched: 1, Total: 1},
+			{RecipeID: "one_of_two", Matched: 1, Total: 2},
+		}},
+		{0.5, []RecipeCoverage{
+			{RecipeID: "one_of_one", Matched: 1, Total: 1},
+			{RecipeID: "one_of_two", Matched: 1, Total: 2},
+		}},
+		{1.0, []RecipeCoverage{
+			{RecipeID: "one_of_one", Matched: 1, Total: 1},
+		}},
+	}
+
+	for _, tt := range tests {
+		got, err := store.FindRecipesByComponentCoverage(ctx, []string{"ore", "ore"}, tt.minRatio)
+		if err != nil {
+			t.Fatalf("FindRecipesByComponentCoverage(%v) failed: %v", tt.minRatio, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("minRatio %v: got %+v, want %+v", tt.minRatio, got, tt.want)
+		}
+	}
+}
+
+func BenchmarkGetRecipe(b *testing.B) {
+	ctx := context.Background()
+	db, err := Open(":memory:")
+	if err != nil {
+		b.Fatalf("opening database: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+	if err := InitSchema(ctx, db.DB); err != nil {
+		b.Fatalf("initializing schema: %v", err)
+	}
+
+	store := NewRecipeStore(db)
+	recipes := []crafting.Recipe{{
+		ID: "build_hull", Name: "Build Hull",
+		Inputs:  []crafting.RecipeInput{{ItemID: "plate", Quantity: 2}, {ItemID: "rivet", Quantity: 4}},
+		Outputs: []crafting.RecipeOutput{{ItemID: "hull", Quantity: 1}},
+	}}
+	if err := store.BulkInsertRecipes(ctx, recipes); err != nil {
+		b.Fatalf("BulkInsertRecipes failed: %v", err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := store.GetRecipe(ctx, "build_hull"); err != nil {
+			b.Fatalf("GetRecipe failed: %v", err)
+		}
+	}
+}
+
+func TestListCategories(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	recipes := []crafting.Recipe{
+		{ID: "a", Name: "A", Category: "Ship"},
+		{ID: "b", Name: "B", Category: "Ammunition"},
+		{ID: "c", Name: "C", Category: "Ship"},
+		{ID: "d", Name: "D"},
+	}
+	if err := NewRecipeStore(db).BulkInsertRecipes(ctx, recipes); err != nil {
+		t.Fatalf("BulkInsertRecipes failed: %v", err)
+	}
+	skills := []crafting.Skill{
+		{ID: "mining", Name: "Mining", Category: "gathering"},
+		{ID: "salvage", Name: "Salvage", Category: "gathering"},
+	}
+	if err := NewSkillStore(db).BulkInsertSkills(ctx, skills); err != nil {
+		t.Fatalf("BulkInsertSkills failed: %v", err)
+	}
+
+	got, err := NewRecipeStore(db).ListCategories(ctx)
+	if err != nil {
+		t.Fatalf("RecipeStore.ListCategories failed: %v", err)
+	}
+	want := []CategoryCount{{Category: "Ammunition", Count: 1}, {Category: "Ship", Count: 2}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("recipe categories: got %+v, want %+v", got, want)
+	}
+
+	got, err = NewSkillStore(db).ListCategories(ctx)
+	if err != nil {
+		t.Fatalf("SkillStore.ListCategories failed: %v", err)
+	}
+	want = []CategoryCount{{Category: "gathering", Count: 2}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("skill categories: got %+v, want %+v", got, want)
+	}
+}
+
+func TestDeleteRecipe(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	store := NewRecipeStore(db)
+	recipes := []crafting.Recipe{
+		{
+			ID: "smelt_iron", Name: "Smelt Iron",
+			Inputs:  []crafting.RecipeInput{{ItemID: "ore_iron", Quantity: 3}},
+			Outputs: []crafting.RecipeOutput{{ItemID: "iron_bar", Quantity: 1}},
+		},
+		{
+			ID: "smelt_copper", Name: "Smelt Copper",
+			Inputs:  []crafting.RecipeInput{{ItemID: "ore_copper", Quantity: 3}},
+			Outputs: []crafting.RecipeOutput{{ItemID: "copper_bar", Quantity: 1}},
+		},
+	}
+	if err := store.BulkInsertRecipes(ctx, recipes); err != nil {
+		t.Fatalf("BulkInsertRecipes failed: %v", err)
+	}
+	if err := NewIllegalRecipesStore(db).MarkIllegal(ctx, "smelt_iron", "banned", "nowhere"); err != nil {
+		t.Fatalf("MarkIllegal failed: %v", err)
+	}
+
+	gen := db.RecipeGeneration()
+	deleted, err := store.DeleteRecipe(ctx, "smelt_iron")
+	if err != nil {
+		t.Fatalf("DeleteRecipe failed: %v", err)
+	}
+	if !deleted {
+		t.Error("expected DeleteRecipe to report the recipe was deleted")
+	}
+	if db.RecipeGeneration() == gen {
+		t.Error("expected DeleteRecipe to invalidate recipe caches")
+	}
+
+	for _, table := range []string{"recipe_inputs", "recipe_outputs", "illegal_recipes"} {
+		var n int
+		if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM `+table+` WHERE recipe_id = 'smelt_iron'`).Scan(&n); err != nil {
+			t.Fatalf("counting %s: %v", table, err)
+		}
+		if n != 0 {
+			t.Errorf("expected no %s rows left for smelt_iron, got %d", table, n)
+		}
+	}
+	if r, err := store.GetRecipe(ctx, "smelt_copper"); err != nil || r == nil || len(r.Inputs) != 1 {
+		t.Errorf("expected smelt_copper to be untouched, got %+v, %v", r, err)
+	}
+
+	deleted, err = store.DeleteRecipe(ctx, "smelt_iron")
+	if err != nil {
+		t.Fatalf("second DeleteRecipe failed: %v", err)
+	}
+	if deleted {
+		t.Error("expected DeleteRecipe of a missing recipe to report false")
+	}
+}
+
+func TestBulkInsertRecipesDropsStaleChildRows(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	store := NewRecipeStore(db)
+	iron := crafting.Recipe{
+		ID: "smelt_iron", Name: "Smelt Iron",
+		Inputs:  []crafting.RecipeInput{{ItemID: "ore_iron", Quantity: 3, Alternatives: []string{"scrap_iron"}}},
+		Outputs: []crafting.RecipeOutput{{ItemID: "iron_bar", Quantity: 1}},
+		Tags:    []string{"smelting"},
+	}
+	copper := crafting.Recipe{
+		ID: "smelt_copper", Name: "Smelt Copper",
+		Inputs:  []crafting.RecipeInput{{ItemID: "ore_copper", Quantity: 3}},
+		Outputs: []crafting.RecipeOutput{{ItemID: "copper_bar", Quantity: 1}},
+	}
+	if err := store.BulkInsertRecipes(ctx, []crafting.Recipe{iron, copper}); err != nil {
+		t.Fatalf("BulkInsertRecipes failed: %v", err)
+	}
+	if err := NewIllegalRecipesStore(db).MarkIllegal(ctx, "smelt_iron", "banned", "nowhere"); err != nil {
+		t.Fatalf("MarkIllegal failed: %v", err)
+	}
+
+	// Re-importing without smelt_iron drops it like DeleteRecipe would
+	if err := store.BulkInsertRecipes(ctx, []crafting.Recipe{copper}); err != nil {
+		t.Fatalf("re-import failed: %v", err)
+	}
+	for _, table := range recipeChildTables {
+		var n int
+		if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM `+table+` WHERE recipe_id = 'smelt_iron'`).Scan(&n); err != nil {
+			t.Fatalf("counting %s: %v", table, err)
+		}
+		if n != 0 {
+			t.Errorf("expected no %s rows left for smelt_iron, got %d", table, n)
+		}
+	}
+}
+
+func TestRecipeInputAlternatives(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	store := NewRecipeStore(db)
+	recipes := []crafting.Recipe{
+		{
+			ID: "frame", Name: "Frame",
+			Inputs: []crafting.RecipeInput{
+				{ItemID: "bolt", Quantity: 1},
+				{ItemID: "steel_bar", Quantity: 2, Alternatives: []string{"titanium_bar", "copper_bar"}},
+			},
+			Outputs: []crafting.RecipeOutput{{ItemID: "frame", Quantity: 1}},
+		},
+	}
+	if err := store.BulkInsertRecipes(ctx, recipes); err != nil {
+		t.Fatalf("BulkInsertRecipes failed: %v", err)
+	}
+
+	want := []crafting.RecipeInput{
+		{ItemID: "bolt", Quantity: 1},
+		{ItemID: "steel_bar", Quantity: 2, Alternatives: []string{"copper_bar", "titanium_bar"}},
+	}
+	got, err := store.GetRecipe(ctx, "frame")
+	if err != nil {
+		t.Fatalf("GetRecipe failed: %v", err)
+	}
+	if !reflect.DeepEqual(got.Inputs, want) {
+		t.Errorf("GetRecipe inputs:\n got %+v\nwant %+v", got.Inputs, want)
+	}
+	all, err := store.GetAllRecipes(ctx)
+	if err != nil {
+		t.Fatalf("GetAllRecipes failed: %v", err)
+	}
+	if len(all) != 1 || !reflect.DeepEqual(all[0].Inputs, want) {
+		t.Errorf("GetAllRecipes inputs:\n got %+v\nwant %+v", all, want)
+	}
+
+	// Holding an alternative covers the input it stands in for.
+	coverage, err := store.FindRecipesByComponentCoverage(ctx, []string{"copper_bar"}, 0.5)
+	if err != nil {
+		t.Fatalf("FindRecipesByComponentCoverage failed: %v", err)
+	}
+	if wantCov := []RecipeCoverage{{RecipeID: "frame", Matched: 1, Total: 2}}; !reflect.DeepEqual(coverage, wantCov) {
+		t.Errorf("coverage: got %+v, want %+v", coverage, wantCov)
+	}
+
+	// Re-importing without alternatives clears them.
+	recipes[0].Inputs[1].Alternatives = nil
+	if err := store.BulkInsertRecipes(ctx, recipes); err != nil {
+		t.Fatalf("re-import failed: %v", err)
+	}
+	got, err = store.GetRecipe(ctx, "frame")
+	if err != nil {
+		t.Fatalf("GetRecipe failed: %v", err)
+	}
+	if got.Inputs[1].Alternatives != nil {
+		t.Errorf("expected alternatives to be cleared, got %v", got.Inputs[1].Alternatives)
+	}
+}
+
+func TestRecipeSuccessRate(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	store := NewRecipeStore(db)
+	recipes := []crafting.Recipe{
+		{ID: "risky", Name: "Risky", SuccessRate: 0.6,
+			Outputs: []crafting.RecipeOutput{{ItemID: "a", Quantity: 1}}},
+		{ID: "unset", Name: "Unset",
+			Outputs: []crafting.RecipeOutput{{ItemID: "b", Quantity: 1}}},
+		{ID: "bogus", Name: "Bogus", SuccessRate: 1.5,
+			Outputs: []crafting.RecipeOutput{{ItemID: "c", Quantity: 1}}},
+	}
+	if err := store.BulkInsertRecipes(ctx, recipes); err != nil {
+		t.Fatalf("BulkInsertRecipes failed: %v", err)
+	}
+
+	want := map[string]float64{"risky": 0.6, "unset": 1, "bogus": 1}
+	for id, rate := range want {
+		r, err := store.GetRecipe(ctx, id)
+		if err != nil {
+			t.Fatalf("GetRecipe(%s) failed: %v", id, err)
+		}
+		if r.SuccessRate != rate {
+			t.Errorf("GetRecipe(%s): expected success rate %v, got %v", id, rate, r.SuccessRate)
+		}
+	}
+
+	all, err := store.GetAllRecipes(ctx)
+	if err != nil {
+		t.Fatalf("GetAllRecipes failed: %v", err)
+	}
+	for _, r := range all {
+		if r.SuccessRate != want[r.ID] {
+			t.Errorf("GetAllRecipes(%s): expected success rate %v, got %v", r.ID, want[r.ID], r.SuccessRate)
+		}
+	}
+}
+
+func TestRecipeTags(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	store := NewRecipeStore(db)
+	recipes := []crafting.Recipe{
+		{ID: "ration", Name: "Ration", Tags: []string{"Tradeable", " consumable ", "tradeable", ""},
+			Outputs: []crafting.RecipeOutput{{ItemID: "ration", Quantity: 1}}},
+		{ID: "plain", Name: "Plain",
+			Outputs: []crafting.RecipeOutput{{ItemID: "plain", Quantity: 1}}},
+	}
+	if err := store.BulkInsertRecipes(ctx, recipes); err != nil {
+		t.Fatalf("BulkInsertRecipes failed: %v", err)
+	}
+
+	// Tags are normalized, de-duplicated and sorted.
+	want := []string{"consumable", "tradeable"}
+	got, err := store.GetRecipe(ctx, "ration")
+	if err != nil {
+		t.Fatalf("GetRecipe failed: %v", err)
+	}
+	if !reflect.DeepEqual(got.Tags, want) {
+		t.Errorf("GetRecipe tags: got %v, want %v", got.Tags, want)
+	}
+	all, err := store.GetAllRecipes(ctx)
+	if err != nil {
+		t.Fatalf("GetAllRecipes failed: %v", err)
+	}
+	for _, r := range all {
+		if r.ID == "ration" && !reflect.DeepEqual(r.Tags, want) {
+			t.Errorf("GetAllRecipes tags: got %v, want %v", r.Tags, want)
+		}
+		if r.ID == "plain" && r.Tags != nil {
+			t.Errorf("expected no tags on plain, got %v", r.Tags)
+		}
+	}
+
+	// Re-importing replaces the tags, and deleting the recipe removes them.
+	recipes[0].Tags = []string{"faction_locked"}
+	if err := store.BulkInsertRecipes(ctx, recipes); err != nil {
+		t.Fatalf("re-import failed: %v", err)
+	}
+	if got, _ = store.GetRecipe(ctx, "ration"); !reflect.DeepEqual(got.Tags, []string{"faction_locked"}) {
+		t.Errorf("expected re-imported tags, got %v", got.Tags)
+	}
+	if _, err := store.DeleteRecipe(ctx, "ration"); err != nil {
+		t.Fatalf("DeleteRecipe failed: %v", err)
+	}
+	var n int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM recipe_tags`).Scan(&n); err != nil || n != 0 {
+		t.Errorf("expected no tags after delete, got %d (err=%v)", n, err)
+	}
+}
+
+func TestSearchRecipesMatchesLiterally(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	recipes := []crafting.Recipe{
+		{ID: "boost_50", Name: "50% Booster"},
+		{ID: "boost_500", Name: "500 Booster"},
+		{ID: "wire_a_b", Name: "Wire a_b"},
+		{ID: "wire_axb", Name: "Wire axb"},
+		{ID: "path", Name: `Path C:\Tools`},
+		{ID: "plain", Name: "Plain Hull"},
+	}
+	store := NewRecipeStore(db)
+	if err := store.BulkInsertRecipes(ctx, recipes); err != nil {
+		t.Fatalf("BulkInsertRecipes failed: %v", err)
+	}
+
+	tests := []struct {
+		term string
+		want []string
+	}{
+		{"50%", []string{"boost_50"}},
+		{"%", []string{"boost_50"}},
+		{"a_b", []string{"wire_a_b"}},
+		{"_", []string{"wire_a_b"}},
+		{`C:\T`, []string{"path"}},
+		{`\%`, nil},
+		{"booster", []string{"boost_50", "boost_500"}},
+	}
+	for _, tt := range tests {
+		hits, err := store.SearchRecipes(ctx, tt.term, 10)
+		if err != nil {
+			t.Fatalf("SearchRecipes(%q) failed: %v", tt.term, err)
+		}
+		var got []string
+		for _, h := range hits {
+			got = append(got, h.RecipeID)
+		}
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SearchRecipes(%q) = %v, want %v", tt.term, got, tt.want)
+		}
+	}
+}
+
+func TestFindInputItemsByPrefix(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	recipes := []crafting.Recipe{
+		{
+			ID: "smelt",
+			Inputs: []crafting.RecipeInput{
+				{ItemID: "ore_iron_t1", Quantity: 1, Alternatives: []string{"ore_iron_t2"}},
+				{ItemID: "ORE_IRON_T3", Quantity: 1},
+				{ItemID: "ore_ironwood", Quantity: 1},
+			},
+			Outputs: []crafting.RecipeOutput{{ItemID: "ingot", Quantity: 1}},
+		},
+		{
+			ID:      "refine",
+			Inputs:  []crafting.RecipeInput{{ItemID: "ore_iron_t1", Quantity: 2}, {ItemID: "oreXiron_t4", Quantity: 1}},
+			Outputs: []crafting.RecipeOutput{{ItemID: "steel", Quantity: 1}},
+		},
+	}
+	store := NewRecipeStore(db)
+	if err := store.BulkInsertRecipes(ctx, recipes); err != nil {
+		t.Fatalf("BulkInsertRecipes failed: %v", err)
+	}
+
+	// The underscore is literal and the match is case-sensitive
+	got, err := store.FindInputItemsByPrefix(ctx, "ore_iron_")
+	if err != nil {
+		t.Fatalf("FindInputItemsByPrefix failed: %v", err)
+	}
+	want := []string{"ore_iron_t1", "ore_iron_t2"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestRecipeStatementsSharedAndClosed(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	for _, store := range []*RecipeStore{NewRecipeStore(db), NewRecipeStore(db)} {
+		if _, err := store.GetRecipe(ctx, "missing"); err != nil {
+			t.Fatalf("GetRecipe failed: %v", err)
+		}
+	}
+	first := db.recipeStmts
+	if first == nil {
+		t.Fatal("expected GetRecipe to prepare statements on the database")
+	}
+	if _, err := NewRecipeStore(db).GetRecipe(ctx, "missing"); err != nil {
+		t.Fatalf("GetRecipe failed: %v", err)
+	}
+	if db.recipeStmts != first {
+		t.Error("expected every RecipeStore to share one set of statements")
+	}
+
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if db.recipeStmts != nil {
+		t.Error("expected Close to release the prepared statements")
+	}
+}
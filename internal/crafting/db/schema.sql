@@ -11,6 +11,7 @@ CREATE TABLE IF NOT EXISTS recipes (
     description     TEXT,
     category        TEXT,
     crafting_time   INTEGER DEFAULT 0,
+    success_rate    REAL NOT NULL DEFAULT 1.0,
     last_updated_tick INTEGER DEFAULT 0
 );
 
@@ -31,9 +32,28 @@ CREATE TABLE IF NOT EXISTS recipe_outputs (
 );
 
 -- Inverted indexes for fast lookups
+-- Interchangeable items accepted in place of a recipe input
+CREATE TABLE IF NOT EXISTS recipe_input_alternatives (
+    recipe_id           TEXT NOT NULL,
+    item_id             TEXT NOT NULL,
+    alternative_item_id TEXT NOT NULL,
+    PRIMARY KEY (recipe_id, item_id, alternative_item_id),
+    FOREIGN KEY (recipe_id) REFERENCES recipes(id) ON DELETE CASCADE
+);
+
+-- Free-form attributes that classify recipes beyond their category
+CREATE TABLE IF NOT EXISTS recipe_tags (
+    recipe_id TEXT NOT NULL,
+    tag       TEXT NOT NULL,
+    PRIMARY KEY (recipe_id, tag),
+    FOREIGN KEY (recipe_id) REFERENCES recipes(id) ON DELETE CASCADE
+);
+
 CREATE INDEX IF NOT EXISTS idx_recipe_inputs_item ON recipe_inputs(item_id);
+CREATE INDEX IF NOT EXISTS idx_recipe_input_alternatives_item ON recipe_input_alternatives(alternative_item_id);
 CREATE INDEX IF NOT EXISTS idx_recipe_outputs_item ON recipe_outputs(item_id);
 CREATE INDEX IF NOT EXISTS idx_recipes_category ON recipes(category);
+CREATE INDEX IF NOT EXISTS idx_recipe_tags_tag ON recipe_tags(tag);
 
 -- ============================================
 -- SKILL DATA
@@ -64,7 +84,7 @@ CREATE TABLE IF NOT EXISTS skill_prerequisites (
 CREATE TABLE IF NOT EXISTS skill_levels (
     skill_id        TEXT NOT NULL,
     level           INTEGER NOT NULL,
-    xp_required     INTEGER NOT NULL,
+    xp_required     INTEGER NOT NULL,  -- cumulative XP from level 0 to this level
     PRIMARY KEY (skill_id, level),
     FOREIGN KEY (skill_id) REFERENCES skills(id) ON DELETE CASCADE
 );
@@ -95,6 +115,8 @@ CREATE TABLE IF NOT EXISTS market_price_summary (
     max_price_7d    INTEGER,
     price_trend     TEXT CHECK (price_trend IN ('rising', 'falling', 'stable')),
     last_updated    TEXT,
+    median_price_7d REAL,
+    volatility_7d   REAL,
     PRIMARY KEY (item_id, station_id, price_type)
 );
 
@@ -217,6 +239,20 @@ CREATE TABLE IF NOT EXISTS illegal_recipes (
 
 CREATE INDEX IF NOT EXISTS idx_illegal_recipes_recipe_id ON illegal_recipes(recipe_id);
 
+-- ============================================
+-- ACQUISITION SOURCES
+-- ============================================
+
+-- Non-market ways to obtain an item (mine, loot, mission, vendor)
+CREATE TABLE IF NOT EXISTS acquisition_sources (
+    item_id     TEXT NOT NULL,
+    method      TEXT NOT NULL,
+    detail      TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (item_id, method, detail)
+);
+
+CREATE INDEX IF NOT EXISTS idx_acquisition_sources_item ON acquisition_sources(item_id);
+
 -- ============================================
 -- CATEGORY PRIORITY DATA
 -- ============================================
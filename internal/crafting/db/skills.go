@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"slices"
 
 	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
 )
@@ -82,6 +83,7 @@ func (s *SkillStore) getSkillPrerequisites(ctx context.Context, skillID string)
 		SELECT prereq_skill_id, level_required
 		FROM skill_prerequisites
 		WHERE skill_id = ?
+		ORDER BY prereq_skill_id
 	`, skillID)
 	if err != nil {
 		return nil, fmt.Errorf("querying skill prerequisites: %w", err)
@@ -139,6 +141,8 @@ func (s *SkillStore) GetSkillName(ctx context.Context, id string) (string, error
 }
 
 // GetXPForLevel retrieves the XP required to reach a specific level of a skill.
+// Thresholds are cumulative: the value is the total XP needed to go from
+// level 0 to level, not the increment over level-1.
 func (s *SkillStore) GetXPForLevel(ctx context.Context, skillID string, level int) (int, error) {
 	var xp int
 	err := s.db.QueryRowContext(ctx, `
@@ -155,6 +159,40 @@ func (s *SkillStore) GetXPForLevel(ctx context.Context, skillID string, level in
 	return xp, nil
 }
 
+// GetXPBetweenLevels returns the total XP needed to train a skill from level
+// from up to level to, i.e. the difference of their cumulative thresholds.
+// Level 0 needs no XP, and the result is 0 when to <= from. ok is false when
+// the skill has no threshold recorded for either level, since the difference
+// cannot be known.
+func (s *SkillStore) GetXPBetweenLevels(ctx context.Context, skillID string, from, to int) (int, bool, error) {
+	if to <= from {
+		return 0, true, nil
+	}
+
+	var fromXP, toXP sql.NullInt64
+	err := s.db.QueryRowContext(ctx, `
+		SELECT
+			MAX(CASE WHEN level = ? THEN xp_required END),
+			MAX(CASE WHEN level = ? THEN xp_required END)
+		FROM skill_levels
+		WHERE skill_id = ? AND level IN (?, ?)
+	`, from, to, skillID, from, to).Scan(&fromXP, &toXP)
+	if err != nil {
+		return 0, false, fmt.Errorf("querying XP between levels: %w", err)
+	}
+
+	if from <= 0 {
+		fromXP = sql.NullInt64{Valid: true}
+	}
+	if !fromXP.Valid || !toXP.Valid {
+		return 0, false, nil
+	}
+	if toXP.Int64 <= fromXP.Int64 {
+		return 0, true, nil
+	}
+	return int(toXP.Int64 - fromXP.Int64), true, nil
+}
+
 // ListSkillsByCategory lists all skills in a category.
 func (s *SkillStore) ListSkillsByCategory(ctx context.Context, category string) ([]string, error) {
 	rows, err := s.db.QueryContext(ctx, `
@@ -177,6 +215,12 @@ func (s *SkillStore) ListSkillsByCategory(ctx context.Context, category string)
 	return ids, rows.Err()
 }
 
+// ListCategories returns every distinct skill category with its skill count,
+// ordered by category. Uncategorized skills are omitted.
+func (s *SkillStore) ListCategories(ctx context.Context) ([]CategoryCount, error) {
+	return listCategories(ctx, s.db, "skills")
+}
+
 // GetAllSkillIDs returns all skill IDs.
 func (s *SkillStore) GetAllSkillIDs(ctx context.Context) ([]string, error) {
 	rows, err := s.db.QueryContext(ctx, `SELECT id FROM skills`)
@@ -197,6 +241,28 @@ func (s *SkillStore) GetAllSkillIDs(ctx context.Context) ([]string, error) {
 	return ids, rows.Err()
 }
 
+// GetAllSkills retrieves every skill with its prerequisites and XP
+// thresholds, ordered by ID.
+func (s *SkillStore) GetAllSkills(ctx context.Context) ([]crafting.Skill, error) {
+	ids, err := s.GetAllSkillIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(ids)
+
+	skills := make([]crafting.Skill, 0, len(ids))
+	for _, id := range ids {
+		skill, err := s.GetSkill(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if skill != nil {
+			skills = append(skills, *skill)
+		}
+	}
+	return skills, nil
+}
+
 // BulkInsertSkills inserts multiple skills in a transaction.
 func (s *SkillStore) BulkInsertSkills(ctx context.Context, skills []crafting.Skill) error {
 	return s.db.InTransaction(ctx, func(tx *sql.Tx) error {
@@ -226,6 +292,20 @@ func (s *SkillStore) BulkInsertSkills(ctx context.Context, skills []crafting.Ski
 			return fmt.Errorf("preparing level statement: %w", err)
 		}
 		defer func() { _ = levelStmt.Close() }()
+
+		// Clear old child rows before re-inserting, so a re-import replaces
+		// prerequisites and levels instead of adding to them.
+		delPrereqsStmt, err := tx.PrepareContext(ctx, `DELETE FROM skill_prerequisites WHERE skill_id = ?`)
+		if err != nil {
+			return fmt.Errorf("preparing delete prerequisites statement: %w", err)
+		}
+		defer func() { _ = delPrereqsStmt.Close() }()
+
+		delLevelsStmt, err := tx.PrepareContext(ctx, `DELETE FROM skill_levels WHERE skill_id = ?`)
+		if err != nil {
+			return fmt.Errorf("preparing delete levels statement: %w", err)
+		}
+		defer func() { _ = delLevelsStmt.Close() }()
 		
 		for _, sk := range skills {
 			xpPerLevel := "[]"
@@ -248,6 +328,13 @@ func (s *SkillStore) BulkInsertSkills(ctx context.Context, skills []crafting.Ski
 			if err != nil {
 				return fmt.Errorf("inserting skill %s: %w", sk.ID, err)
 			}
+
+			if _, err := delPrereqsStmt.ExecContext(ctx, sk.ID); err != nil {
+				return fmt.Errorf("clearing prerequisites for %s: %w", sk.ID, err)
+			}
+			if _, err := delLevelsStmt.ExecContext(ctx, sk.ID); err != nil {
+				return fmt.Errorf("clearing levels for %s: %w", sk.ID, err)
+			}
 			
 			for _, prereq := range sk.Prerequisites {
 				_, err := prereqStmt.ExecContext(ctx, sk.ID, prereq.SkillID, prereq.LevelRequired)
@@ -271,7 +358,121 @@ func (s *SkillStore) BulkInsertSkills(ctx context.Context, skills []crafting.Ski
 // ClearSkills removes all skill data.
 func (s *SkillStore) ClearSkills(ctx context.Context) error {
 	return s.db.InTransaction(ctx, func(tx *sql.Tx) error {
+		// Foreign keys are not enforced, so child rows are cleared explicitly.
+		if _, err := tx.ExecContext(ctx, `DELETE FROM skill_prerequisites`); err != nil {
+			return fmt.Errorf("clearing skill prerequisites: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM skill_levels`); err != nil {
+			return fmt.Errorf("clearing skill levels: %w", err)
+		}
 		_, err := tx.ExecContext(ctx, `DELETE FROM skills`)
 		return err
 	})
 }
+
+// DeleteSkill removes a single skill along with its levels and its own
+// prerequisites. It reports whether the skill existed.
+//
+// Other skills that list the deleted skill as a prerequisite keep that row,
+// so FindDanglingPrerequisites reports them instead of the requirement
+// silently disappearing.
+func (s *SkillStore) DeleteSkill(ctx context.Context, id string) (bool, error) {
+	var deleted bool
+	err := s.db.InTransaction(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM skill_prerequisites WHERE skill_id = ?`, id); err != nil {
+			return fmt.Errorf("deleting prerequisites for %s: %w", id, err)
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM skill_levels WHERE skill_id = ?`, id); err != nil {
+			return fmt.Errorf("deleting levels for %s: %w", id, err)
+		}
+
+		result, err := tx.ExecContext(ctx, `DELETE FROM skills WHERE id = ?`, id)
+		if err != nil {
+			return fmt.Errorf("deleting skill %s: %w", id, err)
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		deleted = n > 0
+		return nil
+	})
+	return deleted, err
+}
+
+// NonIncreasingThreshold is a skill level whose cumulative XP threshold is not
+// greater than the level below it.
+type NonIncreasingThreshold struct {
+	SkillID    string
+	Level      int
+	XPRequired int
+	PreviousXP int
+}
+
+// FindNonIncreasingThresholds returns every skill level whose xp_required does
+// not exceed the previous level's. Thresholds are cumulative, so each level
+// must cost strictly more in total than the one before; a violation usually
+// means the source data holds per-level increments or is out of order.
+func (s *SkillStore) FindNonIncreasingThresholds(ctx context.Context) ([]NonIncreasingThreshold, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT skill_id, level, xp_required, prev_xp
+		FROM (
+			SELECT skill_id, level, xp_required,
+				LAG(xp_required) OVER (PARTITION BY skill_id ORDER BY level) AS prev_xp
+			FROM skill_levels
+		)
+		WHERE prev_xp IS NOT NULL AND xp_required <= prev_xp
+		ORDER BY skill_id, level
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("querying XP thresholds: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var bad []NonIncreasingThreshold
+	for rows.Next() {
+		var t NonIncreasingThreshold
+		if err := rows.Scan(&t.SkillID, &t.Level, &t.XPRequired, &t.PreviousXP); err != nil {
+			return nil, fmt.Errorf("scanning XP threshold: %w", err)
+		}
+		bad = append(bad, t)
+	}
+
+	return bad, rows.Err()
+}
+
+// DanglingPrerequisite is a skill prerequisite whose prereq_skill_id has no
+// matching row in the skills table.
+type DanglingPrerequisite struct {
+	SkillID        string
+	MissingSkillID string
+	LevelRequired  int
+}
+
+// FindDanglingPrerequisites returns every skill prerequisite that points at a
+// skill which was never imported. Foreign keys are not enforced on import, so
+// this is the only way to detect broken references in the exported data.
+func (s *SkillStore) FindDanglingPrerequisites(ctx context.Context) ([]DanglingPrerequisite, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT sp.skill_id, sp.prereq_skill_id, sp.level_required
+		FROM skill_prerequisites sp
+		LEFT JOIN skills s ON s.id = sp.prereq_skill_id
+		WHERE s.id IS NULL
+		ORDER BY sp.skill_id, sp.prereq_skill_id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("querying dangling prerequisites: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var dangling []DanglingPrerequisite
+	for rows.Next() {
+		var d DanglingPrerequisite
+		if err := rows.Scan(&d.SkillID, &d.MissingSkillID, &d.LevelRequired); err != nil {
+			return nil, fmt.Errorf("scanning dangling prerequisite: %w", err)
+		}
+		dangling = append(dangling, d)
+	}
+
+	return dangling, rows.Err()
+}
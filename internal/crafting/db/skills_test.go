@@ -0,0 +1,180 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
+)
+
+func TestFindDanglingPrerequisites(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	store := NewSkillStore(db)
+
+	skills := []crafting.Skill{
+		{ID: "mining", Name: "Mining", Category: "Industry", MaxLevel: 10},
+		{
+			ID: "refining", Name: "Refining", Category: "Industry", MaxLevel: 10,
+			Prerequisites: []crafting.SkillRequirement{
+				{SkillID: "mining", LevelRequired: 2},
+				{SkillID: "smelting", LevelRequired: 3},
+			},
+		},
+	}
+	if err := store.BulkInsertSkills(ctx, skills); err != nil {
+		t.Fatalf("BulkInsertSkills failed: %v", err)
+	}
+
+	dangling, err := store.FindDanglingPrerequisites(ctx)
+	if err != nil {
+		t.Fatalf("FindDanglingPrerequisites failed: %v", err)
+	}
+
+	if len(dangling) != 1 {
+		t.Fatalf("expected 1 dangling prerequisite, got %d: %+v", len(dangling), dangling)
+	}
+	got := dangling[0]
+	if got.SkillID != "refining" || got.MissingSkillID != "smelting" || got.LevelRequired != 3 {
+		t.Errorf("unexpected dangling prerequisite: %+v", got)
+	}
+}
+
+func TestGetXPBetweenLevels(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	store := NewSkillStore(db)
+
+	skills := []crafting.Skill{{
+		ID: "armor", Name: "Armor", Category: "Combat", MaxLevel: 5,
+		XPThresholds: []int{500, 1500, 3000, 5000, 8000},
+	}}
+	if err := store.BulkInsertSkills(ctx, skills); err != nil {
+		t.Fatalf("BulkInsertSkills failed: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		skillID  string
+		from, to int
+		want     int
+		wantOK   bool
+	}{
+		{"from zero", "armor", 0, 3, 3000, true},
+		{"one level", "armor", 2, 3, 1500, true},
+		{"several levels", "armor", 2, 5, 6500, true},
+		{"already there", "armor", 4, 4, 0, true},
+		{"above target", "armor", 5, 2, 0, true},
+		{"target level missing", "armor", 2, 6, 0, false},
+		{"current level missing", "armor", 6, 7, 0, false},
+		{"unknown skill", "shields", 0, 2, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok, err := store.GetXPBetweenLevels(ctx, tt.skillID, tt.from, tt.to)
+			if err != nil {
+				t.Fatalf("GetXPBetweenLevels failed: %v", err)
+			}
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("GetXPBetweenLevels(%s, %d, %d) = %d, %v, want %d, %v", tt.skillID, tt.from, tt.to, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestFindNonIncreasingThresholds(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	store := NewSkillStore(db)
+
+	skills := []crafting.Skill{
+		// Cumulative table from the game catalog.
+		{
+			ID: "armor", Name: "Armor", Category: "Combat", MaxLevel: 10,
+			XPThresholds: []int{500, 1500, 3000, 5000, 8000, 12000, 17000, 23000, 30000, 40000},
+		},
+		// Per-level increments that dip mid-table.
+		{
+			ID: "shields", Name: "Shields", Category: "Combat", MaxLevel: 5,
+			XPThresholds: []int{500, 1000, 1000, 800, 2000},
+		},
+	}
+	if err := store.BulkInsertSkills(ctx, skills); err != nil {
+		t.Fatalf("BulkInsertSkills failed: %v", err)
+	}
+
+	bad, err := store.FindNonIncreasingThresholds(ctx)
+	if err != nil {
+		t.Fatalf("FindNonIncreasingThresholds failed: %v", err)
+	}
+
+	want := []NonIncreasingThreshold{
+		{SkillID: "shields", Level: 3, XPRequired: 1000, PreviousXP: 1000},
+		{SkillID: "shields", Level: 4, XPRequired: 800, PreviousXP: 1000},
+	}
+	if len(bad) != len(want) {
+		t.Fatalf("expected %d problems, got %d: %+v", len(want), len(bad), bad)
+	}
+	for i := range want {
+		if bad[i] != want[i] {
+			t.Errorf("problem %d = %+v, want %+v", i, bad[i], want[i])
+		}
+	}
+}
+
+func TestDeleteSkill(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	store := NewSkillStore(db)
+	skills := []crafting.Skill{
+		{ID: "mining", Name: "Mining", MaxLevel: 10, XPThresholds: []int{100, 300}},
+		{
+			ID: "refining", Name: "Refining", MaxLevel: 10, XPThresholds: []int{200},
+			Prerequisites: []crafting.SkillRequirement{{SkillID: "mining", LevelRequired: 2}},
+		},
+	}
+	if err := store.BulkInsertSkills(ctx, skills); err != nil {
+		t.Fatalf("BulkInsertSkills failed: %v", err)
+	}
+
+	deleted, err := store.DeleteSkill(ctx, "mining")
+	if err != nil {
+		t.Fatalf("DeleteSkill failed: %v", err)
+	}
+	if !deleted {
+		t.Error("expected DeleteSkill to report the skill was deleted")
+	}
+
+	var levels int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM skill_levels WHERE skill_id = 'mining'`).Scan(&levels); err != nil {
+		t.Fatalf("counting levels: %v", err)
+	}
+	if levels != 0 {
+		t.Errorf("expected mining levels to be deleted, got %d", levels)
+	}
+
+	// refining still requires mining, which is now reported as dangling.
+	dangling, err := store.FindDanglingPrerequisites(ctx)
+	if err != nil {
+		t.Fatalf("FindDanglingPrerequisites failed: %v", err)
+	}
+	if len(dangling) != 1 || dangling[0].SkillID != "refining" || dangling[0].MissingSkillID != "mining" {
+		t.Errorf("expected refining -> mining to be dangling, got %+v", dangling)
+	}
+
+	deleted, err = store.DeleteSkill(ctx, "mining")
+	if err != nil {
+		t.Fatalf("second DeleteSkill failed: %v", err)
+	}
+	if deleted {
+		t.Error("expected DeleteSkill of a missing skill to report false")
+	}
+}
@@ -0,0 +1,76 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
+)
+
+// maxBatchInventories caps how many inventories one batch_query may run.
+const maxBatchInventories = 50
+
+// BatchQuery executes the batch_query tool logic: the same craft query run
+// against each labelled inventory. Recipes are loaded at most once across
+// the whole batch, so overlapping inventories share the lookup work.
+func (e *Engine) BatchQuery(ctx context.Context, req crafting.BatchQueryRequest) (*crafting.BatchQueryResponse, error) {
+	if len(req.Inventories) == 0 {
+		return nil, invalidInputf("inventories is required")
+	}
+	if len(req.Inventories) > maxBatchInventories {
+		return nil, invalidInputf("at most %d inventories per batch, got %d", maxBatchInventories, len(req.Inventories))
+	}
+	seen := make(map[string]bool, len(req.Inventories))
+	for i, inv := range req.Inventories {
+		if inv.Label == "" {
+			return nil, invalidInputf("inventory %d has no label", i)
+		}
+		if seen[inv.Label] {
+			return nil, invalidInputf("duplicate inventory label: %s", inv.Label)
+		}
+		seen[inv.Label] = true
+	}
+
+	memo := newRecipeMemo(e)
+	resp := &crafting.BatchQueryResponse{
+		Results: make([]crafting.BatchQueryResult, 0, len(req.Inventories)),
+	}
+	for _, inv := range req.Inventories {
+		query := req.CraftQueryRequest
+		query.Components = inv.Components
+
+		result, err := e.craftQuery(ctx, query, memo.get, false)
+		if err != nil {
+			return nil, fmt.Errorf("querying inventory %s: %w", inv.Label, err)
+		}
+		resp.Results = append(resp.Results, crafting.BatchQueryResult{
+			Label:    inv.Label,
+			Response: result,
+		})
+	}
+	return resp, nil
+}
+
+// recipeMemo remembers recipe lookups, including misses, for the duration
+// of a single call. Callers must not modify the recipes it returns.
+type recipeMemo struct {
+	e    *Engine
+	byID map[string]*crafting.Recipe
+}
+
+func newRecipeMemo(e *Engine) *recipeMemo {
+	return &recipeMemo{e: e, byID: make(map[string]*crafting.Recipe)}
+}
+
+// get returns a recipe by ID, or nil if it does not exist.
+func (m *recipeMemo) get(ctx context.Context, id string) (*crafting.Recipe, error) {
+	if recipe, ok := m.byID[id]; ok {
+		return recipe, nil
+	}
+	recipe, err := m.e.getRecipe(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	m.byID[id] = recipe
+	return recipe, nil
+}
@@ -0,0 +1,113 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
+)
+
+func TestBatchQuery(t *testing.T) {
+	ctx := context.Background()
+	engine := testEngine(t)
+
+	recipes := []crafting.Recipe{
+		{
+			ID: "smelt_plate", Name: "Smelt Plate", Category: "Refining",
+			Inputs:  []crafting.RecipeInput{{ItemID: "ore", Quantity: 3}},
+			Outputs: []crafting.RecipeOutput{{ItemID: "plate", Quantity: 1}},
+		},
+		{
+			ID: "build_hull", Name: "Build Hull", Category: "Components",
+			Inputs: []crafting.RecipeInput{
+				{ItemID: "plate", Quantity: 2},
+				{ItemID: "rivet", Quantity: 1},
+			},
+			Outputs: []crafting.RecipeOutput{{ItemID: "hull", Quantity: 1}},
+		},
+	}
+	if err := engine.recipes.BulkInsertRecipes(ctx, recipes); err != nil {
+		t.Fatalf("BulkInsertRecipes failed: %v", err)
+	}
+
+	resp, err := engine.BatchQuery(ctx, crafting.BatchQueryRequest{
+		Inventories: []crafting.InventorySnapshot{
+			{Label: "miner", Components: []crafting.Component{{ID: "ore", Quantity: 7}}},
+			{Label: "base", Components: []crafting.Component{{ID: "plate", Quantity: 4}, {ID: "rivet", Quantity: 5}}},
+		},
+		CraftQueryRequest: crafting.CraftQueryRequest{
+			// Ignored in favour of each inventory's components
+			Components: []crafting.Component{{ID: "rivet", Quantity: 1}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("BatchQuery failed: %v", err)
+	}
+
+	want := []struct {
+		label    string
+		recipeID string
+		canCraft int
+	}{
+		{"miner", "smelt_plate", 2},
+		{"base", "build_hull", 2},
+	}
+	if len(resp.Results) != len(want) {
+		t.Fatalf("expected %d results, got %d", len(want), len(resp.Results))
+	}
+	for i, w := range want {
+		got := resp.Results[i]
+		if got.Label != w.label {
+			t.Errorf("result %d: expected label %q, got %q", i, w.label, got.Label)
+		}
+		craftable := got.Response.Craftable
+		if len(craftable) != 1 || craftable[0].Recipe.ID != w.recipeID || craftable[0].CanCraftQuantity != w.canCraft {
+			t.Errorf("%s: expected %d x %s craftable, got %+v", w.label, w.canCraft, w.recipeID, craftable)
+		}
+	}
+
+	for _, inventories := range [][]crafting.InventorySnapshot{
+		nil,
+		{{Label: ""}},
+		{{Label: "a"}, {Label: "a"}},
+	} {
+		_, err := engine.BatchQuery(ctx, crafting.BatchQueryRequest{Inventories: inventories})
+		if !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("inventories %+v: expected ErrInvalidInput, got %v", inventories, err)
+		}
+	}
+}
+
+func TestRecipeMemoLoadsOnce(t *testing.T) {
+	ctx := context.Background()
+	engine := testEngine(t)
+	engine.SetRecipeCaching(false)
+
+	recipes := []crafting.Recipe{{
+		ID: "smelt_plate", Name: "Smelt Plate",
+		Inputs:  []crafting.RecipeInput{{ItemID: "ore", Quantity: 3}},
+		Outputs: []crafting.RecipeOutput{{ItemID: "plate", Quantity: 1}},
+	}}
+	if err := engine.recipes.BulkInsertRecipes(ctx, recipes); err != nil {
+		t.Fatalf("BulkInsertRecipes failed: %v", err)
+	}
+
+	memo := newRecipeMemo(engine)
+	first, err := memo.get(ctx, "smelt_plate")
+	if err != nil || first == nil {
+		t.Fatalf("get failed: %v, %v", first, err)
+	}
+
+	// A second lookup is served from the memo even after the row is gone.
+	if _, err := engine.recipes.DeleteRecipe(ctx, "smelt_plate"); err != nil {
+		t.Fatalf("DeleteRecipe failed: %v", err)
+	}
+	second, err := memo.get(ctx, "smelt_plate")
+	if err != nil {
+		t.Fatalf("get failed: %v", err)
+	}
+	if second != first {
+		t.Errorf("expected the memoised recipe, got a fresh load: %+v", second)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"slices"
 	"sort"
 
 	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
@@ -18,14 +19,17 @@ func (e *Engine) BillOfMaterials(ctx context.Context, req crafting.BillOfMateria
 	if req.Quantity <= 0 {
 		req.Quantity = 1
 	}
+	if req.MaxDepth < 0 {
+		return nil, invalidInputf("max_depth must not be negative")
+	}
 
 	// Get the target recipe
-	targetRecipe, err := e.recipes.GetRecipe(ctx, req.RecipeID)
+	targetRecipe, err := e.getRecipe(ctx, req.RecipeID)
 	if err != nil {
 		return nil, fmt.Errorf("getting target recipe: %w", err)
 	}
 	if targetRecipe == nil {
-		return nil, fmt.Errorf("recipe not found: %s", req.RecipeID)
+		return nil, notFoundf("recipe not found: %s", req.RecipeID)
 	}
 
 	// Enrich target recipe with illegal status
@@ -34,127 +38,382 @@ func (e *Engine) BillOfMaterials(ctx context.Context, req crafting.BillOfMateria
 	}
 
 	// Load all recipes to build reverse index
-	allRecipes, err := e.recipes.GetAllRecipes(ctx)
+	allRecipes, err := e.getAllRecipes(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("loading all recipes: %w", err)
 	}
 
-	// Build output -> candidate recipes map, then select the best non-cyclic one.
-	// When multiple recipes produce the same output, prefer:
-	// 1. Shortest craft time
-	// 2. Highest total output quantity (better efficiency)
-	// 3. Lexicographically first recipe_id (for determinism)
-	//
-	// Wrap/unwrap recipe pairs (e.g. wrap_liquid_tritium / unwrap_liquid_tritium)
-	// create inherent cycles since unwrapping X requires contained_X which is made
-	// by wrapping X. We detect and skip these by checking if a recipe's input chain
-	// would require its own output.
-	outputCandidates := make(map[string][]*crafting.Recipe)
-	for i := range allRecipes {
-		for _, output := range allRecipes[i].Outputs {
-			outputCandidates[output.ItemID] = append(outputCandidates[output.ItemID], &allRecipes[i])
+	outputToRecipe := selectCraftRecipes(allRecipes)
+
+	if len(targetRecipe.Outputs) == 0 {
+		return nil, fmt.Errorf("recipe %s has no outputs", targetRecipe.ID)
+	}
+	primaryOutput := targetRecipe.Outputs[0]
+
+	craftableItems, truncated, err := discoverCraftables(ctx, outputToRecipe, []*crafting.Recipe{targetRecipe}, toSet(req.BuyInstead), req.MaxDepth)
+	if err != nil {
+		return nil, err
+	}
+
+	// Topological sort (deepest dependencies first)
+	sortedBottomUp, err := topologicalSort(craftableItems)
+	if err != nil {
+		return nil, fmt.Errorf("topological sort: %w", err)
+	}
+
+	// Calculate demand (top-down: process target first, then dependencies)
+	sortedTopDown := slices.Clone(sortedBottomUp)
+	slices.Reverse(sortedTopDown)
+
+	// In max_craftable mode, solve for the largest quantity the inventory supports
+	var limiting []string
+	if req.MaxCraftable {
+		req.Quantity, limiting = maxCraftableQuantity(sortedTopDown, craftableItems, primaryOutput.ItemID, req.CurrentInventory)
+	}
+
+	targets := map[string]int{primaryOutput.ItemID: req.Quantity}
+	demand, craftRuns, byproducts := propagateDemand(sortedTopDown, craftableItems, targets, false)
+	rawMaterials := rawMaterialsFor(demand, craftableItems)
+
+	// Plan again with extra runs to cover failures when any step can fail
+	var expectedRaw []crafting.BOMItem
+	var expectedCraftRuns map[string]int
+	for itemID, runs := range craftRuns {
+		if runs > 0 && recipeSuccessRate(craftableItems[itemID]) < 1 {
+			var expectedDemand map[string]int
+			expectedDemand, expectedCraftRuns, _ = propagateDemand(sortedTopDown, craftableItems, targets, true)
+			expectedRaw = rawMaterialsFor(expectedDemand, craftableItems)
+			break
 		}
 	}
 
-	outputToRecipe := make(map[string]*crafting.Recipe)
-	for itemID, candidates := range outputCandidates {
-		// Sort candidates by preference (craft time, output qty, id)
-		sort.Slice(candidates, func(i, j int) bool {
-			a, b := candidates[i], candidates[j]
-			if a.CraftingTime != b.CraftingTime {
-				return a.CraftingTime < b.CraftingTime
-			}
-			aq, bq := totalOutputQuantity(a), totalOutputQuantity(b)
-			if aq != bq {
-				return aq > bq
-			}
-			return a.ID < b.ID
-		})
+	leftovers := bomByproducts(byproducts)
+	intermediates := bomIntermediates(craftableItems, demand, craftRuns, targets)
+	craftSteps := bomCraftSteps(sortedBottomUp, craftableItems, craftRuns, expectedCraftRuns)
 
-		// Pick the first candidate that doesn't create a cycle.
-		// A recipe creates a cycle if any of its inputs can only be produced
-		// by a recipe that requires the output item (wrap/unwrap pattern).
-		for _, candidate := range candidates {
-			if !wouldCreateCycle(candidate, itemID, outputCandidates) {
-				outputToRecipe[itemID] = candidate
-				break
-			}
+	totalTime := totalCraftTime(craftableItems, craftRuns)
+	expectedTime := totalCraftTime(craftableItems, expectedCraftRuns)
+
+	// Resolve display names, falling back to IDs for uncatalogued items
+	nameIDs := []string{primaryOutput.ItemID}
+	for _, raw := range rawMaterials {
+		nameIDs = append(nameIDs, raw.ItemID)
+	}
+	for _, inter := range intermediates {
+		nameIDs = append(nameIDs, inter.ItemID)
+	}
+	for _, left := range leftovers {
+		nameIDs = append(nameIDs, left.ItemID)
+	}
+	for _, raw := range expectedRaw {
+		nameIDs = append(nameIDs, raw.ItemID)
+	}
+	names, err := e.itemNames(ctx, nameIDs)
+	if err != nil {
+		return nil, fmt.Errorf("resolving item names: %w", err)
+	}
+	for i := range rawMaterials {
+		rawMaterials[i].ItemName = names[rawMaterials[i].ItemID]
+	}
+	for i := range intermediates {
+		intermediates[i].ItemName = names[intermediates[i].ItemID]
+	}
+	for i := range leftovers {
+		leftovers[i].ItemName = names[leftovers[i].ItemID]
+	}
+	for i := range expectedRaw {
+		expectedRaw[i].ItemName = names[expectedRaw[i].ItemID]
+		expectedRaw[i].Truncated = truncated[expectedRaw[i].ItemID]
+	}
+	for i := range rawMaterials {
+		rawMaterials[i].Truncated = truncated[rawMaterials[i].ItemID]
+	}
+
+	resp := &crafting.BillOfMaterialsResponse{
+		RecipeID:       targetRecipe.ID,
+		RecipeName:     targetRecipe.Name,
+		OutputItemID:   primaryOutput.ItemID,
+		OutputItemName: names[primaryOutput.ItemID],
+		Quantity:       req.Quantity,
+		RawMaterials:   rawMaterials,
+		Intermediates:  intermediates,
+		CraftSteps:     craftSteps,
+		TotalCraftTime: totalTime,
+		Byproducts:     leftovers,
+
+		ExpectedRawMaterials: expectedRaw,
+		ExpectedCraftTime:    expectedTime,
+	}
+	if req.MaxCraftable {
+		resp.LimitingMaterials = limiting
+	}
+
+	if req.StationID != "" {
+		stationID := e.resolveStationID(ctx, req.StationID)
+		analysis, err := e.calculateBOMCost(ctx, stationID, primaryOutput.ItemID, req.Quantity, rawMaterials, expectedRaw)
+		if err != nil {
+			return nil, fmt.Errorf("calculating cost analysis: %w", err)
 		}
+		resp.CostAnalysis = analysis
 	}
 
-	// Discover craftable items via DFS starting from the target recipe
-	// Note: Diamond dependencies (multiple paths to same item) are allowed
+	return resp, nil
+}
+
+// maxCraftChainDepth bounds how many crafting levels discoverCraftables
+// will follow. No real recipe tree comes close; it keeps a pathological
+// import from exhausting the stack in the recursive walk.
+const maxCraftChainDepth = 500
+
+// discoverCraftables walks the inputs of the root recipes and returns the
+// recipe used to make each craftable item they depend on, keyed by item.
+// Each root recipe is used for its own primary output even where
+// selectCraftRecipes would pick a different recipe for that item. Items in
+// buy are treated as raw materials: they are bought, not crafted, and the
+// walk does not descend into their recipes.
+//
+// A positive maxDepth limits the plan to that many crafting levels, the
+// roots being level 1. Craftable items at the cutoff are left as raw
+// materials and returned in truncated. An item reached by several paths is
+// expanded according to its shallowest one.
+// Diamond dependencies (multiple paths to the same item) are allowed.
+func discoverCraftables(ctx context.Context, outputToRecipe map[string]*crafting.Recipe, roots []*crafting.Recipe, buy map[string]bool, maxDepth int) (map[string]*crafting.Recipe, map[string]bool, error) {
 	craftableItems := make(map[string]*crafting.Recipe)
-	visited := make(map[string]bool)
+	depths := make(map[string]int) // shallowest level each item was reached at
 	pathStack := make(map[string]bool)
+	truncated := make(map[string]bool)
 
-	var dfs func(itemID string) error
-	dfs = func(itemID string) error {
-		if visited[itemID] {
+	var dfs func(itemID string, level int) error
+	dfs = func(itemID string, level int) error {
+		// Stop walking a large graph once the caller has given up
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d, ok := depths[itemID]; ok && (maxDepth <= 0 || d <= level) {
 			return nil
 		}
 
 		if pathStack[itemID] {
-			return fmt.Errorf("cycle detected: item %s has circular dependency", itemID)
+			return invalidInputf("cycle detected: item %s has circular dependency", itemID)
 		}
 
-		visited[itemID] = true
+		depths[itemID] = level
 		pathStack[itemID] = true
+		defer delete(pathStack, itemID)
 
 		recipe, exists := outputToRecipe[itemID]
-		if !exists {
-			// Not craftable (raw material)
-			delete(pathStack, itemID)
+		if !exists || buy[itemID] {
+			// Not craftable (raw material), or bought by choice
 			return nil
 		}
+		if maxDepth > 0 && level >= maxDepth {
+			truncated[itemID] = true
+			return nil
+		}
+		if level >= maxCraftChainDepth {
+			return invalidInputf("crafting chain too deep: item %s needs more than %d crafting levels", itemID, maxCraftChainDepth)
+		}
 
+		delete(truncated, itemID)
 		craftableItems[itemID] = recipe
 
 		// Recursively visit dependencies (inputs)
 		for _, inp := range recipe.Inputs {
-			if err := dfs(inp.ItemID); err != nil {
+			if err := dfs(inp.ItemID, level+1); err != nil {
 				return err
 			}
 		}
-
-		delete(pathStack, itemID)
 		return nil
 	}
 
-	// Start DFS with the target recipe explicitly
-	// Use the first output as the primary output for the target
-	if len(targetRecipe.Outputs) == 0 {
-		return nil, fmt.Errorf("recipe %s has no outputs", targetRecipe.ID)
+	// Pin the roots first so a root's output met deeper in another root's
+	// tree keeps the root's recipe
+	for _, root := range roots {
+		itemID := root.Outputs[0].ItemID
+		craftableItems[itemID] = root
+		depths[itemID] = 0
 	}
-	primaryOutput := targetRecipe.Outputs[0]
-	craftableItems[primaryOutput.ItemID] = targetRecipe
+	for _, root := range roots {
+		for _, inp := range root.Inputs {
+			if err := dfs(inp.ItemID, 1); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	return craftableItems, truncated, nil
+}
+
+// bomByproducts lists the byproducts left over after a plan, sorted by ID.
+func bomByproducts(byproducts map[string]int) []crafting.BOMItem {
+	var leftovers []crafting.BOMItem
+	for itemID, qty := range byproducts {
+		leftovers = append(leftovers, crafting.BOMItem{
+			ItemID:   itemID,
+			Quantity: qty,
+		})
+	}
+	sort.Slice(leftovers, func(i, j int) bool {
+		return leftovers[i].ItemID < leftovers[j].ItemID
+	})
+	return leftovers
+}
+
+// bomIntermediates lists the crafted items of a plan other than its
+// targets, sorted by ID.
+func bomIntermediates(craftableItems map[string]*crafting.Recipe, demand, craftRuns, targets map[string]int) []crafting.BOMIntermediate {
+	var intermediates []crafting.BOMIntermediate
+	for itemID, recipe := range craftableItems {
+		runs := craftRuns[itemID]
+		if runs == 0 {
+			continue
+		}
+		// Exclude the target items from intermediates
+		if _, ok := targets[itemID]; ok {
+			continue
+		}
+
+		produced := runs * getOutputQuantityForItem(recipe, itemID)
+
+		intermediates = append(intermediates, crafting.BOMIntermediate{
+			ItemID:        itemID,
+			RecipeID:      recipe.ID,
+			RecipeName:    recipe.Name,
+			CraftRuns:     runs,
+			TotalProduced: produced,
+			TotalNeeded:   demand[itemID],
+			Surplus:       produced - demand[itemID],
+		})
+	}
+	sort.Slice(intermediates, func(i, j int) bool {
+		return intermediates[i].ItemID < intermediates[j].ItemID
+	})
+	return intermediates
+}
+
+// bomCraftSteps lists a plan's craft steps in bottom-up order, deepest
+// dependencies first. expectedCraftRuns may be nil when nothing can fail.
+func bomCraftSteps(sortedBottomUp []string, craftableItems map[string]*crafting.Recipe, craftRuns, expectedCraftRuns map[string]int) []crafting.BOMCraftStep {
+	var craftSteps []crafting.BOMCraftStep
+	stepNum := 1
+	for _, itemID := range sortedBottomUp {
+		recipe := craftableItems[itemID]
+		runs := craftRuns[itemID]
+		if runs == 0 {
+			continue
+		}
+
+		outputQuantity := getOutputQuantityForItem(recipe, itemID)
+
+		step := crafting.BOMCraftStep{
+			StepNumber:   stepNum,
+			RecipeID:     recipe.ID,
+			RecipeName:   recipe.Name,
+			CraftRuns:    runs,
+			OutputItemID: itemID,
+			OutputPerRun: outputQuantity,
+		}
+		if rate := recipeSuccessRate(recipe); rate < 1 {
+			step.SuccessRate = rate
+			step.ExpectedCraftRuns = expectedCraftRuns[itemID]
+		}
+		craftSteps = append(craftSteps, step)
+		stepNum++
+	}
+	return craftSteps
+}
+
+// totalCraftTime sums the craft time of every run in a plan.
+func totalCraftTime(craftableItems map[string]*crafting.Recipe, craftRuns map[string]int) int {
+	total := 0
+	for itemID, runs := range craftRuns {
+		total += craftableItems[itemID].CraftingTime * runs
+	}
+	return total
+}
 
-	for _, inp := range targetRecipe.Inputs {
-		if err := dfs(inp.ItemID); err != nil {
+// calculateBOMCost prices the raw materials of a BOM against the value of the
+// finished output at a station. expectedRaw holds the raw materials allowing
+// for failed crafts, or nil when every step always succeeds.
+func (e *Engine) calculateBOMCost(
+	ctx context.Context,
+	stationID string,
+	outputItemID string,
+	quantity int,
+	rawMaterials []crafting.BOMItem,
+	expectedRaw []crafting.BOMItem,
+) (*crafting.BOMCostAnalysis, error) {
+	analysis := &crafting.BOMCostAnalysis{StationID: stationID}
+
+	for _, raw := range rawMaterials {
+		price, err := e.market.GetBuyPrice(ctx, raw.ItemID, stationID)
+		if err != nil {
 			return nil, err
 		}
+		if price == 0 {
+			analysis.UnpricedItems = append(analysis.UnpricedItems, raw.ItemID)
+			continue
+		}
+		analysis.RawMaterialCost += price * raw.Quantity
 	}
 
-	// Topological sort (deepest dependencies first)
-	sortedBottomUp, err := topologicalSort(craftableItems)
+	analysis.ExpectedRawMaterialCost = analysis.RawMaterialCost
+	if expectedRaw != nil {
+		analysis.ExpectedRawMaterialCost = 0
+		for _, raw := range expectedRaw {
+			price, err := e.market.GetBuyPrice(ctx, raw.ItemID, stationID)
+			if err != nil {
+				return nil, err
+			}
+			analysis.ExpectedRawMaterialCost += price * raw.Quantity
+		}
+	}
+
+	sellPrice, err := e.market.GetSellPrice(ctx, outputItemID, stationID)
 	if err != nil {
-		return nil, fmt.Errorf("topological sort: %w", err)
+		return nil, err
 	}
+	if sellPrice == 0 {
+		analysis.UnpricedItems = append(analysis.UnpricedItems, outputItemID)
+	}
+	analysis.OutputValue = sellPrice * quantity
 
-	// Calculate demand (top-down: process target first, then dependencies)
-	// Create reversed order for demand propagation
-	sortedTopDown := make([]string, len(sortedBottomUp))
-	copy(sortedTopDown, sortedBottomUp)
-	for i, j := 0, len(sortedTopDown)-1; i < j; i, j = i+1, j-1 {
-		sortedTopDown[i], sortedTopDown[j] = sortedTopDown[j], sortedTopDown[i]
+	analysis.NetProfit = analysis.OutputValue - analysis.RawMaterialCost
+	analysis.ExpectedNetProfit = analysis.OutputValue - analysis.ExpectedRawMaterialCost
+	if analysis.RawMaterialCost > 0 {
+		analysis.ProfitMarginPct = float64(analysis.NetProfit) / float64(analysis.RawMaterialCost) * 100
 	}
 
-	demand := make(map[string]int)
-	demand[primaryOutput.ItemID] = req.Quantity
+	return analysis, nil
+}
+
+// propagateDemand walks craftable items top-down and returns the demand still
+// to be met for every item, the craft runs needed for each craftable item,
+// and any byproducts left over, to produce the given quantity of each target
+// item. Targets that share intermediates are planned together, so shared
+// steps are crafted once for their combined demand.
+//
+// Secondary outputs (byproducts such as scrap) are credited against demand
+// for those items before any more are crafted or bought. Items are visited
+// once, so a byproduct only offsets raw materials and items visited after
+// the step that makes it. An item's demand is complete by the time it is
+// visited, so rounding its runs up leaves a surplus of it that nothing
+// else in the plan can use; bomIntermediates reports it.
+//
+// When expected is true, each step's runs are scaled up by 1/success_rate
+// to cover failed crafts. Failed runs consume inputs but produce nothing, so
+// byproducts are still credited from the successful runs only.
+func propagateDemand(sortedTopDown []string, craftableItems map[string]*crafting.Recipe, targets map[string]int, expected bool) (map[string]int, map[string]int, map[string]int) {
+	demand := make(map[string]int, len(targets))
+	for itemID, quantity := range targets {
+		demand[itemID] += quantity
+	}
 
 	craftRuns := make(map[string]int)
+	byproducts := make(map[string]int)
 	for _, itemID := range sortedTopDown {
 		recipe := craftableItems[itemID]
+		creditByproduct(demand, byproducts, itemID)
 		itemDemand := demand[itemID]
 		if itemDemand == 0 {
 			continue
@@ -166,15 +425,42 @@ func (e *Engine) BillOfMaterials(ctx context.Context, req crafting.BillOfMateria
 
 		// Calculate craft runs needed
 		runsNeeded := int(math.Ceil(float64(itemDemand) / float64(outputQuantity)))
-		craftRuns[itemID] = runsNeeded
+		attempts := runsNeeded
+		if expected {
+			attempts = expectedRuns(runsNeeded, recipeSuccessRate(recipe))
+		}
+		craftRuns[itemID] = attempts
+
+		for _, out := range recipe.Outputs {
+			if out.ItemID != itemID && out.Quantity > 0 {
+				byproducts[out.ItemID] += runsNeeded * out.Quantity
+			}
+		}
 
 		// Propagate demand to inputs
 		for _, inp := range recipe.Inputs {
-			demand[inp.ItemID] += runsNeeded * inp.Quantity
+			demand[inp.ItemID] += attempts * inp.Quantity
+		}
+	}
+
+	// Raw materials are never crafted, so credit them once every step is known
+	for itemID := range demand {
+		if craftableItems[itemID] == nil {
+			creditByproduct(demand, byproducts, itemID)
+		}
+	}
+
+	for itemID, qty := range byproducts {
+		if qty == 0 {
+			delete(byproducts, itemID)
 		}
 	}
 
-	// Separate raw materials (items with demand but no recipe)
+	return demand, craftRuns, byproducts
+}
+
+// rawMaterialsFor lists the items in demand that have no recipe, sorted by ID.
+func rawMaterialsFor(demand map[string]int, craftableItems map[string]*crafting.Recipe) []crafting.BOMItem {
 	var rawMaterials []crafting.BOMItem
 	for itemID, qty := range demand {
 		if craftableItems[itemID] == nil && qty > 0 {
@@ -187,74 +473,112 @@ func (e *Engine) BillOfMaterials(ctx context.Context, req crafting.BillOfMateria
 	sort.Slice(rawMaterials, func(i, j int) bool {
 		return rawMaterials[i].ItemID < rawMaterials[j].ItemID
 	})
+	return rawMaterials
+}
 
-	// Build intermediates list
-	var intermediates []crafting.BOMIntermediate
-	for itemID, recipe := range craftableItems {
-		runs := craftRuns[itemID]
-		if runs == 0 {
-			continue
-		}
-		// Exclude the target item from intermediates
-		if itemID == primaryOutput.ItemID {
-			continue
-		}
+// creditByproduct uses any available byproduct of itemID to cover its demand.
+func creditByproduct(demand, byproducts map[string]int, itemID string) {
+	used := min(demand[itemID], byproducts[itemID])
+	demand[itemID] -= used
+	byproducts[itemID] -= used
+}
 
-		outputQuantity := getOutputQuantityForItem(recipe, itemID)
+// maxCraftableSearchLimit caps the quantity search for recipes whose raw
+// materials never run out (e.g. recipes with no inputs at all).
+const maxCraftableSearchLimit = 1 << 20
+
+// maxCraftableQuantity finds the largest quantity of the target item that the
+// inventory's raw materials support end-to-end, along with the raw materials
+// that prevent crafting one more. Intermediates in the inventory are ignored:
+// everything is assumed to be crafted from raw materials, and every craft is
+// assumed to succeed.
+//
+// Craft runs round up at every level, so demand is not linear in quantity but
+// it is monotonic, which makes a doubling plus binary search exact.
+func maxCraftableQuantity(sortedTopDown []string, craftableItems map[string]*crafting.Recipe, targetItemID string, inventory []crafting.Component) (int, []string) {
+	available := make(map[string]int, len(inventory))
+	for _, c := range inventory {
+		available[c.ID] += c.Quantity
+	}
 
-		intermediates = append(intermediates, crafting.BOMIntermediate{
-			ItemID:        itemID,
-			RecipeID:      recipe.ID,
-			RecipeName:    recipe.Name,
-			CraftRuns:     runs,
-			TotalProduced: runs * outputQuantity,
-			TotalNeeded:   demand[itemID],
-		})
+	// shortfall returns the raw materials that run out when crafting quantity units.
+	shortfall := func(quantity int) []string {
+		demand, _, _ := propagateDemand(sortedTopDown, craftableItems, map[string]int{targetItemID: quantity}, false)
+		var short []string
+		for itemID, qty := range demand {
+			if craftableItems[itemID] == nil && qty > available[itemID] {
+				short = append(short, itemID)
+			}
+		}
+		sort.Strings(short)
+		return short
 	}
-	sort.Slice(intermediates, func(i, j int) bool {
-		return intermediates[i].ItemID < intermediates[j].ItemID
-	})
 
-	// Build craft steps (in bottom-up order: deepest dependencies first)
-	var craftSteps []crafting.BOMCraftStep
-	stepNum := 1
-	for _, itemID := range sortedBottomUp {
-		recipe := craftableItems[itemID]
-		runs := craftRuns[itemID]
-		if runs == 0 {
-			continue
+	// Find an infeasible upper bound, then binary search below it
+	lo, hi := 0, 1
+	for hi < maxCraftableSearchLimit && len(shortfall(hi)) == 0 {
+		lo, hi = hi, hi*2
+	}
+	if hi >= maxCraftableSearchLimit && len(shortfall(maxCraftableSearchLimit)) == 0 {
+		return maxCraftableSearchLimit, nil
+	}
+	for hi-lo > 1 {
+		mid := lo + (hi-lo)/2
+		if len(shortfall(mid)) == 0 {
+			lo = mid
+		} else {
+			hi = mid
 		}
+	}
 
-		outputQuantity := getOutputQuantityForItem(recipe, itemID)
+	return lo, shortfall(lo + 1)
+}
 
-		craftSteps = append(craftSteps, crafting.BOMCraftStep{
-			StepNumber:   stepNum,
-			RecipeID:     recipe.ID,
-			RecipeName:   recipe.Name,
-			CraftRuns:    runs,
-			OutputItemID: itemID,
-			OutputPerRun: outputQuantity,
-		})
-		stepNum++
+// selectCraftRecipes picks, for every craftable item, the recipe used to
+// produce it. When multiple recipes produce the same output, prefer:
+// 1. Shortest craft time
+// 2. Highest total output quantity (better efficiency)
+// 3. Lexicographically first recipe_id (for determinism)
+//
+// Wrap/unwrap recipe pairs (e.g. wrap_liquid_tritium / unwrap_liquid_tritium)
+// create inherent cycles since unwrapping X requires contained_X which is made
+// by wrapping X. We detect and skip these by checking if a recipe's input chain
+// would require its own output. Items whose only recipes are cyclic are
+// treated as raw materials and left out of the result.
+func selectCraftRecipes(allRecipes []crafting.Recipe) map[string]*crafting.Recipe {
+	outputCandidates := make(map[string][]*crafting.Recipe)
+	for i := range allRecipes {
+		for _, output := range allRecipes[i].Outputs {
+			outputCandidates[output.ItemID] = append(outputCandidates[output.ItemID], &allRecipes[i])
+		}
 	}
 
-	// Calculate total craft time
-	totalTime := 0
-	for itemID, runs := range craftRuns {
-		recipe := craftableItems[itemID]
-		totalTime += recipe.CraftingTime * runs
-	}
+	outputToRecipe := make(map[string]*crafting.Recipe)
+	for itemID, candidates := range outputCandidates {
+		// Sort candidates by preference (craft time, output qty, id)
+		sort.Slice(candidates, func(i, j int) bool {
+			a, b := candidates[i], candidates[j]
+			if a.CraftingTime != b.CraftingTime {
+				return a.CraftingTime < b.CraftingTime
+			}
+			aq, bq := totalOutputQuantity(a), totalOutputQuantity(b)
+			if aq != bq {
+				return aq > bq
+			}
+			return a.ID < b.ID
+		})
 
-	return &crafting.BillOfMaterialsResponse{
-		RecipeID:       targetRecipe.ID,
-		RecipeName:     targetRecipe.Name,
-		OutputItemID:   primaryOutput.ItemID,
-		Quantity:       req.Quantity,
-		RawMaterials:   rawMaterials,
-		Intermediates:  intermediates,
-		CraftSteps:     craftSteps,
-		TotalCraftTime: totalTime,
-	}, nil
+		// Pick the first candidate that doesn't create a cycle.
+		// A recipe creates a cycle if any of its inputs can only be produced
+		// by a recipe that requires the output item (wrap/unwrap pattern).
+		for _, candidate := range candidates {
+			if !wouldCreateCycle(candidate, itemID, outputCandidates) {
+				outputToRecipe[itemID] = candidate
+				break
+			}
+		}
+	}
+	return outputToRecipe
 }
 
 // wouldCreateCycle checks if using a recipe to produce itemID would create a
@@ -312,6 +636,10 @@ func getOutputQuantityForItem(recipe *crafting.Recipe, itemID string) int {
 	if total == 0 && len(recipe.Outputs) > 0 {
 		total = recipe.Outputs[0].Quantity
 	}
+	// Guard against malformed quantities, which are used as a divisor
+	if total <= 0 {
+		total = 1
+	}
 	return total
 }
 
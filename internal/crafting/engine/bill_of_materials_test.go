@@ -0,0 +1,589 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
+)
+
+func TestBillOfMaterials_CostAnalysis(t *testing.T) {
+	ctx := context.Background()
+	engine := testEngine(t)
+
+	recipes := []crafting.Recipe{
+		{
+			ID: "smelt_plate", Name: "Smelt Plate", CraftingTime: 10,
+			Inputs:  []crafting.RecipeInput{{ItemID: "ore", Quantity: 3}},
+			Outputs: []crafting.RecipeOutput{{ItemID: "plate", Quantity: 1}},
+		},
+		{
+			ID: "build_hull", Name: "Build Hull", CraftingTime: 30,
+			Inputs: []crafting.RecipeInput{
+				{ItemID: "plate", Quantity: 2},
+				{ItemID: "rivet", Quantity: 4},
+				{ItemID: "sealant", Quantity: 1},
+			},
+			Outputs: []crafting.RecipeOutput{{ItemID: "hull", Quantity: 1}},
+		},
+	}
+	if err := engine.recipes.BulkInsertRecipes(ctx, recipes); err != nil {
+		t.Fatalf("BulkInsertRecipes failed: %v", err)
+	}
+
+	_, err := engine.db.ExecContext(ctx, `
+		INSERT INTO items (id, name) VALUES ('hull', 'Hull Section'), ('ore', 'Iron Ore')
+	`)
+	if err != nil {
+		t.Fatalf("inserting items: %v", err)
+	}
+
+	// The plate price must be ignored: it is an intermediate, not a purchase.
+	_, err = engine.db.ExecContext(ctx, `
+		INSERT INTO market_price_summary (item_id, station_id, price_type, avg_price_7d) VALUES
+			('ore', 'station_a', 'buy', 10),
+			('rivet', 'station_a', 'buy', 5),
+			('plate', 'station_a', 'buy', 1000),
+			('hull', 'station_a', 'sell', 200)
+	`)
+	if err != nil {
+		t.Fatalf("inserting price summaries: %v", err)
+	}
+
+	resp, err := engine.BillOfMaterials(ctx, crafting.BillOfMaterialsRequest{
+		RecipeID:  "build_hull",
+		Quantity:  2,
+		StationID: "station_a",
+	})
+	if err != nil {
+		t.Fatalf("BillOfMaterials failed: %v", err)
+	}
+
+	want := &crafting.BOMCostAnalysis{
+		StationID:       "station_a",
+		RawMaterialCost: 12*10 + 8*5,
+		OutputValue:     400,
+		NetProfit:       400 - 160,
+		ProfitMarginPct: 150,
+		UnpricedItems:   []string{"sealant"},
+
+		// Every step always succeeds, so expected matches nominal
+		ExpectedRawMaterialCost: 12*10 + 8*5,
+		ExpectedNetProfit:       400 - 160,
+	}
+	if !reflect.DeepEqual(resp.CostAnalysis, want) {
+		t.Errorf("unexpected cost analysis: got %+v, want %+v", resp.CostAnalysis, want)
+	}
+
+	if resp.OutputItemName != "Hull Section" {
+		t.Errorf("expected output item name %q, got %q", "Hull Section", resp.OutputItemName)
+	}
+	for _, raw := range resp.RawMaterials {
+		want := raw.ItemID // uncatalogued items fall back to their ID
+		if raw.ItemID == "ore" {
+			want = "Iron Ore"
+		}
+		if raw.ItemName != want {
+			t.Errorf("raw material %s: expected name %q, got %q", raw.ItemID, want, raw.ItemName)
+		}
+	}
+
+	resp, err = engine.BillOfMaterials(ctx, crafting.BillOfMaterialsRequest{RecipeID: "build_hull"})
+	if err != nil {
+		t.Fatalf("BillOfMaterials failed: %v", err)
+	}
+	if resp.CostAnalysis != nil {
+		t.Errorf("expected no cost analysis without station_id, got %+v", resp.CostAnalysis)
+	}
+}
+
+func TestBillOfMaterials_MaxCraftable(t *testing.T) {
+	ctx := context.Background()
+	engine := testEngine(t)
+
+	recipes := []crafting.Recipe{
+		{
+			// Two plates per run, so odd hull counts waste a plate
+			ID: "smelt_plate", Name: "Smelt Plate", CraftingTime: 10,
+			Inputs:  []crafting.RecipeInput{{ItemID: "ore", Quantity: 3}},
+			Outputs: []crafting.RecipeOutput{{ItemID: "plate", Quantity: 2}},
+		},
+		{
+			ID: "build_hull", Name: "Build Hull", CraftingTime: 30,
+			Inputs: []crafting.RecipeInput{
+				{ItemID: "plate", Quantity: 1},
+				{ItemID: "rivet", Quantity: 4},
+			},
+			Outputs: []crafting.RecipeOutput{{ItemID: "hull", Quantity: 1}},
+		},
+	}
+	if err := engine.recipes.BulkInsertRecipes(ctx, recipes); err != nil {
+		t.Fatalf("BulkInsertRecipes failed: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		inventory    []crafting.Component
+		wantQuantity int
+		wantLimiting []string
+	}{
+		{
+			name:         "ore limited",
+			inventory:    []crafting.Component{{ID: "ore", Quantity: 9}, {ID: "rivet", Quantity: 100}},
+			wantQuantity: 6,
+			wantLimiting: []string{"ore"},
+		},
+		{
+			name:         "rivet limited",
+			inventory:    []crafting.Component{{ID: "ore", Quantity: 100}, {ID: "rivet", Quantity: 10}},
+			wantQuantity: 2,
+			wantLimiting: []string{"rivet"},
+		},
+		{
+			name:         "both limited",
+			inventory:    []crafting.Component{{ID: "ore", Quantity: 6}, {ID: "rivet", Quantity: 16}},
+			wantQuantity: 4,
+			wantLimiting: []string{"ore", "rivet"},
+		},
+		{
+			name:         "nothing craftable",
+			inventory:    []crafting.Component{{ID: "rivet", Quantity: 16}},
+			wantQuantity: 0,
+			wantLimiting: []string{"ore"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := engine.BillOfMaterials(ctx, crafting.BillOfMaterialsRequest{
+				RecipeID:         "build_hull",
+				MaxCraftable:     true,
+				CurrentInventory: tt.inventory,
+			})
+			if err != nil {
+				t.Fatalf("BillOfMaterials failed: %v", err)
+			}
+			if resp.Quantity != tt.wantQuantity {
+				t.Errorf("expected max quantity %d, got %d", tt.wantQuantity, resp.Quantity)
+			}
+			if !reflect.DeepEqual(resp.LimitingMaterials, tt.wantLimiting) {
+				t.Errorf("expected limiting materials %v, got %v", tt.wantLimiting, resp.LimitingMaterials)
+			}
+		})
+	}
+}
+
+func TestBillOfMaterials_Byproducts(t *testing.T) {
+	ctx := context.Background()
+	engine := testEngine(t)
+
+	recipes := []crafting.Recipe{
+		{
+			ID: "smelt_plate", Name: "Smelt Plate", CraftingTime: 10,
+			Inputs: []crafting.RecipeInput{{ItemID: "ore", Quantity: 3}},
+			Outputs: []crafting.RecipeOutput{
+				{ItemID: "plate", Quantity: 1},
+				{ItemID: "slag", Quantity: 2},
+			},
+		},
+		{
+			ID: "build_hull", Name: "Build Hull", CraftingTime: 30,
+			Inputs: []crafting.RecipeInput{
+				{ItemID: "plate", Quantity: 2},
+				{ItemID: "slag", Quantity: 3},
+				{ItemID: "rivet", Quantity: 1},
+			},
+			Outputs: []crafting.RecipeOutput{
+				{ItemID: "hull", Quantity: 1},
+				{ItemID: "scrap", Quantity: 1},
+			},
+		},
+	}
+	if err := engine.recipes.BulkInsertRecipes(ctx, recipes); err != nil {
+		t.Fatalf("BulkInsertRecipes failed: %v", err)
+	}
+
+	resp, err := engine.BillOfMaterials(ctx, crafting.BillOfMaterialsRequest{
+		RecipeID: "build_hull",
+		Quantity: 1,
+	})
+	if err != nil {
+		t.Fatalf("BillOfMaterials failed: %v", err)
+	}
+
+	// Two smelting runs make 2 plate and 4 slag, covering everything the
+	// hull needs; without byproduct credit plate and slag would each get
+	// their own smelting runs.
+	wantRaw := []crafting.BOMItem{
+		{ItemID: "ore", ItemName: "ore", Quantity: 6},
+		{ItemID: "rivet", ItemName: "rivet", Quantity: 1},
+	}
+	if !reflect.DeepEqual(resp.RawMaterials, wantRaw) {
+		t.Errorf("unexpected raw materials: got %+v, want %+v", resp.RawMaterials, wantRaw)
+	}
+
+	smeltRuns := 0
+	for _, step := range resp.CraftSteps {
+		if step.RecipeID == "smelt_plate" {
+			smeltRuns += step.CraftRuns
+		}
+	}
+	if smeltRuns != 2 {
+		t.Errorf("expected 2 smelting runs in total, got %d: %+v", smeltRuns, resp.CraftSteps)
+	}
+
+	// The hull's own scrap is never consumed.
+	if len(resp.Byproducts) == 0 || resp.Byproducts[0] != (crafting.BOMItem{ItemID: "scrap", ItemName: "scrap", Quantity: 1}) {
+		t.Errorf("expected leftover scrap byproduct, got %+v", resp.Byproducts)
+	}
+}
+
+func TestBillOfMaterials_SuccessRate(t *testing.T) {
+	ctx := context.Background()
+	engine := testEngine(t)
+
+	recipes := []crafting.Recipe{
+		{
+			ID: "smelt_plate", Name: "Smelt Plate", CraftingTime: 10, SuccessRate: 0.5,
+			Inputs:  []crafting.RecipeInput{{ItemID: "ore", Quantity: 3}},
+			Outputs: []crafting.RecipeOutput{{ItemID: "plate", Quantity: 1}},
+		},
+		{
+			ID: "build_hull", Name: "Build Hull", CraftingTime: 30, SuccessRate: 0.75,
+			Inputs: []crafting.RecipeInput{
+				{ItemID: "plate", Quantity: 2},
+				{ItemID: "rivet", Quantity: 1},
+			},
+			Outputs: []crafting.RecipeOutput{{ItemID: "hull", Quantity: 1}},
+		},
+		{
+			ID: "build_frame", Name: "Build Frame", CraftingTime: 5,
+			Inputs:  []crafting.RecipeInput{{ItemID: "rivet", Quantity: 2}},
+			Outputs: []crafting.RecipeOutput{{ItemID: "frame", Quantity: 1}},
+		},
+	}
+	if err := engine.recipes.BulkInsertRecipes(ctx, recipes); err != nil {
+		t.Fatalf("BulkInsertRecipes failed: %v", err)
+	}
+
+	resp, err := engine.BillOfMaterials(ctx, crafting.BillOfMaterialsRequest{
+		RecipeID: "build_hull",
+		Quantity: 3,
+	})
+	if err != nil {
+		t.Fatalf("BillOfMaterials failed: %v", err)
+	}
+
+	// Nominally 3 hull runs need 6 plates from 6 smelts. Expected: 4 hull
+	// attempts need 8 plates, which take 16 smelting attempts.
+	wantRaw := []crafting.BOMItem{
+		{ItemID: "ore", ItemName: "ore", Quantity: 18},
+		{ItemID: "rivet", ItemName: "rivet", Quantity: 3},
+	}
+	if !reflect.DeepEqual(resp.RawMaterials, wantRaw) {
+		t.Errorf("unexpected raw materials: got %+v, want %+v", resp.RawMaterials, wantRaw)
+	}
+	wantExpected := []crafting.BOMItem{
+		{ItemID: "ore", ItemName: "ore", Quantity: 48},
+		{ItemID: "rivet", ItemName: "rivet", Quantity: 4},
+	}
+	if !reflect.DeepEqual(resp.ExpectedRawMaterials, wantExpected) {
+		t.Errorf("unexpected expected raw materials: got %+v, want %+v", resp.ExpectedRawMaterials, wantExpected)
+	}
+	if resp.TotalCraftTime != 6*10+3*30 || resp.ExpectedCraftTime != 16*10+4*30 {
+		t.Errorf("expected craft times 150 and 280, got %d and %d", resp.TotalCraftTime, resp.ExpectedCraftTime)
+	}
+
+	for _, step := range resp.CraftSteps {
+		switch step.RecipeID {
+		case "smelt_plate":
+			if step.CraftRuns != 6 || step.SuccessRate != 0.5 || step.ExpectedCraftRuns != 16 {
+				t.Errorf("unexpected smelting step: %+v", step)
+			}
+		case "build_hull":
+			if step.CraftRuns != 3 || step.SuccessRate != 0.75 || step.ExpectedCraftRuns != 4 {
+				t.Errorf("unexpected hull step: %+v", step)
+			}
+		}
+	}
+
+	// Recipes that always succeed report no expected figures.
+	resp, err = engine.BillOfMaterials(ctx, crafting.BillOfMaterialsRequest{RecipeID: "build_frame"})
+	if err != nil {
+		t.Fatalf("BillOfMaterials failed: %v", err)
+	}
+	if resp.ExpectedRawMaterials != nil || resp.ExpectedCraftTime != 0 || resp.CraftSteps[0].ExpectedCraftRuns != 0 {
+		t.Errorf("expected no risk-adjusted figures for a certain recipe, got %+v", resp)
+	}
+}
+
+func TestBillOfMaterials_BuyInstead(t *testing.T) {
+	ctx := context.Background()
+	engine := testEngine(t)
+
+	recipes := []crafting.Recipe{
+		{
+			ID: "smelt_ingot", Name: "Smelt Ingot", CraftingTime: 5,
+			Inputs:  []crafting.RecipeInput{{ItemID: "ore", Quantity: 2}},
+			Outputs: []crafting.RecipeOutput{{ItemID: "ingot", Quantity: 1}},
+		},
+		{
+			ID: "press_plate", Name: "Press Plate", CraftingTime: 10,
+			Inputs:  []crafting.RecipeInput{{ItemID: "ingot", Quantity: 3}},
+			Outputs: []crafting.RecipeOutput{{ItemID: "plate", Quantity: 1}},
+		},
+		{
+			ID: "build_hull", Name: "Build Hull", CraftingTime: 30,
+			Inputs: []crafting.RecipeInput{
+				{ItemID: "plate", Quantity: 2},
+				{ItemID: "ingot", Quantity: 1},
+			},
+			Outputs: []crafting.RecipeOutput{{ItemID: "hull", Quantity: 1}},
+		},
+	}
+	if err := engine.recipes.BulkInsertRecipes(ctx, recipes); err != nil {
+		t.Fatalf("BulkInsertRecipes failed: %v", err)
+	}
+
+	// Buying plates skips pressing them and the ingots they would need;
+	// the hull's own ingot is still smelted.
+	resp, err := engine.BillOfMaterials(ctx, crafting.BillOfMaterialsRequest{
+		RecipeID:   "build_hull",
+		Quantity:   1,
+		BuyInstead: []string{"plate", "hull"},
+	})
+	if err != nil {
+		t.Fatalf("BillOfMaterials failed: %v", err)
+	}
+
+	wantRaw := []crafting.BOMItem{
+		{ItemID: "ore", ItemName: "ore", Quantity: 2},
+		{ItemID: "plate", ItemName: "plate", Quantity: 2},
+	}
+	if !reflect.DeepEqual(resp.RawMaterials, wantRaw) {
+		t.Errorf("raw materials:\n got %+v\nwant %+v", resp.RawMaterials, wantRaw)
+	}
+	var stepIDs []string
+	for _, step := range resp.CraftSteps {
+		stepIDs = append(stepIDs, step.RecipeID)
+	}
+	if want := []string{"smelt_ingot", "build_hull"}; !reflect.DeepEqual(stepIDs, want) {
+		t.Errorf("craft steps: got %v, want %v", stepIDs, want)
+	}
+}
+
+func TestBillOfMaterials_MaxDepth(t *testing.T) {
+	ctx := context.Background()
+	engine := testEngine(t)
+
+	// hull <- plate <- ingot <- ore, and the hull also takes an ingot directly
+	recipes := []crafting.Recipe{
+		{
+			ID: "smelt_ingot", Name: "Smelt Ingot",
+			Inputs:  []crafting.RecipeInput{{ItemID: "ore", Quantity: 2}},
+			Outputs: []crafting.RecipeOutput{{ItemID: "ingot", Quantity: 1}},
+		},
+		{
+			ID: "press_plate", Name: "Press Plate",
+			Inputs:  []crafting.RecipeInput{{ItemID: "ingot", Quantity: 3}},
+			Outputs: []crafting.RecipeOutput{{ItemID: "plate", Quantity: 1}},
+		},
+		{
+			ID: "build_hull", Name: "Build Hull",
+			Inputs: []crafting.RecipeInput{
+				{ItemID: "plate", Quantity: 2},
+				{ItemID: "ingot", Quantity: 1},
+			},
+			Outputs: []crafting.RecipeOutput{{ItemID: "hull", Quantity: 1}},
+		},
+	}
+	if err := engine.recipes.BulkInsertRecipes(ctx, recipes); err != nil {
+		t.Fatalf("BulkInsertRecipes failed: %v", err)
+	}
+
+	tests := []struct {
+		depth int
+		want  []crafting.BOMItem
+	}{
+		{1, []crafting.BOMItem{
+			{ItemID: "ingot", ItemName: "ingot", Quantity: 1, Truncated: true},
+			{ItemID: "plate", ItemName: "plate", Quantity: 2, Truncated: true},
+		}},
+		// The hull's own ingot is at level 2, so ingots are smelted even
+		// though the plates' ingots sit at the cutoff.
+		{2, []crafting.BOMItem{
+			{ItemID: "ore", ItemName: "ore", Quantity: 14},
+		}},
+		{0, []crafting.BOMItem{
+			{ItemID: "ore", ItemName: "ore", Quantity: 14},
+		}},
+	}
+	for _, tt := range tests {
+		resp, err := engine.BillOfMaterials(ctx, crafting.BillOfMaterialsRequest{
+			RecipeID: "build_hull",
+			Quantity: 1,
+			MaxDepth: tt.depth,
+		})
+		if err != nil {
+			t.Fatalf("BillOfMaterials(max_depth=%d) failed: %v", tt.depth, err)
+		}
+		if !reflect.DeepEqual(resp.RawMaterials, tt.want) {
+			t.Errorf("max_depth=%d raw materials:\n got %+v\nwant %+v", tt.depth, resp.RawMaterials, tt.want)
+		}
+	}
+
+	if _, err := engine.BillOfMaterials(ctx, crafting.BillOfMaterialsRequest{RecipeID: "build_hull", MaxDepth: -1}); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("expected ErrInvalidInput for a negative max_depth, got %v", err)
+	}
+}
+
+func TestBillOfMaterials_LongChain(t *testing.T) {
+	ctx := context.Background()
+	engine := testEngine(t)
+
+	// item_1 <- item_0, item_2 <- item_1, ... up to one past the limit
+	var recipes []crafting.Recipe
+	for i := 1; i <= maxCraftChainDepth+1; i++ {
+		recipes = append(recipes, crafting.Recipe{
+			ID:      fmt.Sprintf("make_%d", i),
+			Name:    fmt.Sprintf("Make %d", i),
+			Inputs:  []crafting.RecipeInput{{ItemID: fmt.Sprintf("item_%d", i-1), Quantity: 1}},
+			Outputs: []crafting.RecipeOutput{{ItemID: fmt.Sprintf("item_%d", i), Quantity: 1}},
+		})
+	}
+	if err := engine.recipes.BulkInsertRecipes(ctx, recipes); err != nil {
+		t.Fatalf("BulkInsertRecipes failed: %v", err)
+	}
+
+	resp, err := engine.BillOfMaterials(ctx, crafting.BillOfMaterialsRequest{
+		RecipeID: fmt.Sprintf("make_%d", maxCraftChainDepth),
+		Quantity: 1,
+	})
+	if err != nil {
+		t.Fatalf("BillOfMaterials at the depth limit failed: %v", err)
+	}
+	if len(resp.CraftSteps) != maxCraftChainDepth {
+		t.Fatalf("expected %d craft steps, got %d", maxCraftChainDepth, len(resp.CraftSteps))
+	}
+	for i, step := range resp.CraftSteps {
+		if want := fmt.Sprintf("make_%d", i+1); step.RecipeID != want {
+			t.Fatalf("step %d: got %s, want %s", i+1, step.RecipeID, want)
+		}
+	}
+	wantRaw := []crafting.BOMItem{{ItemID: "item_0", ItemName: "item_0", Quantity: 1}}
+	if !reflect.DeepEqual(resp.RawMaterials, wantRaw) {
+		t.Errorf("raw materials: got %+v, want %+v", resp.RawMaterials, wantRaw)
+	}
+
+	_, err = engine.BillOfMaterials(ctx, crafting.BillOfMaterialsRequest{
+		RecipeID: fmt.Sprintf("make_%d", maxCraftChainDepth+1),
+		Quantity: 1,
+	})
+	if !errors.Is(err, ErrInvalidInput) || !strings.Contains(err.Error(), "too deep") {
+		t.Errorf("expected a chain too deep error past the limit, got %v", err)
+	}
+}
+
+func TestBillOfMaterials_Surplus(t *testing.T) {
+	ctx := context.Background()
+	engine := testEngine(t)
+
+	// Each frame takes 5 rods, and rods come 2 to a run
+	recipes := []crafting.Recipe{
+		{
+			ID: "draw_rod", Name: "Draw Rod",
+			Inputs:  []crafting.RecipeInput{{ItemID: "ore", Quantity: 1}},
+			Outputs: []crafting.RecipeOutput{{ItemID: "rod", Quantity: 2}},
+		},
+		{
+			ID: "build_frame", Name: "Build Frame",
+			Inputs:  []crafting.RecipeInput{{ItemID: "rod", Quantity: 5}},
+			Outputs: []crafting.RecipeOutput{{ItemID: "frame", Quantity: 1}},
+		},
+	}
+	if err := engine.recipes.BulkInsertRecipes(ctx, recipes); err != nil {
+		t.Fatalf("BulkInsertRecipes failed: %v", err)
+	}
+
+	for _, tt := range []struct {
+		quantity, runs, surplus int
+	}{
+		{1, 3, 1},
+		{2, 5, 0},
+	} {
+		resp, err := engine.BillOfMaterials(ctx, crafting.BillOfMaterialsRequest{
+			RecipeID: "build_frame",
+			Quantity: tt.quantity,
+		})
+		if err != nil {
+			t.Fatalf("BillOfMaterials failed: %v", err)
+		}
+		want := []crafting.BOMIntermediate{{
+			ItemID:        "rod",
+			ItemName:      "rod",
+			RecipeID:      "draw_rod",
+			RecipeName:    "Draw Rod",
+			CraftRuns:     tt.runs,
+			TotalProduced: tt.runs * 2,
+			TotalNeeded:   tt.quantity * 5,
+			Surplus:       tt.surplus,
+		}}
+		if !reflect.DeepEqual(resp.Intermediates, want) {
+			t.Errorf("quantity %d intermediates:\n got %+v\nwant %+v", tt.quantity, resp.Intermediates, want)
+		}
+	}
+}
+
+func TestBillOfMaterials_SharedBranchesRoundOnce(t *testing.T) {
+	ctx := context.Background()
+	engine := testEngine(t)
+
+	// The frame takes a rod directly and another through its bracket. Rods
+	// come 2 to a run, so one run covers both branches; rounding each
+	// branch on its own would take two.
+	recipes := []crafting.Recipe{
+		{
+			ID: "draw_rod", Name: "Draw Rod",
+			Inputs:  []crafting.RecipeInput{{ItemID: "ore", Quantity: 1}},
+			Outputs: []crafting.RecipeOutput{{ItemID: "rod", Quantity: 2}},
+		},
+		{
+			ID: "bend_bracket", Name: "Bend Bracket",
+			Inputs:  []crafting.RecipeInput{{ItemID: "rod", Quantity: 1}},
+			Outputs: []crafting.RecipeOutput{{ItemID: "bracket", Quantity: 1}},
+		},
+		{
+			ID: "build_frame", Name: "Build Frame",
+			Inputs: []crafting.RecipeInput{
+				{ItemID: "rod", Quantity: 1},
+				{ItemID: "bracket", Quantity: 1},
+			},
+			Outputs: []crafting.RecipeOutput{{ItemID: "frame", Quantity: 1}},
+		},
+	}
+	if err := engine.recipes.BulkInsertRecipes(ctx, recipes); err != nil {
+		t.Fatalf("BulkInsertRecipes failed: %v", err)
+	}
+
+	resp, err := engine.BillOfMaterials(ctx, crafting.BillOfMaterialsRequest{RecipeID: "build_frame", Quantity: 1})
+	if err != nil {
+		t.Fatalf("BillOfMaterials failed: %v", err)
+	}
+
+	runs := make(map[string]int)
+	for _, step := range resp.CraftSteps {
+		runs[step.RecipeID] = step.CraftRuns
+	}
+	want := map[string]int{"draw_rod": 1, "bend_bracket": 1, "build_frame": 1}
+	if !reflect.DeepEqual(runs, want) {
+		t.Errorf("craft runs: got %v, want %v", runs, want)
+	}
+	wantRaw := []crafting.BOMItem{{ItemID: "ore", ItemName: "ore", Quantity: 1}}
+	if !reflect.DeepEqual(resp.RawMaterials, wantRaw) {
+		t.Errorf("raw materials: got %+v, want %+v", resp.RawMaterials, wantRaw)
+	}
+}
@@ -0,0 +1,46 @@
+package engine
+
+import (
+	"context"
+
+	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
+)
+
+// acquisitionCost prices the missing inputs of a partial match at a station
+// and checks the total against budget. Each input is bought as whichever of
+// its item and alternatives has the lowest non-zero buy price.
+func (e *Engine) acquisitionCost(ctx context.Context, stationID string, missing []crafting.RecipeInput, budget int) (*crafting.AcquisitionCost, error) {
+	cost := &crafting.AcquisitionCost{StationID: stationID, Budget: budget}
+
+	for _, inp := range missing {
+		_, price, err := e.cheapestInputItem(ctx, stationID, inp)
+		if err != nil {
+			return nil, err
+		}
+		if price == 0 {
+			cost.UnpricedItems = append(cost.UnpricedItems, inp.ItemID)
+			continue
+		}
+		cost.TotalCost += price * inp.Quantity
+	}
+
+	cost.WithinBudget = len(cost.UnpricedItems) == 0 && cost.TotalCost <= budget
+	return cost, nil
+}
+
+// cheapestInputItem returns whichever of an input's item and alternatives
+// has the lowest non-zero buy price at a station, with that price. The
+// price is 0 when none of them is sold there.
+func (e *Engine) cheapestInputItem(ctx context.Context, stationID string, inp crafting.RecipeInput) (string, int, error) {
+	bestID, best := inp.ItemID, 0
+	for _, itemID := range append([]string{inp.ItemID}, inp.Alternatives...) {
+		price, err := e.market.GetBuyPrice(ctx, itemID, stationID)
+		if err != nil {
+			return "", 0, err
+		}
+		if price > 0 && (best == 0 || price < best) {
+			bestID, best = itemID, price
+		}
+	}
+	return bestID, best, nil
+}
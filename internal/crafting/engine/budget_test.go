@@ -0,0 +1,97 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
+)
+
+func TestCraftQuery_Budget(t *testing.T) {
+	ctx := context.Background()
+	engine := testEngine(t)
+
+	recipes := []crafting.Recipe{
+		{
+			ID: "make_frame", Name: "Frame",
+			Inputs: []crafting.RecipeInput{
+				{ItemID: "ore", Quantity: 1},
+				{ItemID: "steel", Quantity: 2, Alternatives: []string{"titanium"}},
+			},
+			Outputs: []crafting.RecipeOutput{{ItemID: "frame", Quantity: 1}},
+		},
+		{
+			ID: "make_engine", Name: "Engine",
+			Inputs: []crafting.RecipeInput{
+				{ItemID: "ore", Quantity: 1},
+				{ItemID: "core", Quantity: 1},
+			},
+			Outputs: []crafting.RecipeOutput{{ItemID: "engine", Quantity: 1}},
+		},
+		{
+			ID: "make_probe", Name: "Probe",
+			Inputs: []crafting.RecipeInput{
+				{ItemID: "ore", Quantity: 1},
+				{ItemID: "sensor", Quantity: 1},
+			},
+			Outputs: []crafting.RecipeOutput{{ItemID: "probe", Quantity: 1}},
+		},
+	}
+	if err := engine.recipes.BulkInsertRecipes(ctx, recipes); err != nil {
+		t.Fatalf("BulkInsertRecipes failed: %v", err)
+	}
+
+	// Titanium undercuts steel; sensors have no price at all.
+	_, err := engine.db.ExecContext(ctx, `
+		INSERT INTO market_price_summary (item_id, station_id, price_type, avg_price_7d) VALUES
+			('steel', 'station_a', 'buy', 30),
+			('titanium', 'station_a', 'buy', 20),
+			('core', 'station_a', 'buy', 500)
+	`)
+	if err != nil {
+		t.Fatalf("inserting market prices: %v", err)
+	}
+
+	query := func(exclude bool) map[string]*crafting.AcquisitionCost {
+		t.Helper()
+		resp, err := engine.CraftQuery(ctx, crafting.CraftQueryRequest{
+			Components:        []crafting.Component{{ID: "ore", Quantity: 1}},
+			IncludePartial:    true,
+			StationID:         "station_a",
+			Budget:            100,
+			ExcludeOverBudget: exclude,
+		})
+		if err != nil {
+			t.Fatalf("CraftQuery failed: %v", err)
+		}
+		got := make(map[string]*crafting.AcquisitionCost)
+		for _, m := range resp.PartialComponents {
+			got[m.Recipe.ID] = m.AcquisitionCost
+		}
+		return got
+	}
+
+	want := map[string]*crafting.AcquisitionCost{
+		"make_frame":  {StationID: "station_a", TotalCost: 40, Budget: 100, WithinBudget: true},
+		"make_engine": {StationID: "station_a", TotalCost: 500, Budget: 100},
+		"make_probe":  {StationID: "station_a", Budget: 100, UnpricedItems: []string{"sensor"}},
+	}
+	if got := query(false); !reflect.DeepEqual(got, want) {
+		t.Errorf("acquisition costs = %+v, want %+v", got, want)
+	}
+
+	if got := query(true); len(got) != 1 || got["make_frame"] == nil {
+		t.Errorf("with exclude_over_budget got %v, want only make_frame", got)
+	}
+
+	for _, req := range []crafting.CraftQueryRequest{
+		{Components: []crafting.Component{{ID: "ore", Quantity: 1}}, StationID: "station_a", Budget: -1},
+		{Components: []crafting.Component{{ID: "ore", Quantity: 1}}, Budget: 100},
+	} {
+		if _, err := engine.CraftQuery(ctx, req); !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("budget %d station %q: expected ErrInvalidInput, got %v", req.Budget, req.StationID, err)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package engine
+
+import (
+	"context"
+	"sort"
+
+	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
+)
+
+// CapabilityDiff executes the capability_diff tool logic: it compares the
+// recipes craftable from two inventories, e.g. before and after a trade.
+func (e *Engine) CapabilityDiff(ctx context.Context, req crafting.CapabilityDiffRequest) (*crafting.CapabilityDiffResponse, error) {
+	// Both sides usually share most of their recipes, so load each once
+	memo := newRecipeMemo(e)
+
+	before, err := e.craftableRecipes(ctx, req.Before, memo.get)
+	if err != nil {
+		return nil, err
+	}
+	after, err := e.craftableRecipes(ctx, req.After, memo.get)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &crafting.CapabilityDiffResponse{
+		Gained:  []crafting.CapabilityChange{},
+		Lost:    []crafting.CapabilityChange{},
+		Changed: []crafting.CapabilityChange{},
+	}
+	for id, b := range before {
+		change := crafting.CapabilityChange{
+			RecipeID:       id,
+			RecipeName:     b.recipe.Name,
+			Category:       b.recipe.Category,
+			BeforeQuantity: b.quantity,
+		}
+		a, ok := after[id]
+		switch {
+		case !ok:
+			resp.Lost = append(resp.Lost, change)
+		case a.quantity != b.quantity:
+			change.AfterQuantity = a.quantity
+			resp.Changed = append(resp.Changed, change)
+		default:
+			resp.Unchanged++
+		}
+	}
+	for id, a := range after {
+		if _, ok := before[id]; ok {
+			continue
+		}
+		resp.Gained = append(resp.Gained, crafting.CapabilityChange{
+			RecipeID:      id,
+			RecipeName:    a.recipe.Name,
+			Category:      a.recipe.Category,
+			AfterQuantity: a.quantity,
+		})
+	}
+
+	for _, list := range [][]crafting.CapabilityChange{resp.Gained, resp.Lost, resp.Changed} {
+		sort.Slice(list, func(i, j int) bool { return list[i].RecipeID < list[j].RecipeID })
+	}
+	return resp, nil
+}
+
+// craftableRecipe is a recipe fully craftable from an inventory.
+type craftableRecipe struct {
+	recipe   *crafting.Recipe
+	quantity int
+}
+
+// craftableRecipes returns every recipe fully craftable from components,
+// keyed by recipe ID, with the number of times it can be crafted.
+func (e *Engine) craftableRecipes(
+	ctx context.Context,
+	components []crafting.Component,
+	getRecipe func(context.Context, string) (*crafting.Recipe, error),
+) (map[string]craftableRecipe, error) {
+	inventory := buildInventoryMap(components)
+	componentIDs := make([]string, 0, len(components))
+	for _, c := range components {
+		componentIDs = append(componentIDs, c.ID)
+	}
+
+	coverage, err := e.recipes.FindRecipesByComponentCoverage(ctx, componentIDs, 1.0)
+	if err != nil {
+		return nil, err
+	}
+
+	craftable := make(map[string]craftableRecipe, len(coverage))
+	for _, c := range coverage {
+		recipe, err := getRecipe(ctx, c.RecipeID)
+		if err != nil {
+			return nil, err
+		}
+		if recipe == nil {
+			continue
+		}
+		_, missing, canCraft, _ := e.calculateInputMatch(recipe, inventory, nil)
+		satisfied := len(recipe.Inputs) - len(missing)
+		if calculateMatchRatio(satisfied, len(recipe.Inputs)) < 1.0 {
+			continue
+		}
+		craftable[recipe.ID] = craftableRecipe{recipe: recipe, quantity: max(canCraft, 0)}
+	}
+	return craftable, nil
+}
@@ -0,0 +1,78 @@
+package engine
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
+)
+
+func TestCapabilityDiff(t *testing.T) {
+	ctx := context.Background()
+	engine := testEngine(t)
+
+	recipes := []crafting.Recipe{
+		{
+			ID: "smelt_plate", Name: "Smelt Plate", Category: "Refining",
+			Inputs:  []crafting.RecipeInput{{ItemID: "ore", Quantity: 3}},
+			Outputs: []crafting.RecipeOutput{{ItemID: "plate", Quantity: 1}},
+		},
+		{
+			ID: "build_hull", Name: "Build Hull", Category: "Components",
+			Inputs: []crafting.RecipeInput{
+				{ItemID: "plate", Quantity: 2},
+				{ItemID: "rivet", Quantity: 1},
+			},
+			Outputs: []crafting.RecipeOutput{{ItemID: "hull", Quantity: 1}},
+		},
+		{
+			ID: "make_rivet_box", Name: "Make Rivet Box", Category: "Components",
+			Inputs:  []crafting.RecipeInput{{ItemID: "rivet", Quantity: 10}},
+			Outputs: []crafting.RecipeOutput{{ItemID: "rivet_box", Quantity: 1}},
+		},
+		{
+			ID: "forge_blade", Name: "Forge Blade", Category: "Weapons",
+			Inputs:  []crafting.RecipeInput{{ItemID: "ore", Quantity: 1}},
+			Outputs: []crafting.RecipeOutput{{ItemID: "blade", Quantity: 1}},
+		},
+	}
+	if err := engine.recipes.BulkInsertRecipes(ctx, recipes); err != nil {
+		t.Fatalf("BulkInsertRecipes failed: %v", err)
+	}
+
+	// Trade 6 ore and 5 rivets for 2 plates.
+	resp, err := engine.CapabilityDiff(ctx, crafting.CapabilityDiffRequest{
+		Before: []crafting.Component{{ID: "ore", Quantity: 9}, {ID: "rivet", Quantity: 12}},
+		After:  []crafting.Component{{ID: "ore", Quantity: 3}, {ID: "rivet", Quantity: 7}, {ID: "plate", Quantity: 2}},
+	})
+	if err != nil {
+		t.Fatalf("CapabilityDiff failed: %v", err)
+	}
+
+	want := &crafting.CapabilityDiffResponse{
+		Gained: []crafting.CapabilityChange{
+			{RecipeID: "build_hull", RecipeName: "Build Hull", Category: "Components", AfterQuantity: 1},
+		},
+		Lost: []crafting.CapabilityChange{
+			{RecipeID: "make_rivet_box", RecipeName: "Make Rivet Box", Category: "Components", BeforeQuantity: 1},
+		},
+		Changed: []crafting.CapabilityChange{
+			{RecipeID: "forge_blade", RecipeName: "Forge Blade", Category: "Weapons", BeforeQuantity: 9, AfterQuantity: 3},
+			{RecipeID: "smelt_plate", RecipeName: "Smelt Plate", Category: "Refining", BeforeQuantity: 3, AfterQuantity: 1},
+		},
+	}
+	if !reflect.DeepEqual(resp, want) {
+		t.Errorf("unexpected diff:\n got %+v\nwant %+v", resp, want)
+	}
+
+	// Identical inventories change nothing.
+	same := []crafting.Component{{ID: "ore", Quantity: 3}}
+	resp, err = engine.CapabilityDiff(ctx, crafting.CapabilityDiffRequest{Before: same, After: same})
+	if err != nil {
+		t.Fatalf("CapabilityDiff failed: %v", err)
+	}
+	if len(resp.Gained)+len(resp.Lost)+len(resp.Changed) != 0 || resp.Unchanged != 2 {
+		t.Errorf("expected 2 unchanged recipes and no changes, got %+v", resp)
+	}
+}
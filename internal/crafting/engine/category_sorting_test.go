@@ -30,7 +30,7 @@ func setupTestEngine(t *testing.T) *Engine {
 		t.Fatalf("initializing category priorities: %v", err)
 	}
 
-	return New(database)
+	return New(database, nil)
 }
 
 // indexOf finds the index of a recipe with the given category in the results.
@@ -191,3 +191,51 @@ func TestCraftQuery_AmmunitionFiltering(t *testing.T) {
 		}
 	}
 }
+
+// TestSortCraftable_DeterministicTiebreak verifies that matches tied on tier
+// and strategy are ordered by profit, then quantity, then recipe ID.
+func TestSortCraftable_DeterministicTiebreak(t *testing.T) {
+	e := setupTestEngine(t)
+
+	match := func(id string, qty, profit int) crafting.CraftableMatch {
+		return crafting.CraftableMatch{
+			Recipe:                 crafting.Recipe{ID: id, Category: "Refining"},
+			CanCraftQuantity:       qty,
+			OutputQuantityPossible: qty,
+			ProfitAnalysis:         &crafting.ProfitAnalysis{ProfitPerUnit: profit},
+		}
+	}
+
+	for _, strategy := range crafting.ValidStrategies() {
+		t.Run(string(strategy), func(t *testing.T) {
+			// All tie on input count; ordering below the strategy key must
+			// not depend on the input order.
+			for _, perm := range [][]int{{0, 1, 2, 3}, {3, 2, 1, 0}, {2, 0, 3, 1}} {
+				base := []crafting.CraftableMatch{
+					match("b", 5, 10),
+					match("a", 5, 10),
+					match("c", 9, 10),
+					match("d", 5, 20),
+				}
+				matches := make([]crafting.CraftableMatch, len(base))
+				for i, p := range perm {
+					matches[i] = base[p]
+				}
+				e.sortCraftable(matches, strategy)
+
+				var got string
+				for _, m := range matches {
+					got += m.Recipe.ID
+				}
+				want := "dcab"
+				if strategy != crafting.StrategyMaximizeProfit && strategy != crafting.StrategyMaximizeProfitPerHour &&
+					strategy != crafting.StrategyOptimizeCraftPath {
+					want = "cdab" // quantity first
+				}
+				if got != want {
+					t.Errorf("order from %v = %s, want %s", perm, got, want)
+				}
+			}
+		})
+	}
+}
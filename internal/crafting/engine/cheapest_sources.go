@@ -0,0 +1,52 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
+)
+
+// maxCheapestSources caps how many components one cheapest_sources call
+// may look up.
+const maxCheapestSources = 100
+
+// CheapestSources executes the cheapest_sources tool logic: for each
+// component, the station with the lowest 7-day average buy price.
+// Repeated IDs are looked up once.
+func (e *Engine) CheapestSources(ctx context.Context, req crafting.CheapestSourcesRequest) (*crafting.CheapestSourcesResponse, error) {
+	if len(req.ComponentIDs) == 0 {
+		return nil, invalidInputf("component_ids is required")
+	}
+	if len(req.ComponentIDs) > maxCheapestSources {
+		return nil, invalidInputf("at most %d component_ids per call, got %d", maxCheapestSources, len(req.ComponentIDs))
+	}
+
+	resp := &crafting.CheapestSourcesResponse{
+		Sources: make([]crafting.CheapestSource, 0, len(req.ComponentIDs)),
+	}
+	seen := make(map[string]bool, len(req.ComponentIDs))
+	for _, id := range req.ComponentIDs {
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		cheapest, err := e.market.FindCheapestStation(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("finding cheapest station for %s: %w", id, err)
+		}
+		if cheapest == nil {
+			resp.Unavailable = append(resp.Unavailable, id)
+			continue
+		}
+		resp.Sources = append(resp.Sources, crafting.CheapestSource{
+			ComponentID: id,
+			StationID:   cheapest.StationID,
+			StationName: cheapest.StationName,
+			BuyPrice:    cheapest.Price,
+		})
+	}
+
+	return resp, nil
+}
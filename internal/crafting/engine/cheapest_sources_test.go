@@ -0,0 +1,47 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
+)
+
+func TestCheapestSources(t *testing.T) {
+	ctx := context.Background()
+	engine := testEngine(t)
+
+	_, err := engine.db.ExecContext(ctx, `
+		INSERT INTO market_price_summary (item_id, station_id, price_type, avg_price_7d) VALUES
+			('ore', 'station_a', 'buy', 12),
+			('ore', 'station_b', 'buy', 10),
+			('plate', 'station_a', 'buy', 40),
+			('plate', 'station_b', 'buy', 55)
+	`)
+	if err != nil {
+		t.Fatalf("inserting market prices: %v", err)
+	}
+
+	resp, err := engine.CheapestSources(ctx, crafting.CheapestSourcesRequest{
+		ComponentIDs: []string{"plate", "unobtainium", "ore", "plate"},
+	})
+	if err != nil {
+		t.Fatalf("CheapestSources failed: %v", err)
+	}
+	want := &crafting.CheapestSourcesResponse{
+		Sources: []crafting.CheapestSource{
+			{ComponentID: "plate", StationID: "station_a", BuyPrice: 40},
+			{ComponentID: "ore", StationID: "station_b", BuyPrice: 10},
+		},
+		Unavailable: []string{"unobtainium"},
+	}
+	if !reflect.DeepEqual(resp, want) {
+		t.Errorf("got %+v, want %+v", resp, want)
+	}
+
+	if _, err := engine.CheapestSources(ctx, crafting.CheapestSourcesRequest{}); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("no components: expected ErrInvalidInput, got %v", err)
+	}
+}
@@ -0,0 +1,146 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+
+	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
+)
+
+// CompareRecipes executes the compare_recipes tool logic: a field-by-field
+// diff of two recipes, e.g. a recipe before and after a game update that
+// were imported under different IDs, or two ways of making similar items.
+// Recipes have no skill requirements (see migration 008), so none are
+// compared.
+func (e *Engine) CompareRecipes(ctx context.Context, req crafting.CompareRecipesRequest) (*crafting.CompareRecipesResponse, error) {
+	if req.RecipeA == "" || req.RecipeB == "" {
+		return nil, invalidInputf("recipe_a and recipe_b are required")
+	}
+
+	a, err := e.loadRecipe(ctx, req.RecipeA)
+	if err != nil {
+		return nil, err
+	}
+	b, err := e.loadRecipe(ctx, req.RecipeB)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &crafting.CompareRecipesResponse{
+		RecipeA: a.ID,
+		RecipeB: b.ID,
+		Inputs:  diffRecipeItems(inputItems(a), inputItems(b)),
+		Outputs: diffRecipeItems(outputItems(a), outputItems(b)),
+	}
+
+	field := func(name, va, vb string) {
+		if va != vb {
+			resp.Fields = append(resp.Fields, crafting.FieldChange{Field: name, A: va, B: vb})
+		}
+	}
+	field("name", a.Name, b.Name)
+	field("description", a.Description, b.Description)
+	field("category", a.Category, b.Category)
+	field("crafting_time", strconv.Itoa(a.CraftingTime), strconv.Itoa(b.CraftingTime))
+	field("success_rate", strconv.FormatFloat(a.SuccessRate, 'g', -1, 64), strconv.FormatFloat(b.SuccessRate, 'g', -1, 64))
+
+	tagsA, tagsB := toSet(a.Tags), toSet(b.Tags)
+	for _, tag := range slices.Sorted(maps.Keys(tagsB)) {
+		if !tagsA[tag] {
+			resp.TagsAdded = append(resp.TagsAdded, tag)
+		}
+	}
+	for _, tag := range slices.Sorted(maps.Keys(tagsA)) {
+		if !tagsB[tag] {
+			resp.TagsRemoved = append(resp.TagsRemoved, tag)
+		}
+	}
+
+	resp.Identical = len(resp.Fields) == 0 &&
+		len(resp.TagsAdded) == 0 && len(resp.TagsRemoved) == 0 &&
+		resp.Inputs.Empty() && resp.Outputs.Empty()
+
+	return resp, nil
+}
+
+// loadRecipe gets a recipe by ID, reporting a missing one as not found.
+func (e *Engine) loadRecipe(ctx context.Context, id string) (*crafting.Recipe, error) {
+	recipe, err := e.getRecipe(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("getting recipe: %w", err)
+	}
+	if recipe == nil {
+		return nil, notFoundf("recipe not found: %s", id)
+	}
+	return recipe, nil
+}
+
+// recipeItem is an item's total quantity in one side of a recipe.
+type recipeItem struct {
+	quantity     int
+	alternatives []string
+}
+
+// inputItems indexes a recipe's inputs by item, summing repeated items.
+func inputItems(r *crafting.Recipe) map[string]recipeItem {
+	items := make(map[string]recipeItem, len(r.Inputs))
+	for _, inp := range r.Inputs {
+		it := items[inp.ItemID]
+		it.quantity += inp.Quantity
+		it.alternatives = append(it.alternatives, inp.Alternatives...)
+		items[inp.ItemID] = it
+	}
+	for id, it := range items {
+		slices.Sort(it.alternatives)
+		it.alternatives = slices.Compact(it.alternatives)
+		items[id] = it
+	}
+	return items
+}
+
+// outputItems indexes a recipe's outputs by item, summing repeated items.
+func outputItems(r *crafting.Recipe) map[string]recipeItem {
+	items := make(map[string]recipeItem, len(r.Outputs))
+	for _, out := range r.Outputs {
+		it := items[out.ItemID]
+		it.quantity += out.Quantity
+		items[out.ItemID] = it
+	}
+	return items
+}
+
+// diffRecipeItems compares the items of two recipes.
+func diffRecipeItems(a, b map[string]recipeItem) crafting.RecipeItemDiff {
+	diff := crafting.RecipeItemDiff{
+		Added:   []crafting.RecipeItemChange{},
+		Removed: []crafting.RecipeItemChange{},
+		Changed: []crafting.RecipeItemChange{},
+	}
+	for _, id := range slices.Sorted(maps.Keys(b)) {
+		if _, ok := a[id]; !ok {
+			diff.Added = append(diff.Added, crafting.RecipeItemChange{ItemID: id, QuantityB: b[id].quantity})
+		}
+	}
+	for _, id := range slices.Sorted(maps.Keys(a)) {
+		ia := a[id]
+		ib, ok := b[id]
+		if !ok {
+			diff.Removed = append(diff.Removed, crafting.RecipeItemChange{ItemID: id, QuantityA: ia.quantity})
+			continue
+		}
+		sameAlternatives := slices.Equal(ia.alternatives, ib.alternatives)
+		if ia.quantity == ib.quantity && sameAlternatives {
+			continue
+		}
+		change := crafting.RecipeItemChange{ItemID: id, QuantityA: ia.quantity, QuantityB: ib.quantity}
+		if !sameAlternatives {
+			change.AlternativesA = ia.alternatives
+			change.AlternativesB = ib.alternatives
+		}
+		diff.Changed = append(diff.Changed, change)
+	}
+	return diff
+}
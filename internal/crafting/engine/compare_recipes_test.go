@@ -0,0 +1,83 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
+)
+
+func TestCompareRecipes(t *testing.T) {
+	ctx := context.Background()
+	engine := testEngine(t)
+
+	recipes := []crafting.Recipe{
+		{
+			ID: "hull_v1", Name: "Hull", Category: "Ships", CraftingTime: 60, Tags: []string{"tradeable"},
+			Inputs: []crafting.RecipeInput{
+				{ItemID: "plate", Quantity: 4},
+				{ItemID: "rivet", Quantity: 10},
+				{ItemID: "frame", Quantity: 1, Alternatives: []string{"truss"}},
+			},
+			Outputs: []crafting.RecipeOutput{{ItemID: "hull", Quantity: 1}},
+		},
+		{
+			ID: "hull_v2", Name: "Hull", Category: "Ships", CraftingTime: 45, Tags: []string{"tradeable", "tier2"},
+			Inputs: []crafting.RecipeInput{
+				{ItemID: "plate", Quantity: 6},
+				{ItemID: "frame", Quantity: 1},
+				{ItemID: "sealant", Quantity: 2},
+			},
+			Outputs: []crafting.RecipeOutput{{ItemID: "hull", Quantity: 1}},
+		},
+	}
+	if err := engine.recipes.BulkInsertRecipes(ctx, recipes); err != nil {
+		t.Fatalf("BulkInsertRecipes failed: %v", err)
+	}
+
+	resp, err := engine.CompareRecipes(ctx, crafting.CompareRecipesRequest{RecipeA: "hull_v1", RecipeB: "hull_v2"})
+	if err != nil {
+		t.Fatalf("CompareRecipes failed: %v", err)
+	}
+	if resp.Identical {
+		t.Error("expected the recipes to differ")
+	}
+	wantInputs := crafting.RecipeItemDiff{
+		Added:   []crafting.RecipeItemChange{{ItemID: "sealant", QuantityB: 2}},
+		Removed: []crafting.RecipeItemChange{{ItemID: "rivet", QuantityA: 10}},
+		Changed: []crafting.RecipeItemChange{
+			{ItemID: "frame", QuantityA: 1, QuantityB: 1, AlternativesA: []string{"truss"}},
+			{ItemID: "plate", QuantityA: 4, QuantityB: 6},
+		},
+	}
+	if !reflect.DeepEqual(resp.Inputs, wantInputs) {
+		t.Errorf("inputs = %+v, want %+v", resp.Inputs, wantInputs)
+	}
+	if !resp.Outputs.Empty() {
+		t.Errorf("expected no output changes, got %+v", resp.Outputs)
+	}
+	wantFields := []crafting.FieldChange{{Field: "crafting_time", A: "60", B: "45"}}
+	if !reflect.DeepEqual(resp.Fields, wantFields) {
+		t.Errorf("fields = %+v, want %+v", resp.Fields, wantFields)
+	}
+	if !reflect.DeepEqual(resp.TagsAdded, []string{"tier2"}) || resp.TagsRemoved != nil {
+		t.Errorf("tags added %v removed %v, want [tier2] and none", resp.TagsAdded, resp.TagsRemoved)
+	}
+
+	same, err := engine.CompareRecipes(ctx, crafting.CompareRecipesRequest{RecipeA: "hull_v1", RecipeB: "hull_v1"})
+	if err != nil {
+		t.Fatalf("CompareRecipes failed: %v", err)
+	}
+	if !same.Identical {
+		t.Errorf("expected a recipe to be identical to itself, got %+v", same)
+	}
+
+	if _, err := engine.CompareRecipes(ctx, crafting.CompareRecipesRequest{RecipeA: "hull_v1", RecipeB: "hull_v9"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("unknown recipe: expected ErrNotFound, got %v", err)
+	}
+	if _, err := engine.CompareRecipes(ctx, crafting.CompareRecipesRequest{RecipeA: "hull_v1"}); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("missing recipe_b: expected ErrInvalidInput, got %v", err)
+	}
+}
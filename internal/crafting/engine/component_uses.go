@@ -1,6 +1,7 @@
 package engine
 
 import (
+	"cmp"
 	"context"
 	"fmt"
 	"sort"
@@ -10,6 +11,10 @@ import (
 
 // ComponentUses executes the component_uses tool logic.
 func (e *Engine) ComponentUses(ctx context.Context, req crafting.ComponentUsesRequest) (*crafting.ComponentUsesResponse, error) {
+	if req.AvailableQuantity < 0 {
+		return nil, invalidInputf("available_quantity must not be negative")
+	}
+
 	// Resolve station identifier
 	req.StationID = e.resolveStationID(ctx, req.StationID)
 
@@ -22,16 +27,28 @@ func (e *Engine) ComponentUses(ctx context.Context, req crafting.ComponentUsesRe
 		ItemID: req.ItemID,
 	}
 
+	names, err := e.itemNames(ctx, []string{req.ItemID})
+	if err != nil {
+		return nil, err
+	}
+	resp.ItemName = names[req.ItemID]
+
 	// Find all recipes that use this item
 	recipeIDs, err := e.recipes.FindRecipesByComponents(ctx, []string{req.ItemID})
 	if err != nil {
 		return nil, err
 	}
 
+	var inventory map[string]int
+	if len(req.Components) > 0 {
+		inventory = buildInventoryMap(req.Components)
+	}
+	_, itemListed := inventory[req.ItemID]
+
 	var uses []crafting.ComponentUseInfo
 
 	for _, recipeID := range recipeIDs {
-		recipe, err := e.recipes.GetRecipe(ctx, recipeID)
+		recipe, err := e.getRecipe(ctx, recipeID)
 		if err != nil {
 			return nil, err
 		}
@@ -50,11 +67,14 @@ func (e *Engine) ComponentUses(ctx context.Context, req crafting.ComponentUsesRe
 
 		// Calculate profit if station provided
 		var profitAnalysis *crafting.ProfitAnalysis
-		if req.StationID != "" {
-			profitAnalysis, err = e.calculateProfitAnalysis(ctx, recipe, req.StationID, 1)
+		if req.StationID != "" || req.AutoStation {
+			profitAnalysis, err = e.profitAnalysisFor(ctx, recipe, req.StationID, req.AutoStation, 1, req.PricingModel)
 			if err != nil {
 				return nil, err
 			}
+			if !meetsProfitFilter(req.ProfitFilter, profitAnalysis) {
+				continue
+			}
 		}
 
 		// Enrich with illegal status
@@ -62,11 +82,26 @@ func (e *Engine) ComponentUses(ctx context.Context, req crafting.ComponentUsesRe
 			return nil, fmt.Errorf("enriching illegal status: %w", err)
 		}
 
-		uses = append(uses, crafting.ComponentUseInfo{
+		use := crafting.ComponentUseInfo{
 			Recipe:           *recipe,
 			QuantityPerCraft: quantityNeeded,
 			ProfitAnalysis:   profitAnalysis,
-		})
+		}
+		if req.AvailableQuantity > 0 && quantityNeeded > 0 {
+			use.MaxCraftsFromComponent = req.AvailableQuantity / quantityNeeded
+		}
+		if inventory != nil {
+			_, missing, _, _ := e.calculateInputMatch(recipe, inventory, nil)
+			for _, m := range missing {
+				// The item being looked up is the one the agent has in hand
+				if m.ItemID == req.ItemID && !itemListed {
+					continue
+				}
+				use.InputsMissing = append(use.InputsMissing, m)
+			}
+			use.ReadyToCraft = len(use.InputsMissing) == 0
+		}
+		uses = append(uses, use)
 	}
 
 	// Sort based on strategy
@@ -88,39 +123,55 @@ func (e *Engine) ComponentUses(ctx context.Context, req crafting.ComponentUsesRe
 }
 
 // sortComponentUses sorts component uses based on optimization strategy.
-// Primary sort: Category tier (1-6), Secondary sort: Strategy.
+// Primary sort: Category tier (1-6), Secondary sort: Strategy. Ties fall back
+// to profit per unit, then quantity per craft, then recipe ID, so the order
+// is fully deterministic. USE_INVENTORY_FIRST puts uses that are ready to
+// craft ahead of everything else.
 func (e *Engine) sortComponentUses(uses []crafting.ComponentUseInfo, strategy crafting.OptimizationStrategy) {
 	sort.Slice(uses, func(i, j int) bool {
+		a, b := &uses[i], &uses[j]
+
+		if strategy == crafting.StrategyUseInventoryFirst && a.ReadyToCraft != b.ReadyToCraft {
+			return a.ReadyToCraft
+		}
+
 		// Primary sort: category tier
-		tierI := e.getCategoryTier(uses[i].Recipe.Category)
-		tierJ := e.getCategoryTier(uses[j].Recipe.Category)
-		if tierI != tierJ {
-			return tierI < tierJ
+		if c := cmp.Compare(e.getCategoryTier(a.Recipe.Category), e.getCategoryTier(b.Recipe.Category)); c != 0 {
+			return c < 0
 		}
 
 		// Secondary sort: optimization strategy
+		var c int
 		switch strategy {
 		case crafting.StrategyMaximizeProfit:
-			pi := 0
-			pj := 0
-			if uses[i].ProfitAnalysis != nil {
-				pi = uses[i].ProfitAnalysis.ProfitPerUnit
-			}
-			if uses[j].ProfitAnalysis != nil {
-				pj = uses[j].ProfitAnalysis.ProfitPerUnit
-			}
-			return pi > pj
+			c = cmp.Compare(profitPerUnit(b.ProfitAnalysis), profitPerUnit(a.ProfitAnalysis))
+
+		case crafting.StrategyMaximizeProfitPerHour:
+			c = compareProfitPerHour(b.ProfitAnalysis, a.ProfitAnalysis)
 
 		case crafting.StrategyMaximizeVolume:
-			// Prefer recipes that use less of the component (more recipes possible)
-			return uses[i].QuantityPerCraft < uses[j].QuantityPerCraft
+			// Prefer the most runs from the quantity held, then recipes
+			// that use less of the component
+			c = cmp.Compare(b.MaxCraftsFromComponent, a.MaxCraftsFromComponent)
+			if c == 0 {
+				c = cmp.Compare(a.QuantityPerCraft, b.QuantityPerCraft)
+			}
 
-		case crafting.StrategyUseInventoryFirst:
+		default:
 			// Prefer simpler recipes
-			return len(uses[i].Recipe.Inputs) < len(uses[j].Recipe.Inputs)
+			c = cmp.Compare(len(a.Recipe.Inputs), len(b.Recipe.Inputs))
+		}
+		if c != 0 {
+			return c < 0
+		}
 
-		default:
-			return len(uses[i].Recipe.Inputs) < len(uses[j].Recipe.Inputs)
+		// Tiebreakers
+		if c := cmp.Compare(profitPerUnit(b.ProfitAnalysis), profitPerUnit(a.ProfitAnalysis)); c != 0 {
+			return c < 0
+		}
+		if c := cmp.Compare(a.QuantityPerCraft, b.QuantityPerCraft); c != 0 {
+			return c < 0
 		}
+		return a.Recipe.ID < b.Recipe.ID
 	})
 }
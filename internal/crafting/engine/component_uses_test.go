@@ -0,0 +1,154 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
+)
+
+func TestComponentUses_ReadyToCraft(t *testing.T) {
+	ctx := context.Background()
+	engine := testEngine(t)
+
+	recipes := []crafting.Recipe{
+		{
+			ID: "smelt_plate", Name: "Smelt Plate", Category: "Refining",
+			Inputs:  []crafting.RecipeInput{{ItemID: "ore", Quantity: 3}},
+			Outputs: []crafting.RecipeOutput{{ItemID: "plate", Quantity: 1}},
+		},
+		{
+			ID: "alloy_plate", Name: "Alloy Plate", Category: "Refining",
+			Inputs: []crafting.RecipeInput{
+				{ItemID: "ore", Quantity: 2},
+				{ItemID: "flux", Quantity: 2},
+			},
+			Outputs: []crafting.RecipeOutput{{ItemID: "alloy", Quantity: 1}},
+		},
+		{
+			ID: "ore_crate", Name: "Ore Crate", Category: "Refining",
+			Inputs: []crafting.RecipeInput{
+				{ItemID: "ore", Quantity: 1},
+				{ItemID: "crate", Quantity: 1},
+			},
+			Outputs: []crafting.RecipeOutput{{ItemID: "packed_ore", Quantity: 1}},
+		},
+	}
+	if err := engine.recipes.BulkInsertRecipes(ctx, recipes); err != nil {
+		t.Fatalf("BulkInsertRecipes failed: %v", err)
+	}
+
+	readiness := func(resp *crafting.ComponentUsesResponse) map[string][]crafting.RecipeInput {
+		got := make(map[string][]crafting.RecipeInput)
+		for _, use := range resp.UsedIn {
+			if use.ReadyToCraft != (len(use.InputsMissing) == 0) {
+				t.Errorf("%s: ready_to_craft %v disagrees with missing %+v", use.Recipe.ID, use.ReadyToCraft, use.InputsMissing)
+			}
+			got[use.Recipe.ID] = use.InputsMissing
+		}
+		return got
+	}
+
+	// Ore is not listed, so it is assumed held; only the other inputs count.
+	resp, err := engine.ComponentUses(ctx, crafting.ComponentUsesRequest{
+		ItemID:     "ore",
+		Components: []crafting.Component{{ID: "crate", Quantity: 1}, {ID: "flux", Quantity: 1}},
+	})
+	if err != nil {
+		t.Fatalf("ComponentUses failed: %v", err)
+	}
+	want := map[string][]crafting.RecipeInput{
+		"smelt_plate": nil,
+		"ore_crate":   nil,
+		"alloy_plate": {{ItemID: "flux", Quantity: 1}},
+	}
+	if got := readiness(resp); !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected missing inputs:\n got %+v\nwant %+v", got, want)
+	}
+
+	// Ready uses rank first under the default strategy.
+	if last := resp.UsedIn[len(resp.UsedIn)-1]; last.Recipe.ID != "alloy_plate" {
+		t.Errorf("expected the unready use last, got order %v", recipeIDs(resp.UsedIn))
+	}
+
+	// Listing ore makes its quantity count too.
+	resp, err = engine.ComponentUses(ctx, crafting.ComponentUsesRequest{
+		ItemID:     "ore",
+		Components: []crafting.Component{{ID: "ore", Quantity: 2}, {ID: "crate", Quantity: 1}},
+	})
+	if err != nil {
+		t.Fatalf("ComponentUses failed: %v", err)
+	}
+	want = map[string][]crafting.RecipeInput{
+		"smelt_plate": {{ItemID: "ore", Quantity: 1}},
+		"ore_crate":   nil,
+		"alloy_plate": {{ItemID: "flux", Quantity: 2}},
+	}
+	if got := readiness(resp); !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected missing inputs:\n got %+v\nwant %+v", got, want)
+	}
+
+	// Without components nothing is reported.
+	resp, err = engine.ComponentUses(ctx, crafting.ComponentUsesRequest{ItemID: "ore"})
+	if err != nil {
+		t.Fatalf("ComponentUses failed: %v", err)
+	}
+	for _, use := range resp.UsedIn {
+		if use.ReadyToCraft || use.InputsMissing != nil {
+			t.Errorf("%s: expected no readiness without components, got %+v", use.Recipe.ID, use)
+		}
+	}
+}
+
+func recipeIDs(uses []crafting.ComponentUseInfo) []string {
+	ids := make([]string, len(uses))
+	for i, use := range uses {
+		ids[i] = use.Recipe.ID
+	}
+	return ids
+}
+
+func TestComponentUses_AvailableQuantity(t *testing.T) {
+	ctx := context.Background()
+	engine := testEngine(t)
+
+	recipe := func(id string, oreNeeded int) crafting.Recipe {
+		return crafting.Recipe{
+			ID: id, Name: id, Category: "Refining",
+			Inputs:  []crafting.RecipeInput{{ItemID: "ore", Quantity: oreNeeded}},
+			Outputs: []crafting.RecipeOutput{{ItemID: id, Quantity: 1}},
+		}
+	}
+	recipes := []crafting.Recipe{recipe("big", 3), recipe("medium", 2), recipe("small", 1)}
+	if err := engine.recipes.BulkInsertRecipes(ctx, recipes); err != nil {
+		t.Fatalf("BulkInsertRecipes failed: %v", err)
+	}
+
+	resp, err := engine.ComponentUses(ctx, crafting.ComponentUsesRequest{
+		ItemID:            "ore",
+		AvailableQuantity: 5,
+		Strategy:          crafting.StrategyMaximizeVolume,
+	})
+	if err != nil {
+		t.Fatalf("ComponentUses failed: %v", err)
+	}
+
+	var order []string
+	crafts := make(map[string]int)
+	for _, use := range resp.UsedIn {
+		order = append(order, use.Recipe.ID)
+		crafts[use.Recipe.ID] = use.MaxCraftsFromComponent
+	}
+	if want := []string{"small", "medium", "big"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("order = %v, want %v", order, want)
+	}
+	if want := map[string]int{"small": 5, "medium": 2, "big": 1}; !reflect.DeepEqual(crafts, want) {
+		t.Errorf("max crafts = %v, want %v", crafts, want)
+	}
+
+	if _, err := engine.ComponentUses(ctx, crafting.ComponentUsesRequest{ItemID: "ore", AvailableQuantity: -1}); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("negative available_quantity: expected ErrInvalidInput, got %v", err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/rsned/spacemolt-crafting-server/internal/crafting/db"
 	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
 )
 
@@ -14,12 +15,15 @@ func (e *Engine) CraftPathTo(ctx context.Context, req crafting.CraftPathRequest)
 	if req.TargetQuantity <= 0 {
 		req.TargetQuantity = 1
 	}
+	if !req.Strategy.IsValid() {
+		req.Strategy = crafting.StrategyUseInventoryFirst
+	}
 	
 	// Resolve station identifier
 	req.StationID = e.resolveStationID(ctx, req.StationID)
 
 	// Get the target recipe
-	recipe, err := e.recipes.GetRecipe(ctx, req.TargetRecipeID)
+	recipe, err := e.getRecipe(ctx, req.TargetRecipeID)
 	if err != nil {
 		return nil, err
 	}
@@ -42,7 +46,7 @@ func (e *Engine) CraftPathTo(ctx context.Context, req crafting.CraftPathRequest)
 	inventory := buildInventoryMap(req.CurrentInventory)
 	
 	// Calculate materials needed (single level)
-	materials, err := e.calculateMaterialsNeeded(ctx, recipe, req.TargetQuantity, inventory, req.StationID)
+	materials, err := e.calculateMaterialsNeeded(ctx, recipe, req.TargetQuantity, inventory, req.StationID, req.Strategy)
 	if err != nil {
 		return nil, err
 	}
@@ -74,14 +78,34 @@ func (e *Engine) CraftPathTo(ctx context.Context, req crafting.CraftPathRequest)
 }
 
 // calculateMaterialsNeeded calculates what materials are needed for a recipe.
+// Each material's acquisition methods are ordered with the one recommended
+// by strategy first.
 func (e *Engine) calculateMaterialsNeeded(
 	ctx context.Context,
 	recipe *crafting.Recipe,
 	quantity int,
 	inventory map[string]int,
 	stationID string,
+	strategy crafting.OptimizationStrategy,
 ) ([]crafting.MaterialRequirement, error) {
 	var materials []crafting.MaterialRequirement
+
+	// Pick craft recipes the same way bill_of_materials does
+	allRecipes, err := e.getAllRecipes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("loading all recipes: %w", err)
+	}
+	craftRecipes := selectCraftRecipes(allRecipes)
+
+	// Inventory left over once the target recipe's direct inputs are set
+	// aside; this is what intermediate crafts can draw on.
+	spare := make(map[string]int, len(inventory))
+	for itemID, qty := range inventory {
+		spare[itemID] = qty
+	}
+	for _, inp := range recipe.Inputs {
+		spare[inp.ItemID] -= inp.Quantity * quantity
+	}
 	
 	for _, inp := range recipe.Inputs {
 		needed := inp.Quantity * quantity
@@ -99,45 +123,53 @@ func (e *Engine) calculateMaterialsNeeded(
 		}
 
 		// Check if this item can be crafted
-		craftRecipes, err := e.recipes.FindRecipesByOutput(ctx, inp.ItemID)
-		if err != nil {
-			return nil, err
-		}
-		if len(craftRecipes) > 0 {
+		craftRecipe := craftRecipes[inp.ItemID]
+		if craftRecipe != nil {
 			mat.IsCraftable = true
-			mat.CraftRecipeID = craftRecipes[0] // Use first recipe
+			mat.CraftRecipeID = craftRecipe.ID
 
 			// Enrich with illegal status
-			craftRecipe, err := e.recipes.GetRecipe(ctx, mat.CraftRecipeID)
-			if err != nil {
-				return nil, fmt.Errorf("getting craft recipe: %w", err)
-			}
-			if craftRecipe != nil {
-				if err := e.enrichRecipeWithIllegalStatus(ctx, craftRecipe); err != nil {
-					return nil, fmt.Errorf("enriching illegal status: %w", err)
-				}
-				mat.CraftIllegalStatus = craftRecipe.IllegalStatus
+			if err := e.enrichRecipeWithIllegalStatus(ctx, craftRecipe); err != nil {
+				return nil, fmt.Errorf("enriching illegal status: %w", err)
 			}
+			mat.CraftIllegalStatus = craftRecipe.IllegalStatus
 		}
 
 		// Add acquisition methods
 		if toAcquire > 0 {
-			// TODO: Look up where this item can be acquired
-			// For now, indicate it can be bought if we have market data
+			buyPrice := 0
 			if stationID != "" {
-				price, err := e.market.GetBuyPrice(ctx, inp.ItemID, stationID)
+				buyPrice, err = e.market.GetBuyPrice(ctx, inp.ItemID, stationID)
 				if err != nil {
 					return nil, err
 				}
-				if price > 0 {
+				if buyPrice > 0 {
 					mat.AcquisitionMethods = append(mat.AcquisitionMethods, "buy:"+stationID)
 				}
 			}
 
+			// Non-market sources (mining, loot, missions, vendors)
+			sources, err := e.acquisition.GetSources(ctx, inp.ItemID)
+			if err != nil {
+				return nil, fmt.Errorf("getting acquisition sources: %w", err)
+			}
+			for _, src := range sources {
+				mat.AcquisitionMethods = append(mat.AcquisitionMethods, formatAcquisitionSource(src))
+			}
+
 			// If craftable, that's also an acquisition method
 			if mat.IsCraftable {
 				mat.AcquisitionMethods = append(mat.AcquisitionMethods, "craft:"+mat.CraftRecipeID)
 			}
+
+			if len(mat.AcquisitionMethods) > 0 {
+				recommended, err := e.recommendAcquisitionMethod(ctx, &mat, craftRecipe, buyPrice, stationID, strategy, spare)
+				if err != nil {
+					return nil, err
+				}
+				mat.RecommendedMethod = recommended
+				moveToFront(mat.AcquisitionMethods, recommended)
+			}
 		}
 
 		materials = append(materials, mat)
@@ -166,3 +198,104 @@ func calculatePathSummary(materials []crafting.MaterialRequirement) crafting.Cra
 	
 	return summary
 }
+
+// recommendAcquisitionMethod picks which of mat's acquisition methods to
+// prefer. MINIMIZE_ACQUISITION and USE_INVENTORY_FIRST prefer crafting when
+// spare inventory covers the intermediate recipe's inputs (reserving them so
+// later materials can't count them again). MAXIMIZE_PROFIT and
+// MAXIMIZE_PROFIT_PER_HOUR prefer whichever of buying or crafting is cheaper
+// at market prices. Otherwise the first listed method is kept.
+func (e *Engine) recommendAcquisitionMethod(
+	ctx context.Context,
+	mat *crafting.MaterialRequirement,
+	craftRecipe *crafting.Recipe,
+	buyPrice int,
+	stationID string,
+	strategy crafting.OptimizationStrategy,
+	spare map[string]int,
+) (string, error) {
+	craftMethod := "craft:" + mat.CraftRecipeID
+
+	switch strategy {
+	case crafting.StrategyMinimizeAcquisition, crafting.StrategyUseInventoryFirst:
+		if craftRecipe != nil && reserveCraftInputs(craftRecipe, mat.ItemID, mat.QuantityToAcquire, spare) {
+			return craftMethod, nil
+		}
+
+	case crafting.StrategyMaximizeProfit, crafting.StrategyMaximizeProfitPerHour:
+		if craftRecipe != nil && buyPrice > 0 {
+			runCost, err := e.craftRunCost(ctx, craftRecipe, stationID)
+			if err != nil {
+				return "", err
+			}
+			// Compare per-run cost against buying the same number of units
+			outQty := getOutputQuantityForItem(craftRecipe, mat.ItemID)
+			if runCost > 0 && runCost < buyPrice*outQty {
+				return craftMethod, nil
+			}
+			return "buy:" + stationID, nil
+		}
+	}
+
+	return mat.AcquisitionMethods[0], nil
+}
+
+// reserveCraftInputs reports whether spare inventory covers enough runs of
+// recipe to produce quantity of itemID, deducting the inputs if so.
+func reserveCraftInputs(recipe *crafting.Recipe, itemID string, quantity int, spare map[string]int) bool {
+	outQty := getOutputQuantityForItem(recipe, itemID)
+	runs := (quantity + outQty - 1) / outQty
+
+	for _, inp := range recipe.Inputs {
+		if inp.Quantity > 0 && spare[inp.ItemID] < inp.Quantity*runs {
+			return false
+		}
+	}
+	for _, inp := range recipe.Inputs {
+		if inp.Quantity > 0 {
+			spare[inp.ItemID] -= inp.Quantity * runs
+		}
+	}
+	return true
+}
+
+// craftRunCost estimates the cost of buying the inputs for one run of
+// recipe. It returns 0 when any input has no known price.
+func (e *Engine) craftRunCost(ctx context.Context, recipe *crafting.Recipe, stationID string) (int, error) {
+	total := 0
+	for _, inp := range recipe.Inputs {
+		if inp.Quantity <= 0 {
+			continue
+		}
+		unit, err := e.acquisitionUnitCost(ctx, inp.ItemID, stationID)
+		if err != nil {
+			return 0, err
+		}
+		if unit <= 0 {
+			return 0, nil
+		}
+		total += unit * inp.Quantity
+	}
+	return total, nil
+}
+
+// moveToFront moves method to the start of methods, keeping the relative
+// order of the rest.
+func moveToFront(methods []string, method string) {
+	for i, m := range methods {
+		if m == method {
+			copy(methods[1:i+1], methods[:i])
+			methods[0] = method
+			return
+		}
+	}
+}
+
+// formatAcquisitionSource renders a source as "method:detail", or just
+// "method" when no detail was recorded.
+func formatAcquisitionSource(src db.AcquisitionSource) string {
+	if src.Detail == "" {
+		return src.Method
+	}
+	return src.Method + ":" + src.Detail
+}
@@ -0,0 +1,209 @@
+package engine
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/rsned/spacemolt-crafting-server/internal/crafting/db"
+	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
+)
+
+func TestCraftPathToIncludesAcquisitionSources(t *testing.T) {
+	ctx := context.Background()
+	engine := testEngine(t)
+
+	recipes := []crafting.Recipe{
+		{
+			ID:      "make_plate",
+			Name:    "Make Plate",
+			Inputs:  []crafting.RecipeInput{{ItemID: "ore_iron", Quantity: 2}, {ItemID: "flux", Quantity: 1}},
+			Outputs: []crafting.RecipeOutput{{ItemID: "plate", Quantity: 1}},
+		},
+	}
+	if err := engine.recipes.BulkInsertRecipes(ctx, recipes); err != nil {
+		t.Fatalf("inserting recipes: %v", err)
+	}
+	err := db.NewItemAcquisitionStore(engine.db).ReplaceSources(ctx, []db.AcquisitionSource{
+		{ItemID: "ore_iron", Method: "mine", Detail: "Sol belt"},
+		{ItemID: "ore_iron", Method: "mission"},
+	})
+	if err != nil {
+		t.Fatalf("inserting acquisition sources: %v", err)
+	}
+
+	resp, err := engine.CraftPathTo(ctx, crafting.CraftPathRequest{
+		TargetRecipeID: "make_plate",
+		TargetQuantity: 1,
+	})
+	if err != nil {
+		t.Fatalf("CraftPathTo: %v", err)
+	}
+
+	methods := map[string][]string{}
+	for _, mat := range resp.MaterialsNeeded {
+		methods[mat.ItemID] = mat.AcquisitionMethods
+	}
+	if want := []string{"mine:Sol belt", "mission"}; !reflect.DeepEqual(methods["ore_iron"], want) {
+		t.Errorf("ore_iron methods = %v, want %v", methods["ore_iron"], want)
+	}
+	if len(methods["flux"]) != 0 {
+		t.Errorf("flux methods = %v, want none", methods["flux"])
+	}
+	if resp.Feasible {
+		t.Error("expected path to be infeasible: flux has no acquisition method")
+	}
+}
+
+func TestCraftPathToRecommendedMethod(t *testing.T) {
+	tests := []struct {
+		name      string
+		strategy  crafting.OptimizationStrategy
+		orePrice  int
+		inventory []crafting.Component
+		want      string
+	}{
+		{
+			name:     "profit prefers cheaper craft",
+			strategy: crafting.StrategyMaximizeProfit,
+			orePrice: 10, // 3 ore = 30 < plate at 100
+			want:     "craft:make_plate",
+		},
+		{
+			name:     "profit prefers cheaper buy",
+			strategy: crafting.StrategyMaximizeProfit,
+			orePrice: 50, // 3 ore = 150 > plate at 100
+			want:     "buy:station_a",
+		},
+		{
+			name:      "minimize acquisition crafts from inventory",
+			strategy:  crafting.StrategyMinimizeAcquisition,
+			orePrice:  50,
+			inventory: []crafting.Component{{ID: "ore", Quantity: 6}},
+			want:      "craft:make_plate",
+		},
+		{
+			name:      "minimize acquisition without enough inputs",
+			strategy:  crafting.StrategyMinimizeAcquisition,
+			orePrice:  10,
+			inventory: []crafting.Component{{ID: "ore", Quantity: 5}},
+			want:      "buy:station_a",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			engine := testEngine(t)
+
+			recipes := []crafting.Recipe{
+				{
+					ID:      "make_hull",
+					Name:    "Make Hull",
+					Inputs:  []crafting.RecipeInput{{ItemID: "plate", Quantity: 2}},
+					Outputs: []crafting.RecipeOutput{{ItemID: "hull", Quantity: 1}},
+				},
+				{
+					ID:      "make_plate",
+					Name:    "Make Plate",
+					Inputs:  []crafting.RecipeInput{{ItemID: "ore", Quantity: 3}},
+					Outputs: []crafting.RecipeOutput{{ItemID: "plate", Quantity: 1}},
+				},
+			}
+			if err := engine.recipes.BulkInsertRecipes(ctx, recipes); err != nil {
+				t.Fatalf("inserting recipes: %v", err)
+			}
+			_, err := engine.db.ExecContext(ctx, `
+				INSERT INTO market_price_summary (item_id, station_id, price_type, avg_price_7d) VALUES
+					('plate', 'station_a', 'buy', 100),
+					('ore', 'station_a', 'buy', ?)
+			`, tt.orePrice)
+			if err != nil {
+				t.Fatalf("inserting price summaries: %v", err)
+			}
+
+			resp, err := engine.CraftPathTo(ctx, crafting.CraftPathRequest{
+				TargetRecipeID:   "make_hull",
+				TargetQuantity:   1,
+				CurrentInventory: tt.inventory,
+				StationID:        "station_a",
+				Strategy:         tt.strategy,
+			})
+			if err != nil {
+				t.Fatalf("CraftPathTo: %v", err)
+			}
+			if len(resp.MaterialsNeeded) != 1 {
+				t.Fatalf("expected 1 material, got %d", len(resp.MaterialsNeeded))
+			}
+
+			mat := resp.MaterialsNeeded[0]
+			if mat.RecommendedMethod != tt.want {
+				t.Errorf("RecommendedMethod = %q, want %q", mat.RecommendedMethod, tt.want)
+			}
+			if len(mat.AcquisitionMethods) != 2 || mat.AcquisitionMethods[0] != tt.want {
+				t.Errorf("AcquisitionMethods = %v, want %q first", mat.AcquisitionMethods, tt.want)
+			}
+		})
+	}
+}
+
+func TestCraftPathToPicksBestCraftRecipe(t *testing.T) {
+	ctx := context.Background()
+	engine := testEngine(t)
+
+	recipes := []crafting.Recipe{
+		{
+			ID:      "make_hull",
+			Name:    "Make Hull",
+			Inputs:  []crafting.RecipeInput{{ItemID: "plate", Quantity: 1}, {ItemID: "rivet", Quantity: 1}},
+			Outputs: []crafting.RecipeOutput{{ItemID: "hull", Quantity: 1}},
+		},
+		// Plate: the fastest recipe wins even though it sorts last by ID.
+		{
+			ID:           "a_slow_plate",
+			Name:         "Slow Plate",
+			CraftingTime: 20,
+			Inputs:       []crafting.RecipeInput{{ItemID: "ore", Quantity: 1}},
+			Outputs:      []crafting.RecipeOutput{{ItemID: "plate", Quantity: 1}},
+		},
+		{
+			ID:           "z_fast_plate",
+			Name:         "Fast Plate",
+			CraftingTime: 5,
+			Inputs:       []crafting.RecipeInput{{ItemID: "ore", Quantity: 1}},
+			Outputs:      []crafting.RecipeOutput{{ItemID: "plate", Quantity: 1}},
+		},
+		// Rivet: equal craft times, so the higher yield wins.
+		{
+			ID:           "a_rivet",
+			Name:         "Rivet",
+			CraftingTime: 5,
+			Inputs:       []crafting.RecipeInput{{ItemID: "ore", Quantity: 1}},
+			Outputs:      []crafting.RecipeOutput{{ItemID: "rivet", Quantity: 1}},
+		},
+		{
+			ID:           "b_rivet_batch",
+			Name:         "Rivet Batch",
+			CraftingTime: 5,
+			Inputs:       []crafting.RecipeInput{{ItemID: "ore", Quantity: 1}},
+			Outputs:      []crafting.RecipeOutput{{ItemID: "rivet", Quantity: 4}},
+		},
+	}
+	if err := engine.recipes.BulkInsertRecipes(ctx, recipes); err != nil {
+		t.Fatalf("inserting recipes: %v", err)
+	}
+
+	resp, err := engine.CraftPathTo(ctx, crafting.CraftPathRequest{TargetRecipeID: "make_hull"})
+	if err != nil {
+		t.Fatalf("CraftPathTo: %v", err)
+	}
+
+	got := map[string]string{}
+	for _, mat := range resp.MaterialsNeeded {
+		got[mat.ItemID] = mat.CraftRecipeID
+	}
+	want := map[string]string{"plate": "z_fast_plate", "rivet": "b_rivet_batch"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("craft recipes = %v, want %v", got, want)
+	}
+}
@@ -1,22 +1,38 @@
 package engine
 
 import (
+	"cmp"
 	"context"
 	"fmt"
+	"log/slog"
+	"slices"
 	"sort"
 	"time"
 
+	"github.com/rsned/spacemolt-crafting-server/internal/crafting/db"
 	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
 )
 
 // CraftQuery executes the craft_query tool logic.
 func (e *Engine) CraftQuery(ctx context.Context, req crafting.CraftQueryRequest) (*crafting.CraftQueryResponse, error) {
+	return e.craftQuery(ctx, req, e.getRecipe, false)
+}
+
+// craftQuery runs a craft query, loading candidate recipes through
+// getRecipe. The recipes it returns are only read, never modified. When
+// unlimited is set, every match is returned and req.Limit is ignored, for
+// callers that filter the matches further before limiting them.
+func (e *Engine) craftQuery(
+	ctx context.Context,
+	req crafting.CraftQueryRequest,
+	getRecipe func(context.Context, string) (*crafting.Recipe, error),
+	unlimited bool,
+) (*crafting.CraftQueryResponse, error) {
 	startTime := time.Now()
+	var timings queryTimings
 
 	// Apply defaults
-	if req.Limit <= 0 {
-		req.Limit = 20
-	}
+	req.Limit = e.clampLimit(req.Limit, 20)
 	if req.MinMatchRatio <= 0 {
 		req.MinMatchRatio = 0.25
 	}
@@ -24,9 +40,25 @@ func (e *Engine) CraftQuery(ctx context.Context, req crafting.CraftQueryRequest)
 		req.Strategy = crafting.StrategyUseInventoryFirst
 	}
 
+	if req.Budget < 0 {
+		return nil, invalidInputf("budget must not be negative")
+	}
+	if req.Budget > 0 && req.StationID == "" {
+		return nil, invalidInputf("budget requires station_id to price missing inputs")
+	}
+
 	// Resolve station identifier
 	req.StationID = e.resolveStationID(ctx, req.StationID)
 
+	var pools *componentPools
+	if req.PrefixMatching {
+		components, p, err := e.expandWildcards(ctx, req.Components)
+		if err != nil {
+			return nil, err
+		}
+		req.Components, pools = components, p
+	}
+
 	// Build inventory lookup map
 	inventory := buildInventoryMap(req.Components)
 	componentIDs := make([]string, 0, len(req.Components))
@@ -34,28 +66,64 @@ func (e *Engine) CraftQuery(ctx context.Context, req crafting.CraftQueryRequest)
 		componentIDs = append(componentIDs, c.ID)
 	}
 
-	// Find candidate recipes using inverted index
-	candidateIDs, err := e.recipes.FindRecipesByComponents(ctx, componentIDs)
+	phase := time.Now()
+
+	// Find candidate recipes using inverted index, dropping in SQL any recipe
+	// whose input coverage can't reach the ratio needed to be returned
+	minCoverage := 1.0
+	if req.IncludePartial && !req.OnlyCraftable {
+		minCoverage = req.MinMatchRatio
+		if req.UseQuantityWeightedRatio {
+			// Coverage doesn't bound the weighted ratio, so only require a match
+			minCoverage = 0
+		}
+	}
+	coverage, err := e.recipes.FindRecipesByComponentCoverage(ctx, componentIDs, minCoverage)
 	if err != nil {
 		return nil, err
 	}
+	// Each recipe is checked once, however many of the sources below name it
+	candidateIDs := make([]string, 0, len(coverage))
+	seen := make(map[string]bool, len(coverage))
+	addCandidate := func(id string) {
+		if !seen[id] {
+			seen[id] = true
+			candidateIDs = append(candidateIDs, id)
+		}
+	}
+	for _, c := range coverage {
+		addCandidate(c.RecipeID)
+	}
 
-	// If category filter is set, also include all recipes from that category
-	if req.CategoryFilter != "" {
-		categoryIDs, err := e.recipes.ListRecipesByCategory(ctx, req.CategoryFilter)
+	// The singular category_filter is kept for compatibility and merged
+	// into the list.
+	categories := req.Categories
+	if req.CategoryFilter != "" && !slices.Contains(categories, req.CategoryFilter) {
+		categories = append(slices.Clone(categories), req.CategoryFilter)
+	}
+	includedCategories := toSet(categories)
+
+	// If category filter is set, also include all recipes from those
+	// categories. Only recipes covered above can be fully craftable, so
+	// OnlyCraftable skips this.
+	if len(categories) > 0 && !req.OnlyCraftable {
+		categoryIDs, err := e.recipes.ListRecipesByCategories(ctx, categories)
 		if err != nil {
 			return nil, err
 		}
-		// Merge without duplicates
-		seen := make(map[string]bool)
-		for _, id := range candidateIDs {
-			seen[id] = true
-		}
 		for _, id := range categoryIDs {
-			if !seen[id] {
-				candidateIDs = append(candidateIDs, id)
-				seen[id] = true
-			}
+			addCandidate(id)
+		}
+	}
+
+	phase = since(&timings.candidates, phase)
+
+	excludedCategories := toSet(req.ExcludeCategories)
+	excludedRecipes := toSet(req.ExcludeRecipeIDs)
+	wantedTags := make(map[string]bool, len(req.Tags))
+	for _, tag := range req.Tags {
+		if tag = db.NormalizeTag(tag); tag != "" {
+			wantedTags[tag] = true
 		}
 	}
 
@@ -63,16 +131,24 @@ func (e *Engine) CraftQuery(ctx context.Context, req crafting.CraftQueryRequest)
 	var partialComponents []crafting.PartialComponentMatch
 
 	for _, recipeID := range candidateIDs {
-		recipe, err := e.recipes.GetRecipe(ctx, recipeID)
+		if excludedRecipes[recipeID] {
+			continue
+		}
+
+		phase = time.Now()
+		recipe, err := getRecipe(ctx, recipeID)
+		phase = since(&timings.recipes, phase)
 		if err != nil {
 			return nil, err
 		}
 		if recipe == nil {
+			// The component index names a recipe that no longer exists
+			e.logger.Warn("candidate recipe not found; skipping", "recipe_id", recipeID)
 			continue
 		}
 
 		// Apply category filter
-		if req.CategoryFilter != "" && recipe.Category != req.CategoryFilter {
+		if len(includedCategories) > 0 && !includedCategories[recipe.Category] {
 			continue
 		}
 
@@ -81,25 +157,57 @@ func (e *Engine) CraftQuery(ctx context.Context, req crafting.CraftQueryRequest)
 			continue
 		}
 
+		if excludedCategories[recipe.Category] {
+			continue
+		}
+
+		if len(wantedTags) > 0 && !matchesTags(recipe.Tags, wantedTags, req.RequireAllTags) {
+			continue
+		}
+
 		// Calculate input match
-		have, missing, canCraft := e.calculateInputMatch(recipe, inventory)
-		matchRatio := calculateMatchRatio(len(have), len(recipe.Inputs))
+		have, missing, canCraft, subs := e.calculateInputMatch(recipe, inventory, pools)
+		if req.OnlyCraftable && len(missing) > 0 {
+			continue
+		}
+		satisfied := len(recipe.Inputs) - len(missing)
+		matchRatio := calculateMatchRatio(satisfied, len(recipe.Inputs))
+		weightedRatio := calculateQuantityWeightedRatio(recipe, inventory, pools)
+		filterRatio := matchRatio
+		if req.UseQuantityWeightedRatio {
+			filterRatio = weightedRatio
+		}
+		phase = since(&timings.matching, phase)
+
+		// Only recipes that will be returned are worth pricing
+		fullyCraftable := matchRatio == 1.0
+		if !fullyCraftable && (!req.IncludePartial || filterRatio < req.MinMatchRatio) {
+			continue
+		}
 
 		// Calculate profit if station provided
 		var profitAnalysis *crafting.ProfitAnalysis
-		if req.StationID != "" {
-			profitAnalysis, err = e.calculateProfitAnalysis(ctx, recipe, req.StationID, canCraft)
+		if req.StationID != "" || req.AutoStation {
+			profitAnalysis, err = e.profitAnalysisFor(ctx, recipe, req.StationID, req.AutoStation, canCraft, req.PricingModel)
+			since(&timings.profit, phase)
 			if err != nil {
 				return nil, err
 			}
+			if !meetsProfitFilter(req.ProfitFilter, profitAnalysis) {
+				continue
+			}
 		}
 
-		if matchRatio == 1.0 {
-			// Fully craftable
+		if fullyCraftable {
 			result := crafting.CraftableMatch{
-				Recipe:           *recipe,
-				CanCraftQuantity: canCraft,
-				ProfitAnalysis:   profitAnalysis,
+				Recipe:            *recipe,
+				CanCraftQuantity:  canCraft,
+				ProfitAnalysis:    profitAnalysis,
+				Substitutions:     subs,
+				TotalCraftTimeSec: recipe.CraftingTime * canCraft,
+			}
+			if len(recipe.Outputs) > 0 {
+				result.OutputQuantityPossible = canCraft * getOutputQuantityForItem(recipe, recipe.Outputs[0].ItemID)
 			}
 
 			// Enrich with illegal status
@@ -108,17 +216,29 @@ func (e *Engine) CraftQuery(ctx context.Context, req crafting.CraftQueryRequest)
 			}
 
 			craftable = append(craftable, result)
-		} else if req.IncludePartial && matchRatio >= req.MinMatchRatio {
+		} else {
 			// Partial input match
 			result := crafting.PartialComponentMatch{
-				Recipe:        *recipe,
-				InputsHave:    have,
-				InputsMissing: missing,
-				MatchRatio:    matchRatio,
+				Recipe:                *recipe,
+				InputsHave:            have,
+				InputsMissing:         missing,
+				MatchRatio:            matchRatio,
+				QuantityWeightedRatio: weightedRatio,
+				Substitutions:         subs,
 			}
 
-			if req.StationID != "" {
-				result.ProfitAnalysis = profitAnalysis
+			result.ProfitAnalysis = profitAnalysis
+
+			if req.Budget > 0 {
+				phase = time.Now()
+				result.AcquisitionCost, err = e.acquisitionCost(ctx, req.StationID, missing, req.Budget)
+				since(&timings.profit, phase)
+				if err != nil {
+					return nil, err
+				}
+				if req.ExcludeOverBudget && !result.AcquisitionCost.WithinBudget {
+					continue
+				}
 			}
 
 			// Enrich with illegal status
@@ -131,16 +251,24 @@ func (e *Engine) CraftQuery(ctx context.Context, req crafting.CraftQueryRequest)
 	}
 
 	// Sort results based on strategy
+	phase = time.Now()
 	e.sortCraftable(craftable, req.Strategy)
 	e.sortPartial(partialComponents, req.Strategy)
 
 	// Apply limits
-	if len(craftable) > req.Limit {
+	if !unlimited && len(craftable) > req.Limit {
 		craftable = craftable[:req.Limit]
 	}
-	if len(partialComponents) > req.Limit {
+	if !unlimited && len(partialComponents) > req.Limit {
 		partialComponents = partialComponents[:req.Limit]
 	}
+	since(&timings.sorting, phase)
+
+	elapsed := time.Since(startTime)
+	e.logQueryTimings(ctx, "craft_query", elapsed, timings,
+		slog.Int("candidates_checked", len(candidateIDs)),
+		slog.Int("components", len(req.Components)),
+	)
 
 	return &crafting.CraftQueryResponse{
 		Craftable:         craftable,
@@ -149,80 +277,108 @@ func (e *Engine) CraftQuery(ctx context.Context, req crafting.CraftQueryRequest)
 			TotalRecipesChecked: len(candidateIDs),
 			ComponentsProvided:  len(req.Components),
 			StrategyUsed:        string(req.Strategy),
-			ProcessingTimeMs:    time.Since(startTime).Milliseconds(),
+			ProcessingTimeMs:    elapsed.Milliseconds(),
 		},
 	}, nil
 }
 
 // sortCraftable sorts craftable matches based on optimization strategy.
-// Primary sort: Category tier (1-6), Secondary sort: Strategy.
+// Primary sort: Category tier (1-6), Secondary sort: Strategy, where
+// MAXIMIZE_VOLUME counts output items, OPTIMIZE_CRAFT_PATH prefers fewer
+// inputs and then the quicker batch, and other strategies count runs. Ties
+// fall back to profit per unit, then craftable quantity, then recipe ID, so
+// the order is fully deterministic.
 func (e *Engine) sortCraftable(matches []crafting.CraftableMatch, strategy crafting.OptimizationStrategy) {
 	sort.Slice(matches, func(i, j int) bool {
+		a, b := &matches[i], &matches[j]
+
 		// Primary: sort by category tier
-		tierI := e.getCategoryTier(matches[i].Recipe.Category)
-		tierJ := e.getCategoryTier(matches[j].Recipe.Category)
-		if tierI != tierJ {
-			return tierI < tierJ
+		if c := cmp.Compare(e.getCategoryTier(a.Recipe.Category), e.getCategoryTier(b.Recipe.Category)); c != 0 {
+			return c < 0
 		}
 
 		// Secondary: apply strategy within same tier
+		var c int
 		switch strategy {
 		case crafting.StrategyMaximizeProfit:
-			pi := profitPerUnit(matches[i].ProfitAnalysis)
-			pj := profitPerUnit(matches[j].ProfitAnalysis)
-			return pi > pj
+			c = cmp.Compare(profitPerUnit(b.ProfitAnalysis), profitPerUnit(a.ProfitAnalysis))
 
-		case crafting.StrategyMaximizeVolume:
-			return matches[i].CanCraftQuantity > matches[j].CanCraftQuantity
-
-		case crafting.StrategyUseInventoryFirst:
-			return matches[i].CanCraftQuantity > matches[j].CanCraftQuantity
-
-		case crafting.StrategyMinimizeAcquisition:
-			return matches[i].CanCraftQuantity > matches[j].CanCraftQuantity
+		case crafting.StrategyMaximizeProfitPerHour:
+			c = compareProfitPerHour(b.ProfitAnalysis, a.ProfitAnalysis)
 
 		case crafting.StrategyOptimizeCraftPath:
-			return len(matches[i].Recipe.Inputs) < len(matches[j].Recipe.Inputs)
+			c = cmp.Compare(len(a.Recipe.Inputs), len(b.Recipe.Inputs))
+			if c == 0 {
+				c = compareCraftTime(a.TotalCraftTimeSec, b.TotalCraftTimeSec)
+			}
+
+		case crafting.StrategyMaximizeVolume:
+			// Batch recipes yield more items per run
+			c = cmp.Compare(b.OutputQuantityPossible, a.OutputQuantityPossible)
 
 		default:
-			return matches[i].CanCraftQuantity > matches[j].CanCraftQuantity
+			// USE_INVENTORY_FIRST, MINIMIZE_ACQUISITION
+			c = cmp.Compare(b.CanCraftQuantity, a.CanCraftQuantity)
+		}
+		if c != 0 {
+			return c < 0
+		}
+
+		// Tiebreakers
+		if c := cmp.Compare(profitPerUnit(b.ProfitAnalysis), profitPerUnit(a.ProfitAnalysis)); c != 0 {
+			return c < 0
 		}
+		if c := cmp.Compare(b.CanCraftQuantity, a.CanCraftQuantity); c != 0 {
+			return c < 0
+		}
+		return a.Recipe.ID < b.Recipe.ID
 	})
 }
 
 // sortPartial sorts partial matches based on optimization strategy.
-// Primary sort: Category tier (1-6), Secondary sort: Strategy.
+// Primary sort: Category tier (1-6), Secondary sort: Strategy. Ties fall back
+// to profit per unit, then match ratio, then recipe ID, so the order is
+// fully deterministic.
 func (e *Engine) sortPartial(matches []crafting.PartialComponentMatch, strategy crafting.OptimizationStrategy) {
 	sort.Slice(matches, func(i, j int) bool {
+		a, b := &matches[i], &matches[j]
+
 		// Primary: sort by category tier
-		tierI := e.getCategoryTier(matches[i].Recipe.Category)
-		tierJ := e.getCategoryTier(matches[j].Recipe.Category)
-		if tierI != tierJ {
-			return tierI < tierJ
+		if c := cmp.Compare(e.getCategoryTier(a.Recipe.Category), e.getCategoryTier(b.Recipe.Category)); c != 0 {
+			return c < 0
 		}
 
 		// Secondary: apply strategy within same tier
+		var c int
 		switch strategy {
 		case crafting.StrategyMaximizeProfit:
-			pi := profitPerUnit(matches[i].ProfitAnalysis)
-			pj := profitPerUnit(matches[j].ProfitAnalysis)
-			return pi > pj
+			c = cmp.Compare(profitPerUnit(b.ProfitAnalysis), profitPerUnit(a.ProfitAnalysis))
 
-		case crafting.StrategyMaximizeVolume:
-			return matches[i].MatchRatio > matches[j].MatchRatio
-
-		case crafting.StrategyUseInventoryFirst:
-			return matches[i].MatchRatio > matches[j].MatchRatio
+		case crafting.StrategyMaximizeProfitPerHour:
+			c = compareProfitPerHour(b.ProfitAnalysis, a.ProfitAnalysis)
 
 		case crafting.StrategyMinimizeAcquisition:
-			return len(matches[i].InputsMissing) < len(matches[j].InputsMissing)
+			c = cmp.Compare(len(a.InputsMissing), len(b.InputsMissing))
 
 		case crafting.StrategyOptimizeCraftPath:
-			return len(matches[i].Recipe.Inputs) < len(matches[j].Recipe.Inputs)
+			c = cmp.Compare(len(a.Recipe.Inputs), len(b.Recipe.Inputs))
 
 		default:
-			return matches[i].MatchRatio > matches[j].MatchRatio
+			// MAXIMIZE_VOLUME, USE_INVENTORY_FIRST
+			c = cmp.Compare(b.MatchRatio, a.MatchRatio)
+		}
+		if c != 0 {
+			return c < 0
 		}
+
+		// Tiebreakers
+		if c := cmp.Compare(profitPerUnit(b.ProfitAnalysis), profitPerUnit(a.ProfitAnalysis)); c != 0 {
+			return c < 0
+		}
+		if c := cmp.Compare(b.MatchRatio, a.MatchRatio); c != 0 {
+			return c < 0
+		}
+		return a.Recipe.ID < b.Recipe.ID
 	})
 }
 
@@ -233,3 +389,58 @@ func profitPerUnit(analysis *crafting.ProfitAnalysis) int {
 	}
 	return analysis.ProfitPerUnit
 }
+
+// compareCraftTime orders craft times shortest first, with unknown (zero)
+// times after every known one.
+func compareCraftTime(a, b int) int {
+	if (a == 0) != (b == 0) {
+		if a == 0 {
+			return 1
+		}
+		return -1
+	}
+	return cmp.Compare(a, b)
+}
+
+// compareProfitPerHour orders a against b by profit per hour, using the same
+// ranking as profitPerHourLess: -1 if a ranks below b, +1 if above, 0 if tied.
+func compareProfitPerHour(a, b *crafting.ProfitAnalysis) int {
+	switch {
+	case profitPerHourLess(a, b):
+		return -1
+	case profitPerHourLess(b, a):
+		return 1
+	default:
+		return 0
+	}
+}
+
+// profitPerHourLess reports whether a ranks below b by profit per hour.
+// Analyses without a rate (no market data or no craft time) rank below any
+// analysis with one; ties fall back to profit per unit.
+func profitPerHourLess(a, b *crafting.ProfitAnalysis) bool {
+	aHas := a != nil && a.ProfitPerHour != 0
+	bHas := b != nil && b.ProfitPerHour != 0
+	if aHas != bHas {
+		return bHas
+	}
+	if aHas && a.ProfitPerHour != b.ProfitPerHour {
+		return a.ProfitPerHour < b.ProfitPerHour
+	}
+	return profitPerUnit(a) < profitPerUnit(b)
+}
+
+// matchesTags reports whether a recipe's tags include any of the wanted
+// tags, or every one of them when all is set.
+func matchesTags(tags []string, wanted map[string]bool, all bool) bool {
+	matched := 0
+	for _, tag := range tags {
+		if wanted[tag] {
+			matched++
+		}
+	}
+	if all {
+		return matched == len(wanted)
+	}
+	return matched > 0
+}
@@ -1,8 +1,14 @@
 package engine
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/rsned/spacemolt-crafting-server/internal/crafting/db"
 	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
@@ -37,7 +43,7 @@ func testEngine(t *testing.T) *Engine {
 	}
 
 	// Create a new engine instance (not closing the DB)
-	return New(database)
+	return New(database, nil)
 }
 
 // TestCraftQuery_IllegalRecipes verifies that illegal recipe status
@@ -125,3 +131,637 @@ func TestCraftQuery_IllegalRecipes(t *testing.T) {
 		t.Errorf("expected ban reason 'test ban', got '%s'", illegalRecipe.IllegalStatus.BanReason)
 	}
 }
+
+// TestSortCraftable_ProfitPerHour verifies that MAXIMIZE_PROFIT_PER_HOUR
+// ranks fast recipes above slow ones and leaves unrated recipes last.
+func TestSortCraftable_ProfitPerHour(t *testing.T) {
+	engine := testEngine(t)
+
+	matches := []crafting.CraftableMatch{
+		{Recipe: crafting.Recipe{ID: "slow", Category: "Refining"}, ProfitAnalysis: &crafting.ProfitAnalysis{ProfitPerUnit: 100, ProfitPerHour: 50}},
+		{Recipe: crafting.Recipe{ID: "no_time", Category: "Refining"}, ProfitAnalysis: &crafting.ProfitAnalysis{ProfitPerUnit: 500}},
+		{Recipe: crafting.Recipe{ID: "no_market", Category: "Refining"}},
+		{Recipe: crafting.Recipe{ID: "fast", Category: "Refining"}, ProfitAnalysis: &crafting.ProfitAnalysis{ProfitPerUnit: 50, ProfitPerHour: 3000}},
+	}
+
+	engine.sortCraftable(matches, crafting.StrategyMaximizeProfitPerHour)
+
+	want := []string{"fast", "slow", "no_time", "no_market"}
+	for i, id := range want {
+		if matches[i].Recipe.ID != id {
+			t.Errorf("position %d: expected %s, got %s", i, id, matches[i].Recipe.ID)
+		}
+	}
+}
+
+func TestCraftQuery_TotalCraftTime(t *testing.T) {
+	ctx := context.Background()
+	engine := testEngine(t)
+
+	recipe := func(id string, craftTime, oreNeeded int) crafting.Recipe {
+		return crafting.Recipe{
+			ID: id, Name: id, Category: "Refining", CraftingTime: craftTime,
+			Inputs:  []crafting.RecipeInput{{ItemID: "ore", Quantity: oreNeeded}},
+			Outputs: []crafting.RecipeOutput{{ItemID: id, Quantity: 1}},
+		}
+	}
+	recipes := []crafting.Recipe{
+		recipe("untimed", 0, 1),
+		recipe("slow_batch", 60, 2),   // 10 runs, 600s
+		recipe("quick_batch", 100, 5), // 4 runs, 400s
+	}
+	if err := engine.recipes.BulkInsertRecipes(ctx, recipes); err != nil {
+		t.Fatalf("BulkInsertRecipes failed: %v", err)
+	}
+
+	resp, err := engine.CraftQuery(ctx, crafting.CraftQueryRequest{
+		Components: []crafting.Component{{ID: "ore", Quantity: 20}},
+		Strategy:   crafting.StrategyOptimizeCraftPath,
+	})
+	if err != nil {
+		t.Fatalf("CraftQuery failed: %v", err)
+	}
+
+	// Equal input counts, so the quicker batch comes first and the
+	// untimed recipe last.
+	var got []string
+	times := make(map[string]int)
+	for _, m := range resp.Craftable {
+		got = append(got, m.Recipe.ID)
+		times[m.Recipe.ID] = m.TotalCraftTimeSec
+	}
+	if want := []string{"quick_batch", "slow_batch", "untimed"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("order = %v, want %v", got, want)
+	}
+	if want := map[string]int{"quick_batch": 400, "slow_batch": 600, "untimed": 0}; !reflect.DeepEqual(times, want) {
+		t.Errorf("total craft times = %v, want %v", times, want)
+	}
+}
+
+func TestCraftQuery_OutputQuantityPossible(t *testing.T) {
+	ctx := context.Background()
+	engine := testEngine(t)
+
+	recipes := []crafting.Recipe{
+		{
+			ID: "make_rivets", Name: "Rivets", Category: "Refining",
+			Inputs:  []crafting.RecipeInput{{ItemID: "ore", Quantity: 5}},
+			Outputs: []crafting.RecipeOutput{{ItemID: "rivet", Quantity: 10}},
+		},
+		{
+			ID: "make_plate", Name: "Plate", Category: "Refining",
+			Inputs:  []crafting.RecipeInput{{ItemID: "ore", Quantity: 2}},
+			Outputs: []crafting.RecipeOutput{{ItemID: "plate", Quantity: 1}},
+		},
+	}
+	if err := engine.recipes.BulkInsertRecipes(ctx, recipes); err != nil {
+		t.Fatalf("BulkInsertRecipes failed: %v", err)
+	}
+
+	resp, err := engine.CraftQuery(ctx, crafting.CraftQueryRequest{
+		Components: []crafting.Component{{ID: "ore", Quantity: 20}},
+		Strategy:   crafting.StrategyMaximizeVolume,
+	})
+	if err != nil {
+		t.Fatalf("CraftQuery failed: %v", err)
+	}
+
+	// Plates can be run more often, but rivets yield more items.
+	type counts struct{ runs, items int }
+	var got []string
+	have := make(map[string]counts)
+	for _, m := range resp.Craftable {
+		got = append(got, m.Recipe.ID)
+		have[m.Recipe.ID] = counts{m.CanCraftQuantity, m.OutputQuantityPossible}
+	}
+	if want := []string{"make_rivets", "make_plate"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("order = %v, want %v", got, want)
+	}
+	want := map[string]counts{"make_rivets": {4, 40}, "make_plate": {10, 10}}
+	if !reflect.DeepEqual(have, want) {
+		t.Errorf("counts = %v, want %v", have, want)
+	}
+}
+
+func TestCraftQuery_PartialQuantitiesDoNotCount(t *testing.T) {
+	ctx := context.Background()
+	engine := testEngine(t)
+
+	recipes := []crafting.Recipe{{
+		ID: "build_hull", Name: "Build Hull",
+		Inputs: []crafting.RecipeInput{
+			{ItemID: "plate", Quantity: 2},
+			{ItemID: "rivet", Quantity: 100},
+		},
+		Outputs: []crafting.RecipeOutput{{ItemID: "hull", Quantity: 1}},
+	}}
+	if err := engine.recipes.BulkInsertRecipes(ctx, recipes); err != nil {
+		t.Fatalf("BulkInsertRecipes failed: %v", err)
+	}
+
+	resp, err := engine.CraftQuery(ctx, crafting.CraftQueryRequest{
+		Components: []crafting.Component{
+			{ID: "plate", Quantity: 2},
+			{ID: "rivet", Quantity: 1},
+		},
+		IncludePartial: true,
+	})
+	if err != nil {
+		t.Fatalf("CraftQuery failed: %v", err)
+	}
+
+	if len(resp.Craftable) != 0 {
+		t.Errorf("expected no craftable recipes with 1 of 100 rivets, got %+v", resp.Craftable)
+	}
+	if len(resp.PartialComponents) != 1 {
+		t.Fatalf("expected 1 partial match, got %d", len(resp.PartialComponents))
+	}
+	if got := resp.PartialComponents[0].MatchRatio; got != 0.5 {
+		t.Errorf("expected match ratio 0.5 (plates satisfied, rivets short), got %v", got)
+	}
+}
+
+func TestCraftQuery_MalformedQuantities(t *testing.T) {
+	ctx := context.Background()
+	engine := testEngine(t)
+
+	// Inserted directly, bypassing the import normalization
+	recipes := []crafting.Recipe{
+		{
+			ID: "zero_input", Name: "Zero Input",
+			Inputs: []crafting.RecipeInput{
+				{ItemID: "ore", Quantity: 2},
+				{ItemID: "catalyst", Quantity: 0},
+			},
+			Outputs: []crafting.RecipeOutput{{ItemID: "plate", Quantity: 1}},
+		},
+		{
+			ID: "negative_input", Name: "Negative Input",
+			Inputs:  []crafting.RecipeInput{{ItemID: "ore", Quantity: -1}},
+			Outputs: []crafting.RecipeOutput{{ItemID: "slag", Quantity: 0}},
+		},
+	}
+	if err := engine.recipes.BulkInsertRecipes(ctx, recipes); err != nil {
+		t.Fatalf("BulkInsertRecipes failed: %v", err)
+	}
+
+	resp, err := engine.CraftQuery(ctx, crafting.CraftQueryRequest{
+		Components: []crafting.Component{{ID: "ore", Quantity: 5}},
+	})
+	if err != nil {
+		t.Fatalf("CraftQuery failed: %v", err)
+	}
+
+	canCraft := map[string]int{}
+	for _, m := range resp.Craftable {
+		canCraft[m.Recipe.ID] = m.CanCraftQuantity
+	}
+	if canCraft["zero_input"] != 2 {
+		t.Errorf("expected zero_input craftable 2 times, got %+v", canCraft)
+	}
+	if n, ok := canCraft["negative_input"]; ok && n < 0 {
+		t.Errorf("expected non-negative craft quantity for negative_input, got %d", n)
+	}
+
+	if _, err := engine.BillOfMaterials(ctx, crafting.BillOfMaterialsRequest{RecipeID: "negative_input"}); err != nil {
+		t.Errorf("BillOfMaterials failed on malformed recipe: %v", err)
+	}
+}
+
+func TestCraftQuery_QuantityWeightedRatio(t *testing.T) {
+	ctx := context.Background()
+	engine := testEngine(t)
+
+	recipes := []crafting.Recipe{
+		{
+			// Lopsided: almost all the units are ore
+			ID: "mostly_ore", Name: "Mostly Ore",
+			Inputs: []crafting.RecipeInput{
+				{ItemID: "ore", Quantity: 90},
+				{ItemID: "gem", Quantity: 10},
+			},
+			Outputs: []crafting.RecipeOutput{{ItemID: "alloy", Quantity: 1}},
+		},
+		{
+			ID: "mostly_gem", Name: "Mostly Gem",
+			Inputs: []crafting.RecipeInput{
+				{ItemID: "ore", Quantity: 10},
+				{ItemID: "gem", Quantity: 90},
+			},
+			Outputs: []crafting.RecipeOutput{{ItemID: "jewel", Quantity: 1}},
+		},
+	}
+	if err := engine.recipes.BulkInsertRecipes(ctx, recipes); err != nil {
+		t.Fatalf("BulkInsertRecipes failed: %v", err)
+	}
+
+	components := []crafting.Component{{ID: "ore", Quantity: 500}}
+
+	partialIDs := func(weighted bool) map[string]float64 {
+		t.Helper()
+		resp, err := engine.CraftQuery(ctx, crafting.CraftQueryRequest{
+			Components:               components,
+			IncludePartial:           true,
+			MinMatchRatio:            0.8,
+			UseQuantityWeightedRatio: weighted,
+		})
+		if err != nil {
+			t.Fatalf("CraftQuery failed: %v", err)
+		}
+		got := map[string]float64{}
+		for _, m := range resp.PartialComponents {
+			got[m.Recipe.ID] = m.QuantityWeightedRatio
+		}
+		return got
+	}
+
+	// Both recipes have half their inputs satisfied, below 0.8
+	if got := partialIDs(false); len(got) != 0 {
+		t.Errorf("expected no partial matches by input count, got %v", got)
+	}
+
+	// Surplus ore is capped at 90, so mostly_ore is 90/100 and mostly_gem 10/100
+	got := partialIDs(true)
+	if len(got) != 1 || got["mostly_ore"] != 0.9 {
+		t.Errorf("expected only mostly_ore with weighted ratio 0.9, got %v", got)
+	}
+}
+
+func TestCraftQuery_OnlyCraftable(t *testing.T) {
+	ctx := context.Background()
+	engine := testEngine(t)
+
+	recipes := []crafting.Recipe{
+		{
+			ID: "plate", Name: "Plate", Category: "Components",
+			Inputs:  []crafting.RecipeInput{{ItemID: "ore", Quantity: 2}},
+			Outputs: []crafting.RecipeOutput{{ItemID: "plate", Quantity: 1}},
+		},
+		{
+			ID: "alloy", Name: "Alloy", Category: "Components",
+			Inputs:  []crafting.RecipeInput{{ItemID: "ore", Quantity: 2}, {ItemID: "gem", Quantity: 1}},
+			Outputs: []crafting.RecipeOutput{{ItemID: "alloy", Quantity: 1}},
+		},
+		{
+			// Covered by inventory, but not enough ore
+			ID: "beam", Name: "Beam", Category: "Components",
+			Inputs:  []crafting.RecipeInput{{ItemID: "ore", Quantity: 50}},
+			Outputs: []crafting.RecipeOutput{{ItemID: "beam", Quantity: 1}},
+		},
+		{
+			ID: "gear", Name: "Gear", Category: "Components",
+			Inputs:  []crafting.RecipeInput{{ItemID: "cog", Quantity: 1}},
+			Outputs: []crafting.RecipeOutput{{ItemID: "gear", Quantity: 1}},
+		},
+	}
+	if err := engine.recipes.BulkInsertRecipes(ctx, recipes); err != nil {
+		t.Fatalf("BulkInsertRecipes failed: %v", err)
+	}
+
+	resp, err := engine.CraftQuery(ctx, crafting.CraftQueryRequest{
+		Components:     []crafting.Component{{ID: "ore", Quantity: 10}},
+		IncludePartial: true,
+		MinMatchRatio:  0.1,
+		CategoryFilter: "Components",
+		OnlyCraftable:  true,
+	})
+	if err != nil {
+		t.Fatalf("CraftQuery failed: %v", err)
+	}
+
+	if len(resp.Craftable) != 1 || resp.Craftable[0].Recipe.ID != "plate" {
+		t.Errorf("expected only plate to be craftable, got %+v", resp.Craftable)
+	}
+	if len(resp.PartialComponents) != 0 {
+		t.Errorf("expected no partial matches, got %d", len(resp.PartialComponents))
+	}
+	// Only the fully covered plate and beam are candidates; alloy and the
+	// rest of the category are never loaded.
+	if resp.QueryStats.TotalRecipesChecked != 2 {
+		t.Errorf("expected 2 recipes checked, got %d", resp.QueryStats.TotalRecipesChecked)
+	}
+}
+
+func TestCraftQuery_Exclusions(t *testing.T) {
+	ctx := context.Background()
+	engine := testEngine(t)
+
+	recipe := func(id, category string) crafting.Recipe {
+		return crafting.Recipe{
+			ID: id, Name: id, Category: category,
+			Inputs:  []crafting.RecipeInput{{ItemID: "ore", Quantity: 1}},
+			Outputs: []crafting.RecipeOutput{{ItemID: id + "_out", Quantity: 1}},
+		}
+	}
+	recipes := []crafting.Recipe{
+		recipe("plate", "Components"),
+		recipe("rivet", "Components"),
+		recipe("laser", "Weapons"),
+		recipe("hull", "Ships"),
+	}
+	if err := engine.recipes.BulkInsertRecipes(ctx, recipes); err != nil {
+		t.Fatalf("BulkInsertRecipes failed: %v", err)
+	}
+
+	resp, err := engine.CraftQuery(ctx, crafting.CraftQueryRequest{
+		Components:        []crafting.Component{{ID: "ore", Quantity: 10}},
+		ExcludeCategories: []string{"Weapons", "Ships"},
+		ExcludeRecipeIDs:  []string{"rivet"},
+	})
+	if err != nil {
+		t.Fatalf("CraftQuery failed: %v", err)
+	}
+
+	if len(resp.Craftable) != 1 || resp.Craftable[0].Recipe.ID != "plate" {
+		ids := make([]string, 0, len(resp.Craftable))
+		for _, m := range resp.Craftable {
+			ids = append(ids, m.Recipe.ID)
+		}
+		t.Errorf("expected only plate, got %v", ids)
+	}
+}
+
+func TestCraftQuery_MultipleCategories(t *testing.T) {
+	ctx := context.Background()
+	engine := testEngine(t)
+
+	recipe := func(id, category string) crafting.Recipe {
+		return crafting.Recipe{
+			ID: id, Name: id, Category: category,
+			Inputs:  []crafting.RecipeInput{{ItemID: "ore", Quantity: 1}},
+			Outputs: []crafting.RecipeOutput{{ItemID: id + "_out", Quantity: 1}},
+		}
+	}
+	recipes := []crafting.Recipe{
+		recipe("laser", "Weapons"),
+		recipe("plating", "Armor"),
+		recipe("drill", "Mining"),
+		recipe("hull", "Ships"),
+	}
+	if err := engine.recipes.BulkInsertRecipes(ctx, recipes); err != nil {
+		t.Fatalf("BulkInsertRecipes failed: %v", err)
+	}
+
+	resp, err := engine.CraftQuery(ctx, crafting.CraftQueryRequest{
+		Components:     []crafting.Component{{ID: "ore", Quantity: 10}},
+		Categories:     []string{"Weapons", "Armor"},
+		CategoryFilter: "Mining", // legacy field is merged into the list
+	})
+	if err != nil {
+		t.Fatalf("CraftQuery failed: %v", err)
+	}
+
+	got := map[string]bool{}
+	for _, m := range resp.Craftable {
+		got[m.Recipe.ID] = true
+	}
+	want := map[string]bool{"laser": true, "plating": true, "drill": true}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("craftable = %v, want %v", got, want)
+	}
+}
+
+func TestCraftQuery_CandidatesCheckedOnce(t *testing.T) {
+	ctx := context.Background()
+	engine := testEngine(t)
+
+	recipes := []crafting.Recipe{
+		{
+			ID: "laser", Name: "Laser", Category: "Weapons",
+			Inputs:  []crafting.RecipeInput{{ItemID: "ore", Quantity: 1}},
+			Outputs: []crafting.RecipeOutput{{ItemID: "laser", Quantity: 1}},
+		},
+		{
+			ID: "cannon", Name: "Cannon", Category: "Weapons",
+			Inputs:  []crafting.RecipeInput{{ItemID: "steel", Quantity: 1}},
+			Outputs: []crafting.RecipeOutput{{ItemID: "cannon", Quantity: 1}},
+		},
+		{
+			ID: "drill", Name: "Drill", Category: "Mining",
+			Inputs:  []crafting.RecipeInput{{ItemID: "ore", Quantity: 1}},
+			Outputs: []crafting.RecipeOutput{{ItemID: "drill", Quantity: 1}},
+		},
+	}
+	if err := engine.recipes.BulkInsertRecipes(ctx, recipes); err != nil {
+		t.Fatalf("BulkInsertRecipes failed: %v", err)
+	}
+
+	// The laser both uses ore and is in the filtered category, and ore is
+	// listed twice; it must still be checked once.
+	resp, err := engine.CraftQuery(ctx, crafting.CraftQueryRequest{
+		Components: []crafting.Component{{ID: "ore", Quantity: 5}, {ID: "ore", Quantity: 5}},
+		Categories: []string{"Weapons"},
+	})
+	if err != nil {
+		t.Fatalf("CraftQuery failed: %v", err)
+	}
+
+	if resp.QueryStats.TotalRecipesChecked != 3 {
+		t.Errorf("expected 3 recipes checked, got %d", resp.QueryStats.TotalRecipesChecked)
+	}
+	if len(resp.Craftable) != 1 || resp.Craftable[0].Recipe.ID != "laser" {
+		t.Errorf("expected laser once in craftable, got %+v", resp.Craftable)
+	}
+}
+
+func TestCraftQuery_Tags(t *testing.T) {
+	ctx := context.Background()
+	engine := testEngine(t)
+
+	recipe := func(id string, tags ...string) crafting.Recipe {
+		return crafting.Recipe{
+			ID: id, Name: id, Category: "Consumables", Tags: tags,
+			Inputs:  []crafting.RecipeInput{{ItemID: "ore", Quantity: 1}},
+			Outputs: []crafting.RecipeOutput{{ItemID: id + "_out", Quantity: 1}},
+		}
+	}
+	recipes := []crafting.Recipe{
+		recipe("ration", "consumable", "tradeable"),
+		recipe("stim", "consumable", "faction_locked"),
+		recipe("badge", "tradeable"),
+		recipe("untagged"),
+	}
+	if err := engine.recipes.BulkInsertRecipes(ctx, recipes); err != nil {
+		t.Fatalf("BulkInsertRecipes failed: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		tags []string
+		all  bool
+		want map[string]bool
+	}{
+		{"any", []string{"Tradeable", "faction_locked"}, false, map[string]bool{"ration": true, "stim": true, "badge": true}},
+		{"all", []string{"consumable", "tradeable"}, true, map[string]bool{"ration": true}},
+		{"none set", nil, false, map[string]bool{"ration": true, "stim": true, "badge": true, "untagged": true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := engine.CraftQuery(ctx, crafting.CraftQueryRequest{
+				Components:     []crafting.Component{{ID: "ore", Quantity: 10}},
+				Tags:           tt.tags,
+				RequireAllTags: tt.all,
+			})
+			if err != nil {
+				t.Fatalf("CraftQuery failed: %v", err)
+			}
+			got := map[string]bool{}
+			for _, m := range resp.Craftable {
+				got[m.Recipe.ID] = true
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("craftable = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCraftQuery_InputAlternatives(t *testing.T) {
+	ctx := context.Background()
+	engine := testEngine(t)
+
+	recipes := []crafting.Recipe{
+		{
+			ID: "frame", Name: "Frame", Category: "Components",
+			Inputs: []crafting.RecipeInput{
+				{ItemID: "steel_bar", Quantity: 2, Alternatives: []string{"titanium_bar", "copper_bar"}},
+				{ItemID: "bolt", Quantity: 1},
+			},
+			Outputs: []crafting.RecipeOutput{{ItemID: "frame", Quantity: 1}},
+		},
+		{
+			ID: "truss", Name: "Truss", Category: "Components",
+			Inputs: []crafting.RecipeInput{
+				{ItemID: "steel_bar", Quantity: 4, Alternatives: []string{"titanium_bar"}},
+				{ItemID: "rivet", Quantity: 2},
+			},
+			Outputs: []crafting.RecipeOutput{{ItemID: "truss", Quantity: 1}},
+		},
+	}
+	if err := engine.recipes.BulkInsertRecipes(ctx, recipes); err != nil {
+		t.Fatalf("BulkInsertRecipes failed: %v", err)
+	}
+
+	// No steel at all: the frame is only found through its alternatives,
+	// and copper is preferred because more of it is held.
+	resp, err := engine.CraftQuery(ctx, crafting.CraftQueryRequest{
+		Components: []crafting.Component{
+			{ID: "titanium_bar", Quantity: 2},
+			{ID: "copper_bar", Quantity: 6},
+			{ID: "bolt", Quantity: 5},
+			{ID: "rivet", Quantity: 2},
+		},
+		IncludePartial: true,
+		MinMatchRatio:  0.5,
+	})
+	if err != nil {
+		t.Fatalf("CraftQuery failed: %v", err)
+	}
+
+	if len(resp.Craftable) != 1 || resp.Craftable[0].Recipe.ID != "frame" {
+		t.Fatalf("expected frame to be craftable, got %+v", resp.Craftable)
+	}
+	frame := resp.Craftable[0]
+	if frame.CanCraftQuantity != 3 {
+		t.Errorf("expected 3 frames from 6 copper bars, got %d", frame.CanCraftQuantity)
+	}
+	wantSubs := []crafting.InputSubstitution{{ItemID: "steel_bar", SubstituteID: "copper_bar"}}
+	if !reflect.DeepEqual(frame.Substitutions, wantSubs) {
+		t.Errorf("expected substitutions %+v, got %+v", wantSubs, frame.Substitutions)
+	}
+
+	// The truss takes titanium but not copper, and 2 of 4 bars is not enough.
+	if len(resp.PartialComponents) != 1 || resp.PartialComponents[0].Recipe.ID != "truss" {
+		t.Fatalf("expected truss as a partial match, got %+v", resp.PartialComponents)
+	}
+	truss := resp.PartialComponents[0]
+	wantMissing := []crafting.RecipeInput{{ItemID: "titanium_bar", Quantity: 2}}
+	if !reflect.DeepEqual(truss.InputsMissing, wantMissing) {
+		t.Errorf("expected missing %+v, got %+v", wantMissing, truss.InputsMissing)
+	}
+	if truss.QuantityWeightedRatio != 4.0/6.0 {
+		t.Errorf("expected weighted ratio 4/6, got %v", truss.QuantityWeightedRatio)
+	}
+}
+
+// TestCraftQuery_SlowQueryLog verifies that a query over the slow query
+// threshold logs a warning with its phase timings.
+func TestCraftQuery_SlowQueryLog(t *testing.T) {
+	ctx := context.Background()
+	engine := testEngine(t)
+
+	var buf bytes.Buffer
+	engine.logger = slog.New(slog.NewJSONHandler(&buf, nil))
+	engine.SetSlowQueryThreshold(time.Nanosecond)
+
+	recipes := []crafting.Recipe{
+		{ID: "plate", Name: "Plate", Category: "Refining", Inputs: []crafting.RecipeInput{{ItemID: "ore", Quantity: 2}}, Outputs: []crafting.RecipeOutput{{ItemID: "plate", Quantity: 1}}},
+	}
+	if err := engine.recipes.BulkInsertRecipes(ctx, recipes); err != nil {
+		t.Fatalf("inserting recipes: %v", err)
+	}
+
+	_, err := engine.CraftQuery(ctx, crafting.CraftQueryRequest{
+		Components: []crafting.Component{{ID: "ore", Quantity: 4}},
+	})
+	if err != nil {
+		t.Fatalf("CraftQuery: %v", err)
+	}
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("decoding log entry %q: %v", buf.String(), err)
+	}
+	if entry["level"] != "WARN" || entry["msg"] != "slow query" {
+		t.Errorf("got level %v msg %v, want WARN slow query", entry["level"], entry["msg"])
+	}
+	for _, key := range []string{"query", "total", "candidates", "recipes", "matching", "profit", "sorting", "candidates_checked"} {
+		if _, ok := entry[key]; !ok {
+			t.Errorf("log entry missing %q: %s", key, buf.String())
+		}
+	}
+
+	// Below the threshold, timings are only logged at debug level.
+	buf.Reset()
+	engine.SetSlowQueryThreshold(time.Hour)
+	if _, err := engine.CraftQuery(ctx, crafting.CraftQueryRequest{
+		Components: []crafting.Component{{ID: "ore", Quantity: 4}},
+	}); err != nil {
+		t.Fatalf("CraftQuery: %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("expected no log output at info level, got %s", buf.String())
+	}
+}
+
+func TestCraftQuery_MaxResults(t *testing.T) {
+	ctx := context.Background()
+	engine := testEngine(t)
+
+	var recipes []crafting.Recipe
+	for i := range 5 {
+		id := fmt.Sprintf("recipe_%d", i)
+		recipes = append(recipes, crafting.Recipe{
+			ID: id, Name: id,
+			Inputs:  []crafting.RecipeInput{{ItemID: "ore", Quantity: 1}},
+			Outputs: []crafting.RecipeOutput{{ItemID: id, Quantity: 1}},
+		})
+	}
+	if err := engine.recipes.BulkInsertRecipes(ctx, recipes); err != nil {
+		t.Fatalf("BulkInsertRecipes failed: %v", err)
+	}
+	engine.SetMaxResults(3)
+
+	for _, limit := range []int{0, 100000} {
+		resp, err := engine.CraftQuery(ctx, crafting.CraftQueryRequest{
+			Components: []crafting.Component{{ID: "ore", Quantity: 10}},
+			Limit:      limit,
+		})
+		if err != nil {
+			t.Fatalf("CraftQuery failed: %v", err)
+		}
+		if len(resp.Craftable) != 3 {
+			t.Errorf("limit %d: expected results clamped to 3, got %d", limit, len(resp.Craftable))
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
+)
+
+// CraftRecommendations executes the craft_recommendations tool logic.
+// It merges recipes that can be crafted right now with recipes that are a
+// single item acquisition away, and ranks them by estimated profit scaled
+// down by the effort needed before crafting can start.
+//
+// Recipes no longer have skill gates (see migration 008), so there is no
+// "one skill level away" category.
+func (e *Engine) CraftRecommendations(ctx context.Context, req crafting.CraftRecommendationsRequest) (*crafting.CraftRecommendationsResponse, error) {
+	// Apply defaults
+	req.Limit = e.clampLimit(req.Limit, 10)
+
+	query, err := e.CraftQuery(ctx, crafting.CraftQueryRequest{
+		Components:        req.Components,
+		IncludePartial:    true,
+		IncludeAmmunition: true,
+		MinMatchRatio:     0.01,
+		Strategy:          crafting.StrategyMaximizeProfit,
+		StationID:         req.StationID,
+		PricingModel:      req.PricingModel,
+		Limit:             100,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("querying craftable recipes: %w", err)
+	}
+	stationID := e.resolveStationID(ctx, req.StationID)
+
+	var recs []crafting.CraftRecommendation
+
+	for _, m := range query.Craftable {
+		recs = append(recs, crafting.CraftRecommendation{
+			RecipeID:        m.Recipe.ID,
+			RecipeName:      m.Recipe.Name,
+			Category:        m.Recipe.Category,
+			Reason:          crafting.ReasonCraftableNow,
+			EstimatedProfit: profitPerUnit(m.ProfitAnalysis),
+		})
+	}
+
+	for _, m := range query.PartialComponents {
+		if len(m.InputsMissing) != 1 {
+			continue
+		}
+		missing := m.InputsMissing[0]
+
+		unitCost, err := e.acquisitionUnitCost(ctx, missing.ItemID, stationID)
+		if err != nil {
+			return nil, err
+		}
+		cost := unitCost * missing.Quantity
+		if req.MaxAcquisitionCost > 0 && cost > req.MaxAcquisitionCost {
+			continue
+		}
+
+		recs = append(recs, crafting.CraftRecommendation{
+			RecipeID:        m.Recipe.ID,
+			RecipeName:      m.Recipe.Name,
+			Category:        m.Recipe.Category,
+			Reason:          crafting.ReasonOneAcquisitionAway,
+			MissingInput:    &missing,
+			AcquisitionCost: cost,
+			EstimatedProfit: profitPerUnit(m.ProfitAnalysis),
+			Effort:          1,
+		})
+	}
+
+	for i := range recs {
+		recs[i].Score = float64(recs[i].EstimatedProfit) / float64(1+recs[i].Effort)
+	}
+
+	sort.SliceStable(recs, func(i, j int) bool {
+		if recs[i].Score != recs[j].Score {
+			return recs[i].Score > recs[j].Score
+		}
+		if recs[i].Effort != recs[j].Effort {
+			return recs[i].Effort < recs[j].Effort
+		}
+		return recs[i].RecipeID < recs[j].RecipeID
+	})
+
+	if len(recs) > req.Limit {
+		recs = recs[:req.Limit]
+	}
+	if recs == nil {
+		recs = []crafting.CraftRecommendation{}
+	}
+
+	return &crafting.CraftRecommendationsResponse{Recommendations: recs}, nil
+}
+
+// acquisitionUnitCost estimates what one unit of an item costs to buy,
+// using the station's market price when available and MSRP otherwise.
+func (e *Engine) acquisitionUnitCost(ctx context.Context, itemID, stationID string) (int, error) {
+	if stationID != "" {
+		price, err := e.market.GetBuyPrice(ctx, itemID, stationID)
+		if err != nil {
+			return 0, err
+		}
+		if price > 0 {
+			return price, nil
+		}
+	}
+	return e.market.GetItemMSRP(ctx, itemID)
+}
@@ -0,0 +1,94 @@
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
+)
+
+func TestCraftRecommendations(t *testing.T) {
+	ctx := context.Background()
+	engine := testEngine(t)
+
+	recipes := []crafting.Recipe{
+		{
+			ID: "smelt_plate", Name: "Smelt Plate",
+			Inputs:  []crafting.RecipeInput{{ItemID: "ore", Quantity: 2}},
+			Outputs: []crafting.RecipeOutput{{ItemID: "plate", Quantity: 1}},
+		},
+		{
+			ID: "build_beam", Name: "Build Beam",
+			Inputs: []crafting.RecipeInput{
+				{ItemID: "ore", Quantity: 2},
+				{ItemID: "bolt", Quantity: 3},
+			},
+			Outputs: []crafting.RecipeOutput{{ItemID: "beam", Quantity: 1}},
+		},
+		{
+			ID: "build_frame", Name: "Build Frame",
+			Inputs: []crafting.RecipeInput{
+				{ItemID: "ore", Quantity: 1},
+				{ItemID: "bolt", Quantity: 1},
+				{ItemID: "gem", Quantity: 1},
+			},
+			Outputs: []crafting.RecipeOutput{{ItemID: "frame", Quantity: 1}},
+		},
+	}
+	if err := engine.recipes.BulkInsertRecipes(ctx, recipes); err != nil {
+		t.Fatalf("BulkInsertRecipes failed: %v", err)
+	}
+
+	_, err := engine.db.ExecContext(ctx, `
+		INSERT INTO items (id, name, base_value) VALUES
+			('ore', 'Ore', 5), ('bolt', 'Bolt', 4), ('gem', 'Gem', 50),
+			('plate', 'Plate', 20), ('beam', 'Beam', 100), ('frame', 'Frame', 200)
+	`)
+	if err != nil {
+		t.Fatalf("inserting items: %v", err)
+	}
+
+	components := []crafting.Component{{ID: "ore", Quantity: 10}}
+
+	resp, err := engine.CraftRecommendations(ctx, crafting.CraftRecommendationsRequest{Components: components})
+	if err != nil {
+		t.Fatalf("CraftRecommendations failed: %v", err)
+	}
+
+	// build_frame is two acquisitions away and must not be recommended.
+	got := map[string]crafting.CraftRecommendation{}
+	for _, rec := range resp.Recommendations {
+		got[rec.RecipeID] = rec
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected 2 recommendations, got %+v", resp.Recommendations)
+	}
+
+	if rec := got["smelt_plate"]; rec.Reason != crafting.ReasonCraftableNow || rec.Effort != 0 {
+		t.Errorf("expected smelt_plate craftable now with no effort, got %+v", rec)
+	}
+
+	beam := got["build_beam"]
+	if beam.Reason != crafting.ReasonOneAcquisitionAway || beam.Effort != 1 {
+		t.Errorf("expected build_beam one acquisition away, got %+v", beam)
+	}
+	if beam.MissingInput == nil || beam.MissingInput.ItemID != "bolt" || beam.MissingInput.Quantity != 3 {
+		t.Errorf("expected 3 bolts missing, got %+v", beam.MissingInput)
+	}
+	if beam.AcquisitionCost != 12 {
+		t.Errorf("expected MSRP acquisition cost 12, got %d", beam.AcquisitionCost)
+	}
+
+	resp, err = engine.CraftRecommendations(ctx, crafting.CraftRecommendationsRequest{
+		Components:         components,
+		MaxAcquisitionCost: 10,
+	})
+	if err != nil {
+		t.Fatalf("CraftRecommendations failed: %v", err)
+	}
+	for _, rec := range resp.Recommendations {
+		if rec.RecipeID == "build_beam" {
+			t.Errorf("expected build_beam to exceed max acquisition cost, got %+v", rec)
+		}
+	}
+}
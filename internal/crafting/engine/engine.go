@@ -4,7 +4,11 @@ package engine
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
+	"maps"
+	"math"
+	"slices"
+	"time"
 
 	"github.com/rsned/spacemolt-crafting-server/internal/crafting/db"
 	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
@@ -14,36 +18,121 @@ import (
 type Engine struct {
 	db        *db.DB
 	recipes   *db.RecipeStore
+	items     *db.ItemStore
 	skills    *db.SkillStore
 	market    *db.MarketStore
 	catPri    *db.CategoryPriorityStore
 	illegalStore *db.IllegalRecipesStore
+	acquisition  *db.ItemAcquisitionStore
 
 	// Cached priority map for fast lookups
 	categoryPriorities map[string]int
+
+	// recipeCache avoids re-reading the static recipe catalog per query
+	recipeCache recipeCache
+
+	// staleAfter is the market data age that triggers a stale-data warning
+	staleAfter time.Duration
+
+	logger *slog.Logger
+
+	// slowQuery is the query duration that is logged as a warning
+	slowQuery time.Duration
+
+	// maxResults caps the limit a request may ask for
+	maxResults int
 }
 
-// New creates a new Engine with the given database stores.
-func New(database *db.DB) *Engine {
+// DefaultStaleDataAge is how old market data may be before profit analyses
+// and price summaries carry a stale-data warning.
+const DefaultStaleDataAge = 48 * time.Hour
+
+// DefaultSlowQueryThreshold is how long a query may run before its timings
+// are logged as a warning rather than at debug level.
+const DefaultSlowQueryThreshold = 500 * time.Millisecond
+
+// DefaultMaxResults is the most results per list a request may ask for,
+// whatever limit it passes.
+const DefaultMaxResults = 100
+
+// New creates a new Engine with the given database stores. A nil logger
+// uses slog.Default().
+func New(database *db.DB, logger *slog.Logger) *Engine {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
 	// Load category priorities into memory for fast access
 	priorities, err := database.CategoryPriorities().GetAllCategories(context.Background())
 	if err != nil {
-		// Log warning but continue - will use tier 6 (default) for all
-		log.Printf("WARNING: Failed to load category priorities: %v", err)
+		// Continue with tier 6 (default) for every category
+		logger.Warn("failed to load category priorities; using the default tier", "error", err)
 		priorities = make(map[string]int)
 	}
 
 	return &Engine{
 		db:                 database,
 		recipes:            db.NewRecipeStore(database),
+		items:              db.NewItemStore(database),
 		skills:             db.NewSkillStore(database),
 		market:             db.NewMarketStore(database),
 		catPri:             database.CategoryPriorities(),
 		illegalStore:       db.NewIllegalRecipesStore(database),
+		acquisition:        db.NewItemAcquisitionStore(database),
 		categoryPriorities: priorities,
+		staleAfter:         DefaultStaleDataAge,
+		logger:             logger,
+		slowQuery:          DefaultSlowQueryThreshold,
+		maxResults:         DefaultMaxResults,
 	}
 }
 
+// SetStaleDataAge sets how old market data may be before results carry a
+// stale-data warning. Zero disables the warning.
+func (e *Engine) SetStaleDataAge(d time.Duration) {
+	e.staleAfter = d
+}
+
+// SetSlowQueryThreshold sets how long a query may run before its timings are
+// logged as a warning. Zero disables the warning; timings are still logged at
+// debug level.
+func (e *Engine) SetSlowQueryThreshold(d time.Duration) {
+	e.slowQuery = d
+}
+
+// SetMaxResults sets the most results per list a request may ask for.
+// Larger limits are clamped to it. Values below 1 restore DefaultMaxResults.
+func (e *Engine) SetMaxResults(n int) {
+	if n < 1 {
+		n = DefaultMaxResults
+	}
+	e.maxResults = n
+}
+
+// clampLimit applies a request's limit: def when it is unset, and never
+// more than the engine's maximum.
+func (e *Engine) clampLimit(limit, def int) int {
+	if limit <= 0 {
+		limit = def
+	}
+	return min(limit, e.maxResults)
+}
+
+// staleWarning returns a warning for market data last updated at asOf, or ""
+// if the data is fresh enough or warnings are disabled.
+func (e *Engine) staleWarning(asOf time.Time) string {
+	if e.staleAfter <= 0 || asOf.IsZero() || time.Since(asOf) <= e.staleAfter {
+		return ""
+	}
+	return fmt.Sprintf("market data is %d hours old, older than the %d hour freshness threshold; prices may have moved",
+		db.AgeHours(asOf, time.Now()), int(e.staleAfter.Hours()))
+}
+
+// ReadOnly reports whether the engine's database rejects writes.
+func (e *Engine) ReadOnly() bool {
+	return e.db.ReadOnly()
+}
+
 // resolveStationID resolves a user-provided station identifier (which may be
 // a station_id, poi_id, or name) to the canonical station_id used in market
 // data. If no matching station is found, the original identifier is returned
@@ -73,47 +162,64 @@ func (e *Engine) getCategoryTier(category string) int {
 // access to recipes. The checkSkillRequirements function has been removed.
 
 // calculateInputMatch calculates how well the agent's inventory matches recipe input requirements.
+// An input with alternatives is matched against whichever listed item the
+// agent holds most of; any alternative used is reported in subs. Items in
+// a wildcard pool share its quantity; pools may be nil.
 func (e *Engine) calculateInputMatch(
 	recipe *crafting.Recipe,
 	inventory map[string]int,
-) (have []crafting.RecipeInput, missing []crafting.RecipeInput, canCraft int) {
+	pools *componentPools,
+) (have []crafting.RecipeInput, missing []crafting.RecipeInput, canCraft int, subs []crafting.InputSubstitution) {
 	if len(recipe.Inputs) == 0 {
-		return nil, nil, 0
+		return nil, nil, 0, nil
 	}
 
 	canCraft = -1 // will be set to minimum craftable quantity
 
-	for _, req := range recipe.Inputs {
-		available := inventory[req.ItemID]
+	for i, sup := range inputSupplies(recipe, inventory, pools) {
+		req := recipe.Inputs[i]
+		// Malformed data: a non-positive requirement is trivially satisfied
+		// and must not be used as a divisor
+		if req.Quantity <= 0 {
+			continue
+		}
+
+		itemID, available := sup.itemID, sup.available
+		if itemID != req.ItemID {
+			subs = append(subs, crafting.InputSubstitution{
+				ItemID:       req.ItemID,
+				SubstituteID: itemID,
+			})
+		}
 
 		if available >= req.Quantity {
 			// Have enough for at least one craft
 			have = append(have, crafting.RecipeInput{
-				ItemID:   req.ItemID,
+				ItemID:   itemID,
 				Quantity: req.Quantity,
 			})
 
 			// How many times can we craft with this input?
-			thisCanCraft := available / req.Quantity
-			if canCraft < 0 || thisCanCraft < canCraft {
-				canCraft = thisCanCraft
+			if canCraft < 0 || sup.runs < canCraft {
+				canCraft = sup.runs
 			}
 		} else if available > 0 {
 			// Have some but not enough
 			have = append(have, crafting.RecipeInput{
-				ItemID:   req.ItemID,
+				ItemID:   itemID,
 				Quantity: available,
 			})
 			missing = append(missing, crafting.RecipeInput{
-				ItemID:   req.ItemID,
+				ItemID:   itemID,
 				Quantity: req.Quantity - available,
 			})
 			canCraft = 0
 		} else {
-			// Have none
+			// Have none; any of the alternatives would do
 			missing = append(missing, crafting.RecipeInput{
-				ItemID:   req.ItemID,
-				Quantity: req.Quantity,
+				ItemID:       req.ItemID,
+				Quantity:     req.Quantity,
+				Alternatives: req.Alternatives,
 			})
 			canCraft = 0
 		}
@@ -123,34 +229,123 @@ func (e *Engine) calculateInputMatch(
 		canCraft = 0
 	}
 
-	return have, missing, canCraft
+	return have, missing, canCraft, subs
+}
+
+// inputSupply is the item chosen for one recipe input, the units of it
+// available towards one craft, and how many crafts it can supply.
+type inputSupply struct {
+	itemID    string
+	available int
+	runs      int
+}
+
+// inputSupplies picks the item for each recipe input with bestInputItem.
+// Inputs whose items come from the same wildcard pool draw on its quantity
+// together: it is handed out in input order a craft's worth at a time, and
+// supplies as many crafts as it covers their combined requirement.
+// Inputs with a non-positive quantity get a zero supply.
+func inputSupplies(recipe *crafting.Recipe, inventory map[string]int, pools *componentPools) []inputSupply {
+	supplies := make([]inputSupply, len(recipe.Inputs))
+	demand := make(map[string]int) // per craft, by pool
+	for i, inp := range recipe.Inputs {
+		if inp.Quantity <= 0 {
+			continue
+		}
+		itemID, available := bestInputItem(inp, inventory)
+		supplies[i] = inputSupply{itemID: itemID, available: available, runs: available / inp.Quantity}
+		if pool, ok := pools.of(itemID); ok {
+			demand[pool] += inp.Quantity
+		}
+	}
+	if len(demand) == 0 {
+		return supplies
+	}
+
+	remaining := make(map[string]int, len(demand))
+	for pool := range demand {
+		remaining[pool] = pools.quantity[pool]
+	}
+	for i, inp := range recipe.Inputs {
+		pool, ok := pools.of(supplies[i].itemID)
+		if inp.Quantity <= 0 || !ok {
+			continue
+		}
+		supplies[i].available = remaining[pool]
+		supplies[i].runs = pools.quantity[pool] / demand[pool]
+		remaining[pool] -= min(inp.Quantity, remaining[pool])
+	}
+	return supplies
+}
+
+// bestInputItem returns the item used to satisfy an input and how many the
+// inventory holds: the listed item, or the alternative held in the largest
+// quantity when that is more. Each input is matched independently, so two
+// inputs sharing an alternative may both count the same units.
+func bestInputItem(input crafting.RecipeInput, inventory map[string]int) (string, int) {
+	best, held := input.ItemID, inventory[input.ItemID]
+	for _, alt := range input.Alternatives {
+		if n := inventory[alt]; n > held {
+			best, held = alt, n
+		}
+	}
+	return best, held
 }
 
-// calculateMatchRatio returns the ratio of matched inputs to total inputs.
-func calculateMatchRatio(have, total int) float64 {
+// calculateMatchRatio returns the ratio of fully satisfied inputs to total
+// inputs. An input held in a smaller quantity than one craft needs does not
+// count, so a ratio of 1.0 always means the recipe can be crafted now.
+func calculateMatchRatio(satisfied, total int) float64 {
 	if total == 0 {
 		return 0
 	}
-	return float64(have) / float64(total)
+	return float64(satisfied) / float64(total)
+}
+
+// calculateQuantityWeightedRatio returns the units of inputs held over the
+// units needed for one craft. Each input counts at most its requirement, so
+// a surplus of one input can't make up for a shortfall of another. Items in
+// a wildcard pool share its quantity; pools may be nil.
+func calculateQuantityWeightedRatio(recipe *crafting.Recipe, inventory map[string]int, pools *componentPools) float64 {
+	var held, needed int
+	for i, sup := range inputSupplies(recipe, inventory, pools) {
+		inp := recipe.Inputs[i]
+		if inp.Quantity <= 0 {
+			continue
+		}
+		needed += inp.Quantity
+		held += min(sup.available, inp.Quantity)
+	}
+	if needed == 0 {
+		return 0
+	}
+	return float64(held) / float64(needed)
 }
 
 // calculateProfitAnalysis calculates profit metrics for a recipe at a station.
+// The pricing model selects which observed price is used for inputs and
+// outputs; an empty or unknown model uses the representative price.
 func (e *Engine) calculateProfitAnalysis(
 	ctx context.Context,
 	recipe *crafting.Recipe,
 	stationID string,
 	canCraftQuantity int,
+	model crafting.PricingModel,
 ) (*crafting.ProfitAnalysis, error) {
 	if stationID == "" {
 		return nil, nil
 	}
+	if !model.IsValid() {
+		model = crafting.PricingAverage
+	}
 
 	// Get primary output for stats
 	var primaryOutput crafting.RecipeOutput
 	if len(recipe.Outputs) > 0 {
 		primaryOutput = recipe.Outputs[0]
 	} else {
-		return nil, nil // No outputs
+		e.logger.Debug("recipe has no outputs; skipping profit analysis", "recipe_id", recipe.ID)
+		return nil, nil
 	}
 
 	// Get market price stats for output
@@ -159,53 +354,83 @@ func (e *Engine) calculateProfitAnalysis(
 		return nil, err
 	}
 
-	// If no market data available, return nil
-	if outputStats == nil {
-		return nil, nil
+	// Items without market data at the station are priced at MSRP and
+	// reported in MissingPrices. The analysis is only dropped when the
+	// station has no data for any item in the recipe.
+	var missing []string
+	var asOf time.Time
+	marketPrice := func(itemID, orderType string, stats *db.MarketPriceStats) (int, bool, error) {
+		if stats != nil {
+			if asOf.IsZero() || stats.LastUpdated.Before(asOf) {
+				asOf = stats.LastUpdated
+			}
+			return modelPrice(stats, model, orderType), true, nil
+		}
+		missing = append(missing, itemID)
+		msrp, err := e.market.GetItemMSRP(ctx, itemID)
+		return msrp, false, err
 	}
 
 	// Calculate total output value from all outputs
 	var totalOutputPrice int
 	for _, output := range recipe.Outputs {
-		var price int
-		if output.ItemID == primaryOutput.ItemID {
-			price = outputStats.RepresentativePrice
-		} else {
+		stats := outputStats
+		if output.ItemID != primaryOutput.ItemID {
 			// For multi-output recipes, get stats for each output
-			stats, err := e.market.GetPriceStats(ctx, output.ItemID, stationID, "sell")
+			stats, err = e.market.GetPriceStats(ctx, output.ItemID, stationID, "sell")
 			if err != nil {
 				return nil, err
 			}
-			if stats == nil {
-				// No market data for this output, can't calculate profit
-				return nil, nil
-			}
-			price = stats.RepresentativePrice
+		}
+		price, _, err := marketPrice(output.ItemID, "sell", stats)
+		if err != nil {
+			return nil, err
 		}
 		totalOutputPrice += price * output.Quantity
 	}
 
 	// Calculate input cost using market stats
 	var inputCost int
+	breakdown := make([]crafting.ComponentCost, 0, len(recipe.Inputs))
 	for _, inp := range recipe.Inputs {
 		inputStats, err := e.market.GetPriceStats(ctx, inp.ItemID, stationID, "buy")
 		if err != nil {
 			return nil, err
 		}
-		if inputStats == nil {
-			// No market data for this input, use MSRP
-			msrp, err := e.market.GetItemMSRP(ctx, inp.ItemID)
-			if err != nil {
-				return nil, err
-			}
-			inputCost += msrp * inp.Quantity
-		} else {
-			inputCost += inputStats.RepresentativePrice * inp.Quantity
+		price, fromMarket, err := marketPrice(inp.ItemID, "buy", inputStats)
+		if err != nil {
+			return nil, err
+		}
+		line := crafting.ComponentCost{
+			ItemID:      inp.ItemID,
+			Quantity:    inp.Quantity,
+			UnitPrice:   price,
+			LineCost:    price * inp.Quantity,
+			PriceSource: "market",
+		}
+		if !fromMarket {
+			line.PriceSource = "msrp"
 		}
+		inputCost += line.LineCost
+		breakdown = append(breakdown, line)
+	}
+
+	if len(missing) == len(recipe.Outputs)+len(recipe.Inputs) {
+		e.logger.Debug("no market data for recipe at station; skipping profit analysis",
+			"recipe_id", recipe.ID, "station_id", stationID)
+		return nil, nil
+	}
+	if len(missing) > 0 {
+		e.logger.Debug("pricing items without market data at MSRP",
+			"recipe_id", recipe.ID, "station_id", stationID, "items", missing)
 	}
 
 	profitPerUnit := totalOutputPrice - inputCost
 
+	// Failed runs still consume their inputs, so only the output is discounted
+	rate := recipeSuccessRate(recipe)
+	expectedOutputValue := int(math.Round(float64(totalOutputPrice) * rate))
+
 	var marginPct float64
 	if inputCost > 0 {
 		marginPct = float64(profitPerUnit) / float64(inputCost) * 100
@@ -219,42 +444,173 @@ func (e *Engine) calculateProfitAnalysis(
 
 	// Determine market status from confidence score
 	marketStatus := "no_market_data"
-	if outputStats.ConfidenceScore >= 0.8 {
-		marketStatus = "high_confidence"
-	} else if outputStats.ConfidenceScore >= 0.5 {
-		marketStatus = "medium_confidence"
-	} else if outputStats.ConfidenceScore > 0 {
-		marketStatus = "low_confidence"
-	}
-
-	// Handle nullable PriceTrend
+	pricingMethod := "msrp_only"
 	priceTrend := "unknown"
-	if outputStats.PriceTrend != nil {
-		priceTrend = *outputStats.PriceTrend
+	var totalVolume, sampleCount int
+	if outputStats != nil {
+		if outputStats.ConfidenceScore >= 0.8 {
+			marketStatus = "high_confidence"
+		} else if outputStats.ConfidenceScore >= 0.5 {
+			marketStatus = "medium_confidence"
+		} else if outputStats.ConfidenceScore > 0 {
+			marketStatus = "low_confidence"
+		}
+
+		// Handle nullable PriceTrend
+		if outputStats.PriceTrend != nil {
+			priceTrend = *outputStats.PriceTrend
+		}
+		pricingMethod = outputStats.StatMethod
+		totalVolume = outputStats.TotalVolume
+		sampleCount = outputStats.SampleCount
 	}
 
 	analysis := &crafting.ProfitAnalysis{
-		OutputSellPrice: totalOutputPrice,
-		InputCost:       inputCost,
-		ProfitPerUnit:   profitPerUnit,
-		ProfitMarginPct: marginPct,
-		TotalVolume24h:  outputStats.TotalVolume,
-		PriceTrend:      priceTrend,
+		StationID:          stationID,
+		OutputSellPrice:    totalOutputPrice,
+		InputCost:          inputCost,
+		InputCostBreakdown: breakdown,
+		ProfitPerUnit:      profitPerUnit,
+		ProfitMarginPct:    marginPct,
+		PricingModel:       string(model),
+		TotalVolume24h:     totalVolume,
+		PriceTrend:         priceTrend,
+		DataComplete:       len(missing) == 0,
+		MissingPrices:      missing,
+		PriceAsOf:          asOf,
+		DataAgeHours:       db.AgeHours(asOf, time.Now()),
+		StaleWarning:       e.staleWarning(asOf),
 
 		// NEW fields from Phase 3
 		MSRP:          msrp,
 		MarketStatus:  marketStatus,
-		PricingMethod: outputStats.StatMethod,
-		SampleCount:   outputStats.SampleCount,
+		PricingMethod: pricingMethod,
+		SampleCount:   sampleCount,
+
+		SuccessRate:           rate,
+		ExpectedOutputValue:   expectedOutputValue,
+		ExpectedProfitPerUnit: expectedOutputValue - inputCost,
 	}
 
 	if canCraftQuantity > 0 {
 		analysis.TotalPotentialProfit = profitPerUnit * canCraftQuantity
 	}
 
+	// Guard against recipes with no recorded craft time
+	if recipe.CraftingTime > 0 {
+		analysis.ProfitPerHour = float64(profitPerUnit) / (float64(recipe.CraftingTime) / 3600)
+	}
+
 	return analysis, nil
 }
 
+// profitAnalysisFor prices recipe at stationID. When stationID is empty and
+// auto is set it tries every station with market data for any of the
+// recipe's items and keeps the one with the highest profit per unit, ties
+// going to the lowest station ID. It returns nil when there is no station
+// to price at.
+func (e *Engine) profitAnalysisFor(
+	ctx context.Context,
+	recipe *crafting.Recipe,
+	stationID string,
+	auto bool,
+	canCraftQuantity int,
+	model crafting.PricingModel,
+) (*crafting.ProfitAnalysis, error) {
+	if stationID != "" || !auto {
+		return e.calculateProfitAnalysis(ctx, recipe, stationID, canCraftQuantity, model)
+	}
+
+	candidates := make(map[string]bool)
+	addStations := func(itemID string) error {
+		stations, err := e.market.PriceStatsStations(ctx, itemID)
+		for _, st := range stations {
+			candidates[st] = true
+		}
+		return err
+	}
+	for _, out := range recipe.Outputs {
+		if err := addStations(out.ItemID); err != nil {
+			return nil, err
+		}
+	}
+	for _, inp := range recipe.Inputs {
+		if err := addStations(inp.ItemID); err != nil {
+			return nil, err
+		}
+	}
+
+	var best *crafting.ProfitAnalysis
+	for _, st := range slices.Sorted(maps.Keys(candidates)) {
+		analysis, err := e.calculateProfitAnalysis(ctx, recipe, st, canCraftQuantity, model)
+		if err != nil {
+			return nil, err
+		}
+		if analysis != nil && (best == nil || analysis.ProfitPerUnit > best.ProfitPerUnit) {
+			best = analysis
+		}
+	}
+	return best, nil
+}
+
+// meetsProfitFilter reports whether analysis clears every threshold set in
+// f. Results without an analysis (no market data) only pass when no
+// threshold is set.
+func meetsProfitFilter(f crafting.ProfitFilter, analysis *crafting.ProfitAnalysis) bool {
+	if !f.IsSet() {
+		return true
+	}
+	if analysis == nil {
+		return false
+	}
+	if f.MinProfitMarginPct != nil && analysis.ProfitMarginPct < *f.MinProfitMarginPct {
+		return false
+	}
+	if f.MinProfitPerUnit != nil && analysis.ProfitPerUnit < *f.MinProfitPerUnit {
+		return false
+	}
+	return true
+}
+
+// recipeSuccessRate returns the chance that one run of recipe succeeds,
+// treating an unset or out-of-range rate as 1.0.
+func recipeSuccessRate(recipe *crafting.Recipe) float64 {
+	if recipe.SuccessRate <= 0 || recipe.SuccessRate > 1 {
+		return 1
+	}
+	return recipe.SuccessRate
+}
+
+// expectedRuns returns the craft runs to attempt so that, on average, runs
+// of them succeed at the given rate.
+func expectedRuns(runs int, rate float64) int {
+	if rate >= 1 {
+		return runs
+	}
+	// Allow for float error so 3 runs at 0.6 is 5 attempts, not 6
+	return int(math.Ceil(float64(runs)/rate - 1e-9))
+}
+
+// modelPrice picks the price to use from market stats under a pricing model.
+// side is "buy" when the item is being purchased (an input) and "sell" when
+// it is being sold (an output). Missing min/max prices fall back to the
+// representative price.
+func modelPrice(stats *db.MarketPriceStats, model crafting.PricingModel, side string) int {
+	var price int
+	switch {
+	case model == crafting.PricingPessimistic && side == "buy",
+		model == crafting.PricingOptimistic && side == "sell":
+		price = stats.MaxPrice
+	case model == crafting.PricingPessimistic && side == "sell",
+		model == crafting.PricingOptimistic && side == "buy":
+		price = stats.MinPrice
+	}
+	if price <= 0 {
+		return stats.RepresentativePrice
+	}
+	return price
+}
+
 // buildInventoryMap converts a component slice to a map for efficient lookup.
 func buildInventoryMap(components []crafting.Component) map[string]int {
 	m := make(map[string]int, len(components))
@@ -264,6 +620,35 @@ func buildInventoryMap(components []crafting.Component) map[string]int {
 	return m
 }
 
+// toSet converts a string slice to a set for efficient membership checks.
+func toSet(values []string) map[string]bool {
+	m := make(map[string]bool, len(values))
+	for _, v := range values {
+		m[v] = true
+	}
+	return m
+}
+
+// itemNames returns display names for ids, logging any ID with no catalogued
+// name. Such IDs map to themselves.
+func (e *Engine) itemNames(ctx context.Context, ids []string) (map[string]string, error) {
+	names, err := e.items.GetItemNames(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	var uncatalogued []string
+	for id, name := range names {
+		if name == id {
+			uncatalogued = append(uncatalogued, id)
+		}
+	}
+	if len(uncatalogued) > 0 {
+		slices.Sort(uncatalogued)
+		e.logger.Debug("items missing from catalog; using IDs as names", "items", uncatalogued)
+	}
+	return names, nil
+}
+
 // enrichRecipeWithIllegalStatus adds illegal status to recipe results
 func (e *Engine) enrichRecipeWithIllegalStatus(
 	ctx context.Context,
@@ -297,7 +682,7 @@ func (e *Engine) RecipeMarketProfitability(ctx context.Context, stationID, empir
 	inventory := buildInventoryMap(components)
 
 	// Get all recipes
-	recipes, err := e.recipes.GetAllRecipes(ctx)
+	recipes, err := e.getAllRecipes(ctx)
 	if err != nil {
 		return nil, err
 	}
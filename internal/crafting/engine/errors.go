@@ -0,0 +1,39 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/rsned/spacemolt-crafting-server/internal/crafting/db"
+)
+
+// Domain error kinds. Engine methods wrap these so callers can tell a bad
+// request apart from an internal failure with errors.Is.
+var (
+	// ErrNotFound reports that a requested recipe, skill or item does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrInvalidInput reports that request parameters are missing or invalid.
+	ErrInvalidInput = errors.New("invalid input")
+	// ErrReadOnly reports a write attempted against a read-only database.
+	ErrReadOnly = db.ErrReadOnly
+)
+
+// domainError carries a caller-facing message while matching its kind.
+type domainError struct {
+	kind error
+	msg  string
+}
+
+func (e *domainError) Error() string { return e.msg }
+
+func (e *domainError) Unwrap() error { return e.kind }
+
+// notFoundf returns an ErrNotFound error with a formatted message.
+func notFoundf(format string, args ...any) error {
+	return &domainError{kind: ErrNotFound, msg: fmt.Sprintf(format, args...)}
+}
+
+// invalidInputf returns an ErrInvalidInput error with a formatted message.
+func invalidInputf(format string, args ...any) error {
+	return &domainError{kind: ErrInvalidInput, msg: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,81 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
+)
+
+// EstimateRecipeScan previews how much of the recipe catalog a tool call
+// would touch without running it. When componentIDs is non-empty the call is
+// assumed to be driven by those components, so only recipes using at least
+// one of them are scanned and that count bounds the result size. Otherwise
+// the whole catalog is scanned and no result count is estimated.
+func (e *Engine) EstimateRecipeScan(ctx context.Context, componentIDs []string) (*crafting.ToolCallEstimate, error) {
+	total, err := e.recipes.CountRecipes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	est := &crafting.ToolCallEstimate{
+		Valid:         true,
+		RecipesTotal:  total,
+		RecipesToScan: total,
+	}
+	if len(componentIDs) == 0 {
+		return est, nil
+	}
+
+	candidates, err := e.recipes.FindRecipesByComponents(ctx, componentIDs)
+	if err != nil {
+		return nil, fmt.Errorf("finding candidate recipes: %w", err)
+	}
+	n := len(candidates)
+	est.RecipesToScan = n
+	est.EstimatedResults = &n
+	return est, nil
+}
+
+// EstimateBillOfMaterials previews a bill_of_materials call without planning
+// it. It walks the target's dependency tree the way BillOfMaterials would,
+// honoring max_depth and buy_instead, and reports how many distinct recipes
+// and craft steps the plan would cover.
+func (e *Engine) EstimateBillOfMaterials(ctx context.Context, req crafting.BillOfMaterialsRequest) (*crafting.ToolCallEstimate, error) {
+	if req.MaxDepth < 0 {
+		return nil, invalidInputf("max_depth must not be negative")
+	}
+
+	targetRecipe, err := e.getRecipe(ctx, req.RecipeID)
+	if err != nil {
+		return nil, fmt.Errorf("getting target recipe: %w", err)
+	}
+	if targetRecipe == nil {
+		return nil, notFoundf("recipe not found: %s", req.RecipeID)
+	}
+	if len(targetRecipe.Outputs) == 0 {
+		return nil, fmt.Errorf("recipe %s has no outputs", targetRecipe.ID)
+	}
+
+	allRecipes, err := e.getAllRecipes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("loading all recipes: %w", err)
+	}
+
+	craftableItems, _, err := discoverCraftables(ctx, selectCraftRecipes(allRecipes), []*crafting.Recipe{targetRecipe}, toSet(req.BuyInstead), req.MaxDepth)
+	if err != nil {
+		return nil, err
+	}
+
+	recipeIDs := make(map[string]bool, len(craftableItems))
+	for _, recipe := range craftableItems {
+		recipeIDs[recipe.ID] = true
+	}
+
+	return &crafting.ToolCallEstimate{
+		Valid:         true,
+		RecipesTotal:  len(allRecipes),
+		RecipesToScan: len(recipeIDs),
+		CraftSteps:    len(craftableItems),
+	}, nil
+}
@@ -0,0 +1,69 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
+)
+
+// FindArbitrage executes the find_arbitrage tool logic: components whose
+// average sell price at one station, less the fee, beats their average buy
+// price at another by more than the minimum spread.
+func (e *Engine) FindArbitrage(ctx context.Context, req crafting.FindArbitrageRequest) (*crafting.FindArbitrageResponse, error) {
+	if req.FeePct < 0 || req.FeePct >= 100 {
+		return nil, invalidInputf("fee_pct must be at least 0 and below 100")
+	}
+	if req.MinSpread < 0 {
+		return nil, invalidInputf("min_spread must not be negative")
+	}
+	limit := e.clampLimit(req.Limit, 20)
+
+	spreads, err := e.market.FindPriceSpreads(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("finding price spreads: %w", err)
+	}
+
+	resp := &crafting.FindArbitrageResponse{
+		Opportunities: []crafting.ArbitrageOpportunity{},
+	}
+	for _, sp := range spreads {
+		fee := int(math.Round(float64(sp.SellPrice) * req.FeePct / 100))
+		spread := sp.SellPrice - fee - sp.BuyPrice
+		if spread <= req.MinSpread {
+			continue
+		}
+		resp.Opportunities = append(resp.Opportunities, crafting.ArbitrageOpportunity{
+			ComponentID:   sp.ItemID,
+			BuyStationID:  sp.BuyStationID,
+			BuyPrice:      sp.BuyPrice,
+			SellStationID: sp.SellStationID,
+			SellPrice:     sp.SellPrice,
+			Fee:           fee,
+			Spread:        spread,
+			SpreadPct:     float64(spread) / float64(sp.BuyPrice) * 100,
+		})
+	}
+
+	// The fee can reorder spreads that were sorted gross of it
+	sort.Slice(resp.Opportunities, func(i, j int) bool {
+		a, b := resp.Opportunities[i], resp.Opportunities[j]
+		if a.Spread != b.Spread {
+			return a.Spread > b.Spread
+		}
+		if a.ComponentID != b.ComponentID {
+			return a.ComponentID < b.ComponentID
+		}
+		if a.BuyStationID != b.BuyStationID {
+			return a.BuyStationID < b.BuyStationID
+		}
+		return a.SellStationID < b.SellStationID
+	})
+	if len(resp.Opportunities) > limit {
+		resp.Opportunities = resp.Opportunities[:limit]
+	}
+
+	return resp, nil
+}
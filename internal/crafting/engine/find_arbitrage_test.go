@@ -0,0 +1,80 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
+)
+
+func TestFindArbitrage(t *testing.T) {
+	ctx := context.Background()
+	engine := testEngine(t)
+
+	// Ore: buy 10 at station_a, sell 30 at station_b.
+	// Gem: buy 100 at station_b, sell 120 at station_a.
+	// Dust sells for less than it costs everywhere.
+	_, err := engine.db.ExecContext(ctx, `
+		INSERT INTO market_price_summary (item_id, station_id, price_type, avg_price_7d) VALUES
+			('ore', 'station_a', 'buy', 10),
+			('ore', 'station_a', 'sell', 8),
+			('ore', 'station_b', 'buy', 35),
+			('ore', 'station_b', 'sell', 30),
+			('gem', 'station_a', 'sell', 120),
+			('gem', 'station_b', 'buy', 100),
+			('dust', 'station_a', 'buy', 5),
+			('dust', 'station_b', 'sell', 4)
+	`)
+	if err != nil {
+		t.Fatalf("inserting market prices: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		req  crafting.FindArbitrageRequest
+		want []crafting.ArbitrageOpportunity
+	}{
+		{
+			name: "no fee",
+			want: []crafting.ArbitrageOpportunity{
+				{ComponentID: "gem", BuyStationID: "station_b", BuyPrice: 100, SellStationID: "station_a", SellPrice: 120, Spread: 20, SpreadPct: 20},
+				{ComponentID: "ore", BuyStationID: "station_a", BuyPrice: 10, SellStationID: "station_b", SellPrice: 30, Spread: 20, SpreadPct: 200},
+			},
+		},
+		{
+			// A 10% fee costs the gem trade 12 and the ore trade 3.
+			name: "fee reorders",
+			req:  crafting.FindArbitrageRequest{FeePct: 10},
+			want: []crafting.ArbitrageOpportunity{
+				{ComponentID: "ore", BuyStationID: "station_a", BuyPrice: 10, SellStationID: "station_b", SellPrice: 30, Fee: 3, Spread: 17, SpreadPct: 170},
+				{ComponentID: "gem", BuyStationID: "station_b", BuyPrice: 100, SellStationID: "station_a", SellPrice: 120, Fee: 12, Spread: 8, SpreadPct: 8},
+			},
+		},
+		{
+			name: "threshold",
+			req:  crafting.FindArbitrageRequest{FeePct: 10, MinSpread: 8},
+			want: []crafting.ArbitrageOpportunity{
+				{ComponentID: "ore", BuyStationID: "station_a", BuyPrice: 10, SellStationID: "station_b", SellPrice: 30, Fee: 3, Spread: 17, SpreadPct: 170},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := engine.FindArbitrage(ctx, tt.req)
+			if err != nil {
+				t.Fatalf("FindArbitrage failed: %v", err)
+			}
+			if !reflect.DeepEqual(resp.Opportunities, tt.want) {
+				t.Errorf("got %+v, want %+v", resp.Opportunities, tt.want)
+			}
+		})
+	}
+
+	for _, req := range []crafting.FindArbitrageRequest{{FeePct: -1}, {FeePct: 100}, {MinSpread: -1}} {
+		if _, err := engine.FindArbitrage(ctx, req); !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("%+v: expected ErrInvalidInput, got %v", req, err)
+		}
+	}
+}
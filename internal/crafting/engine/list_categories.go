@@ -0,0 +1,42 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
+)
+
+// ListCategories executes the list_categories tool logic.
+// It returns every recipe and skill category with its size so clients can
+// discover valid category_filter and categories values.
+func (e *Engine) ListCategories(ctx context.Context) (*crafting.ListCategoriesResponse, error) {
+	recipeCategories, err := e.recipes.ListCategories(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing recipe categories: %w", err)
+	}
+	skillCategories, err := e.skills.ListCategories(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing skill categories: %w", err)
+	}
+
+	resp := &crafting.ListCategoriesResponse{
+		RecipeCategories: make([]crafting.CategoryInfo, 0, len(recipeCategories)),
+		SkillCategories:  make([]crafting.CategoryInfo, 0, len(skillCategories)),
+	}
+	for _, c := range recipeCategories {
+		resp.RecipeCategories = append(resp.RecipeCategories, crafting.CategoryInfo{
+			Category:     c.Category,
+			Count:        c.Count,
+			PriorityTier: e.getCategoryTier(c.Category),
+		})
+	}
+	for _, c := range skillCategories {
+		resp.SkillCategories = append(resp.SkillCategories, crafting.CategoryInfo{
+			Category: c.Category,
+			Count:    c.Count,
+		})
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,32 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
+)
+
+// ListStations executes the list_stations tool logic.
+// It returns every station with market price data so clients can discover
+// valid station_id values.
+func (e *Engine) ListStations(ctx context.Context, req crafting.ListStationsRequest) (*crafting.ListStationsResponse, error) {
+	stations, err := e.market.ListStations(ctx, req.ComponentID)
+	if err != nil {
+		return nil, fmt.Errorf("listing stations: %w", err)
+	}
+
+	resp := &crafting.ListStationsResponse{
+		Stations: make([]crafting.StationInfo, 0, len(stations)),
+	}
+	for _, st := range stations {
+		resp.Stations = append(resp.Stations, crafting.StationInfo{
+			StationID:   st.StationID,
+			Name:        st.Name,
+			Empire:      st.Empire,
+			ItemsPriced: st.ItemCount,
+		})
+	}
+
+	return resp, nil
+}
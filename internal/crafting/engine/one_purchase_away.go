@@ -0,0 +1,111 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
+)
+
+// maxOnePurchaseItems caps max_purchases. Beyond a few items the recipe is
+// a shopping trip rather than one purchase away.
+const maxOnePurchaseItems = 3
+
+// OnePurchaseAway executes the one_purchase_away tool logic. It finds
+// recipes that are not craftable from the given components but would be
+// after buying their missing inputs at the station, and prices the
+// purchase. Recipes with an input the station does not sell are left out.
+//
+// Recipes no longer have skill gates (see migration 008), so skills are
+// not taken into account.
+func (e *Engine) OnePurchaseAway(ctx context.Context, req crafting.OnePurchaseAwayRequest) (*crafting.OnePurchaseAwayResponse, error) {
+	if req.StationID == "" {
+		return nil, invalidInputf("station_id is required to price purchases")
+	}
+	if req.Budget < 0 {
+		return nil, invalidInputf("budget must not be negative")
+	}
+	if req.MaxPurchases == 0 {
+		req.MaxPurchases = 1
+	}
+	if req.MaxPurchases < 0 || req.MaxPurchases > maxOnePurchaseItems {
+		return nil, invalidInputf("max_purchases must be between 1 and %d", maxOnePurchaseItems)
+	}
+	req.Limit = e.clampLimit(req.Limit, 10)
+
+	// Every partial match is needed: the ones a purchase away can rank
+	// anywhere in craft_query's order, so only the final list is limited.
+	query, err := e.craftQuery(ctx, crafting.CraftQueryRequest{
+		Components:        req.Components,
+		IncludePartial:    true,
+		IncludeAmmunition: true,
+		MinMatchRatio:     0.01,
+		StationID:         req.StationID,
+		PricingModel:      req.PricingModel,
+
+		// Count inputs held in part, which only need topping up
+		UseQuantityWeightedRatio: true,
+	}, e.getRecipe, true)
+	if err != nil {
+		return nil, fmt.Errorf("querying partial matches: %w", err)
+	}
+	stationID := e.resolveStationID(ctx, req.StationID)
+
+	resp := &crafting.OnePurchaseAwayResponse{
+		StationID: stationID,
+		Recipes:   []crafting.OnePurchaseMatch{},
+	}
+
+	for _, m := range query.PartialComponents {
+		if len(m.InputsMissing) > req.MaxPurchases {
+			continue
+		}
+
+		match := crafting.OnePurchaseMatch{
+			RecipeID:       m.Recipe.ID,
+			RecipeName:     m.Recipe.Name,
+			Category:       m.Recipe.Category,
+			ProfitAnalysis: m.ProfitAnalysis,
+		}
+		sold := true
+		for _, inp := range m.InputsMissing {
+			itemID, price, err := e.cheapestInputItem(ctx, stationID, inp)
+			if err != nil {
+				return nil, err
+			}
+			if price == 0 {
+				sold = false
+				break
+			}
+			match.Purchases = append(match.Purchases, crafting.Purchase{
+				ItemID:    itemID,
+				Quantity:  inp.Quantity,
+				UnitPrice: price,
+				Cost:      price * inp.Quantity,
+			})
+			match.TotalCost += price * inp.Quantity
+		}
+		if !sold || (req.Budget > 0 && match.TotalCost > req.Budget) {
+			continue
+		}
+
+		resp.Recipes = append(resp.Recipes, match)
+	}
+
+	sort.SliceStable(resp.Recipes, func(i, j int) bool {
+		a, b := resp.Recipes[i], resp.Recipes[j]
+		if a.TotalCost != b.TotalCost {
+			return a.TotalCost < b.TotalCost
+		}
+		if len(a.Purchases) != len(b.Purchases) {
+			return len(a.Purchases) < len(b.Purchases)
+		}
+		return a.RecipeID < b.RecipeID
+	})
+	if len(resp.Recipes) > req.Limit {
+		resp.Recipes = resp.Recipes[:req.Limit]
+	}
+
+	return resp, nil
+}
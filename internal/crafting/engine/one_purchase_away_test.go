@@ -0,0 +1,180 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
+)
+
+func TestOnePurchaseAway(t *testing.T) {
+	ctx := context.Background()
+	engine := testEngine(t)
+
+	recipes := []crafting.Recipe{
+		{
+			ID: "make_frame", Name: "Frame",
+			Inputs: []crafting.RecipeInput{
+				{ItemID: "ore", Quantity: 2},
+				{ItemID: "steel", Quantity: 2, Alternatives: []string{"titanium"}},
+			},
+			Outputs: []crafting.RecipeOutput{{ItemID: "frame", Quantity: 1}},
+		},
+		{
+			ID: "make_engine", Name: "Engine",
+			Inputs: []crafting.RecipeInput{
+				{ItemID: "ore", Quantity: 1},
+				{ItemID: "core", Quantity: 1},
+				{ItemID: "coil", Quantity: 1},
+			},
+			Outputs: []crafting.RecipeOutput{{ItemID: "engine", Quantity: 1}},
+		},
+		{
+			ID: "make_probe", Name: "Probe",
+			Inputs: []crafting.RecipeInput{
+				{ItemID: "ore", Quantity: 1},
+				{ItemID: "sensor", Quantity: 1},
+			},
+			Outputs: []crafting.RecipeOutput{{ItemID: "probe", Quantity: 1}},
+		},
+		{
+			ID: "smelt", Name: "Smelt",
+			Inputs:  []crafting.RecipeInput{{ItemID: "ore", Quantity: 1}},
+			Outputs: []crafting.RecipeOutput{{ItemID: "ingot", Quantity: 1}},
+		},
+	}
+	if err := engine.recipes.BulkInsertRecipes(ctx, recipes); err != nil {
+		t.Fatalf("BulkInsertRecipes failed: %v", err)
+	}
+
+	// Titanium undercuts steel; sensors have no price at all.
+	_, err := engine.db.ExecContext(ctx, `
+		INSERT INTO market_price_summary (item_id, station_id, price_type, avg_price_7d) VALUES
+			('ore', 'station_a', 'buy', 5),
+			('steel', 'station_a', 'buy', 30),
+			('titanium', 'station_a', 'buy', 20),
+			('core', 'station_a', 'buy', 100),
+			('coil', 'station_a', 'buy', 10)
+	`)
+	if err != nil {
+		t.Fatalf("inserting market prices: %v", err)
+	}
+
+	query := func(req crafting.OnePurchaseAwayRequest) []crafting.OnePurchaseMatch {
+		t.Helper()
+		req.Components = []crafting.Component{{ID: "ore", Quantity: 1}}
+		req.StationID = "station_a"
+		resp, err := engine.OnePurchaseAway(ctx, req)
+		if err != nil {
+			t.Fatalf("OnePurchaseAway failed: %v", err)
+		}
+		return resp.Recipes
+	}
+
+	// The frame needs one more ore as well as the titanium, so it takes two
+	// purchases; the smelt is craftable already and the probe can't be
+	// finished here.
+	if got := query(crafting.OnePurchaseAwayRequest{}); len(got) != 0 {
+		t.Errorf("expected no single-purchase recipes, got %+v", got)
+	}
+
+	got := query(crafting.OnePurchaseAwayRequest{MaxPurchases: 2})
+	want := []crafting.OnePurchaseMatch{
+		{
+			RecipeID: "make_frame", RecipeName: "Frame",
+			Purchases: []crafting.Purchase{
+				{ItemID: "ore", Quantity: 1, UnitPrice: 5, Cost: 5},
+				{ItemID: "titanium", Quantity: 2, UnitPrice: 20, Cost: 40},
+			},
+			TotalCost: 45,
+		},
+		{
+			RecipeID: "make_engine", RecipeName: "Engine",
+			Purchases: []crafting.Purchase{
+				{ItemID: "coil", Quantity: 1, UnitPrice: 10, Cost: 10},
+				{ItemID: "core", Quantity: 1, UnitPrice: 100, Cost: 100},
+			},
+			TotalCost: 110,
+		},
+	}
+	for i := range got {
+		got[i].ProfitAnalysis = nil
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("max_purchases=2:\n got %+v\nwant %+v", got, want)
+	}
+
+	got = query(crafting.OnePurchaseAwayRequest{MaxPurchases: 2, Budget: 100})
+	if len(got) != 1 || got[0].RecipeID != "make_frame" {
+		t.Errorf("budget 100: expected only make_frame, got %+v", got)
+	}
+
+	for _, req := range []crafting.OnePurchaseAwayRequest{
+		{},
+		{StationID: "station_a", Budget: -1},
+		{StationID: "station_a", MaxPurchases: maxOnePurchaseItems + 1},
+	} {
+		if _, err := engine.OnePurchaseAway(ctx, req); !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("%+v: expected ErrInvalidInput, got %v", req, err)
+		}
+	}
+}
+
+func TestOnePurchaseAway_RanksPastQueryLimit(t *testing.T) {
+	ctx := context.Background()
+	engine := testEngine(t)
+	engine.SetMaxResults(1)
+
+	// The decoys have more of their inputs but are two purchases away, so
+	// they outrank the engine in craft_query's order.
+	recipes := []crafting.Recipe{
+		{
+			ID: "a_decoy", Name: "Decoy A",
+			Inputs: []crafting.RecipeInput{
+				{ItemID: "ore", Quantity: 1}, {ItemID: "gear", Quantity: 1}, {ItemID: "cog", Quantity: 1},
+				{ItemID: "bolt", Quantity: 1}, {ItemID: "nut", Quantity: 1},
+			},
+			Outputs: []crafting.RecipeOutput{{ItemID: "decoy_a", Quantity: 1}},
+		},
+		{
+			ID: "b_decoy", Name: "Decoy B",
+			Inputs: []crafting.RecipeInput{
+				{ItemID: "ore", Quantity: 1}, {ItemID: "gear", Quantity: 1}, {ItemID: "cog", Quantity: 1},
+				{ItemID: "bolt", Quantity: 1}, {ItemID: "nut", Quantity: 1},
+			},
+			Outputs: []crafting.RecipeOutput{{ItemID: "decoy_b", Quantity: 1}},
+		},
+		{
+			ID: "z_engine", Name: "Engine",
+			Inputs:  []crafting.RecipeInput{{ItemID: "ore", Quantity: 1}, {ItemID: "core", Quantity: 10}},
+			Outputs: []crafting.RecipeOutput{{ItemID: "engine", Quantity: 1}},
+		},
+	}
+	if err := engine.recipes.BulkInsertRecipes(ctx, recipes); err != nil {
+		t.Fatalf("BulkInsertRecipes failed: %v", err)
+	}
+	_, err := engine.db.ExecContext(ctx, `
+		INSERT INTO market_price_summary (item_id, station_id, price_type, avg_price_7d) VALUES
+			('core', 'station_a', 'buy', 7),
+			('bolt', 'station_a', 'buy', 1),
+			('nut', 'station_a', 'buy', 1)
+	`)
+	if err != nil {
+		t.Fatalf("inserting market prices: %v", err)
+	}
+
+	resp, err := engine.OnePurchaseAway(ctx, crafting.OnePurchaseAwayRequest{
+		Components: []crafting.Component{
+			{ID: "ore", Quantity: 1}, {ID: "gear", Quantity: 1}, {ID: "cog", Quantity: 1},
+		},
+		StationID: "station_a",
+	})
+	if err != nil {
+		t.Fatalf("OnePurchaseAway failed: %v", err)
+	}
+	if len(resp.Recipes) != 1 || resp.Recipes[0].RecipeID != "z_engine" || resp.Recipes[0].TotalCost != 70 {
+		t.Errorf("expected z_engine for 70, got %+v", resp.Recipes)
+	}
+}
@@ -0,0 +1,71 @@
+package engine
+
+import (
+	"context"
+	"time"
+
+	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
+)
+
+const (
+	defaultPriceHistoryLimit = 100
+	maxPriceHistoryLimit     = 1000
+)
+
+// PriceHistory executes the price_history tool logic.
+// It returns the raw price observations for an item at a station so clients
+// can chart trends themselves.
+func (e *Engine) PriceHistory(ctx context.Context, req crafting.PriceHistoryRequest) (*crafting.PriceHistoryResponse, error) {
+	if req.ComponentID == "" {
+		return nil, invalidInputf("component_id is required")
+	}
+	if req.StationID == "" {
+		return nil, invalidInputf("station_id is required")
+	}
+
+	// Apply defaults
+	if req.PriceType == "" {
+		req.PriceType = "sell"
+	}
+	if req.PriceType != "buy" && req.PriceType != "sell" {
+		return nil, invalidInputf("invalid price_type %q: must be \"buy\" or \"sell\"", req.PriceType)
+	}
+	if req.Limit <= 0 {
+		req.Limit = defaultPriceHistoryLimit
+	}
+	if req.Limit > maxPriceHistoryLimit {
+		req.Limit = maxPriceHistoryLimit
+	}
+
+	var since time.Time
+	if req.Since != "" {
+		var err error
+		since, err = time.Parse(time.RFC3339, req.Since)
+		if err != nil {
+			return nil, invalidInputf("invalid since timestamp: %v", err)
+		}
+	}
+
+	stationID := e.resolveStationID(ctx, req.StationID)
+
+	history, err := e.market.GetPriceHistory(ctx, req.ComponentID, stationID, req.PriceType, since, req.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &crafting.PriceHistoryResponse{
+		ComponentID: req.ComponentID,
+		StationID:   stationID,
+		PriceType:   req.PriceType,
+		Points:      make([]crafting.PricePoint, 0, len(history)),
+	}
+	for _, p := range history {
+		resp.Points = append(resp.Points, crafting.PricePoint{
+			Price:      p.Price,
+			Volume24h:  p.Volume24h,
+			RecordedAt: p.RecordedAt,
+		})
+	}
+
+	return resp, nil
+}
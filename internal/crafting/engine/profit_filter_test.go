@@ -0,0 +1,184 @@
+package engine
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
+)
+
+func TestProfitFilter(t *testing.T) {
+	ctx := context.Background()
+	engine := testEngine(t)
+
+	recipes := []crafting.Recipe{
+		{
+			ID: "make_steel", Name: "Steel",
+			Inputs:  []crafting.RecipeInput{{ItemID: "ore", Quantity: 10}},
+			Outputs: []crafting.RecipeOutput{{ItemID: "steel", Quantity: 1}},
+		},
+		{
+			ID: "make_wire", Name: "Wire",
+			Inputs:  []crafting.RecipeInput{{ItemID: "ore", Quantity: 14}},
+			Outputs: []crafting.RecipeOutput{{ItemID: "wire", Quantity: 1}},
+		},
+		{
+			ID: "make_gizmo", Name: "Gizmo",
+			Inputs:  []crafting.RecipeInput{{ItemID: "ore", Quantity: 1}},
+			Outputs: []crafting.RecipeOutput{{ItemID: "gizmo", Quantity: 1}},
+		},
+	}
+	if err := engine.recipes.BulkInsertRecipes(ctx, recipes); err != nil {
+		t.Fatalf("BulkInsertRecipes failed: %v", err)
+	}
+
+	// At station_a steel makes 50 (50%) and wire 10 (~7%); gizmo is unpriced.
+	_, err := engine.db.ExecContext(ctx, `
+		INSERT INTO market_price_stats
+		(item_id, station_id, empire_id, order_type, stat_method, representative_price,
+		 sample_count, total_volume, min_price, max_price, stddev, confidence_score, last_updated)
+		VALUES
+			('ore', 'station_a', NULL, 'buy', 'median', 10, 10, 100, 9, 11, 1, 0.9, datetime('now')),
+			('steel', 'station_a', NULL, 'sell', 'median', 150, 10, 100, 140, 160, 1, 0.9, datetime('now')),
+			('wire', 'station_a', NULL, 'sell', 'median', 150, 10, 100, 140, 160, 1, 0.9, datetime('now')),
+			('ore', 'station_b', NULL, 'buy', 'median', 10, 10, 100, 9, 11, 1, 0.9, datetime('now')),
+			('steel', 'station_b', NULL, 'sell', 'median', 200, 10, 100, 190, 210, 1, 0.9, datetime('now'))
+	`)
+	if err != nil {
+		t.Fatalf("inserting market stats: %v", err)
+	}
+
+	margin := func(v float64) *float64 { return &v }
+	perUnit := func(v int) *int { return &v }
+
+	tests := []struct {
+		name      string
+		stationID string
+		filter    crafting.ProfitFilter
+		want      []string
+	}{
+		{"no filter", "station_a", crafting.ProfitFilter{}, []string{"make_gizmo", "make_steel", "make_wire"}},
+		{"margin", "station_a", crafting.ProfitFilter{MinProfitMarginPct: margin(20)}, []string{"make_steel"}},
+		{"per unit", "station_a", crafting.ProfitFilter{MinProfitPerUnit: perUnit(5)}, []string{"make_steel", "make_wire"}},
+		{"both", "station_a", crafting.ProfitFilter{MinProfitMarginPct: margin(5), MinProfitPerUnit: perUnit(20)}, []string{"make_steel"}},
+		{"no station", "", crafting.ProfitFilter{MinProfitPerUnit: perUnit(1000)}, []string{"make_gizmo", "make_steel", "make_wire"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := engine.CraftQuery(ctx, crafting.CraftQueryRequest{
+				Components:   []crafting.Component{{ID: "ore", Quantity: 100}},
+				StationID:    tt.stationID,
+				ProfitFilter: tt.filter,
+			})
+			if err != nil {
+				t.Fatalf("CraftQuery failed: %v", err)
+			}
+			var got []string
+			for _, m := range query.Craftable {
+				got = append(got, m.Recipe.ID)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("craft_query: expected %v, got %v", tt.want, got)
+			}
+
+			uses, err := engine.ComponentUses(ctx, crafting.ComponentUsesRequest{
+				ItemID:       "ore",
+				StationID:    tt.stationID,
+				ProfitFilter: tt.filter,
+			})
+			if err != nil {
+				t.Fatalf("ComponentUses failed: %v", err)
+			}
+			got = recipeIDs(uses.UsedIn)
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("component_uses: expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	// Steel makes 50% at station_a and 100% at station_b.
+	lookup, err := engine.RecipeLookup(ctx, crafting.RecipeLookupRequest{
+		RecipeID:     "make_steel",
+		StationIDs:   []string{"station_a", "station_b"},
+		ProfitFilter: crafting.ProfitFilter{MinProfitMarginPct: margin(60)},
+	})
+	if err != nil {
+		t.Fatalf("RecipeLookup failed: %v", err)
+	}
+	if len(lookup.StationProfits) != 1 || lookup.StationProfits[0].StationID != "station_b" || lookup.BestStationID != "station_b" {
+		t.Errorf("expected only station_b to pass, got %+v", lookup.StationProfits)
+	}
+}
+
+func TestAutoStation(t *testing.T) {
+	ctx := context.Background()
+	engine := testEngine(t)
+
+	recipes := []crafting.Recipe{
+		{
+			ID: "make_steel", Name: "Steel",
+			Inputs:  []crafting.RecipeInput{{ItemID: "ore", Quantity: 10}},
+			Outputs: []crafting.RecipeOutput{{ItemID: "steel", Quantity: 1}},
+		},
+		{
+			ID: "make_wire", Name: "Wire",
+			Inputs:  []crafting.RecipeInput{{ItemID: "ore", Quantity: 10}},
+			Outputs: []crafting.RecipeOutput{{ItemID: "wire", Quantity: 1}},
+		},
+	}
+	if err := engine.recipes.BulkInsertRecipes(ctx, recipes); err != nil {
+		t.Fatalf("BulkInsertRecipes failed: %v", err)
+	}
+
+	// Steel sells best at station_b; wire is only traded at station_a.
+	_, err := engine.db.ExecContext(ctx, `
+		INSERT INTO market_price_stats
+		(item_id, station_id, empire_id, order_type, stat_method, representative_price,
+		 sample_count, total_volume, min_price, max_price, stddev, confidence_score, last_updated)
+		VALUES
+			('ore', 'station_a', NULL, 'buy', 'median', 10, 10, 100, 9, 11, 1, 0.9, datetime('now')),
+			('steel', 'station_a', NULL, 'sell', 'median', 150, 10, 100, 140, 160, 1, 0.9, datetime('now')),
+			('wire', 'station_a', NULL, 'sell', 'median', 120, 10, 100, 110, 130, 1, 0.9, datetime('now')),
+			('ore', 'station_b', NULL, 'buy', 'median', 10, 10, 100, 9, 11, 1, 0.9, datetime('now')),
+			('steel', 'station_b', NULL, 'sell', 'median', 200, 10, 100, 190, 210, 1, 0.9, datetime('now'))
+	`)
+	if err != nil {
+		t.Fatalf("inserting market stats: %v", err)
+	}
+
+	query, err := engine.CraftQuery(ctx, crafting.CraftQueryRequest{
+		Components:  []crafting.Component{{ID: "ore", Quantity: 100}},
+		AutoStation: true,
+	})
+	if err != nil {
+		t.Fatalf("CraftQuery failed: %v", err)
+	}
+	got := make(map[string]string)
+	for _, m := range query.Craftable {
+		if m.ProfitAnalysis == nil {
+			t.Fatalf("%s: expected a profit analysis", m.Recipe.ID)
+		}
+		got[m.Recipe.ID] = m.ProfitAnalysis.StationID
+	}
+	want := map[string]string{"make_steel": "station_b", "make_wire": "station_a"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("chosen stations = %v, want %v", got, want)
+	}
+
+	// An explicit station wins over auto selection.
+	lookup, err := engine.RecipeLookup(ctx, crafting.RecipeLookupRequest{
+		RecipeID:    "make_steel",
+		StationID:   "station_a",
+		AutoStation: true,
+	})
+	if err != nil {
+		t.Fatalf("RecipeLookup failed: %v", err)
+	}
+	if lookup.ProfitAnalysis == nil || lookup.ProfitAnalysis.StationID != "station_a" {
+		t.Errorf("expected station_a analysis, got %+v", lookup.ProfitAnalysis)
+	}
+}
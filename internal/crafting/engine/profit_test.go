@@ -1,8 +1,12 @@
 package engine
 
 import (
+	"bytes"
 	"context"
+	"log/slog"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/rsned/spacemolt-crafting-server/internal/crafting/db"
 	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
@@ -53,7 +57,7 @@ func TestCalculateProfitAnalysisWithMarketStats(t *testing.T) {
 	}
 
 	// Create engine
-	eng := New(database)
+	eng := New(database, nil)
 
 	// Create a test recipe
 	recipe := &crafting.Recipe{
@@ -68,7 +72,7 @@ func TestCalculateProfitAnalysisWithMarketStats(t *testing.T) {
 	}
 
 	t.Run("calculates profit with market data", func(t *testing.T) {
-		analysis, err := eng.calculateProfitAnalysis(ctx, recipe, "Test Station", 5)
+		analysis, err := eng.calculateProfitAnalysis(ctx, recipe, "Test Station", 5, crafting.PricingAverage)
 		if err != nil {
 			t.Fatalf("calculateProfitAnalysis failed: %v", err)
 		}
@@ -111,7 +115,7 @@ func TestCalculateProfitAnalysisWithMarketStats(t *testing.T) {
 	})
 
 	t.Run("returns nil when no station specified", func(t *testing.T) {
-		analysis, err := eng.calculateProfitAnalysis(ctx, recipe, "", 5)
+		analysis, err := eng.calculateProfitAnalysis(ctx, recipe, "", 5, crafting.PricingAverage)
 		if err != nil {
 			t.Fatalf("calculateProfitAnalysis failed: %v", err)
 		}
@@ -120,4 +124,200 @@ func TestCalculateProfitAnalysisWithMarketStats(t *testing.T) {
 			t.Error("expected nil analysis when no station specified, got analysis")
 		}
 	})
+
+	t.Run("applies pricing model", func(t *testing.T) {
+		tests := []struct {
+			model      crafting.PricingModel
+			wantOutput int
+			wantInput  int
+		}{
+			{"", 150, 50},
+			{crafting.PricingAverage, 150, 50},
+			// Sell comp_steel at its min 140, buy ore_iron at its max 8
+			{crafting.PricingPessimistic, 140, 80},
+			// Sell comp_steel at its max 160, buy ore_iron at its min 3
+			{crafting.PricingOptimistic, 160, 30},
+		}
+		for _, tt := range tests {
+			analysis, err := eng.calculateProfitAnalysis(ctx, recipe, "Test Station", 1, tt.model)
+			if err != nil {
+				t.Fatalf("calculateProfitAnalysis(%q) failed: %v", tt.model, err)
+			}
+			if analysis.OutputSellPrice != tt.wantOutput || analysis.InputCost != tt.wantInput {
+				t.Errorf("model %q: expected output %d and input %d, got %d and %d",
+					tt.model, tt.wantOutput, tt.wantInput, analysis.OutputSellPrice, analysis.InputCost)
+			}
+		}
+	})
+
+	t.Run("calculates profit per hour", func(t *testing.T) {
+		timed := *recipe
+		timed.CraftingTime = 120 // 30 crafts per hour
+
+		analysis, err := eng.calculateProfitAnalysis(ctx, &timed, "Test Station", 1, crafting.PricingAverage)
+		if err != nil {
+			t.Fatalf("calculateProfitAnalysis failed: %v", err)
+		}
+		if analysis.ProfitPerHour != 3000 {
+			t.Errorf("expected profit per hour 3000, got %v", analysis.ProfitPerHour)
+		}
+
+		analysis, err = eng.calculateProfitAnalysis(ctx, recipe, "Test Station", 1, crafting.PricingAverage)
+		if err != nil {
+			t.Fatalf("calculateProfitAnalysis failed: %v", err)
+		}
+		if analysis.ProfitPerHour != 0 {
+			t.Errorf("expected no profit per hour without craft time, got %v", analysis.ProfitPerHour)
+		}
+	})
+
+	t.Run("discounts output by success rate", func(t *testing.T) {
+		risky := *recipe
+		risky.SuccessRate = 0.8
+
+		analysis, err := eng.calculateProfitAnalysis(ctx, &risky, "Test Station", 1, crafting.PricingAverage)
+		if err != nil {
+			t.Fatalf("calculateProfitAnalysis failed: %v", err)
+		}
+		// Nominal figures are unchanged; 80% of the 150 output is expected
+		// for the same 50 of inputs.
+		if analysis.ProfitPerUnit != 100 {
+			t.Errorf("expected nominal profit per unit 100, got %d", analysis.ProfitPerUnit)
+		}
+		if analysis.SuccessRate != 0.8 || analysis.ExpectedOutputValue != 120 || analysis.ExpectedProfitPerUnit != 70 {
+			t.Errorf("expected rate 0.8, output 120 and profit 70, got %v, %d and %d",
+				analysis.SuccessRate, analysis.ExpectedOutputValue, analysis.ExpectedProfitPerUnit)
+		}
+
+		analysis, err = eng.calculateProfitAnalysis(ctx, recipe, "Test Station", 1, crafting.PricingAverage)
+		if err != nil {
+			t.Fatalf("calculateProfitAnalysis failed: %v", err)
+		}
+		if analysis.SuccessRate != 1 || analysis.ExpectedProfitPerUnit != analysis.ProfitPerUnit {
+			t.Errorf("expected an unset rate to mean 1.0, got rate %v and expected profit %d",
+				analysis.SuccessRate, analysis.ExpectedProfitPerUnit)
+		}
+	})
+	t.Run("breaks down input cost per component", func(t *testing.T) {
+		if _, err := database.ExecContext(ctx, `
+			INSERT INTO items (id, name, base_value, category) VALUES ('flux', 'Flux', 7, 'refined')
+		`); err != nil {
+			t.Fatalf("inserting flux: %v", err)
+		}
+		fluxed := *recipe
+		fluxed.Inputs = []crafting.RecipeInput{
+			{ItemID: "ore_iron", Quantity: 10},
+			{ItemID: "flux", Quantity: 2},
+		}
+
+		analysis, err := eng.calculateProfitAnalysis(ctx, &fluxed, "Test Station", 1, crafting.PricingAverage)
+		if err != nil {
+			t.Fatalf("calculateProfitAnalysis failed: %v", err)
+		}
+		// flux has no buy data at the station, so it falls back to its MSRP.
+		want := []crafting.ComponentCost{
+			{ItemID: "ore_iron", Quantity: 10, UnitPrice: 5, LineCost: 50, PriceSource: "market"},
+			{ItemID: "flux", Quantity: 2, UnitPrice: 7, LineCost: 14, PriceSource: "msrp"},
+		}
+		if len(analysis.InputCostBreakdown) != len(want) {
+			t.Fatalf("expected %d breakdown lines, got %+v", len(want), analysis.InputCostBreakdown)
+		}
+		for i, line := range analysis.InputCostBreakdown {
+			if line != want[i] {
+				t.Errorf("line %d: expected %+v, got %+v", i, want[i], line)
+			}
+		}
+		if analysis.InputCost != 64 {
+			t.Errorf("expected input cost 64, got %d", analysis.InputCost)
+		}
+	})
+	t.Run("flags missing prices", func(t *testing.T) {
+		if _, err := database.ExecContext(ctx, `
+			INSERT INTO items (id, name, base_value, category) VALUES ('gear', 'Gear', 90, 'component')
+		`); err != nil {
+			t.Fatalf("inserting gear: %v", err)
+		}
+
+		analysis, err := eng.calculateProfitAnalysis(ctx, recipe, "Test Station", 1, crafting.PricingAverage)
+		if err != nil {
+			t.Fatalf("calculateProfitAnalysis failed: %v", err)
+		}
+		if !analysis.DataComplete || len(analysis.MissingPrices) != 0 {
+			t.Errorf("expected complete data, got %v with missing %v", analysis.DataComplete, analysis.MissingPrices)
+		}
+
+		// gear has no sell data, so the output is priced at its MSRP of 90
+		// while the ore_iron input still uses the station's buy price.
+		partial := *recipe
+		partial.Outputs = []crafting.RecipeOutput{{ItemID: "gear", Quantity: 1}}
+		analysis, err = eng.calculateProfitAnalysis(ctx, &partial, "Test Station", 1, crafting.PricingAverage)
+		if err != nil {
+			t.Fatalf("calculateProfitAnalysis failed: %v", err)
+		}
+		if analysis == nil {
+			t.Fatal("expected a best-effort analysis, got nil")
+		}
+		if analysis.DataComplete || len(analysis.MissingPrices) != 1 || analysis.MissingPrices[0] != "gear" {
+			t.Errorf("expected gear to be missing, got %v with missing %v", analysis.DataComplete, analysis.MissingPrices)
+		}
+		if analysis.ProfitPerUnit != 40 || analysis.PricingMethod != "msrp_only" || analysis.MarketStatus != "no_market_data" {
+			t.Errorf("expected MSRP-based profit 40, got %d (%s, %s)",
+				analysis.ProfitPerUnit, analysis.PricingMethod, analysis.MarketStatus)
+		}
+
+		analysis, err = eng.calculateProfitAnalysis(ctx, &partial, "Other Station", 1, crafting.PricingAverage)
+		if err != nil {
+			t.Fatalf("calculateProfitAnalysis failed: %v", err)
+		}
+		if analysis != nil {
+			t.Errorf("expected nil analysis at a station with no data, got %+v", analysis)
+		}
+	})
+	t.Run("logs missing market data", func(t *testing.T) {
+		var buf bytes.Buffer
+		eng.logger = slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		defer func() { eng.logger = slog.Default() }()
+
+		partial := *recipe
+		partial.Outputs = []crafting.RecipeOutput{{ItemID: "gear", Quantity: 1}}
+		if _, err := eng.calculateProfitAnalysis(ctx, &partial, "Test Station", 1, crafting.PricingAverage); err != nil {
+			t.Fatalf("calculateProfitAnalysis failed: %v", err)
+		}
+		if _, err := eng.calculateProfitAnalysis(ctx, &partial, "Other Station", 1, crafting.PricingAverage); err != nil {
+			t.Fatalf("calculateProfitAnalysis failed: %v", err)
+		}
+
+		logged := buf.String()
+		for _, want := range []string{"pricing items without market data at MSRP", "items=[gear]", "skipping profit analysis", "station_id=\"Other Station\""} {
+			if !strings.Contains(logged, want) {
+				t.Errorf("log missing %q:\n%s", want, logged)
+			}
+		}
+	})
+	t.Run("reports the age of the stalest price", func(t *testing.T) {
+		if _, err := database.ExecContext(ctx, `
+			UPDATE market_price_stats SET last_updated = datetime('now', '-100 hours') WHERE item_id = 'ore_iron'
+		`); err != nil {
+			t.Fatalf("aging ore_iron stats: %v", err)
+		}
+
+		analysis, err := eng.calculateProfitAnalysis(ctx, recipe, "Test Station", 1, crafting.PricingAverage)
+		if err != nil {
+			t.Fatalf("calculateProfitAnalysis failed: %v", err)
+		}
+		if analysis.DataAgeHours != 100 || analysis.StaleWarning == "" {
+			t.Errorf("expected a stale warning for 100 hour old data, got age %d and warning %q",
+				analysis.DataAgeHours, analysis.StaleWarning)
+		}
+
+		eng.SetStaleDataAge(200 * time.Hour)
+		defer eng.SetStaleDataAge(DefaultStaleDataAge)
+		analysis, err = eng.calculateProfitAnalysis(ctx, recipe, "Test Station", 1, crafting.PricingAverage)
+		if err != nil {
+			t.Fatalf("calculateProfitAnalysis failed: %v", err)
+		}
+		if analysis.StaleWarning != "" {
+			t.Errorf("expected no warning under a 200 hour threshold, got %q", analysis.StaleWarning)
+		}
+	})
 }
@@ -0,0 +1,152 @@
+package engine
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
+)
+
+// recipeCache holds a snapshot of every recipe, loaded lazily on first use
+// and reloaded when the database's recipe generation changes (i.e. after an
+// import through any RecipeStore).
+type recipeCache struct {
+	mu       sync.RWMutex
+	disabled bool
+	loaded   bool
+	gen      uint64
+	all      []crafting.Recipe
+	byID     map[string]*crafting.Recipe
+}
+
+// SetRecipeCaching enables or disables the in-memory recipe cache. Caching is
+// on by default; disabling it makes every lookup read from the database.
+func (e *Engine) SetRecipeCaching(enabled bool) {
+	e.recipeCache.mu.Lock()
+	defer e.recipeCache.mu.Unlock()
+
+	e.recipeCache.disabled = !enabled
+	e.recipeCache.loaded = false
+	e.recipeCache.all = nil
+	e.recipeCache.byID = nil
+}
+
+// Warmup loads the recipe catalog ahead of the first query, so that query
+// doesn't pay for a cold read from SQLite. It returns the number of recipes
+// loaded.
+func (e *Engine) Warmup(ctx context.Context) (int, error) {
+	c := &e.recipeCache
+	if c.isDisabled() {
+		// Nothing is kept, but the read still warms SQLite's page cache
+		all, err := e.recipes.GetAllRecipes(ctx)
+		return len(all), err
+	}
+
+	if err := e.loadRecipeCache(ctx); err != nil {
+		return 0, err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.all), nil
+}
+
+// getRecipe returns a recipe by ID, or nil if it does not exist. The returned
+// recipe is a copy the caller may modify.
+func (e *Engine) getRecipe(ctx context.Context, id string) (*crafting.Recipe, error) {
+	c := &e.recipeCache
+	if c.isDisabled() {
+		return e.recipes.GetRecipe(ctx, id)
+	}
+
+	if err := e.loadRecipeCache(ctx); err != nil {
+		return nil, err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	recipe, ok := c.byID[id]
+	if !ok {
+		return nil, nil
+	}
+	clone := cloneRecipe(*recipe)
+	return &clone, nil
+}
+
+// getAllRecipes returns every recipe. The returned slice is a copy the caller
+// may modify.
+func (e *Engine) getAllRecipes(ctx context.Context) ([]crafting.Recipe, error) {
+	c := &e.recipeCache
+	if c.isDisabled() {
+		return e.recipes.GetAllRecipes(ctx)
+	}
+
+	if err := e.loadRecipeCache(ctx); err != nil {
+		return nil, err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	all := make([]crafting.Recipe, len(c.all))
+	for i, r := range c.all {
+		all[i] = cloneRecipe(r)
+	}
+	return all, nil
+}
+
+func (c *recipeCache) isDisabled() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.disabled
+}
+
+// loadRecipeCache (re)loads the snapshot if it is missing or stale.
+func (e *Engine) loadRecipeCache(ctx context.Context) error {
+	c := &e.recipeCache
+	gen := e.db.RecipeGeneration()
+
+	c.mu.RLock()
+	fresh := c.loaded && c.gen == gen
+	c.mu.RUnlock()
+	if fresh {
+		return nil
+	}
+
+	// Load outside the lock; concurrent loaders just race to store the same data
+	all, err := e.recipes.GetAllRecipes(ctx)
+	if err != nil {
+		return err
+	}
+	byID := make(map[string]*crafting.Recipe, len(all))
+	for i := range all {
+		byID[all[i].ID] = &all[i]
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.disabled {
+		c.loaded = true
+		c.gen = gen
+		c.all = all
+		c.byID = byID
+	}
+	return nil
+}
+
+// cloneRecipe copies a recipe so cached data is never shared with callers.
+func cloneRecipe(r crafting.Recipe) crafting.Recipe {
+	r.Inputs = slices.Clone(r.Inputs)
+	for i := range r.Inputs {
+		r.Inputs[i].Alternatives = slices.Clone(r.Inputs[i].Alternatives)
+	}
+	r.Outputs = slices.Clone(r.Outputs)
+	r.Tags = slices.Clone(r.Tags)
+	if r.IllegalStatus != nil {
+		status := *r.IllegalStatus
+		r.IllegalStatus = &status
+	}
+	return r
+}
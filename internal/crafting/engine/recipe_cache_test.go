@@ -0,0 +1,106 @@
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
+)
+
+func TestRecipeCache(t *testing.T) {
+	ctx := context.Background()
+	engine := testEngine(t)
+
+	recipes := []crafting.Recipe{{
+		ID: "smelt_plate", Name: "Smelt Plate",
+		Inputs:  []crafting.RecipeInput{{ItemID: "ore", Quantity: 3, Alternatives: []string{"scrap"}}},
+		Outputs: []crafting.RecipeOutput{{ItemID: "plate", Quantity: 1}},
+	}}
+	if err := engine.recipes.BulkInsertRecipes(ctx, recipes); err != nil {
+		t.Fatalf("BulkInsertRecipes failed: %v", err)
+	}
+
+	recipeName := func() string {
+		t.Helper()
+		recipe, err := engine.getRecipe(ctx, "smelt_plate")
+		if err != nil {
+			t.Fatalf("getRecipe failed: %v", err)
+		}
+		return recipe.Name
+	}
+
+	if got := recipeName(); got != "Smelt Plate" {
+		t.Fatalf("expected %q, got %q", "Smelt Plate", got)
+	}
+
+	// Callers may modify what they get back without corrupting the cache
+	recipe, _ := engine.getRecipe(ctx, "smelt_plate")
+	recipe.Name = "Mutated"
+	recipe.Inputs[0].Quantity = 99
+	recipe.Inputs[0].Alternatives[0] = "mutated"
+	recipe, _ = engine.getRecipe(ctx, "smelt_plate")
+	if recipe.Name != "Smelt Plate" || recipe.Inputs[0].Quantity != 3 || recipe.Inputs[0].Alternatives[0] != "scrap" {
+		t.Errorf("cached recipe was modified through a returned copy: %+v", recipe)
+	}
+
+	// Writes that bypass the RecipeStore are not seen while cached
+	if _, err := engine.db.ExecContext(ctx, `UPDATE recipes SET name = 'Direct Edit'`); err != nil {
+		t.Fatalf("updating recipe: %v", err)
+	}
+	if got := recipeName(); got != "Smelt Plate" {
+		t.Errorf("expected cached name %q, got %q", "Smelt Plate", got)
+	}
+
+	// Disabling the cache reads straight from the database
+	engine.SetRecipeCaching(false)
+	if got := recipeName(); got != "Direct Edit" {
+		t.Errorf("expected uncached name %q, got %q", "Direct Edit", got)
+	}
+	engine.SetRecipeCaching(true)
+
+	// Imports invalidate the cache
+	recipes[0].Name = "Smelt Plate v2"
+	if err := engine.recipes.BulkInsertRecipes(ctx, recipes); err != nil {
+		t.Fatalf("BulkInsertRecipes failed: %v", err)
+	}
+	if got := recipeName(); got != "Smelt Plate v2" {
+		t.Errorf("expected reloaded name %q, got %q", "Smelt Plate v2", got)
+	}
+
+	if missing, err := engine.getRecipe(ctx, "missing"); err != nil || missing != nil {
+		t.Errorf("expected nil for missing recipe, got %+v, %v", missing, err)
+	}
+}
+
+func TestWarmup(t *testing.T) {
+	ctx := context.Background()
+	engine := testEngine(t)
+
+	recipes := []crafting.Recipe{
+		{ID: "smelt_plate", Name: "Smelt Plate", Outputs: []crafting.RecipeOutput{{ItemID: "plate", Quantity: 1}}},
+		{ID: "draw_wire", Name: "Draw Wire", Outputs: []crafting.RecipeOutput{{ItemID: "wire", Quantity: 1}}},
+	}
+	if err := engine.recipes.BulkInsertRecipes(ctx, recipes); err != nil {
+		t.Fatalf("BulkInsertRecipes failed: %v", err)
+	}
+
+	n, err := engine.Warmup(ctx)
+	if err != nil {
+		t.Fatalf("Warmup failed: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("expected 2 recipes warmed, got %d", n)
+	}
+	if !engine.recipeCache.loaded || len(engine.recipeCache.byID) != 2 {
+		t.Errorf("expected the recipe cache to be loaded, got loaded=%v with %d recipes",
+			engine.recipeCache.loaded, len(engine.recipeCache.byID))
+	}
+
+	engine.SetRecipeCaching(false)
+	if n, err := engine.Warmup(ctx); err != nil || n != 2 {
+		t.Errorf("uncached Warmup = %d, %v; want 2, nil", n, err)
+	}
+	if engine.recipeCache.loaded {
+		t.Error("uncached Warmup filled the recipe cache")
+	}
+}
@@ -4,12 +4,32 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
 )
 
-// RecipeLookup executes the recipe_lookup tool logic.
+// minSearchLength is the shortest search term recipe_lookup accepts once
+// surrounding spaces are trimmed. Shorter terms match most of the catalog.
+const minSearchLength = 2
+
+// searchLimit is how many search hits recipe_lookup returns.
+const searchLimit = 10
+
+// RecipeLookup executes the recipe_lookup tool logic. At least one of
+// RecipeID and Search is required. Search is trimmed and must then be at
+// least minSearchLength characters; it matches recipe names containing the
+// term literally, and at most 10 hits are returned.
 func (e *Engine) RecipeLookup(ctx context.Context, req crafting.RecipeLookupRequest) (*crafting.RecipeLookupResponse, error) {
+	req.Search = strings.TrimSpace(req.Search)
+	switch {
+	case req.RecipeID == "" && req.Search == "":
+		return nil, invalidInputf("recipe_id or search is required")
+	case req.Search != "" && utf8.RuneCountInString(req.Search) < minSearchLength:
+		return nil, invalidInputf("search must be at least %d characters", minSearchLength)
+	}
+
 	// Resolve station identifier
 	req.StationID = e.resolveStationID(ctx, req.StationID)
 
@@ -17,7 +37,7 @@ func (e *Engine) RecipeLookup(ctx context.Context, req crafting.RecipeLookupRequ
 
 	// If search term provided, search first
 	if req.Search != "" {
-		hits, err := e.recipes.SearchRecipes(ctx, req.Search, 10)
+		hits, err := e.recipes.SearchRecipes(ctx, req.Search, e.clampLimit(searchLimit, searchLimit))
 		if err != nil {
 			return nil, err
 		}
@@ -46,7 +66,7 @@ func (e *Engine) RecipeLookup(ctx context.Context, req crafting.RecipeLookupRequ
 	}
 	
 	// Get the recipe
-	recipe, err := e.recipes.GetRecipe(ctx, req.RecipeID)
+	recipe, err := e.getRecipe(ctx, req.RecipeID)
 	if err != nil {
 		return nil, err
 	}
@@ -56,13 +76,25 @@ func (e *Engine) RecipeLookup(ctx context.Context, req crafting.RecipeLookupRequ
 	resp.Recipe = recipe
 
 	// Calculate profit analysis if station provided
-	if req.StationID != "" {
-		analysis, err := e.calculateProfitAnalysis(ctx, recipe, req.StationID, 1)
+	if req.StationID != "" || req.AutoStation {
+		analysis, err := e.profitAnalysisFor(ctx, recipe, req.StationID, req.AutoStation, 1, req.PricingModel)
 		if err != nil {
 			return nil, err
 		}
 		resp.ProfitAnalysis = analysis
 	}
+
+	// Compare profit across stations if requested
+	if len(req.StationIDs) > 0 {
+		profits, err := e.stationProfits(ctx, recipe, req.StationIDs, req.PricingModel, req.ProfitFilter)
+		if err != nil {
+			return nil, err
+		}
+		resp.StationProfits = profits
+		if len(profits) > 0 {
+			resp.BestStationID = profits[0].StationID
+		}
+	}
 	
 	// Find recipes that use this recipe's outputs as inputs
 	usedInMap := make(map[string]bool)
@@ -90,3 +122,40 @@ func (e *Engine) RecipeLookup(ctx context.Context, req crafting.RecipeLookupRequ
 
 	return resp, nil
 }
+
+// stationProfits calculates profit analysis for a recipe at each station,
+// sorted by profit per unit (best first). Stations without any market data
+// for the recipe, or below the filter's thresholds, are omitted.
+func (e *Engine) stationProfits(ctx context.Context, recipe *crafting.Recipe, stationIDs []string, model crafting.PricingModel, filter crafting.ProfitFilter) ([]crafting.StationProfit, error) {
+	seen := make(map[string]bool, len(stationIDs))
+	profits := make([]crafting.StationProfit, 0, len(stationIDs))
+	for _, identifier := range stationIDs {
+		stationID := e.resolveStationID(ctx, identifier)
+		if stationID == "" || seen[stationID] {
+			continue
+		}
+		seen[stationID] = true
+
+		analysis, err := e.calculateProfitAnalysis(ctx, recipe, stationID, 1, model)
+		if err != nil {
+			return nil, err
+		}
+		if analysis == nil || !meetsProfitFilter(filter, analysis) {
+			continue
+		}
+		profits = append(profits, crafting.StationProfit{
+			StationID:      stationID,
+			ProfitAnalysis: analysis,
+		})
+	}
+
+	sort.Slice(profits, func(i, j int) bool {
+		pi, pj := profits[i].ProfitAnalysis.ProfitPerUnit, profits[j].ProfitAnalysis.ProfitPerUnit
+		if pi != pj {
+			return pi > pj
+		}
+		return profits[i].StationID < profits[j].StationID
+	})
+
+	return profits, nil
+}
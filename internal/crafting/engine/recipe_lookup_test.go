@@ -0,0 +1,105 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
+)
+
+func TestRecipeLookup_StationProfits(t *testing.T) {
+	ctx := context.Background()
+	engine := testEngine(t)
+
+	recipes := []crafting.Recipe{{
+		ID: "recipe_steel", Name: "Steel Component",
+		Inputs:  []crafting.RecipeInput{{ItemID: "ore_iron", Quantity: 10}},
+		Outputs: []crafting.RecipeOutput{{ItemID: "comp_steel", Quantity: 1}},
+	}}
+	if err := engine.recipes.BulkInsertRecipes(ctx, recipes); err != nil {
+		t.Fatalf("BulkInsertRecipes failed: %v", err)
+	}
+
+	_, err := engine.db.ExecContext(ctx, `
+		INSERT INTO market_price_stats
+		(item_id, station_id, empire_id, order_type, stat_method, representative_price,
+		 sample_count, total_volume, min_price, max_price, stddev, confidence_score, last_updated)
+		VALUES
+			('comp_steel', 'station_a', NULL, 'sell', 'median', 150, 10, 100, 140, 160, 1, 0.9, datetime('now')),
+			('ore_iron', 'station_a', NULL, 'buy', 'median', 10, 10, 100, 9, 11, 1, 0.9, datetime('now')),
+			('comp_steel', 'station_b', NULL, 'sell', 'median', 200, 10, 100, 190, 210, 1, 0.9, datetime('now')),
+			('ore_iron', 'station_b', NULL, 'buy', 'median', 12, 10, 100, 11, 13, 1, 0.9, datetime('now'))
+	`)
+	if err != nil {
+		t.Fatalf("inserting market stats: %v", err)
+	}
+
+	resp, err := engine.RecipeLookup(ctx, crafting.RecipeLookupRequest{
+		RecipeID:   "recipe_steel",
+		StationIDs: []string{"station_a", "station_b", "station_none", "station_a"},
+	})
+	if err != nil {
+		t.Fatalf("RecipeLookup failed: %v", err)
+	}
+
+	if len(resp.StationProfits) != 2 {
+		t.Fatalf("expected 2 station profits, got %d: %+v", len(resp.StationProfits), resp.StationProfits)
+	}
+	if resp.StationProfits[0].StationID != "station_b" || resp.StationProfits[0].ProfitAnalysis.ProfitPerUnit != 80 {
+		t.Errorf("expected station_b first with profit 80, got %s with %d",
+			resp.StationProfits[0].StationID, resp.StationProfits[0].ProfitAnalysis.ProfitPerUnit)
+	}
+	if resp.StationProfits[1].StationID != "station_a" || resp.StationProfits[1].ProfitAnalysis.ProfitPerUnit != 50 {
+		t.Errorf("expected station_a second with profit 50, got %s with %d",
+			resp.StationProfits[1].StationID, resp.StationProfits[1].ProfitAnalysis.ProfitPerUnit)
+	}
+	if resp.BestStationID != "station_b" {
+		t.Errorf("expected best station station_b, got %q", resp.BestStationID)
+	}
+}
+
+func TestRecipeLookup_SearchTerms(t *testing.T) {
+	ctx := context.Background()
+	engine := testEngine(t)
+
+	recipes := []crafting.Recipe{
+		{
+			ID: "recipe_steel", Name: "Steel Plate",
+			Inputs:  []crafting.RecipeInput{{ItemID: "ore_iron", Quantity: 10}},
+			Outputs: []crafting.RecipeOutput{{ItemID: "steel_plate", Quantity: 1}},
+		},
+		{
+			ID: "recipe_wire", Name: "Copper Wire",
+			Inputs:  []crafting.RecipeInput{{ItemID: "ore_copper", Quantity: 2}},
+			Outputs: []crafting.RecipeOutput{{ItemID: "wire", Quantity: 1}},
+		},
+	}
+	if err := engine.recipes.BulkInsertRecipes(ctx, recipes); err != nil {
+		t.Fatalf("BulkInsertRecipes failed: %v", err)
+	}
+
+	// Surrounding spaces are trimmed before searching.
+	resp, err := engine.RecipeLookup(ctx, crafting.RecipeLookupRequest{Search: "  steel "})
+	if err != nil {
+		t.Fatalf("RecipeLookup failed: %v", err)
+	}
+	if resp.Recipe == nil || resp.Recipe.ID != "recipe_steel" {
+		t.Errorf("expected the single hit to be looked up, got %+v", resp)
+	}
+
+	// A wildcard is matched literally rather than returning the catalog.
+	resp, err = engine.RecipeLookup(ctx, crafting.RecipeLookupRequest{Search: "%%"})
+	if err != nil {
+		t.Fatalf("RecipeLookup failed: %v", err)
+	}
+	if len(resp.SearchResults) != 0 {
+		t.Errorf("expected no hits for %q, got %+v", "%%", resp.SearchResults)
+	}
+
+	for _, search := range []string{"", "   ", "s", " s "} {
+		if _, err := engine.RecipeLookup(ctx, crafting.RecipeLookupRequest{Search: search}); !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("search %q: expected ErrInvalidInput, got %v", search, err)
+		}
+	}
+}
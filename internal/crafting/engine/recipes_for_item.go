@@ -0,0 +1,73 @@
+package engine
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
+)
+
+// RecipesForItem executes the recipes_for_item tool logic: every recipe
+// that produces an item, the complement of component_uses. Recipes are
+// ordered fastest first, then by most of the item per run, then recipe ID.
+func (e *Engine) RecipesForItem(ctx context.Context, req crafting.RecipesForItemRequest) (*crafting.RecipesForItemResponse, error) {
+	if req.ItemID == "" {
+		return nil, invalidInputf("item_id is required")
+	}
+
+	resp := &crafting.RecipesForItemResponse{
+		ItemID:     req.ItemID,
+		ProducedBy: []crafting.ItemRecipeInfo{},
+	}
+
+	names, err := e.itemNames(ctx, []string{req.ItemID})
+	if err != nil {
+		return nil, err
+	}
+	resp.ItemName = names[req.ItemID]
+
+	recipeIDs, err := e.recipes.FindRecipesByOutput(ctx, req.ItemID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, recipeID := range recipeIDs {
+		recipe, err := e.getRecipe(ctx, recipeID)
+		if err != nil {
+			return nil, err
+		}
+		if recipe == nil {
+			continue
+		}
+
+		if err := e.enrichRecipeWithIllegalStatus(ctx, recipe); err != nil {
+			return nil, fmt.Errorf("enriching illegal status: %w", err)
+		}
+
+		resp.ProducedBy = append(resp.ProducedBy, crafting.ItemRecipeInfo{
+			RecipeID:       recipe.ID,
+			RecipeName:     recipe.Name,
+			Category:       recipe.Category,
+			CraftingTime:   recipe.CraftingTime,
+			OutputQuantity: getOutputQuantityForItem(recipe, req.ItemID),
+			InputCount:     len(recipe.Inputs),
+			IllegalStatus:  recipe.IllegalStatus,
+		})
+	}
+
+	sort.Slice(resp.ProducedBy, func(i, j int) bool {
+		a, b := &resp.ProducedBy[i], &resp.ProducedBy[j]
+		if c := cmp.Compare(a.CraftingTime, b.CraftingTime); c != 0 {
+			return c < 0
+		}
+		if c := cmp.Compare(b.OutputQuantity, a.OutputQuantity); c != 0 {
+			return c < 0
+		}
+		return a.RecipeID < b.RecipeID
+	})
+	resp.TotalRecipes = len(resp.ProducedBy)
+
+	return resp, nil
+}
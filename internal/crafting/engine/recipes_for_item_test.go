@@ -0,0 +1,76 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
+)
+
+func TestRecipesForItem(t *testing.T) {
+	ctx := context.Background()
+	engine := testEngine(t)
+
+	recipes := []crafting.Recipe{
+		{
+			ID: "smelt_plate_slow", Name: "Careful Smelting", Category: "Refining", CraftingTime: 20,
+			Inputs:  []crafting.RecipeInput{{ItemID: "ore", Quantity: 2}},
+			Outputs: []crafting.RecipeOutput{{ItemID: "plate", Quantity: 1}},
+		},
+		{
+			ID: "smelt_plate_bulk", Name: "Bulk Smelting", Category: "Refining", CraftingTime: 10,
+			Inputs: []crafting.RecipeInput{
+				{ItemID: "ore", Quantity: 5},
+				{ItemID: "flux", Quantity: 1},
+			},
+			Outputs: []crafting.RecipeOutput{
+				{ItemID: "plate", Quantity: 3},
+				{ItemID: "slag", Quantity: 1},
+			},
+		},
+		{
+			ID: "smelt_plate_fast", Name: "Quick Smelting", Category: "Refining", CraftingTime: 10,
+			Inputs:  []crafting.RecipeInput{{ItemID: "ore", Quantity: 3}},
+			Outputs: []crafting.RecipeOutput{{ItemID: "plate", Quantity: 1}},
+		},
+		{
+			ID: "build_hull", Name: "Build Hull", Category: "Components", CraftingTime: 5,
+			Inputs:  []crafting.RecipeInput{{ItemID: "plate", Quantity: 2}},
+			Outputs: []crafting.RecipeOutput{{ItemID: "hull", Quantity: 1}},
+		},
+	}
+	if err := engine.recipes.BulkInsertRecipes(ctx, recipes); err != nil {
+		t.Fatalf("BulkInsertRecipes failed: %v", err)
+	}
+
+	resp, err := engine.RecipesForItem(ctx, crafting.RecipesForItemRequest{ItemID: "plate"})
+	if err != nil {
+		t.Fatalf("RecipesForItem failed: %v", err)
+	}
+	want := []crafting.ItemRecipeInfo{
+		{RecipeID: "smelt_plate_bulk", RecipeName: "Bulk Smelting", Category: "Refining", CraftingTime: 10, OutputQuantity: 3, InputCount: 2},
+		{RecipeID: "smelt_plate_fast", RecipeName: "Quick Smelting", Category: "Refining", CraftingTime: 10, OutputQuantity: 1, InputCount: 1},
+		{RecipeID: "smelt_plate_slow", RecipeName: "Careful Smelting", Category: "Refining", CraftingTime: 20, OutputQuantity: 1, InputCount: 1},
+	}
+	if !reflect.DeepEqual(resp.ProducedBy, want) {
+		t.Errorf("unexpected recipes:\n got %+v\nwant %+v", resp.ProducedBy, want)
+	}
+	if resp.TotalRecipes != 3 {
+		t.Errorf("expected 3 recipes, got %d", resp.TotalRecipes)
+	}
+
+	// Raw materials have no recipes, which is not an error.
+	resp, err = engine.RecipesForItem(ctx, crafting.RecipesForItemRequest{ItemID: "ore"})
+	if err != nil {
+		t.Fatalf("RecipesForItem failed: %v", err)
+	}
+	if len(resp.ProducedBy) != 0 || resp.TotalRecipes != 0 {
+		t.Errorf("expected no recipes for a raw material, got %+v", resp)
+	}
+
+	if _, err := engine.RecipesForItem(ctx, crafting.RecipesForItemRequest{}); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("expected ErrInvalidInput without item_id, got %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rsned/spacemolt-crafting-server/internal/crafting/db"
+	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
+)
+
+// SetMarketPrice executes the set_market_price tool logic.
+// It records a single observed price for an item at a station and refreshes
+// the price summary for just that market, so an agent can push a price it
+// has just seen without a full market import.
+func (e *Engine) SetMarketPrice(ctx context.Context, req crafting.SetMarketPriceRequest) (*crafting.SetMarketPriceResponse, error) {
+	if e.db.ReadOnly() {
+		return nil, fmt.Errorf("set_market_price: %w", ErrReadOnly)
+	}
+	if req.ComponentID == "" {
+		return nil, invalidInputf("component_id is required")
+	}
+	if req.StationID == "" {
+		return nil, invalidInputf("station_id is required")
+	}
+	if req.BuyPrice < 0 || req.SellPrice < 0 || req.Volume24h < 0 {
+		return nil, invalidInputf("buy_price, sell_price and volume_24h must not be negative")
+	}
+	if req.BuyPrice == 0 && req.SellPrice == 0 {
+		return nil, invalidInputf("at least one of buy_price or sell_price is required")
+	}
+
+	observedAt := time.Now().UTC()
+	if req.ObservedAt != "" {
+		var err error
+		observedAt, err = time.Parse(time.RFC3339, req.ObservedAt)
+		if err != nil {
+			return nil, invalidInputf("invalid observed_at timestamp: %v", err)
+		}
+	}
+
+	item, err := e.items.GetItem(ctx, req.ComponentID)
+	if err != nil {
+		return nil, err
+	}
+	if item == nil {
+		return nil, notFoundf("item not found: %s", req.ComponentID)
+	}
+
+	stationID := e.resolveStationID(ctx, req.StationID)
+
+	point := db.MarketDataPoint{
+		ItemID:    req.ComponentID,
+		StationID: stationID,
+		BuyPrice:  req.BuyPrice,
+		SellPrice: req.SellPrice,
+		Volume24h: req.Volume24h,
+		Timestamp: observedAt,
+	}
+	if err := e.market.ImportMarketData(ctx, []db.MarketDataPoint{point}); err != nil {
+		return nil, err
+	}
+
+	pair := []db.ItemStation{{ItemID: req.ComponentID, StationID: stationID}}
+	if err := e.market.RefreshPriceSummariesFor(ctx, pair, db.TrendConfig{}); err != nil {
+		return nil, err
+	}
+
+	buy, sell, err := e.market.GetPriceSummary(ctx, req.ComponentID, stationID)
+	if err != nil {
+		return nil, err
+	}
+	for _, summary := range []*crafting.MarketPriceSummary{buy, sell} {
+		if summary != nil {
+			summary.StaleWarning = e.staleWarning(summary.PriceAsOf)
+		}
+	}
+
+	return &crafting.SetMarketPriceResponse{
+		ComponentID: req.ComponentID,
+		StationID:   stationID,
+		RecordedAt:  observedAt,
+		BuySummary:  buy,
+		SellSummary: sell,
+	}, nil
+}
@@ -0,0 +1,129 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
+)
+
+func TestSetMarketPrice(t *testing.T) {
+	ctx := context.Background()
+	engine := testEngine(t)
+
+	if _, err := engine.db.ExecContext(ctx, `
+		INSERT INTO items (id, name, base_value, category) VALUES ('ore_iron', 'Iron Ore', 5, 'ore')
+	`); err != nil {
+		t.Fatalf("inserting item: %v", err)
+	}
+
+	now := time.Now().UTC()
+	for i, price := range []int{100, 110} {
+		_, err := engine.SetMarketPrice(ctx, crafting.SetMarketPriceRequest{
+			ComponentID: "ore_iron",
+			StationID:   "sol_station",
+			SellPrice:   price,
+			Volume24h:   40,
+			ObservedAt:  now.Add(time.Duration(i-2) * time.Hour).Format(time.RFC3339),
+		})
+		if err != nil {
+			t.Fatalf("SetMarketPrice(%d): %v", price, err)
+		}
+	}
+
+	resp, err := engine.SetMarketPrice(ctx, crafting.SetMarketPriceRequest{
+		ComponentID: "ore_iron",
+		StationID:   "sol_station",
+		BuyPrice:    90,
+		SellPrice:   120,
+	})
+	if err != nil {
+		t.Fatalf("SetMarketPrice: %v", err)
+	}
+
+	if resp.SellSummary == nil || resp.SellSummary.AvgPrice7d != 110 || resp.SellSummary.MaxPrice7d != 120 {
+		t.Errorf("expected sell summary to cover all three prices, got %+v", resp.SellSummary)
+	}
+	if resp.BuySummary == nil || resp.BuySummary.AvgPrice7d != 90 {
+		t.Errorf("expected buy summary of 90, got %+v", resp.BuySummary)
+	}
+	if price, _ := engine.market.GetSellPrice(ctx, "ore_iron", "sol_station"); price != 110 {
+		t.Errorf("expected queries to see the refreshed sell price 110, got %d", price)
+	}
+}
+
+func TestSetMarketPrice_StaleWarning(t *testing.T) {
+	ctx := context.Background()
+	engine := testEngine(t)
+
+	if _, err := engine.db.ExecContext(ctx, `
+		INSERT INTO items (id, name, base_value, category) VALUES ('ore_iron', 'Iron Ore', 5, 'ore')
+	`); err != nil {
+		t.Fatalf("inserting item: %v", err)
+	}
+
+	observed := time.Now().UTC().Add(-72 * time.Hour).Truncate(time.Second)
+	resp, err := engine.SetMarketPrice(ctx, crafting.SetMarketPriceRequest{
+		ComponentID: "ore_iron",
+		StationID:   "sol_station",
+		SellPrice:   100,
+		ObservedAt:  observed.Format(time.RFC3339),
+	})
+	if err != nil {
+		t.Fatalf("SetMarketPrice: %v", err)
+	}
+
+	sell := resp.SellSummary
+	if sell == nil || !sell.PriceAsOf.Equal(observed) || sell.DataAgeHours != 72 {
+		t.Fatalf("expected a summary as of %v and 72 hours old, got %+v", observed, sell)
+	}
+	if sell.StaleWarning == "" {
+		t.Error("expected a stale warning for 72 hour old data")
+	}
+
+	engine.SetStaleDataAge(0)
+	resp, err = engine.SetMarketPrice(ctx, crafting.SetMarketPriceRequest{
+		ComponentID: "ore_iron",
+		StationID:   "sol_station",
+		SellPrice:   100,
+		ObservedAt:  observed.Add(time.Minute).Format(time.RFC3339),
+	})
+	if err != nil {
+		t.Fatalf("SetMarketPrice: %v", err)
+	}
+	if resp.SellSummary.StaleWarning != "" {
+		t.Errorf("expected no warning when disabled, got %q", resp.SellSummary.StaleWarning)
+	}
+}
+
+func TestSetMarketPrice_InvalidInput(t *testing.T) {
+	ctx := context.Background()
+	engine := testEngine(t)
+
+	if _, err := engine.db.ExecContext(ctx, `
+		INSERT INTO items (id, name, base_value, category) VALUES ('ore_iron', 'Iron Ore', 5, 'ore')
+	`); err != nil {
+		t.Fatalf("inserting item: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		req     crafting.SetMarketPriceRequest
+		wantErr error
+	}{
+		{"missing station", crafting.SetMarketPriceRequest{ComponentID: "ore_iron", SellPrice: 1}, ErrInvalidInput},
+		{"no price", crafting.SetMarketPriceRequest{ComponentID: "ore_iron", StationID: "s"}, ErrInvalidInput},
+		{"negative price", crafting.SetMarketPriceRequest{ComponentID: "ore_iron", StationID: "s", SellPrice: 5, BuyPrice: -1}, ErrInvalidInput},
+		{"bad timestamp", crafting.SetMarketPriceRequest{ComponentID: "ore_iron", StationID: "s", SellPrice: 5, ObservedAt: "now"}, ErrInvalidInput},
+		{"unknown item", crafting.SetMarketPriceRequest{ComponentID: "ore_unobtanium", StationID: "s", SellPrice: 5}, ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := engine.SetMarketPrice(ctx, tt.req); !errors.Is(err, tt.wantErr) {
+				t.Errorf("expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
+)
+
+// maxShoppingListTargets caps how many targets one shopping_list may plan.
+const maxShoppingListTargets = 50
+
+// ShoppingList executes the shopping_list tool logic. It plans every target
+// as one bill of materials, so an intermediate needed by several targets is
+// crafted once for their combined demand rather than once per target, and
+// also reports what each target would need on its own.
+func (e *Engine) ShoppingList(ctx context.Context, req crafting.ShoppingListRequest) (*crafting.ShoppingListResponse, error) {
+	if len(req.Targets) == 0 {
+		return nil, invalidInputf("targets is required")
+	}
+	if len(req.Targets) > maxShoppingListTargets {
+		return nil, invalidInputf("at most %d targets per shopping list, got %d", maxShoppingListTargets, len(req.Targets))
+	}
+
+	// Merge repeated recipes; each target item must come from one recipe
+	var roots []*crafting.Recipe
+	quantities := make(map[string]int) // by recipe ID
+	targets := make(map[string]int)    // by primary output item ID
+	recipeFor := make(map[string]string)
+	for _, t := range req.Targets {
+		if t.RecipeID == "" {
+			return nil, invalidInputf("every target needs a recipe_id")
+		}
+		quantity := t.Quantity
+		if quantity <= 0 {
+			quantity = 1
+		}
+
+		if _, ok := quantities[t.RecipeID]; !ok {
+			recipe, err := e.getRecipe(ctx, t.RecipeID)
+			if err != nil {
+				return nil, fmt.Errorf("getting target recipe: %w", err)
+			}
+			if recipe == nil {
+				return nil, notFoundf("recipe not found: %s", t.RecipeID)
+			}
+			if len(recipe.Outputs) == 0 {
+				return nil, fmt.Errorf("recipe %s has no outputs", recipe.ID)
+			}
+			itemID := recipe.Outputs[0].ItemID
+			if other, ok := recipeFor[itemID]; ok {
+				return nil, invalidInputf("recipes %s and %s both target %s; pick one", other, recipe.ID, itemID)
+			}
+			recipeFor[itemID] = recipe.ID
+			roots = append(roots, recipe)
+		}
+		quantities[t.RecipeID] += quantity
+	}
+	for _, root := range roots {
+		targets[root.Outputs[0].ItemID] = quantities[root.ID]
+	}
+
+	allRecipes, err := e.getAllRecipes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("loading all recipes: %w", err)
+	}
+	craftableItems, _, err := discoverCraftables(ctx, selectCraftRecipes(allRecipes), roots, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	sortedBottomUp, err := topologicalSort(craftableItems)
+	if err != nil {
+		return nil, fmt.Errorf("topological sort: %w", err)
+	}
+	sortedTopDown := slices.Clone(sortedBottomUp)
+	slices.Reverse(sortedTopDown)
+
+	demand, craftRuns, byproducts := propagateDemand(sortedTopDown, craftableItems, targets, false)
+	resp := &crafting.ShoppingListResponse{
+		Targets:        make([]crafting.ShoppingListTargetResult, 0, len(roots)),
+		RawMaterials:   rawMaterialsFor(demand, craftableItems),
+		Intermediates:  bomIntermediates(craftableItems, demand, craftRuns, targets),
+		CraftSteps:     bomCraftSteps(sortedBottomUp, craftableItems, craftRuns, nil),
+		TotalCraftTime: totalCraftTime(craftableItems, craftRuns),
+		Byproducts:     bomByproducts(byproducts),
+	}
+
+	for _, root := range roots {
+		itemID := root.Outputs[0].ItemID
+		alone := map[string]int{itemID: targets[itemID]}
+		targetDemand, targetRuns, _ := propagateDemand(sortedTopDown, craftableItems, alone, false)
+		resp.Targets = append(resp.Targets, crafting.ShoppingListTargetResult{
+			RecipeID:       root.ID,
+			RecipeName:     root.Name,
+			OutputItemID:   itemID,
+			Quantity:       targets[itemID],
+			RawMaterials:   rawMaterialsFor(targetDemand, craftableItems),
+			TotalCraftTime: totalCraftTime(craftableItems, targetRuns),
+		})
+	}
+
+	// Resolve display names, falling back to IDs for uncatalogued items
+	var nameIDs []string
+	for _, t := range resp.Targets {
+		nameIDs = append(nameIDs, t.OutputItemID)
+		for _, raw := range t.RawMaterials {
+			nameIDs = append(nameIDs, raw.ItemID)
+		}
+	}
+	for _, list := range [][]crafting.BOMItem{resp.RawMaterials, resp.Byproducts} {
+		for _, item := range list {
+			nameIDs = append(nameIDs, item.ItemID)
+		}
+	}
+	for _, inter := range resp.Intermediates {
+		nameIDs = append(nameIDs, inter.ItemID)
+	}
+	names, err := e.itemNames(ctx, nameIDs)
+	if err != nil {
+		return nil, fmt.Errorf("resolving item names: %w", err)
+	}
+	nameItems := func(items []crafting.BOMItem) {
+		for i := range items {
+			items[i].ItemName = names[items[i].ItemID]
+		}
+	}
+	for i := range resp.Targets {
+		resp.Targets[i].OutputItemName = names[resp.Targets[i].OutputItemID]
+		nameItems(resp.Targets[i].RawMaterials)
+	}
+	nameItems(resp.RawMaterials)
+	nameItems(resp.Byproducts)
+	for i := range resp.Intermediates {
+		resp.Intermediates[i].ItemName = names[resp.Intermediates[i].ItemID]
+	}
+
+	return resp, nil
+}
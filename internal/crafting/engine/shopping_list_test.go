@@ -0,0 +1,120 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
+)
+
+func TestShoppingList(t *testing.T) {
+	ctx := context.Background()
+	engine := testEngine(t)
+
+	// One smelt run makes two plates, enough for a hull and a frame together.
+	recipes := []crafting.Recipe{
+		{
+			ID: "smelt_plate", Name: "Smelt Plate", CraftingTime: 10,
+			Inputs:  []crafting.RecipeInput{{ItemID: "ore", Quantity: 3}},
+			Outputs: []crafting.RecipeOutput{{ItemID: "plate", Quantity: 2}},
+		},
+		{
+			ID: "build_hull", Name: "Build Hull", CraftingTime: 30,
+			Inputs: []crafting.RecipeInput{
+				{ItemID: "plate", Quantity: 1},
+				{ItemID: "rivet", Quantity: 4},
+			},
+			Outputs: []crafting.RecipeOutput{{ItemID: "hull", Quantity: 1}},
+		},
+		{
+			ID: "build_frame", Name: "Build Frame", CraftingTime: 20,
+			Inputs:  []crafting.RecipeInput{{ItemID: "plate", Quantity: 1}},
+			Outputs: []crafting.RecipeOutput{{ItemID: "frame", Quantity: 1}},
+		},
+		{
+			ID: "cast_hull", Name: "Cast Hull", CraftingTime: 90,
+			Inputs:  []crafting.RecipeInput{{ItemID: "ore", Quantity: 20}},
+			Outputs: []crafting.RecipeOutput{{ItemID: "hull", Quantity: 1}},
+		},
+	}
+	if err := engine.recipes.BulkInsertRecipes(ctx, recipes); err != nil {
+		t.Fatalf("BulkInsertRecipes failed: %v", err)
+	}
+
+	resp, err := engine.ShoppingList(ctx, crafting.ShoppingListRequest{
+		Targets: []crafting.ShoppingListTarget{
+			{RecipeID: "build_hull", Quantity: 1},
+			{RecipeID: "build_frame"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("ShoppingList failed: %v", err)
+	}
+
+	// Combined, the shared smelt runs once; alone, each target pays for a run.
+	wantRaw := []crafting.BOMItem{
+		{ItemID: "ore", ItemName: "ore", Quantity: 3},
+		{ItemID: "rivet", ItemName: "rivet", Quantity: 4},
+	}
+	if !reflect.DeepEqual(resp.RawMaterials, wantRaw) {
+		t.Errorf("combined raw materials:\n got %+v\nwant %+v", resp.RawMaterials, wantRaw)
+	}
+	if resp.TotalCraftTime != 60 {
+		t.Errorf("expected combined craft time 60, got %d", resp.TotalCraftTime)
+	}
+	if len(resp.Intermediates) != 1 || resp.Intermediates[0].CraftRuns != 1 || resp.Intermediates[0].TotalNeeded != 2 {
+		t.Errorf("expected one smelt run for two plates, got %+v", resp.Intermediates)
+	}
+	if len(resp.CraftSteps) != 3 || resp.CraftSteps[0].RecipeID != "smelt_plate" {
+		t.Errorf("expected smelting first of three steps, got %+v", resp.CraftSteps)
+	}
+
+	if len(resp.Targets) != 2 {
+		t.Fatalf("expected 2 target results, got %+v", resp.Targets)
+	}
+	hull, frame := resp.Targets[0], resp.Targets[1]
+	if hull.RecipeID != "build_hull" || hull.OutputItemID != "hull" || hull.TotalCraftTime != 40 {
+		t.Errorf("unexpected hull result: %+v", hull)
+	}
+	if frame.Quantity != 1 || !reflect.DeepEqual(frame.RawMaterials, []crafting.BOMItem{{ItemID: "ore", ItemName: "ore", Quantity: 3}}) {
+		t.Errorf("expected a frame alone to need 3 ore, got %+v", frame)
+	}
+
+	t.Run("merges repeated recipes", func(t *testing.T) {
+		resp, err := engine.ShoppingList(ctx, crafting.ShoppingListRequest{
+			Targets: []crafting.ShoppingListTarget{
+				{RecipeID: "build_frame", Quantity: 2},
+				{RecipeID: "build_frame", Quantity: 1},
+			},
+		})
+		if err != nil {
+			t.Fatalf("ShoppingList failed: %v", err)
+		}
+		if len(resp.Targets) != 1 || resp.Targets[0].Quantity != 3 {
+			t.Errorf("expected one target for 3 frames, got %+v", resp.Targets)
+		}
+		if len(resp.RawMaterials) != 1 || resp.RawMaterials[0].Quantity != 6 {
+			t.Errorf("expected 6 ore for two smelt runs, got %+v", resp.RawMaterials)
+		}
+	})
+
+	t.Run("rejects invalid targets", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			targets []crafting.ShoppingListTarget
+			want    error
+		}{
+			{"empty", nil, ErrInvalidInput},
+			{"missing recipe", []crafting.ShoppingListTarget{{RecipeID: "nope"}}, ErrNotFound},
+			{"two recipes for one item", []crafting.ShoppingListTarget{{RecipeID: "build_hull"}, {RecipeID: "cast_hull"}}, ErrInvalidInput},
+		}
+		for _, tt := range tests {
+			_, err := engine.ShoppingList(ctx, crafting.ShoppingListRequest{Targets: tt.targets})
+			if !errors.Is(err, tt.want) {
+				t.Errorf("%s: expected %v, got %v", tt.name, tt.want, err)
+			}
+		}
+	})
+}
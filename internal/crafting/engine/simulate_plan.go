@@ -0,0 +1,140 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
+)
+
+// maxPlanSteps caps how many steps one simulate_plan may run.
+const maxPlanSteps = 200
+
+// SimulatePlan executes the simulate_plan tool logic. It carries out each
+// step against the inventory in turn, so outputs of earlier steps can feed
+// later ones, and stops at the first step whose inputs are not all held.
+// Every craft is assumed to succeed. An input is taken from the listed item
+// first, then from its alternatives in order.
+func (e *Engine) SimulatePlan(ctx context.Context, req crafting.SimulatePlanRequest) (*crafting.SimulatePlanResponse, error) {
+	if len(req.Steps) == 0 {
+		return nil, invalidInputf("steps is required")
+	}
+	if len(req.Steps) > maxPlanSteps {
+		return nil, invalidInputf("at most %d steps per plan, got %d", maxPlanSteps, len(req.Steps))
+	}
+
+	// Load every recipe up front so an unknown ID fails the call rather
+	// than the simulation
+	recipes := make([]*crafting.Recipe, len(req.Steps))
+	for i, step := range req.Steps {
+		if step.RecipeID == "" {
+			return nil, invalidInputf("step %d needs a recipe_id", i+1)
+		}
+		recipe, err := e.getRecipe(ctx, step.RecipeID)
+		if err != nil {
+			return nil, fmt.Errorf("getting recipe: %w", err)
+		}
+		if recipe == nil {
+			return nil, notFoundf("recipe not found: %s", step.RecipeID)
+		}
+		recipes[i] = recipe
+	}
+
+	inventory := make(map[string]int, len(req.Components))
+	for _, c := range req.Components {
+		if c.Quantity > 0 {
+			inventory[c.ID] += c.Quantity
+		}
+	}
+
+	resp := &crafting.SimulatePlanResponse{
+		Executable: true,
+		Steps:      []crafting.PlanStepResult{},
+	}
+	for i, step := range req.Steps {
+		runs := step.Runs
+		if runs <= 0 {
+			runs = 1
+		}
+		recipe := recipes[i]
+
+		consumed, missing := planStepInputs(recipe, runs, inventory)
+		if len(missing) > 0 {
+			resp.Executable = false
+			resp.FailedStep = &crafting.PlanStepFailure{
+				Step:     i + 1,
+				RecipeID: recipe.ID,
+				Runs:     runs,
+				Missing:  missing,
+			}
+			break
+		}
+
+		result := crafting.PlanStepResult{
+			Step:     i + 1,
+			RecipeID: recipe.ID,
+			Runs:     runs,
+			Consumed: consumed,
+			Produced: make([]crafting.Component, 0, len(recipe.Outputs)),
+		}
+		for _, c := range consumed {
+			inventory[c.ItemID] -= c.Quantity
+		}
+		for _, out := range recipe.Outputs {
+			if out.Quantity <= 0 {
+				continue
+			}
+			inventory[out.ItemID] += out.Quantity * runs
+			result.Produced = append(result.Produced, crafting.Component{ID: out.ItemID, Quantity: out.Quantity * runs})
+		}
+		resp.Steps = append(resp.Steps, result)
+	}
+
+	resp.EndingInventory = make([]crafting.Component, 0, len(inventory))
+	for id, qty := range inventory {
+		if qty > 0 {
+			resp.EndingInventory = append(resp.EndingInventory, crafting.Component{ID: id, Quantity: qty})
+		}
+	}
+	sort.Slice(resp.EndingInventory, func(i, j int) bool {
+		return resp.EndingInventory[i].ID < resp.EndingInventory[j].ID
+	})
+
+	return resp, nil
+}
+
+// planStepInputs works out what runs of recipe would take from inventory,
+// without changing it. Each input is drawn from the listed item, then from
+// its alternatives in order. missing holds the shortfall of every input
+// that cannot be covered; when it is empty consumed is what to deduct.
+func planStepInputs(recipe *crafting.Recipe, runs int, inventory map[string]int) (consumed, missing []crafting.RecipeInput) {
+	// Inputs of the same step can share an item, so draw from a scratch tally
+	taken := make(map[string]int)
+	for _, inp := range recipe.Inputs {
+		if inp.Quantity <= 0 {
+			continue
+		}
+		need := inp.Quantity * runs
+		for _, itemID := range append([]string{inp.ItemID}, inp.Alternatives...) {
+			take := min(need, inventory[itemID]-taken[itemID])
+			if take <= 0 {
+				continue
+			}
+			taken[itemID] += take
+			consumed = append(consumed, crafting.RecipeInput{ItemID: itemID, Quantity: take})
+			need -= take
+			if need == 0 {
+				break
+			}
+		}
+		if need > 0 {
+			missing = append(missing, crafting.RecipeInput{
+				ItemID:       inp.ItemID,
+				Quantity:     need,
+				Alternatives: inp.Alternatives,
+			})
+		}
+	}
+	return consumed, missing
+}
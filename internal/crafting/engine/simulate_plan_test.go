@@ -0,0 +1,121 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
+)
+
+func TestSimulatePlan(t *testing.T) {
+	ctx := context.Background()
+	engine := testEngine(t)
+
+	recipes := []crafting.Recipe{
+		{
+			ID: "smelt_plate", Name: "Smelt Plate",
+			Inputs:  []crafting.RecipeInput{{ItemID: "ore", Quantity: 3}},
+			Outputs: []crafting.RecipeOutput{{ItemID: "plate", Quantity: 2}},
+		},
+		{
+			ID: "build_hull", Name: "Build Hull",
+			Inputs: []crafting.RecipeInput{
+				{ItemID: "plate", Quantity: 3},
+				{ItemID: "rivet", Quantity: 4, Alternatives: []string{"bolt"}},
+			},
+			Outputs: []crafting.RecipeOutput{{ItemID: "hull", Quantity: 1}},
+		},
+	}
+	if err := engine.recipes.BulkInsertRecipes(ctx, recipes); err != nil {
+		t.Fatalf("BulkInsertRecipes failed: %v", err)
+	}
+
+	inventory := []crafting.Component{
+		{ID: "ore", Quantity: 7},
+		{ID: "rivet", Quantity: 3},
+		{ID: "bolt", Quantity: 2},
+	}
+
+	t.Run("executable", func(t *testing.T) {
+		// Plates from the first step feed the hull; the rivet shortfall is
+		// made up with a bolt.
+		resp, err := engine.SimulatePlan(ctx, crafting.SimulatePlanRequest{
+			Components: inventory,
+			Steps: []crafting.PlanStep{
+				{RecipeID: "smelt_plate", Runs: 2},
+				{RecipeID: "build_hull"},
+			},
+		})
+		if err != nil {
+			t.Fatalf("SimulatePlan failed: %v", err)
+		}
+		if !resp.Executable || resp.FailedStep != nil || len(resp.Steps) != 2 {
+			t.Fatalf("expected both steps to run, got %+v", resp)
+		}
+		wantConsumed := []crafting.RecipeInput{
+			{ItemID: "plate", Quantity: 3},
+			{ItemID: "rivet", Quantity: 3},
+			{ItemID: "bolt", Quantity: 1},
+		}
+		if !reflect.DeepEqual(resp.Steps[1].Consumed, wantConsumed) {
+			t.Errorf("hull consumed %+v, want %+v", resp.Steps[1].Consumed, wantConsumed)
+		}
+		wantEnd := []crafting.Component{
+			{ID: "bolt", Quantity: 1},
+			{ID: "hull", Quantity: 1},
+			{ID: "ore", Quantity: 1},
+			{ID: "plate", Quantity: 1},
+		}
+		if !reflect.DeepEqual(resp.EndingInventory, wantEnd) {
+			t.Errorf("ending inventory %+v, want %+v", resp.EndingInventory, wantEnd)
+		}
+	})
+
+	t.Run("first failing step", func(t *testing.T) {
+		// One smelt run leaves only two plates for the hull.
+		resp, err := engine.SimulatePlan(ctx, crafting.SimulatePlanRequest{
+			Components: inventory,
+			Steps: []crafting.PlanStep{
+				{RecipeID: "smelt_plate"},
+				{RecipeID: "build_hull"},
+				{RecipeID: "smelt_plate"},
+			},
+		})
+		if err != nil {
+			t.Fatalf("SimulatePlan failed: %v", err)
+		}
+		if resp.Executable || len(resp.Steps) != 1 {
+			t.Fatalf("expected the plan to stop after one step, got %+v", resp)
+		}
+		want := &crafting.PlanStepFailure{
+			Step: 2, RecipeID: "build_hull", Runs: 1,
+			Missing: []crafting.RecipeInput{{ItemID: "plate", Quantity: 1}},
+		}
+		if !reflect.DeepEqual(resp.FailedStep, want) {
+			t.Errorf("failed step %+v, want %+v", resp.FailedStep, want)
+		}
+		wantEnd := []crafting.Component{
+			{ID: "bolt", Quantity: 2},
+			{ID: "ore", Quantity: 4},
+			{ID: "plate", Quantity: 2},
+			{ID: "rivet", Quantity: 3},
+		}
+		if !reflect.DeepEqual(resp.EndingInventory, wantEnd) {
+			t.Errorf("ending inventory %+v, want %+v", resp.EndingInventory, wantEnd)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		if _, err := engine.SimulatePlan(ctx, crafting.SimulatePlanRequest{}); !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("no steps: expected ErrInvalidInput, got %v", err)
+		}
+		_, err := engine.SimulatePlan(ctx, crafting.SimulatePlanRequest{
+			Steps: []crafting.PlanStep{{RecipeID: "no_such_recipe"}},
+		})
+		if !errors.Is(err, ErrNotFound) {
+			t.Errorf("unknown recipe: expected ErrNotFound, got %v", err)
+		}
+	})
+}
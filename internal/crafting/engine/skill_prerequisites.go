@@ -0,0 +1,131 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
+)
+
+// SkillPrerequisites executes the skill_prerequisites tool logic.
+// It walks the prerequisite graph below the target skill and returns every
+// skill that must be trained first, ordered so that each skill appears after
+// its own prerequisites.
+func (e *Engine) SkillPrerequisites(ctx context.Context, req crafting.SkillPrerequisitesRequest) (*crafting.SkillPrerequisitesResponse, error) {
+	target, err := e.skills.GetSkill(ctx, req.SkillID)
+	if err != nil {
+		return nil, fmt.Errorf("getting skill: %w", err)
+	}
+	if target == nil {
+		return nil, notFoundf("skill not found: %s", req.SkillID)
+	}
+
+	// required tracks the highest level demanded of each prerequisite skill
+	// across all paths; order records the post-order (train-first) sequence.
+	required := make(map[string]int)
+	names := map[string]string{target.ID: target.Name}
+	var order []string
+
+	visited := make(map[string]bool)
+	onPath := make(map[string]bool)
+	var path []string
+
+	var visit func(skill *crafting.Skill) error
+	visit = func(skill *crafting.Skill) error {
+		if onPath[skill.ID] {
+			cycle := append(append([]string{}, path[slices.Index(path, skill.ID):]...), skill.ID)
+			return invalidInputf("prerequisite cycle detected: %s", strings.Join(cycle, " -> "))
+		}
+		if visited[skill.ID] {
+			return nil
+		}
+
+		onPath[skill.ID] = true
+		path = append(path, skill.ID)
+
+		prereqs := make([]crafting.SkillRequirement, len(skill.Prerequisites))
+		copy(prereqs, skill.Prerequisites)
+		sort.Slice(prereqs, func(i, j int) bool {
+			return prereqs[i].SkillID < prereqs[j].SkillID
+		})
+
+		for _, p := range prereqs {
+			if p.LevelRequired > required[p.SkillID] {
+				required[p.SkillID] = p.LevelRequired
+			}
+
+			prereq, err := e.skills.GetSkill(ctx, p.SkillID)
+			if err != nil {
+				return fmt.Errorf("getting prerequisite skill %s: %w", p.SkillID, err)
+			}
+			if prereq == nil {
+				// Unknown skill: report it, but there is nothing further to walk.
+				if !visited[p.SkillID] {
+					e.logger.Warn("prerequisite skill not found; using its ID as the name",
+						"skill_id", p.SkillID, "required_by", skill.ID)
+					visited[p.SkillID] = true
+					names[p.SkillID] = p.SkillID
+					order = append(order, p.SkillID)
+				}
+				continue
+			}
+
+			names[prereq.ID] = prereq.Name
+			if err := visit(prereq); err != nil {
+				return err
+			}
+		}
+
+		path = path[:len(path)-1]
+		onPath[skill.ID] = false
+		visited[skill.ID] = true
+		if skill.ID != target.ID {
+			order = append(order, skill.ID)
+		}
+		return nil
+	}
+
+	if err := visit(target); err != nil {
+		return nil, err
+	}
+
+	resp := &crafting.SkillPrerequisitesResponse{
+		SkillID:       target.ID,
+		SkillName:     target.Name,
+		Trainable:     true,
+		Prerequisites: make([]crafting.SkillPrerequisiteStep, 0, len(order)),
+	}
+
+	for _, skillID := range order {
+		step := crafting.SkillPrerequisiteStep{
+			SkillID:       skillID,
+			SkillName:     names[skillID],
+			CurrentLevel:  req.Skills[skillID],
+			RequiredLevel: required[skillID],
+		}
+		step.Met = step.CurrentLevel >= step.RequiredLevel
+
+		if !step.Met {
+			resp.Trainable = false
+
+			xp, ok, err := e.skills.GetXPBetweenLevels(ctx, skillID, step.CurrentLevel, step.RequiredLevel)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				step.XPRemaining = &xp
+				resp.TotalXPRemaining += xp
+			} else {
+				e.logger.Warn("skill XP threshold missing; omitting XP remaining",
+					"skill_id", skillID, "from_level", step.CurrentLevel, "to_level", step.RequiredLevel)
+			}
+		}
+
+		resp.Prerequisites = append(resp.Prerequisites, step)
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,119 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
+)
+
+func TestSkillPrerequisites_Chain(t *testing.T) {
+	ctx := context.Background()
+	engine := testEngine(t)
+
+	thresholds := []int{100, 300, 600}
+	skills := []crafting.Skill{
+		{ID: "mining", Name: "Mining", Category: "Industry", MaxLevel: 3, XPThresholds: thresholds},
+		{
+			ID: "refining", Name: "Refining", Category: "Industry", MaxLevel: 3, XPThresholds: thresholds,
+			Prerequisites: []crafting.SkillRequirement{{SkillID: "mining", LevelRequired: 2}},
+		},
+		{
+			ID: "alloys", Name: "Alloys", Category: "Industry", MaxLevel: 3, XPThresholds: thresholds,
+			Prerequisites: []crafting.SkillRequirement{
+				{SkillID: "refining", LevelRequired: 1},
+				{SkillID: "mining", LevelRequired: 3},
+			},
+		},
+	}
+	if err := engine.skills.BulkInsertSkills(ctx, skills); err != nil {
+		t.Fatalf("BulkInsertSkills failed: %v", err)
+	}
+
+	resp, err := engine.SkillPrerequisites(ctx, crafting.SkillPrerequisitesRequest{
+		SkillID: "alloys",
+		Skills:  crafting.AgentSkillState{"mining": 1},
+	})
+	if err != nil {
+		t.Fatalf("SkillPrerequisites failed: %v", err)
+	}
+
+	if resp.Trainable {
+		t.Error("expected alloys to not be trainable yet")
+	}
+	if len(resp.Prerequisites) != 2 {
+		t.Fatalf("expected 2 prerequisites, got %d: %+v", len(resp.Prerequisites), resp.Prerequisites)
+	}
+
+	// Mining must come before refining, and carries the highest level demanded.
+	mining, refining := resp.Prerequisites[0], resp.Prerequisites[1]
+	if mining.SkillID != "mining" || refining.SkillID != "refining" {
+		t.Fatalf("unexpected training order: %s, %s", mining.SkillID, refining.SkillID)
+	}
+	if mining.RequiredLevel != 3 || mining.CurrentLevel != 1 || mining.XPRemaining == nil || *mining.XPRemaining != 500 {
+		t.Errorf("unexpected mining step: %+v", mining)
+	}
+	if refining.RequiredLevel != 1 || refining.XPRemaining == nil || *refining.XPRemaining != 100 {
+		t.Errorf("unexpected refining step: %+v", refining)
+	}
+	if resp.TotalXPRemaining != 600 {
+		t.Errorf("expected 600 total XP remaining, got %d", resp.TotalXPRemaining)
+	}
+}
+
+func TestSkillPrerequisites_MissingThresholds(t *testing.T) {
+	ctx := context.Background()
+	engine := testEngine(t)
+
+	skills := []crafting.Skill{
+		{ID: "mining", Name: "Mining", Category: "Industry", MaxLevel: 3},
+		{
+			ID: "refining", Name: "Refining", Category: "Industry", MaxLevel: 3, XPThresholds: []int{100, 300, 600},
+			Prerequisites: []crafting.SkillRequirement{{SkillID: "mining", LevelRequired: 2}},
+		},
+	}
+	if err := engine.skills.BulkInsertSkills(ctx, skills); err != nil {
+		t.Fatalf("BulkInsertSkills failed: %v", err)
+	}
+
+	resp, err := engine.SkillPrerequisites(ctx, crafting.SkillPrerequisitesRequest{SkillID: "refining"})
+	if err != nil {
+		t.Fatalf("SkillPrerequisites failed: %v", err)
+	}
+
+	if len(resp.Prerequisites) != 1 {
+		t.Fatalf("expected 1 prerequisite, got %d: %+v", len(resp.Prerequisites), resp.Prerequisites)
+	}
+	if xp := resp.Prerequisites[0].XPRemaining; xp != nil {
+		t.Errorf("expected no XP remaining without thresholds, got %d", *xp)
+	}
+	if resp.TotalXPRemaining != 0 {
+		t.Errorf("expected 0 total XP remaining, got %d", resp.TotalXPRemaining)
+	}
+}
+
+func TestSkillPrerequisites_Cycle(t *testing.T) {
+	ctx := context.Background()
+	engine := testEngine(t)
+
+	skills := []crafting.Skill{
+		{
+			ID: "a", Name: "A", Category: "Test", MaxLevel: 1,
+			Prerequisites: []crafting.SkillRequirement{{SkillID: "b", LevelRequired: 1}},
+		},
+		{
+			ID: "b", Name: "B", Category: "Test", MaxLevel: 1,
+			Prerequisites: []crafting.SkillRequirement{{SkillID: "a", LevelRequired: 1}},
+		},
+	}
+	if err := engine.skills.BulkInsertSkills(ctx, skills); err != nil {
+		t.Fatalf("BulkInsertSkills failed: %v", err)
+	}
+
+	_, err := engine.SkillPrerequisites(ctx, crafting.SkillPrerequisitesRequest{SkillID: "a"})
+	if !errors.Is(err, ErrInvalidInput) || !strings.Contains(err.Error(), "a -> b -> a") {
+		t.Errorf("expected cycle error, got %v", err)
+	}
+}
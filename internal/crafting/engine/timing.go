@@ -0,0 +1,46 @@
+package engine
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// queryTimings accumulates the time a query spends in each phase.
+type queryTimings struct {
+	candidates time.Duration // finding candidate recipe IDs
+	recipes    time.Duration // loading recipes
+	matching   time.Duration // matching inputs against the inventory
+	profit     time.Duration // profit analysis
+	sorting    time.Duration // sorting and limiting results
+}
+
+// since adds the time elapsed since start to *d and returns the current
+// time, so consecutive phases can be timed from a single clock reading.
+func since(d *time.Duration, start time.Time) time.Time {
+	now := time.Now()
+	*d += now.Sub(start)
+	return now
+}
+
+// logQueryTimings logs the phase breakdown of a query at debug level, or as
+// a warning when total exceeds the slow query threshold.
+func (e *Engine) logQueryTimings(ctx context.Context, query string, total time.Duration, t queryTimings, attrs ...slog.Attr) {
+	level, msg := slog.LevelDebug, "query timings"
+	if e.slowQuery > 0 && total >= e.slowQuery {
+		level, msg = slog.LevelWarn, "slow query"
+	}
+	if !e.logger.Enabled(ctx, level) {
+		return
+	}
+	attrs = append([]slog.Attr{
+		slog.String("query", query),
+		slog.Duration("total", total),
+		slog.Duration("candidates", t.candidates),
+		slog.Duration("recipes", t.recipes),
+		slog.Duration("matching", t.matching),
+		slog.Duration("profit", t.profit),
+		slog.Duration("sorting", t.sorting),
+	}, attrs...)
+	e.logger.LogAttrs(ctx, level, msg, attrs...)
+}
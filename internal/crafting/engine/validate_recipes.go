@@ -0,0 +1,190 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
+)
+
+// recipeEdge links an output item to one of its inputs via a recipe.
+type recipeEdge struct {
+	recipeID string
+	inputID  string
+}
+
+// ValidateRecipes executes the validate_recipes tool logic.
+// It builds the output -> recipe -> input graph for every recipe, counting
+// alternative inputs as inputs, and reports every elementary dependency cycle
+// as the ordered list of items and recipes forming the loop, plus recipes
+// that directly consume their own output.
+func (e *Engine) ValidateRecipes(ctx context.Context) (*crafting.ValidateRecipesResponse, error) {
+	allRecipes, err := e.getAllRecipes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("loading all recipes: %w", err)
+	}
+
+	resp := &crafting.ValidateRecipesResponse{
+		RecipesChecked:         len(allRecipes),
+		Cycles:                 []crafting.RecipeCycle{},
+		SelfReferencingRecipes: []crafting.SelfReferencingRecipe{},
+	}
+
+	// Every recipe that produces an item contributes edges from that item to
+	// each of the recipe's inputs and their alternatives. Self edges are
+	// reported separately.
+	edges := make(map[string][]recipeEdge)
+	for _, recipe := range allRecipes {
+		for _, out := range recipe.Outputs {
+			seen := make(map[string]bool)
+			for _, inp := range recipe.Inputs {
+				for _, inputID := range append([]string{inp.ItemID}, inp.Alternatives...) {
+					if seen[inputID] {
+						continue
+					}
+					seen[inputID] = true
+					if inputID == out.ItemID {
+						resp.SelfReferencingRecipes = append(resp.SelfReferencingRecipes, crafting.SelfReferencingRecipe{
+							RecipeID: recipe.ID,
+							ItemID:   out.ItemID,
+						})
+						continue
+					}
+					edges[out.ItemID] = append(edges[out.ItemID], recipeEdge{recipeID: recipe.ID, inputID: inputID})
+				}
+			}
+		}
+	}
+
+	items := make([]string, 0, len(edges))
+	for itemID, list := range edges {
+		items = append(items, itemID)
+		sort.Slice(list, func(i, j int) bool {
+			if list[i].recipeID != list[j].recipeID {
+				return list[i].recipeID < list[j].recipeID
+			}
+			return list[i].inputID < list[j].inputID
+		})
+	}
+	sort.Strings(items)
+
+	// Only items in the same strongly connected component can share a
+	// cycle, so enumerate the cycles of each component on its own.
+	for _, component := range stronglyConnectedItems(items, edges) {
+		if len(component) < 2 {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		resp.Cycles = append(resp.Cycles, elementaryCycles(component, edges)...)
+	}
+
+	sort.Slice(resp.SelfReferencingRecipes, func(i, j int) bool {
+		return resp.SelfReferencingRecipes[i].RecipeID < resp.SelfReferencingRecipes[j].RecipeID
+	})
+
+	return resp, nil
+}
+
+// stronglyConnectedItems splits the item graph into strongly connected
+// components using Tarjan's algorithm. Each component is sorted, and the
+// components are ordered by their smallest item.
+func stronglyConnectedItems(items []string, edges map[string][]recipeEdge) [][]string {
+	index := make(map[string]int)
+	lowlink := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	var components [][]string
+	next := 0
+
+	var visit func(itemID string)
+	visit = func(itemID string) {
+		index[itemID] = next
+		lowlink[itemID] = next
+		next++
+		stack = append(stack, itemID)
+		onStack[itemID] = true
+
+		for _, edge := range edges[itemID] {
+			if _, seen := index[edge.inputID]; !seen {
+				visit(edge.inputID)
+				lowlink[itemID] = min(lowlink[itemID], lowlink[edge.inputID])
+			} else if onStack[edge.inputID] {
+				lowlink[itemID] = min(lowlink[itemID], index[edge.inputID])
+			}
+		}
+
+		if lowlink[itemID] != index[itemID] {
+			return
+		}
+		var component []string
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+			component = append(component, top)
+			if top == itemID {
+				break
+			}
+		}
+		sort.Strings(component)
+		components = append(components, component)
+	}
+
+	for _, itemID := range items {
+		if _, seen := index[itemID]; !seen {
+			visit(itemID)
+		}
+	}
+
+	sort.Slice(components, func(i, j int) bool {
+		return components[i][0] < components[j][0]
+	})
+	return components
+}
+
+// elementaryCycles lists every elementary cycle within a sorted strongly
+// connected component. Each cycle is rooted at its smallest item and found
+// by a DFS from that root through larger items only, so it is reported
+// exactly once. Recipes producing the same item from the same input give
+// distinct cycles.
+func elementaryCycles(component []string, edges map[string][]recipeEdge) []crafting.RecipeCycle {
+	rank := make(map[string]int, len(component))
+	for i, itemID := range component {
+		rank[itemID] = i
+	}
+
+	var cycles []crafting.RecipeCycle
+	for r, root := range component {
+		onPath := map[string]bool{root: true}
+		pathItems := []string{root}
+		var pathRecipes []string
+
+		var dfs func(itemID string)
+		dfs = func(itemID string) {
+			for _, edge := range edges[itemID] {
+				i, inComponent := rank[edge.inputID]
+				switch {
+				case edge.inputID == root:
+					cycles = append(cycles, crafting.RecipeCycle{
+						ItemIDs:   append(slices.Clone(pathItems), root),
+						RecipeIDs: append(slices.Clone(pathRecipes), edge.recipeID),
+					})
+				case inComponent && i > r && !onPath[edge.inputID]:
+					onPath[edge.inputID] = true
+					pathItems = append(pathItems, edge.inputID)
+					pathRecipes = append(pathRecipes, edge.recipeID)
+					dfs(edge.inputID)
+					pathItems = pathItems[:len(pathItems)-1]
+					pathRecipes = pathRecipes[:len(pathRecipes)-1]
+					onPath[edge.inputID] = false
+				}
+			}
+		}
+		dfs(root)
+	}
+	return cycles
+}
@@ -0,0 +1,117 @@
+package engine
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
+)
+
+func TestValidateRecipes(t *testing.T) {
+	ctx := context.Background()
+	engine := testEngine(t)
+
+	recipes := []crafting.Recipe{
+		{
+			ID: "wrap_gas", Name: "Wrap Gas",
+			Inputs:  []crafting.RecipeInput{{ItemID: "gas", Quantity: 1}},
+			Outputs: []crafting.RecipeOutput{{ItemID: "contained_gas", Quantity: 1}},
+		},
+		{
+			ID: "unwrap_gas", Name: "Unwrap Gas",
+			Inputs:  []crafting.RecipeInput{{ItemID: "contained_gas", Quantity: 1}},
+			Outputs: []crafting.RecipeOutput{{ItemID: "gas", Quantity: 1}},
+		},
+		{
+			ID: "catalyst_loop", Name: "Catalyst Loop",
+			Inputs:  []crafting.RecipeInput{{ItemID: "catalyst", Quantity: 1}, {ItemID: "ore", Quantity: 2}},
+			Outputs: []crafting.RecipeOutput{{ItemID: "catalyst", Quantity: 2}},
+		},
+		{
+			ID: "plate", Name: "Plate",
+			Inputs:  []crafting.RecipeInput{{ItemID: "ore", Quantity: 3}},
+			Outputs: []crafting.RecipeOutput{{ItemID: "plate", Quantity: 1}},
+		},
+	}
+	if err := engine.recipes.BulkInsertRecipes(ctx, recipes); err != nil {
+		t.Fatalf("BulkInsertRecipes failed: %v", err)
+	}
+
+	resp, err := engine.ValidateRecipes(ctx)
+	if err != nil {
+		t.Fatalf("ValidateRecipes failed: %v", err)
+	}
+
+	if resp.RecipesChecked != 4 {
+		t.Errorf("expected 4 recipes checked, got %d", resp.RecipesChecked)
+	}
+
+	if len(resp.Cycles) != 1 {
+		t.Fatalf("expected 1 cycle, got %d: %+v", len(resp.Cycles), resp.Cycles)
+	}
+	wantCycle := crafting.RecipeCycle{
+		ItemIDs:   []string{"contained_gas", "gas", "contained_gas"},
+		RecipeIDs: []string{"wrap_gas", "unwrap_gas"},
+	}
+	if !reflect.DeepEqual(resp.Cycles[0], wantCycle) {
+		t.Errorf("unexpected cycle: got %+v, want %+v", resp.Cycles[0], wantCycle)
+	}
+
+	wantSelf := []crafting.SelfReferencingRecipe{{RecipeID: "catalyst_loop", ItemID: "catalyst"}}
+	if !reflect.DeepEqual(resp.SelfReferencingRecipes, wantSelf) {
+		t.Errorf("unexpected self-referencing recipes: got %+v, want %+v", resp.SelfReferencingRecipes, wantSelf)
+	}
+}
+
+func TestValidateRecipesSharedNodeCycles(t *testing.T) {
+	ctx := context.Background()
+	engine := testEngine(t)
+
+	recipes := []crafting.Recipe{
+		// a -> b -> a and a -> c -> b -> a share a and b
+		{
+			ID: "make_a", Name: "Make A",
+			Inputs:  []crafting.RecipeInput{{ItemID: "b", Quantity: 1}, {ItemID: "c", Quantity: 1}},
+			Outputs: []crafting.RecipeOutput{{ItemID: "a", Quantity: 1}},
+		},
+		{
+			ID: "make_b", Name: "Make B",
+			Inputs:  []crafting.RecipeInput{{ItemID: "a", Quantity: 1}},
+			Outputs: []crafting.RecipeOutput{{ItemID: "b", Quantity: 1}},
+		},
+		{
+			ID: "make_c", Name: "Make C",
+			Inputs:  []crafting.RecipeInput{{ItemID: "b", Quantity: 1}},
+			Outputs: []crafting.RecipeOutput{{ItemID: "c", Quantity: 1}},
+		},
+		// d -> e -> d closes through an alternative input
+		{
+			ID: "make_d", Name: "Make D",
+			Inputs:  []crafting.RecipeInput{{ItemID: "ore", Quantity: 1, Alternatives: []string{"e"}}},
+			Outputs: []crafting.RecipeOutput{{ItemID: "d", Quantity: 1}},
+		},
+		{
+			ID: "make_e", Name: "Make E",
+			Inputs:  []crafting.RecipeInput{{ItemID: "d", Quantity: 1}},
+			Outputs: []crafting.RecipeOutput{{ItemID: "e", Quantity: 1}},
+		},
+	}
+	if err := engine.recipes.BulkInsertRecipes(ctx, recipes); err != nil {
+		t.Fatalf("BulkInsertRecipes failed: %v", err)
+	}
+
+	resp, err := engine.ValidateRecipes(ctx)
+	if err != nil {
+		t.Fatalf("ValidateRecipes failed: %v", err)
+	}
+
+	want := []crafting.RecipeCycle{
+		{ItemIDs: []string{"a", "b", "a"}, RecipeIDs: []string{"make_a", "make_b"}},
+		{ItemIDs: []string{"a", "c", "b", "a"}, RecipeIDs: []string{"make_a", "make_c", "make_b"}},
+		{ItemIDs: []string{"d", "e", "d"}, RecipeIDs: []string{"make_d", "make_e"}},
+	}
+	if !reflect.DeepEqual(resp.Cycles, want) {
+		t.Errorf("unexpected cycles:\ngot  %+v\nwant %+v", resp.Cycles, want)
+	}
+}
@@ -0,0 +1,97 @@
+package engine
+
+import (
+	"context"
+	"strings"
+
+	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
+)
+
+// wildcardSuffix ends a component ID that stands for every item ID
+// beginning with the rest of it, e.g. "ore_iron_*".
+const wildcardSuffix = "*"
+
+// componentPools records which wildcard component each expanded item came
+// from. The items of one wildcard share its quantity rather than each
+// holding all of it. A nil *componentPools has no pools.
+type componentPools struct {
+	pool     map[string]string // item ID -> wildcard component ID
+	quantity map[string]int    // wildcard component ID -> units held
+}
+
+// of returns the pool itemID belongs to, if any.
+func (p *componentPools) of(itemID string) (string, bool) {
+	if p == nil {
+		return "", false
+	}
+	pool, ok := p.pool[itemID]
+	return pool, ok
+}
+
+// expandWildcards replaces each wildcard component with the recipe input
+// items its prefix matches, and returns the pools they draw on. Each item
+// is listed in the wildcard's quantity, so it can be matched on its own,
+// and belongs to that wildcard's pool so that inputSupplies can stop a
+// recipe from counting the same units twice. A component listed exactly
+// keeps its own quantity and is not pooled. A wildcard listed more than
+// once holds the sum of its quantities, as repeated inventory entries do.
+// An item matched by several wildcards belongs to the one with the largest
+// quantity. Components without a wildcard are returned as they are.
+func (e *Engine) expandWildcards(ctx context.Context, components []crafting.Component) ([]crafting.Component, *componentPools, error) {
+	expanded := make([]crafting.Component, 0, len(components))
+	exact := make(map[string]bool, len(components))
+	var wildcards []crafting.Component
+	for _, c := range components {
+		prefix, ok := strings.CutSuffix(c.ID, wildcardSuffix)
+		if !ok {
+			if strings.Contains(c.ID, wildcardSuffix) {
+				return nil, nil, invalidInputf("component %q: %s is only allowed at the end of an ID", c.ID, wildcardSuffix)
+			}
+			exact[c.ID] = true
+			expanded = append(expanded, c)
+			continue
+		}
+		if prefix == "" || strings.Contains(prefix, wildcardSuffix) {
+			return nil, nil, invalidInputf("component %q: a wildcard needs a prefix and a single trailing %s", c.ID, wildcardSuffix)
+		}
+		wildcards = append(wildcards, c)
+	}
+	if len(wildcards) == 0 {
+		return expanded, nil, nil
+	}
+
+	pools := &componentPools{
+		pool:     make(map[string]string),
+		quantity: make(map[string]int, len(wildcards)),
+	}
+	var wildcardIDs, order []string
+	for _, w := range wildcards {
+		if _, seen := pools.quantity[w.ID]; !seen {
+			wildcardIDs = append(wildcardIDs, w.ID)
+		}
+		pools.quantity[w.ID] += w.Quantity
+	}
+	for _, wildcardID := range wildcardIDs {
+		itemIDs, err := e.recipes.FindInputItemsByPrefix(ctx, strings.TrimSuffix(wildcardID, wildcardSuffix))
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, id := range itemIDs {
+			if exact[id] {
+				continue
+			}
+			current, ok := pools.pool[id]
+			if !ok {
+				order = append(order, id)
+			}
+			if !ok || pools.quantity[wildcardID] > pools.quantity[current] {
+				pools.pool[id] = wildcardID
+			}
+		}
+	}
+	for _, id := range order {
+		expanded = append(expanded, crafting.Component{ID: id, Quantity: pools.quantity[pools.pool[id]]})
+	}
+
+	return expanded, pools, nil
+}
@@ -0,0 +1,143 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
+)
+
+func TestCraftQuery_PrefixMatching(t *testing.T) {
+	ctx := context.Background()
+	engine := testEngine(t)
+
+	recipes := []crafting.Recipe{
+		{
+			ID: "smelt_t2", Name: "Smelt T2",
+			Inputs:  []crafting.RecipeInput{{ItemID: "ore_iron_t2", Quantity: 4}},
+			Outputs: []crafting.RecipeOutput{{ItemID: "ingot", Quantity: 1}},
+		},
+		{
+			ID: "smelt_t3", Name: "Smelt T3",
+			Inputs:  []crafting.RecipeInput{{ItemID: "ore_iron_t3", Quantity: 2}},
+			Outputs: []crafting.RecipeOutput{{ItemID: "ingot", Quantity: 2}},
+		},
+		{
+			ID: "smelt_copper", Name: "Smelt Copper",
+			Inputs:  []crafting.RecipeInput{{ItemID: "ore_copper", Quantity: 1}},
+			Outputs: []crafting.RecipeOutput{{ItemID: "copper", Quantity: 1}},
+		},
+	}
+	if err := engine.recipes.BulkInsertRecipes(ctx, recipes); err != nil {
+		t.Fatalf("BulkInsertRecipes failed: %v", err)
+	}
+
+	query := func(prefix bool) map[string]int {
+		t.Helper()
+		resp, err := engine.CraftQuery(ctx, crafting.CraftQueryRequest{
+			Components: []crafting.Component{
+				{ID: "ore_iron_*", Quantity: 8},
+				{ID: "ore_iron_t3", Quantity: 2},
+			},
+			PrefixMatching: prefix,
+		})
+		if err != nil {
+			t.Fatalf("CraftQuery failed: %v", err)
+		}
+		got := make(map[string]int)
+		for _, m := range resp.Craftable {
+			got[m.Recipe.ID] = m.CanCraftQuantity
+		}
+		return got
+	}
+
+	// Exact matching by default: only the listed t3 ore counts
+	if got := query(false); len(got) != 1 || got["smelt_t3"] != 1 {
+		t.Errorf("exact matching: got %v, want only smelt_t3 x1", got)
+	}
+
+	// The wildcard covers t2; the exact t3 entry keeps its own quantity
+	got := query(true)
+	if len(got) != 2 || got["smelt_t2"] != 2 || got["smelt_t3"] != 1 {
+		t.Errorf("prefix matching: got %v, want smelt_t2 x2 and smelt_t3 x1", got)
+	}
+
+	for _, id := range []string{"*", "ore_*_t1", "ore_**"} {
+		_, err := engine.CraftQuery(ctx, crafting.CraftQueryRequest{
+			Components:     []crafting.Component{{ID: id, Quantity: 1}},
+			PrefixMatching: true,
+		})
+		if !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("%q: expected ErrInvalidInput, got %v", id, err)
+		}
+	}
+}
+
+func TestCraftQuery_PrefixMatchingSharesPool(t *testing.T) {
+	ctx := context.Background()
+	engine := testEngine(t)
+
+	recipes := []crafting.Recipe{
+		{
+			ID: "alloy", Name: "Alloy",
+			Inputs: []crafting.RecipeInput{
+				{ItemID: "ore_iron_t1", Quantity: 10},
+				{ItemID: "ore_iron_t2", Quantity: 10},
+			},
+			Outputs: []crafting.RecipeOutput{{ItemID: "alloy", Quantity: 1}},
+		},
+	}
+	if err := engine.recipes.BulkInsertRecipes(ctx, recipes); err != nil {
+		t.Fatalf("BulkInsertRecipes failed: %v", err)
+	}
+
+	query := func(quantity int) *crafting.CraftQueryResponse {
+		t.Helper()
+		resp, err := engine.CraftQuery(ctx, crafting.CraftQueryRequest{
+			Components:     []crafting.Component{{ID: "ore_iron_*", Quantity: quantity}},
+			IncludePartial: true,
+			PrefixMatching: true,
+		})
+		if err != nil {
+			t.Fatalf("CraftQuery failed: %v", err)
+		}
+		return resp
+	}
+
+	// 10 units cover one tier, not both
+	resp := query(10)
+	if len(resp.Craftable) != 0 {
+		t.Errorf("10 units: expected nothing craftable, got %+v", resp.Craftable)
+	}
+	if len(resp.PartialComponents) != 1 {
+		t.Fatalf("10 units: expected one partial match, got %+v", resp.PartialComponents)
+	}
+	m := resp.PartialComponents[0]
+	wantMissing := []crafting.RecipeInput{{ItemID: "ore_iron_t2", Quantity: 10}}
+	if !reflect.DeepEqual(m.InputsMissing, wantMissing) || m.MatchRatio != 0.5 || m.QuantityWeightedRatio != 0.5 {
+		t.Errorf("10 units: got missing %+v, ratio %v, weighted %v", m.InputsMissing, m.MatchRatio, m.QuantityWeightedRatio)
+	}
+
+	// 45 units make two alloys, with 5 left over
+	resp = query(45)
+	if len(resp.Craftable) != 1 || resp.Craftable[0].CanCraftQuantity != 2 {
+		t.Errorf("45 units: expected alloy x2, got %+v", resp.Craftable)
+	}
+
+	// A repeated wildcard adds up like repeated inventory entries
+	resp, err := engine.CraftQuery(ctx, crafting.CraftQueryRequest{
+		Components: []crafting.Component{
+			{ID: "ore_iron_*", Quantity: 10},
+			{ID: "ore_iron_*", Quantity: 10},
+		},
+		PrefixMatching: true,
+	})
+	if err != nil {
+		t.Fatalf("CraftQuery failed: %v", err)
+	}
+	if len(resp.Craftable) != 1 || resp.Craftable[0].CanCraftQuantity != 1 {
+		t.Errorf("repeated wildcard: expected alloy x1, got %+v", resp.Craftable)
+	}
+}
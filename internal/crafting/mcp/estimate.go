@@ -0,0 +1,68 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
+)
+
+// estimateTool previews a tool call for tools/call with validate set. The
+// arguments have already been validated against the schema; this only
+// estimates how much of the recipe catalog the call would scan, except that
+// bill_of_materials also checks its recipe exists.
+func (s *Server) estimateTool(ctx context.Context, name string, args json.RawMessage) (any, error) {
+	if _, ok := findTool(name); !ok {
+		return nil, fmt.Errorf("unknown tool: %s", name)
+	}
+
+	var componentIDs []string
+	switch name {
+	case "craft_query":
+		var req crafting.CraftQueryRequest
+		if err := json.Unmarshal(args, &req); err != nil {
+			return nil, err
+		}
+		componentIDs = inventoryIDs(req.Components)
+	case "craft_recommendations":
+		var req crafting.CraftRecommendationsRequest
+		if err := json.Unmarshal(args, &req); err != nil {
+			return nil, err
+		}
+		componentIDs = inventoryIDs(req.Components)
+	case "component_uses":
+		var req crafting.ComponentUsesRequest
+		if err := json.Unmarshal(args, &req); err != nil {
+			return nil, err
+		}
+		componentIDs = []string{req.ItemID}
+	case "bill_of_materials":
+		var req crafting.BillOfMaterialsRequest
+		if err := json.Unmarshal(args, &req); err != nil {
+			return nil, err
+		}
+		est, err := s.engine.EstimateBillOfMaterials(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		est.Tool = name
+		return est, nil
+	}
+
+	est, err := s.engine.EstimateRecipeScan(ctx, componentIDs)
+	if err != nil {
+		return nil, err
+	}
+	est.Tool = name
+	return est, nil
+}
+
+// inventoryIDs returns the item IDs of an inventory list.
+func inventoryIDs(components []crafting.Component) []string {
+	ids := make([]string, 0, len(components))
+	for _, c := range components {
+		ids = append(ids, c.ID)
+	}
+	return ids
+}
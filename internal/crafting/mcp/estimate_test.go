@@ -0,0 +1,96 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
+)
+
+func TestToolsCallValidateOnly(t *testing.T) {
+	ctx := context.Background()
+
+	recipes := []crafting.Recipe{
+		{ID: "plate", Name: "Plate", Inputs: []crafting.RecipeInput{{ItemID: "ore", Quantity: 2}}, Outputs: []crafting.RecipeOutput{{ItemID: "plate", Quantity: 1}}},
+		{ID: "wire", Name: "Wire", Inputs: []crafting.RecipeInput{{ItemID: "ore", Quantity: 1}}, Outputs: []crafting.RecipeOutput{{ItemID: "wire", Quantity: 1}}},
+		{ID: "hull", Name: "Hull", Inputs: []crafting.RecipeInput{{ItemID: "plate", Quantity: 4}}, Outputs: []crafting.RecipeOutput{{ItemID: "hull", Quantity: 1}}},
+	}
+	s := testServer(t, recipes...)
+
+	intPtr := func(n int) *int { return &n }
+	tests := []struct {
+		name    string
+		params  string
+		want    crafting.ToolCallEstimate
+		wantErr string
+	}{
+		{
+			name:   "component driven",
+			params: `{"name":"craft_query","validate":true,"arguments":{"components":[{"id":"ore","quantity":5}]}}`,
+			want:   crafting.ToolCallEstimate{Tool: "craft_query", Valid: true, RecipesTotal: 3, RecipesToScan: 2, EstimatedResults: intPtr(2)},
+		},
+		{
+			name:   "bill of materials tree",
+			params: `{"name":"bill_of_materials","validate":true,"arguments":{"recipe_id":"hull"}}`,
+			want:   crafting.ToolCallEstimate{Tool: "bill_of_materials", Valid: true, RecipesTotal: 3, RecipesToScan: 2, CraftSteps: 2},
+		},
+		{
+			name:   "bill of materials buying intermediates",
+			params: `{"name":"bill_of_materials","validate":true,"arguments":{"recipe_id":"hull","buy_instead":["plate"]}}`,
+			want:   crafting.ToolCallEstimate{Tool: "bill_of_materials", Valid: true, RecipesTotal: 3, RecipesToScan: 1, CraftSteps: 1},
+		},
+		{
+			name:   "bill of materials depth limit",
+			params: `{"name":"bill_of_materials","validate":true,"arguments":{"recipe_id":"hull","max_depth":1}}`,
+			want:   crafting.ToolCallEstimate{Tool: "bill_of_materials", Valid: true, RecipesTotal: 3, RecipesToScan: 1, CraftSteps: 1},
+		},
+		{
+			name:    "unknown recipe",
+			params:  `{"name":"bill_of_materials","validate":true,"arguments":{"recipe_id":"missing"}}`,
+			wantErr: "recipe not found: missing",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := s.handleToolsCall(ctx, json.RawMessage(tt.params))
+			if err != nil {
+				t.Fatalf("handleToolsCall: %v", err)
+			}
+			call := result.(ToolCallResult)
+			if tt.wantErr != "" {
+				if !call.IsError || call.Content[0].Text != tt.wantErr {
+					t.Fatalf("result = %+v, want tool error %q", call, tt.wantErr)
+				}
+				return
+			}
+			if call.IsError {
+				t.Fatalf("unexpected tool error: %+v", call.Content)
+			}
+
+			var got crafting.ToolCallEstimate
+			if err := json.Unmarshal([]byte(call.Content[0].Text), &got); err != nil {
+				t.Fatalf("decoding estimate: %v", err)
+			}
+			if got.Tool != tt.want.Tool || got.Valid != tt.want.Valid ||
+				got.RecipesTotal != tt.want.RecipesTotal || got.RecipesToScan != tt.want.RecipesToScan || got.CraftSteps != tt.want.CraftSteps {
+				t.Errorf("estimate = %+v, want %+v", got, tt.want)
+			}
+			switch {
+			case tt.want.EstimatedResults == nil && got.EstimatedResults != nil:
+				t.Errorf("expected no result estimate, got %d", *got.EstimatedResults)
+			case tt.want.EstimatedResults != nil && (got.EstimatedResults == nil || *got.EstimatedResults != *tt.want.EstimatedResults):
+				t.Errorf("EstimatedResults = %v, want %d", got.EstimatedResults, *tt.want.EstimatedResults)
+			}
+		})
+	}
+
+	// Invalid arguments are still rejected in validate mode.
+	_, err := s.handleToolsCall(ctx, json.RawMessage(`{"name":"craft_query","validate":true,"arguments":{}}`))
+	var rpcErr *Error
+	if !errors.As(err, &rpcErr) || rpcErr.Code != ErrCodeInvalidParams {
+		t.Errorf("expected invalid params error, got %v", err)
+	}
+}
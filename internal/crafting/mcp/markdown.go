@@ -0,0 +1,202 @@
+package mcp
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
+)
+
+// Result formats accepted in tools/call params.
+const (
+	formatJSON     = "json"
+	formatMarkdown = "markdown"
+)
+
+// renderMarkdown formats a tool result as Markdown for a human reader.
+// Results without a dedicated layout are shown as a fenced JSON block.
+func renderMarkdown(result any, resultJSON []byte) string {
+	var b strings.Builder
+	switch r := result.(type) {
+	case *crafting.BillOfMaterialsResponse:
+		writeBOMMarkdown(&b, r)
+	case *crafting.ShoppingListResponse:
+		writeShoppingListMarkdown(&b, r)
+	case *crafting.CraftQueryResponse:
+		b.WriteString("# Craft Query\n")
+		writeCraftQueryMarkdown(&b, r, "##")
+	case *crafting.BatchQueryResponse:
+		b.WriteString("# Batch Query\n")
+		for _, res := range r.Results {
+			fmt.Fprintf(&b, "\n## %s\n", res.Label)
+			writeCraftQueryMarkdown(&b, res.Response, "###")
+		}
+	default:
+		fmt.Fprintf(&b, "```json\n%s\n```\n", resultJSON)
+	}
+	return b.String()
+}
+
+// itemLabel shows an item's name with its ID, or just the ID when the item
+// has no catalogued name.
+func itemLabel(id, name string) string {
+	if name == "" || name == id {
+		return "`" + id + "`"
+	}
+	return fmt.Sprintf("%s (`%s`)", name, id)
+}
+
+func writeBOMMarkdown(b *strings.Builder, r *crafting.BillOfMaterialsResponse) {
+	fmt.Fprintf(b, "# Bill of Materials: %d x %s\n", r.Quantity, itemLabel(r.OutputItemID, r.OutputItemName))
+	fmt.Fprintf(b, "\nRecipe: %s (`%s`)\n", r.RecipeName, r.RecipeID)
+
+	writeGatherList(b, "Raw materials to gather", r.RawMaterials)
+	writeBuildChecklist(b, r.CraftSteps)
+	writeItemList(b, "Byproducts", r.Byproducts)
+	fmt.Fprintf(b, "\nTotal craft time: %ds\n", r.TotalCraftTime)
+	if len(r.LimitingMaterials) > 0 {
+		fmt.Fprintf(b, "\nLimited by: %s\n", strings.Join(r.LimitingMaterials, ", "))
+	}
+
+	if c := r.CostAnalysis; c != nil {
+		fmt.Fprintf(b, "\n## Cost at %s\n\n", c.StationID)
+		fmt.Fprintf(b, "- Raw material cost: %d\n", c.RawMaterialCost)
+		fmt.Fprintf(b, "- Output value: %d\n", c.OutputValue)
+		fmt.Fprintf(b, "- Net profit: %d (%.1f%%)\n", c.NetProfit, c.ProfitMarginPct)
+		if len(c.UnpricedItems) > 0 {
+			fmt.Fprintf(b, "- Unpriced: %s\n", strings.Join(c.UnpricedItems, ", "))
+		}
+	}
+}
+
+func writeShoppingListMarkdown(b *strings.Builder, r *crafting.ShoppingListResponse) {
+	b.WriteString("# Shopping List\n\n")
+	for _, t := range r.Targets {
+		fmt.Fprintf(b, "- %d x %s via %s\n", t.Quantity, itemLabel(t.OutputItemID, t.OutputItemName), t.RecipeName)
+	}
+
+	writeGatherList(b, "Raw materials to gather", r.RawMaterials)
+	writeBuildChecklist(b, r.CraftSteps)
+	writeItemList(b, "Byproducts", r.Byproducts)
+	fmt.Fprintf(b, "\nTotal craft time: %ds\n", r.TotalCraftTime)
+}
+
+// writeGatherList writes raw materials as a checklist.
+func writeGatherList(b *strings.Builder, title string, items []crafting.BOMItem) {
+	if len(items) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n## %s\n\n", title)
+	for _, item := range items {
+		fmt.Fprintf(b, "- [ ] %d x %s", item.Quantity, itemLabel(item.ItemID, item.ItemName))
+		if item.Truncated {
+			b.WriteString(" (craftable; not expanded)")
+		}
+		b.WriteString("\n")
+	}
+}
+
+// writeBuildChecklist writes craft steps as a numbered checklist in build
+// order.
+func writeBuildChecklist(b *strings.Builder, steps []crafting.BOMCraftStep) {
+	if len(steps) == 0 {
+		return
+	}
+	b.WriteString("\n## Build order\n\n")
+	for _, step := range steps {
+		fmt.Fprintf(b, "%d. [ ] %s: %d run(s) -> %d x `%s`",
+			step.StepNumber, step.RecipeName, step.CraftRuns, step.CraftRuns*step.OutputPerRun, step.OutputItemID)
+		if step.ExpectedCraftRuns > 0 {
+			fmt.Fprintf(b, " (expect %d runs at %.0f%% success)", step.ExpectedCraftRuns, step.SuccessRate*100)
+		}
+		b.WriteString("\n")
+	}
+}
+
+// writeItemList writes a plain bulleted list of items.
+func writeItemList(b *strings.Builder, title string, items []crafting.BOMItem) {
+	if len(items) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n## %s\n\n", title)
+	for _, item := range items {
+		fmt.Fprintf(b, "- %d x %s\n", item.Quantity, itemLabel(item.ItemID, item.ItemName))
+	}
+}
+
+// writeCraftQueryMarkdown summarizes a craft query under headings of the
+// given level.
+func writeCraftQueryMarkdown(b *strings.Builder, r *crafting.CraftQueryResponse, heading string) {
+	if r == nil {
+		return
+	}
+
+	fmt.Fprintf(b, "\n%s Craftable now (%d)\n\n", heading, len(r.Craftable))
+	if len(r.Craftable) == 0 {
+		b.WriteString("Nothing is fully craftable.\n")
+	}
+	for _, m := range r.Craftable {
+		fmt.Fprintf(b, "- **%s** (`%s`): up to %d", m.Recipe.Name, m.Recipe.ID, m.CanCraftQuantity)
+		if m.OutputQuantityPossible > m.CanCraftQuantity {
+			fmt.Fprintf(b, " runs, %d items", m.OutputQuantityPossible)
+		}
+		if m.TotalCraftTimeSec > 0 {
+			fmt.Fprintf(b, " in %ds", m.TotalCraftTimeSec)
+		}
+		writeProfitSuffix(b, m.ProfitAnalysis)
+		b.WriteString("\n")
+		writeSubstitutions(b, m.Substitutions)
+	}
+
+	if len(r.PartialComponents) > 0 {
+		fmt.Fprintf(b, "\n%s Partial matches (%d)\n\n", heading, len(r.PartialComponents))
+		for _, m := range r.PartialComponents {
+			fmt.Fprintf(b, "- **%s** (`%s`): %.0f%% of inputs", m.Recipe.Name, m.Recipe.ID, m.MatchRatio*100)
+			if len(m.InputsMissing) > 0 {
+				missing := make([]string, 0, len(m.InputsMissing))
+				for _, inp := range m.InputsMissing {
+					missing = append(missing, fmt.Sprintf("%d x `%s`", inp.Quantity, inp.ItemID))
+				}
+				fmt.Fprintf(b, "; missing %s", strings.Join(missing, ", "))
+			}
+			writeAcquisitionCost(b, m.AcquisitionCost)
+			writeProfitSuffix(b, m.ProfitAnalysis)
+			b.WriteString("\n")
+			writeSubstitutions(b, m.Substitutions)
+		}
+	}
+
+	s := r.QueryStats
+	fmt.Fprintf(b, "\n_Checked %d recipes in %d ms using %s._\n", s.TotalRecipesChecked, s.ProcessingTimeMs, s.StrategyUsed)
+}
+
+func writeProfitSuffix(b *strings.Builder, p *crafting.ProfitAnalysis) {
+	if p == nil {
+		return
+	}
+	fmt.Fprintf(b, " (profit %d/unit, %.1f%% margin", p.ProfitPerUnit, p.ProfitMarginPct)
+	if !p.DataComplete {
+		b.WriteString(", partial price data")
+	}
+	b.WriteString(")")
+}
+
+func writeAcquisitionCost(b *strings.Builder, c *crafting.AcquisitionCost) {
+	if c == nil {
+		return
+	}
+	switch {
+	case len(c.UnpricedItems) > 0:
+		fmt.Fprintf(b, "; buying them costs at least %d, no price for %s", c.TotalCost, strings.Join(c.UnpricedItems, ", "))
+	case c.WithinBudget:
+		fmt.Fprintf(b, "; buying them costs %d, within budget", c.TotalCost)
+	default:
+		fmt.Fprintf(b, "; buying them costs %d, over the %d budget", c.TotalCost, c.Budget)
+	}
+}
+
+func writeSubstitutions(b *strings.Builder, subs []crafting.InputSubstitution) {
+	for _, sub := range subs {
+		fmt.Fprintf(b, "  - uses `%s` in place of `%s`\n", sub.SubstituteID, sub.ItemID)
+	}
+}
@@ -0,0 +1,78 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
+)
+
+func TestToolsCallMarkdown(t *testing.T) {
+	ctx := context.Background()
+
+	recipes := []crafting.Recipe{
+		{ID: "plate", Name: "Plate", Inputs: []crafting.RecipeInput{{ItemID: "ore", Quantity: 2}}, Outputs: []crafting.RecipeOutput{{ItemID: "plate", Quantity: 1}}},
+		{ID: "hull", Name: "Hull", Inputs: []crafting.RecipeInput{{ItemID: "plate", Quantity: 4}}, Outputs: []crafting.RecipeOutput{{ItemID: "hull", Quantity: 1}}},
+	}
+	s := testServer(t, recipes...)
+
+	tests := []struct {
+		name   string
+		params string
+		want   []string
+	}{
+		{
+			name:   "bill of materials checklist",
+			params: `{"name":"bill_of_materials","format":"markdown","arguments":{"recipe_id":"hull","quantity":2}}`,
+			want: []string{
+				"# Bill of Materials: 2 x",
+				"- [ ] 16 x `ore`",
+				"1. [ ] Plate: 8 run(s) -> 8 x `plate`",
+				"2. [ ] Hull: 2 run(s) -> 2 x `hull`",
+			},
+		},
+		{
+			name:   "craft query summary",
+			params: `{"name":"craft_query","format":"markdown","arguments":{"components":[{"id":"ore","quantity":5}]}}`,
+			want: []string{
+				"## Craftable now (1)",
+				"- **Plate** (`plate`): up to 2",
+			},
+		},
+		{
+			name:   "fenced json fallback",
+			params: `{"name":"recipe_lookup","format":"markdown","arguments":{"recipe_id":"plate"}}`,
+			want:   []string{"```json\n{", `"id": "plate"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := s.handleToolsCall(ctx, json.RawMessage(tt.params))
+			if err != nil {
+				t.Fatalf("handleToolsCall: %v", err)
+			}
+			call := result.(ToolCallResult)
+			if call.IsError {
+				t.Fatalf("unexpected tool error: %s", call.Content[0].Text)
+			}
+			text := call.Content[0].Text
+			for _, want := range tt.want {
+				if !strings.Contains(text, want) {
+					t.Errorf("output missing %q:\n%s", want, text)
+				}
+			}
+		})
+	}
+
+	t.Run("unknown format", func(t *testing.T) {
+		_, err := s.handleToolsCall(ctx, json.RawMessage(`{"name":"craft_query","format":"xml","arguments":{}}`))
+		var rpcErr *Error
+		if !errors.As(err, &rpcErr) || rpcErr.Code != ErrCodeInvalidParams {
+			t.Fatalf("err = %v, want invalid params error", err)
+		}
+	})
+}
@@ -89,7 +89,7 @@ func TestRecipeMarketProfitability(t *testing.T) {
 		t.Fatalf("recalculating stats: %v", err)
 	}
 
-	eng := engine.New(database)
+	eng := engine.New(database, nil)
 
 	t.Run("returns all recipes with market profitability", func(t *testing.T) {
 		result, err := eng.RecipeMarketProfitability(ctx, "Test Station", "", nil)
@@ -0,0 +1,160 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Metric names recorded around each tool call.
+const (
+	metricToolCalls        = "tool_calls_total"           // labels: tool, outcome
+	metricToolCallDuration = "tool_call_duration_seconds" // labels: tool
+)
+
+// Metrics receives instrumentation from the server. Implementations must be
+// safe for concurrent use and must not retain or modify labels. Adapters for
+// collectors such as Prometheus implement this interface.
+type Metrics interface {
+	// IncCounter adds one to the named counter.
+	IncCounter(name string, labels map[string]string)
+	// ObserveHistogram records value in the named histogram.
+	ObserveHistogram(name string, value float64, labels map[string]string)
+}
+
+// NopMetrics discards all metrics. It is the server's default.
+type NopMetrics struct{}
+
+func (NopMetrics) IncCounter(string, map[string]string)                {}
+func (NopMetrics) ObserveHistogram(string, float64, map[string]string) {}
+
+// SetMetrics sets where tool call metrics are recorded. A nil m restores the
+// no-op default. When m is an *InMemoryMetrics, the metrics tool reports it.
+func (s *Server) SetMetrics(m Metrics) {
+	if m == nil {
+		m = NopMetrics{}
+	}
+	s.metrics = m
+}
+
+// recordToolCall records the outcome and duration of one tool call. tool
+// must be a known tool name so arbitrary input can't create new series.
+func (s *Server) recordToolCall(ctx context.Context, tool string, d time.Duration, err error) {
+	outcome := "ok"
+	switch {
+	case err == nil:
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		outcome = "timeout"
+	case isToolError(err):
+		outcome = "tool_error"
+	default:
+		outcome = "error"
+	}
+	s.metrics.IncCounter(metricToolCalls, map[string]string{"tool": tool, "outcome": outcome})
+	s.metrics.ObserveHistogram(metricToolCallDuration, d.Seconds(), map[string]string{"tool": tool})
+}
+
+// InMemoryMetrics keeps counters and histogram summaries in memory so they
+// can be read back with the metrics tool.
+type InMemoryMetrics struct {
+	mu         sync.Mutex
+	counters   map[string]*CounterValue
+	histograms map[string]*HistogramValue
+}
+
+// NewInMemoryMetrics returns an empty InMemoryMetrics.
+func NewInMemoryMetrics() *InMemoryMetrics {
+	return &InMemoryMetrics{
+		counters:   make(map[string]*CounterValue),
+		histograms: make(map[string]*HistogramValue),
+	}
+}
+
+// CounterValue is a counter's current value.
+type CounterValue struct {
+	Name   string            `json:"name"`
+	Labels map[string]string `json:"labels,omitempty"`
+	Value  int64             `json:"value"`
+}
+
+// HistogramValue summarizes the values observed by a histogram.
+type HistogramValue struct {
+	Name   string            `json:"name"`
+	Labels map[string]string `json:"labels,omitempty"`
+	Count  int64             `json:"count"`
+	Sum    float64           `json:"sum"`
+	Min    float64           `json:"min"`
+	Max    float64           `json:"max"`
+}
+
+// MetricsSnapshot is the response for the metrics tool.
+type MetricsSnapshot struct {
+	Counters   []CounterValue   `json:"counters"`
+	Histograms []HistogramValue `json:"histograms"`
+}
+
+// seriesKey identifies a metric by name and labels, independent of the
+// order the labels were given in.
+func seriesKey(name string, labels map[string]string) string {
+	var b strings.Builder
+	b.WriteString(name)
+	for _, k := range slices.Sorted(maps.Keys(labels)) {
+		b.WriteString("|" + k + "=" + labels[k])
+	}
+	return b.String()
+}
+
+// IncCounter implements Metrics.
+func (m *InMemoryMetrics) IncCounter(name string, labels map[string]string) {
+	key := seriesKey(name, labels)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c, ok := m.counters[key]
+	if !ok {
+		c = &CounterValue{Name: name, Labels: maps.Clone(labels)}
+		m.counters[key] = c
+	}
+	c.Value++
+}
+
+// ObserveHistogram implements Metrics.
+func (m *InMemoryMetrics) ObserveHistogram(name string, value float64, labels map[string]string) {
+	key := seriesKey(name, labels)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	h, ok := m.histograms[key]
+	if !ok {
+		h = &HistogramValue{Name: name, Labels: maps.Clone(labels), Min: value, Max: value}
+		m.histograms[key] = h
+	}
+	h.Count++
+	h.Sum += value
+	h.Min = min(h.Min, value)
+	h.Max = max(h.Max, value)
+}
+
+// Snapshot returns a copy of every series, ordered by name and labels.
+func (m *InMemoryMetrics) Snapshot() MetricsSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snap := MetricsSnapshot{
+		Counters:   make([]CounterValue, 0, len(m.counters)),
+		Histograms: make([]HistogramValue, 0, len(m.histograms)),
+	}
+	for _, key := range slices.Sorted(maps.Keys(m.counters)) {
+		c := *m.counters[key]
+		c.Labels = maps.Clone(c.Labels)
+		snap.Counters = append(snap.Counters, c)
+	}
+	for _, key := range slices.Sorted(maps.Keys(m.histograms)) {
+		h := *m.histograms[key]
+		h.Labels = maps.Clone(h.Labels)
+		snap.Histograms = append(snap.Histograms, h)
+	}
+	return snap
+}
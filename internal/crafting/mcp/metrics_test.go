@@ -0,0 +1,80 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestToolCallMetrics(t *testing.T) {
+	ctx := context.Background()
+	s := testServer(t)
+
+	toolNames := func() []string {
+		result, err := s.handleToolsList(ctx, nil)
+		if err != nil {
+			t.Fatalf("handleToolsList: %v", err)
+		}
+		var names []string
+		for _, tool := range result.(ToolsListResult).Tools {
+			names = append(names, tool.Name)
+		}
+		return names
+	}
+	if slices.Contains(toolNames(), "metrics") {
+		t.Error("metrics tool listed without in-memory metrics")
+	}
+
+	m := NewInMemoryMetrics()
+	s.SetMetrics(m)
+	if !slices.Contains(toolNames(), "metrics") {
+		t.Error("metrics tool not listed with in-memory metrics")
+	}
+
+	calls := []string{
+		`{"name":"list_categories","arguments":{}}`,
+		`{"name":"list_categories","arguments":{}}`,
+		`{"name":"bill_of_materials","arguments":{"recipe_id":"missing"}}`,
+	}
+	for _, params := range calls {
+		if _, err := s.handleToolsCall(ctx, json.RawMessage(params)); err != nil {
+			t.Fatalf("handleToolsCall(%s): %v", params, err)
+		}
+	}
+
+	result, err := s.handleToolsCall(ctx, json.RawMessage(`{"name":"metrics","arguments":{}}`))
+	if err != nil {
+		t.Fatalf("handleToolsCall(metrics): %v", err)
+	}
+	var snap MetricsSnapshot
+	if err := json.Unmarshal([]byte(result.(ToolCallResult).Content[0].Text), &snap); err != nil {
+		t.Fatalf("decoding snapshot: %v", err)
+	}
+
+	counts := make(map[string]int64)
+	for _, c := range snap.Counters {
+		if c.Name == metricToolCalls {
+			counts[c.Labels["tool"]+"/"+c.Labels["outcome"]] = c.Value
+		}
+	}
+	want := map[string]int64{"list_categories/ok": 2, "bill_of_materials/tool_error": 1}
+	for key, n := range want {
+		if counts[key] != n {
+			t.Errorf("%s = %d, want %d (all counts %v)", key, counts[key], n, counts)
+		}
+	}
+
+	var observed int64
+	for _, h := range snap.Histograms {
+		if h.Name == metricToolCallDuration && h.Labels["tool"] == "list_categories" {
+			observed = h.Count
+			if h.Min > h.Max || h.Sum < h.Max {
+				t.Errorf("inconsistent histogram %+v", h)
+			}
+		}
+	}
+	if observed != 2 {
+		t.Errorf("list_categories duration count = %d, want 2", observed)
+	}
+}
@@ -2,15 +2,21 @@
 package mcp
 
 import (
-	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"os"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/rsned/spacemolt-crafting-server/internal/crafting/engine"
+	"github.com/rsned/spacemolt-crafting-server/internal/version"
 )
 
 // Server implements an MCP server over stdio.
@@ -18,8 +24,29 @@ type Server struct {
 	engine   *engine.Engine
 	logger   *slog.Logger
 	handlers map[string]MethodHandler
+
+	// workers bounds how many requests are processed concurrently.
+	workers int
+	// transport selects the message framing used by Run.
+	transport Transport
+	// toolTimeout bounds each tools/call; zero disables the deadline.
+	toolTimeout time.Duration
+	// writeMu serializes responses so concurrent requests never interleave
+	// their output on the stream.
+	writeMu sync.Mutex
+	// structuredOutput is set at initialize when the client's protocol
+	// version supports structuredContent in tool results.
+	structuredOutput atomic.Bool
+	// metrics records the count, outcome and latency of tool calls.
+	metrics Metrics
 }
 
+// defaultWorkers is the default number of requests processed concurrently.
+const defaultWorkers = 8
+
+// defaultToolTimeout is the default deadline for a single tools/call.
+const defaultToolTimeout = 5 * time.Second
+
 // MethodHandler handles a specific JSON-RPC method.
 type MethodHandler func(ctx context.Context, params json.RawMessage) (any, error)
 
@@ -30,19 +57,37 @@ func NewServer(eng *engine.Engine, logger *slog.Logger) *Server {
 	}
 	
 	s := &Server{
-		engine:   eng,
-		logger:   logger,
-		handlers: make(map[string]MethodHandler),
+		engine:      eng,
+		logger:      logger,
+		handlers:    make(map[string]MethodHandler),
+		workers:     defaultWorkers,
+		transport:   TransportNewline,
+		toolTimeout: defaultToolTimeout,
+		metrics:     NopMetrics{},
 	}
 	
 	// Register handlers
 	s.handlers["initialize"] = s.handleInitialize
 	s.handlers["tools/list"] = s.handleToolsList
 	s.handlers["tools/call"] = s.handleToolsCall
+	s.handlers["notifications/initialized"] = s.handleNotificationNoop
 	
 	return s
 }
 
+// SetTransport selects the message framing used by Run. The default is
+// TransportNewline.
+func (s *Server) SetTransport(t Transport) {
+	s.transport = t
+}
+
+// SetToolTimeout sets the deadline applied to each tools/call. A call that
+// runs past it is cancelled and reported as a tool error. Zero or a negative
+// duration disables the deadline. The default is 5 seconds.
+func (s *Server) SetToolTimeout(d time.Duration) {
+	s.toolTimeout = d
+}
+
 // Request represents a JSON-RPC request.
 type Request struct {
 	JSONRPC string          `json:"jsonrpc"`
@@ -66,6 +111,12 @@ type Error struct {
 	Data    any    `json:"data,omitempty"`
 }
 
+// Error implements the error interface so handlers can return a specific
+// JSON-RPC error code instead of ErrCodeInternal.
+func (e *Error) Error() string {
+	return e.Message
+}
+
 // Standard JSON-RPC error codes.
 const (
 	ErrCodeParse       = -32700
@@ -77,11 +128,20 @@ const (
 
 // Run starts the server, reading from stdin and writing to stdout.
 func (s *Server) Run(ctx context.Context) error {
-	reader := bufio.NewReader(os.Stdin)
-	writer := os.Stdout
-	
-	s.logger.Info("MCP server starting")
-	
+	s.logger.Info("MCP server starting", "version", version.String(), "transport", s.transport)
+	return s.serve(ctx, os.Stdin, os.Stdout)
+}
+
+// serve reads requests from r using the server's transport and dispatches each
+// one to a bounded pool of goroutines. Responses carry the request ID and may
+// be written out of order. serve waits for outstanding requests before returning.
+func (s *Server) serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	f := newFramer(s.transport, r, w)
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	sem := make(chan struct{}, s.workers)
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -89,24 +149,107 @@ func (s *Server) Run(ctx context.Context) error {
 		default:
 		}
 		
-		line, err := reader.ReadBytes('\n')
+		msg, err := f.ReadMessage()
 		if err != nil {
 			if err == io.EOF {
 				return nil
 			}
 			return fmt.Errorf("reading input: %w", err)
 		}
-		
-		resp := s.handleRequest(ctx, line)
-		if resp != nil {
-			if err := s.writeResponse(writer, resp); err != nil {
-				s.logger.Error("failed to write response", "error", err)
+
+		// Wait for a free worker
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			resp := s.handleMessage(ctx, msg)
+			if resp != nil {
+				if err := s.writeResponse(f, resp); err != nil {
+					s.logger.Error("failed to write response", "error", err)
+				}
 			}
+		}()
+	}
+}
+
+// handleMessage processes a single request or a JSON-RPC batch. It returns a
+// *Response, a []*Response for batches, or nil when nothing should be written.
+func (s *Server) handleMessage(ctx context.Context, data []byte) any {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		batch := s.handleBatch(ctx, trimmed)
+		if len(batch) == 0 {
+			return nil
+		}
+		return batch
+	}
+
+	resp := s.handleRequest(ctx, data)
+	if resp == nil {
+		return nil
+	}
+	return resp
+}
+
+// handleBatch processes a JSON-RPC batch. Each element is handled in order and
+// notifications contribute no response element.
+func (s *Server) handleBatch(ctx context.Context, data []byte) []*Response {
+	var elems []json.RawMessage
+	if err := json.Unmarshal(data, &elems); err != nil {
+		return []*Response{{
+			JSONRPC: "2.0",
+			Error: &Error{
+				Code:    ErrCodeParse,
+				Message: "Parse error",
+				Data:    err.Error(),
+			},
+		}}
+	}
+	if len(elems) == 0 {
+		return []*Response{{
+			JSONRPC: "2.0",
+			Error: &Error{
+				Code:    ErrCodeInvalidReq,
+				Message: "Invalid Request: empty batch",
+			},
+		}}
+	}
+
+	var responses []*Response
+	for _, elem := range elems {
+		resp := s.handleRequest(ctx, elem)
+		if resp == nil {
+			continue
 		}
+		responses = append(responses, resp)
+	}
+	return responses
+}
+
+// isNotification reports whether data is a well-formed request without an
+// id member, which JSON-RPC defines as a notification.
+func isNotification(data []byte) bool {
+	var req struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+	}
+	if err := json.Unmarshal(data, &req); err != nil {
+		return false
 	}
+	return req.ID == nil && req.Method != ""
 }
 
-// handleRequest processes a single request.
+// handleRequest processes a single request. Notifications are executed for
+// their side effects and return nil, since JSON-RPC forbids replying to them.
 func (s *Server) handleRequest(ctx context.Context, data []byte) *Response {
 	var req Request
 	if err := json.Unmarshal(data, &req); err != nil {
@@ -122,8 +265,14 @@ func (s *Server) handleRequest(ctx context.Context, data []byte) *Response {
 	
 	s.logger.Debug("received request", "method", req.Method, "id", req.ID)
 	
+	notification := isNotification(data)
+
 	handler, ok := s.handlers[req.Method]
 	if !ok {
+		if notification {
+			s.logger.Debug("ignoring unknown notification", "method", req.Method)
+			return nil
+		}
 		return &Response{
 			JSONRPC: "2.0",
 			ID:      req.ID,
@@ -135,7 +284,21 @@ func (s *Server) handleRequest(ctx context.Context, data []byte) *Response {
 	}
 	
 	result, err := handler(ctx, req.Params)
+	if notification {
+		if err != nil {
+			s.logger.Error("notification failed", "method", req.Method, "error", err)
+		}
+		return nil
+	}
 	if err != nil {
+		var rpcErr *Error
+		if errors.As(err, &rpcErr) {
+			return &Response{
+				JSONRPC: "2.0",
+				ID:      req.ID,
+				Error:   rpcErr,
+			}
+		}
 		return &Response{
 			JSONRPC: "2.0",
 			ID:      req.ID,
@@ -153,16 +316,17 @@ func (s *Server) handleRequest(ctx context.Context, data []byte) *Response {
 	}
 }
 
-// writeResponse writes a JSON-RPC response.
-func (s *Server) writeResponse(w io.Writer, resp *Response) error {
+// writeResponse writes a JSON-RPC response or batch of responses. It is safe
+// for concurrent use.
+func (s *Server) writeResponse(f framer, resp any) error {
 	data, err := json.Marshal(resp)
 	if err != nil {
 		return fmt.Errorf("marshaling response: %w", err)
 	}
-	
-	data = append(data, '\n')
-	_, err = w.Write(data)
-	return err
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	return f.WriteMessage(data)
 }
 
 // Initialize result.
@@ -185,12 +349,38 @@ type ToolsCapability struct {
 	ListChanged bool `json:"listChanged,omitempty"`
 }
 
+// Supported MCP protocol versions.
+const (
+	protocolVersionBase = "2024-11-05"
+	// protocolVersionStructured adds structuredContent to tool results.
+	protocolVersionStructured = "2025-06-18"
+)
+
+// InitializeParams are the parameters for initialize.
+type InitializeParams struct {
+	ProtocolVersion string `json:"protocolVersion"`
+}
+
 func (s *Server) handleInitialize(ctx context.Context, params json.RawMessage) (any, error) {
+	var p InitializeParams
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, fmt.Errorf("invalid params: %w", err)
+		}
+	}
+
+	// Versions are dates, so newer clients compare greater
+	protocolVersion := protocolVersionBase
+	if p.ProtocolVersion >= protocolVersionStructured {
+		protocolVersion = protocolVersionStructured
+	}
+	s.structuredOutput.Store(protocolVersion == protocolVersionStructured)
+
 	return InitializeResult{
-		ProtocolVersion: "2024-11-05",
+		ProtocolVersion: protocolVersion,
 		ServerInfo: ServerInfo{
 			Name:    "spacemolt-crafting",
-			Version: "0.1.0",
+			Version: version.String(),
 		},
 		Capabilities: Capabilities{
 			Tools: &ToolsCapability{},
@@ -198,14 +388,26 @@ func (s *Server) handleInitialize(ctx context.Context, params json.RawMessage) (
 	}, nil
 }
 
+// handleNotificationNoop acknowledges notifications that need no action.
+func (s *Server) handleNotificationNoop(ctx context.Context, params json.RawMessage) (any, error) {
+	return nil, nil
+}
+
 // ToolsListResult is the response for tools/list.
 type ToolsListResult struct {
 	Tools []ToolDefinition `json:"tools"`
 }
 
 func (s *Server) handleToolsList(ctx context.Context, params json.RawMessage) (any, error) {
+	tools := GetToolDefinitions()
+	if s.engine.ReadOnly() {
+		tools = slices.DeleteFunc(tools, func(t ToolDefinition) bool { return writeTools[t.Name] })
+	}
+	if _, ok := s.metrics.(*InMemoryMetrics); !ok {
+		tools = slices.DeleteFunc(tools, func(t ToolDefinition) bool { return t.Name == "metrics" })
+	}
 	return ToolsListResult{
-		Tools: GetToolDefinitions(),
+		Tools: tools,
 	}, nil
 }
 
@@ -213,12 +415,15 @@ func (s *Server) handleToolsList(ctx context.Context, params json.RawMessage) (a
 type ToolCallParams struct {
 	Name      string          `json:"name"`
 	Arguments json.RawMessage `json:"arguments"`
+	Validate  bool            `json:"validate,omitempty"` // Validate and estimate cost instead of running
+	Format    string          `json:"format,omitempty"`   // "json" (default) or "markdown"
 }
 
 // ToolCallResult is the response for tools/call.
 type ToolCallResult struct {
-	Content []ContentBlock `json:"content"`
-	IsError bool           `json:"isError,omitempty"`
+	Content           []ContentBlock `json:"content"`
+	StructuredContent any            `json:"structuredContent,omitempty"`
+	IsError           bool           `json:"isError,omitempty"`
 }
 
 type ContentBlock struct {
@@ -233,9 +438,61 @@ func (s *Server) handleToolsCall(ctx context.Context, params json.RawMessage) (a
 	}
 	
 	s.logger.Debug("calling tool", "name", p.Name)
+
+	switch p.Format {
+	case "", formatJSON, formatMarkdown:
+	default:
+		return nil, &Error{
+			Code:    ErrCodeInvalidParams,
+			Message: fmt.Sprintf("unknown format %q (want %q or %q)", p.Format, formatJSON, formatMarkdown),
+		}
+	}
+	
+	// Unknown names share one label so arbitrary input can't create series
+	metricName := "unknown"
+	if tool, ok := findTool(p.Name); ok {
+		metricName = tool.Name
+		if err := validateArguments(tool.InputSchema, p.Arguments); err != nil {
+			return nil, &Error{
+				Code:    ErrCodeInvalidParams,
+				Message: fmt.Sprintf("invalid arguments for %s: %v", p.Name, err),
+			}
+		}
+	}
 	
-	result, err := s.callTool(ctx, p.Name, p.Arguments)
+	callCtx := ctx
+	if s.toolTimeout > 0 {
+		var cancel context.CancelFunc
+		callCtx, cancel = context.WithTimeout(ctx, s.toolTimeout)
+		defer cancel()
+	}
+
+	var result any
+	var err error
+	start := time.Now()
+	if p.Validate {
+		result, err = s.estimateTool(callCtx, p.Name, p.Arguments)
+	} else {
+		result, err = s.callTool(callCtx, p.Name, p.Arguments)
+	}
+	s.recordToolCall(callCtx, metricName, time.Since(start), err)
+	if err != nil && errors.Is(callCtx.Err(), context.DeadlineExceeded) {
+		// The driver may surface cancellation as its own error type, so
+		// check the context rather than err.
+		return ToolCallResult{
+			Content: []ContentBlock{{Type: "text", Text: fmt.Sprintf("%s timed out after %s", p.Name, s.toolTimeout)}},
+			IsError: true,
+		}, nil
+	}
 	if err != nil {
+		// Domain errors are tool results the model can act on; anything
+		// else is a server fault reported as a JSON-RPC error.
+		if isToolError(err) {
+			return ToolCallResult{
+				Content: []ContentBlock{{Type: "text", Text: err.Error()}},
+				IsError: true,
+			}, nil
+		}
 		return ToolCallResult{}, fmt.Errorf("tool call failed: %w", err)
 	}
 	
@@ -245,9 +502,38 @@ func (s *Server) handleToolsCall(ctx context.Context, params json.RawMessage) (a
 		return nil, fmt.Errorf("marshaling result: %w", err)
 	}
 	
-	return ToolCallResult{
-		Content: []ContentBlock{{Type: "text", Text: string(resultJSON)}},
-	}, nil
+	text := string(resultJSON)
+	if p.Format == formatMarkdown {
+		text = renderMarkdown(result, resultJSON)
+	}
+
+	call := ToolCallResult{
+		Content: []ContentBlock{{Type: "text", Text: text}},
+	}
+	if s.structuredOutput.Load() {
+		call.StructuredContent = result
+	}
+	return call, nil
+}
+
+// isToolError reports whether err is a domain error caused by the tool's
+// input, such as an unknown recipe, rather than an internal failure.
+func isToolError(err error) bool {
+	var typeErr *json.UnmarshalTypeError
+	return errors.Is(err, engine.ErrNotFound) ||
+		errors.Is(err, engine.ErrInvalidInput) ||
+		errors.Is(err, engine.ErrReadOnly) ||
+		errors.As(err, &typeErr)
+}
+
+// findTool looks up a tool definition by name.
+func findTool(name string) (ToolDefinition, bool) {
+	for _, tool := range GetToolDefinitions() {
+		if tool.Name == name {
+			return tool, true
+		}
+	}
+	return ToolDefinition{}, false
 }
 
 // callTool dispatches to the appropriate tool handler.
@@ -265,6 +551,40 @@ func (s *Server) callTool(ctx context.Context, name string, args json.RawMessage
 		return s.toolBillOfMaterials(ctx, args)
 	case "recipe_market_profitability":
 		return s.toolRecipeMarketProfitability(ctx, args)
+	case "skill_prerequisites":
+		return s.toolSkillPrerequisites(ctx, args)
+	case "validate_recipes":
+		return s.toolValidateRecipes(ctx, args)
+	case "list_stations":
+		return s.toolListStations(ctx, args)
+	case "price_history":
+		return s.toolPriceHistory(ctx, args)
+	case "craft_recommendations":
+		return s.toolCraftRecommendations(ctx, args)
+	case "list_categories":
+		return s.toolListCategories(ctx, args)
+	case "set_market_price":
+		return s.toolSetMarketPrice(ctx, args)
+	case "batch_query":
+		return s.toolBatchQuery(ctx, args)
+	case "capability_diff":
+		return s.toolCapabilityDiff(ctx, args)
+	case "recipes_for_item":
+		return s.toolRecipesForItem(ctx, args)
+	case "shopping_list":
+		return s.toolShoppingList(ctx, args)
+	case "simulate_plan":
+		return s.toolSimulatePlan(ctx, args)
+	case "compare_recipes":
+		return s.toolCompareRecipes(ctx, args)
+	case "cheapest_sources":
+		return s.toolCheapestSources(ctx, args)
+	case "find_arbitrage":
+		return s.toolFindArbitrage(ctx, args)
+	case "one_purchase_away":
+		return s.toolOnePurchaseAway(ctx, args)
+	case "metrics":
+		return s.toolMetrics(ctx, args)
 	default:
 		return nil, fmt.Errorf("unknown tool: %s", name)
 	}
@@ -0,0 +1,135 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestServeConcurrentRequests(t *testing.T) {
+	s := NewServer(nil, nil)
+
+	// "slow" blocks until "fast" has run, so a sequential server deadlocks.
+	release := make(chan struct{})
+	s.handlers["slow"] = func(ctx context.Context, params json.RawMessage) (any, error) {
+		select {
+		case <-release:
+			return "slow", nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	s.handlers["fast"] = func(ctx context.Context, params json.RawMessage) (any, error) {
+		close(release)
+		return "fast", nil
+	}
+
+	input := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"slow"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"fast"}`,
+	}, "\n") + "\n"
+
+	var out bytes.Buffer
+	if err := s.serve(context.Background(), strings.NewReader(input), &out); err != nil {
+		t.Fatalf("serve failed: %v", err)
+	}
+
+	var results []any
+	scanner := bufio.NewScanner(&out)
+	for scanner.Scan() {
+		var resp struct {
+			ID     float64 `json:"id"`
+			Result any     `json:"result"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &resp); err != nil {
+			t.Fatalf("decoding response %q: %v", scanner.Text(), err)
+		}
+		results = append(results, resp.ID, resp.Result)
+	}
+
+	want := []any{2.0, "fast", 1.0, "slow"}
+	if len(results) != len(want) {
+		t.Fatalf("expected responses %v, got %v", want, results)
+	}
+	for i := range want {
+		if results[i] != want[i] {
+			t.Errorf("expected responses %v, got %v", want, results)
+			break
+		}
+	}
+}
+
+func TestServeBatchRequests(t *testing.T) {
+	s := NewServer(nil, nil)
+
+	var notified bool
+	s.handlers["echo"] = func(ctx context.Context, params json.RawMessage) (any, error) {
+		return string(params), nil
+	}
+	s.handlers["notify"] = func(ctx context.Context, params json.RawMessage) (any, error) {
+		notified = true
+		return nil, nil
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "requests and notification",
+			input: `[{"jsonrpc":"2.0","id":1,"method":"echo","params":"a"},{"jsonrpc":"2.0","method":"notify"},{"jsonrpc":"2.0","id":"b","method":"echo","params":"b"}]`,
+			want:  `[{"jsonrpc":"2.0","id":1,"result":"\"a\""},{"jsonrpc":"2.0","id":"b","result":"\"b\""}]`,
+		},
+		{
+			name:  "only notifications",
+			input: `[{"jsonrpc":"2.0","method":"notify"}]`,
+			want:  ``,
+		},
+		{
+			name:  "empty batch",
+			input: ` []`,
+			want:  `[{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request: empty batch"}}]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := s.serve(context.Background(), strings.NewReader(tt.input+"\n"), &out); err != nil {
+				t.Fatalf("serve failed: %v", err)
+			}
+			if got := strings.TrimSpace(out.String()); got != tt.want {
+				t.Errorf("unexpected output:\n got %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+
+	if !notified {
+		t.Error("expected notification handler to run")
+	}
+}
+
+func TestServeNotifications(t *testing.T) {
+	s := NewServer(nil, nil)
+
+	input := strings.Join([]string{
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","method":"notifications/unknown"}`,
+		`{"jsonrpc":"2.0","id":7,"method":"notifications/initialized"}`,
+	}, "\n") + "\n"
+
+	var out bytes.Buffer
+	if err := s.serve(context.Background(), strings.NewReader(input), &out); err != nil {
+		t.Fatalf("serve failed: %v", err)
+	}
+
+	// Only the request with an id gets a reply.
+	want := `{"jsonrpc":"2.0","id":7}`
+	if got := strings.TrimSpace(out.String()); got != want {
+		t.Errorf("unexpected output:\n got %s\nwant %s", got, want)
+	}
+}
@@ -3,7 +3,9 @@ package mcp
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 
+	"github.com/rsned/spacemolt-crafting-server/internal/crafting/engine"
 	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
 )
 
@@ -44,14 +46,38 @@ func GetToolDefinitions() []ToolDefinition {
 		componentUsesTool(),
 		billOfMaterialsTool(),
 		recipeMarketProfitabilityTool(),
+		skillPrerequisitesTool(),
+		validateRecipesTool(),
+		listStationsTool(),
+		priceHistoryTool(),
+		craftRecommendationsTool(),
+		listCategoriesTool(),
+		setMarketPriceTool(),
+		batchQueryTool(),
+		capabilityDiffTool(),
+		recipesForItemTool(),
+		shoppingListTool(),
+		simulatePlanTool(),
+		compareRecipesTool(),
+		cheapestSourcesTool(),
+		findArbitrageTool(),
+		onePurchaseAwayTool(),
+		metricsTool(),
 	}
 }
 
+// writeTools names the tools that modify the database. They are left out
+// of tools/list when the server runs against a read-only database.
+var writeTools = map[string]bool{
+	"set_market_price": true,
+}
+
 func craftQueryTool() ToolDefinition {
 	minMatch := 0.0
 	maxMatch := 1.0
 	minLimit := 1.0
 	maxLimit := 100.0
+	minBudget := 0.0
 
 	return ToolDefinition{
 		Name:        "craft_query",
@@ -78,7 +104,7 @@ func craftQueryTool() ToolDefinition {
 				},
 				"min_match_ratio": {
 					Type:        "number",
-					Description: "Minimum component match ratio for partial results (0.0-1.0)",
+					Description: "Minimum fraction of inputs held in full quantity for one craft, for partial results (0.0-1.0)",
 					Default:     0.25,
 					Minimum:     &minMatch,
 					Maximum:     &maxMatch,
@@ -86,16 +112,41 @@ func craftQueryTool() ToolDefinition {
 				"optimization_strategy": {
 					Type:        "string",
 					Description: "How to sort/optimize results",
-					Enum:        []string{"MAXIMIZE_PROFIT", "MAXIMIZE_VOLUME", "OPTIMIZE_CRAFT_PATH", "USE_INVENTORY_FIRST", "MINIMIZE_ACQUISITION"},
+					Enum:        []string{"MAXIMIZE_PROFIT", "MAXIMIZE_PROFIT_PER_HOUR", "MAXIMIZE_VOLUME", "OPTIMIZE_CRAFT_PATH", "USE_INVENTORY_FIRST", "MINIMIZE_ACQUISITION"},
 					Default:     "USE_INVENTORY_FIRST",
 				},
 				"station_id": {
 					Type:        "string",
-					Description: "Station ID for market price lookups (required for MAXIMIZE_PROFIT)",
+					Description: "Station ID for market price lookups (required for MAXIMIZE_PROFIT and MAXIMIZE_PROFIT_PER_HOUR)",
 				},
 				"category_filter": {
 					Type:        "string",
-					Description: "Filter to specific recipe category",
+					Description: "Filter to specific recipe category (combined with categories)",
+				},
+				"categories": {
+					Type:        "array",
+					Description: "Filter to recipes in any of these categories",
+					Items:       &Property{Type: "string"},
+				},
+				"exclude_categories": {
+					Type:        "array",
+					Description: "Recipe categories to leave out of results",
+					Items:       &Property{Type: "string"},
+				},
+				"exclude_recipe_ids": {
+					Type:        "array",
+					Description: "Recipe IDs to leave out of results",
+					Items:       &Property{Type: "string"},
+				},
+				"tags": {
+					Type:        "array",
+					Description: "Only include recipes carrying any of these tags (case-insensitive)",
+					Items:       &Property{Type: "string"},
+				},
+				"require_all_tags": {
+					Type:        "boolean",
+					Description: "Require recipes to carry every listed tag instead of any one",
+					Default:     false,
 				},
 				"include_ammunition": {
 					Type:        "boolean",
@@ -109,6 +160,35 @@ func craftQueryTool() ToolDefinition {
 					Minimum:     &minLimit,
 					Maximum:     &maxLimit,
 				},
+				"pricing_model":         pricingModelProperty(),
+				"auto_station":          autoStationProperty(),
+				"min_profit_margin_pct": minProfitMarginPctProperty(),
+				"min_profit_per_unit":   minProfitPerUnitProperty(),
+				"use_quantity_weighted_ratio": {
+					Type:        "boolean",
+					Description: "Apply min_match_ratio to units held over units needed instead of the fraction of fully satisfied inputs",
+					Default:     false,
+				},
+				"only_craftable": {
+					Type:        "boolean",
+					Description: "Return only recipes craftable right now from components; skips partial matching and overrides include_partial",
+					Default:     false,
+				},
+				"budget": {
+					Type:        "integer",
+					Description: "Credits available to buy missing inputs; each partial match reports acquisition_cost at station_id (required) and whether it is within budget",
+					Minimum:     &minBudget,
+				},
+				"exclude_over_budget": {
+					Type:        "boolean",
+					Description: "With budget, drop partial matches whose missing inputs cost more than the budget or cannot all be priced",
+					Default:     false,
+				},
+				"prefix_matching": {
+					Type:        "boolean",
+					Description: "Let a component ID ending in * (e.g. ore_iron_*) stand for every recipe input beginning with the rest of it, sharing the given quantity between them. Exact IDs keep their own quantity",
+					Default:     false,
+				},
 			},
 			Required: []string{"components"},
 		},
@@ -120,7 +200,7 @@ func craftPathToTool() ToolDefinition {
 
 	return ToolDefinition{
 		Name:        "craft_path_to",
-		Description: "Calculate what materials are needed to craft a specific recipe. Returns single-level component expansion with acquisition methods.",
+		Description: "Calculate what materials are needed to craft a specific recipe. Returns single-level component expansion with acquisition methods (market, crafting, and imported sources such as mining or loot).",
 		InputSchema: JSONSchema{
 			Type: "object",
 			Properties: map[string]Property{
@@ -148,7 +228,13 @@ func craftPathToTool() ToolDefinition {
 				},
 				"station_id": {
 					Type:        "string",
-					Description: "Station ID for acquisition method lookups",
+					Description: "Station ID for market acquisition method lookups",
+				},
+				"optimization_strategy": {
+					Type:        "string",
+					Description: "Which acquisition method to recommend: MINIMIZE_ACQUISITION/USE_INVENTORY_FIRST prefer crafting intermediates from inventory, MAXIMIZE_PROFIT/MAXIMIZE_PROFIT_PER_HOUR prefer the cheaper of buying or crafting at station_id prices",
+					Enum:        []string{"MAXIMIZE_PROFIT", "MAXIMIZE_PROFIT_PER_HOUR", "MAXIMIZE_VOLUME", "OPTIMIZE_CRAFT_PATH", "USE_INVENTORY_FIRST", "MINIMIZE_ACQUISITION"},
+					Default:     "USE_INVENTORY_FIRST",
 				},
 			},
 			Required: []string{"target_recipe_id"},
@@ -169,18 +255,29 @@ func recipeLookupTool() ToolDefinition {
 				},
 				"search": {
 					Type:        "string",
-					Description: "Search term for recipe name (alternative to recipe_id)",
+					Description: "Search term for recipe name (alternative to recipe_id); trimmed, at least 2 characters, matched literally as a substring, up to 10 hits",
 				},
 				"station_id": {
 					Type:        "string",
 					Description: "Station for market data",
 				},
+				"station_ids": {
+					Type:        "array",
+					Description: "Stations to compare profit across (returns station_profits sorted best-first)",
+					Items:       &Property{Type: "string"},
+				},
+				"pricing_model":         pricingModelProperty(),
+				"auto_station":          autoStationProperty(),
+				"min_profit_margin_pct": minProfitMarginPctProperty(),
+				"min_profit_per_unit":   minProfitPerUnitProperty(),
 			},
 		},
 	}
 }
 
 func componentUsesTool() ToolDefinition {
+	minAvailable := 0.0
+
 	return ToolDefinition{
 		Name:        "component_uses",
 		Description: "Find all recipes that use a specific component. Useful when acquiring a new item to see crafting options.",
@@ -191,6 +288,11 @@ func componentUsesTool() ToolDefinition {
 					Type:        "string",
 					Description: "Component to look up uses for",
 				},
+				"available_quantity": {
+					Type:        "integer",
+					Description: "How much of the component you have; each use then reports max_crafts_from_component, and MAXIMIZE_VOLUME ranks by it",
+					Minimum:     &minAvailable,
+				},
 				"station_id": {
 					Type:        "string",
 					Description: "Station for market data",
@@ -198,15 +300,66 @@ func componentUsesTool() ToolDefinition {
 				"optimization_strategy": {
 					Type:        "string",
 					Description: "How to sort results",
-					Enum:        []string{"MAXIMIZE_PROFIT", "MAXIMIZE_VOLUME", "USE_INVENTORY_FIRST"},
+					Enum:        []string{"MAXIMIZE_PROFIT", "MAXIMIZE_PROFIT_PER_HOUR", "MAXIMIZE_VOLUME", "USE_INVENTORY_FIRST"},
 					Default:     "USE_INVENTORY_FIRST",
 				},
+				"pricing_model":         pricingModelProperty(),
+				"auto_station":          autoStationProperty(),
+				"min_profit_margin_pct": minProfitMarginPctProperty(),
+				"min_profit_per_unit":   minProfitPerUnitProperty(),
+				"components": {
+					Type:        "array",
+					Description: "Components the agent currently has; each use then reports ready_to_craft and inputs_missing, and USE_INVENTORY_FIRST ranks ready uses first. component_id is assumed held unless listed",
+					Items: &Property{
+						Type: "object",
+						Properties: map[string]Property{
+							"id":       {Type: "string", Description: "Component ID"},
+							"quantity": {Type: "integer", Description: "Quantity available"},
+						},
+						Required: []string{"id", "quantity"},
+					},
+				},
 			},
 			Required: []string{"component_id"},
 		},
 	}
 }
 
+// pricingModelProperty describes the shared pricing_model parameter.
+func pricingModelProperty() Property {
+	return Property{
+		Type:        "string",
+		Description: "Which market prices to use for profit analysis: AVERAGE (representative price), PESSIMISTIC (buy inputs at max, sell outputs at min), or OPTIMISTIC (buy inputs at min, sell outputs at max)",
+		Enum:        []string{"AVERAGE", "PESSIMISTIC", "OPTIMISTIC"},
+		Default:     "AVERAGE",
+	}
+}
+
+// autoStationProperty describes the shared auto_station parameter.
+func autoStationProperty() Property {
+	return Property{
+		Type:        "boolean",
+		Description: "When station_id is omitted, price each recipe at whichever station with market data gives the most profit per unit; profit_analysis.station_id names the station chosen",
+		Default:     false,
+	}
+}
+
+// minProfitMarginPctProperty describes the shared min_profit_margin_pct filter.
+func minProfitMarginPctProperty() Property {
+	return Property{
+		Type:        "number",
+		Description: "With a station, drop results whose profit margin (percent of input cost) is below this, and results with no market data",
+	}
+}
+
+// minProfitPerUnitProperty describes the shared min_profit_per_unit filter.
+func minProfitPerUnitProperty() Property {
+	return Property{
+		Type:        "integer",
+		Description: "With a station, drop results whose profit per craft is below this, and results with no market data",
+	}
+}
+
 // Tool handlers
 
 func (s *Server) toolCraftQuery(ctx context.Context, args json.RawMessage) (any, error) {
@@ -243,6 +396,7 @@ func (s *Server) toolComponentUses(ctx context.Context, args json.RawMessage) (a
 
 func billOfMaterialsTool() ToolDefinition {
 	minQty := 1.0
+	minDepth := 0.0
 
 	return ToolDefinition{
 		Name:        "bill_of_materials",
@@ -260,6 +414,38 @@ func billOfMaterialsTool() ToolDefinition {
 					Default:     1,
 					Minimum:     &minQty,
 				},
+				"station_id": {
+					Type:        "string",
+					Description: "Station ID for market prices (optional, adds raw material cost and profit analysis)",
+				},
+				"max_craftable": {
+					Type:        "boolean",
+					Description: "Ignore quantity and compute the largest quantity current_inventory supports, reporting the limiting raw materials",
+					Default:     false,
+				},
+				"current_inventory": {
+					Type:        "array",
+					Description: "Raw materials on hand, used with max_craftable",
+					Items: &Property{
+						Type: "object",
+						Properties: map[string]Property{
+							"id":       {Type: "string", Description: "Component ID"},
+							"quantity": {Type: "integer", Description: "Quantity available"},
+						},
+						Required: []string{"id", "quantity"},
+					},
+				},
+				"buy_instead": {
+					Type:        "array",
+					Description: "Item IDs to buy rather than craft; they are listed as raw materials and their sub-recipes are not expanded",
+					Items:       &Property{Type: "string"},
+				},
+				"max_depth": {
+					Type:        "integer",
+					Description: "Expand at most this many crafting levels, the target recipe being level 1; craftable items at the cutoff are listed as raw materials marked truncated (0 = no limit)",
+					Default:     0,
+					Minimum:     &minDepth,
+				},
 			},
 			Required: []string{"recipe_id"},
 		},
@@ -323,3 +509,607 @@ func (s *Server) toolRecipeMarketProfitability(ctx context.Context, args json.Ra
 	}
 	return s.engine.RecipeMarketProfitability(ctx, req.StationID, req.EmpireID, req.Components)
 }
+
+func skillPrerequisitesTool() ToolDefinition {
+	return ToolDefinition{
+		Name:        "skill_prerequisites",
+		Description: "Resolve the full prerequisite chain for a skill. Returns every skill that must be trained first, in training order, with current vs required levels and the total XP remaining.",
+		InputSchema: JSONSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"skill_id": {
+					Type:        "string",
+					Description: "Skill ID to resolve prerequisites for",
+				},
+				"skills": {
+					Type:                 "object",
+					Description:          "Agent's current skill levels, keyed by skill ID",
+					AdditionalProperties: &Property{Type: "integer"},
+				},
+			},
+			Required: []string{"skill_id"},
+		},
+	}
+}
+
+func (s *Server) toolSkillPrerequisites(ctx context.Context, args json.RawMessage) (any, error) {
+	var req crafting.SkillPrerequisitesRequest
+	if err := json.Unmarshal(args, &req); err != nil {
+		return nil, err
+	}
+	return s.engine.SkillPrerequisites(ctx, req)
+}
+
+func validateRecipesTool() ToolDefinition {
+	return ToolDefinition{
+		Name:        "validate_recipes",
+		Description: "Scan all recipes for dependency cycles, including cycles through alternative inputs. Returns every distinct cycle as the ordered list of items and recipes forming the loop, plus recipes that consume their own output.",
+		InputSchema: JSONSchema{
+			Type:       "object",
+			Properties: map[string]Property{},
+		},
+	}
+}
+
+func (s *Server) toolValidateRecipes(ctx context.Context, _ json.RawMessage) (any, error) {
+	return s.engine.ValidateRecipes(ctx)
+}
+
+func listStationsTool() ToolDefinition {
+	return ToolDefinition{
+		Name:        "list_stations",
+		Description: "List stations that have market price data, with the number of items priced at each. Use this to discover valid station_id values.",
+		InputSchema: JSONSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"component_id": {
+					Type:        "string",
+					Description: "Only return stations that have a price for this item (optional)",
+				},
+			},
+		},
+	}
+}
+
+func (s *Server) toolListStations(ctx context.Context, args json.RawMessage) (any, error) {
+	var req crafting.ListStationsRequest
+	if len(args) > 0 {
+		if err := json.Unmarshal(args, &req); err != nil {
+			return nil, err
+		}
+	}
+	return s.engine.ListStations(ctx, req)
+}
+
+func priceHistoryTool() ToolDefinition {
+	minLimit := 1.0
+	maxLimit := 1000.0
+
+	return ToolDefinition{
+		Name:        "price_history",
+		Description: "Get the recorded price history for an item at a station, newest first. Use this to chart price trends.",
+		InputSchema: JSONSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"component_id": {
+					Type:        "string",
+					Description: "Item ID to get price history for",
+				},
+				"station_id": {
+					Type:        "string",
+					Description: "Station ID for market data",
+				},
+				"price_type": {
+					Type:        "string",
+					Description: "Which side of the market to return",
+					Enum:        []string{"buy", "sell"},
+					Default:     "sell",
+				},
+				"since": {
+					Type:        "string",
+					Description: "Only return prices recorded at or after this RFC3339 timestamp (optional)",
+				},
+				"limit": {
+					Type:        "integer",
+					Description: "Max points to return",
+					Default:     100,
+					Minimum:     &minLimit,
+					Maximum:     &maxLimit,
+				},
+			},
+			Required: []string{"component_id", "station_id"},
+		},
+	}
+}
+
+func (s *Server) toolPriceHistory(ctx context.Context, args json.RawMessage) (any, error) {
+	var req crafting.PriceHistoryRequest
+	if err := json.Unmarshal(args, &req); err != nil {
+		return nil, err
+	}
+	return s.engine.PriceHistory(ctx, req)
+}
+
+func setMarketPriceTool() ToolDefinition {
+	minZero := 0.0
+
+	return ToolDefinition{
+		Name:        "set_market_price",
+		Description: "Record a market price just observed in game for an item at a station. Updates that market's 7-day summary immediately, without a full market import. Unavailable when the server's database is read-only.",
+		InputSchema: JSONSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"component_id": {
+					Type:        "string",
+					Description: "Item ID the price is for",
+				},
+				"station_id": {
+					Type:        "string",
+					Description: "Station ID, POI ID or station name where the price was seen",
+				},
+				"buy_price": {
+					Type:        "integer",
+					Description: "Observed buy price per unit (optional if sell_price is given)",
+					Minimum:     &minZero,
+				},
+				"sell_price": {
+					Type:        "integer",
+					Description: "Observed sell price per unit (optional if buy_price is given)",
+					Minimum:     &minZero,
+				},
+				"volume_24h": {
+					Type:        "integer",
+					Description: "Observed 24h trading volume (optional)",
+					Minimum:     &minZero,
+				},
+				"observed_at": {
+					Type:        "string",
+					Description: "When the price was observed, as an RFC3339 timestamp (default now)",
+				},
+			},
+			Required: []string{"component_id", "station_id"},
+		},
+	}
+}
+
+func (s *Server) toolSetMarketPrice(ctx context.Context, args json.RawMessage) (any, error) {
+	var req crafting.SetMarketPriceRequest
+	if err := json.Unmarshal(args, &req); err != nil {
+		return nil, err
+	}
+	return s.engine.SetMarketPrice(ctx, req)
+}
+
+func craftRecommendationsTool() ToolDefinition {
+	minLimit := 1.0
+	maxLimit := 100.0
+
+	return ToolDefinition{
+		Name:        "craft_recommendations",
+		Description: "Recommend the best next crafts. Blends recipes craftable now with recipes one item acquisition away, ranked by estimated profit and effort.",
+		InputSchema: JSONSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"components": {
+					Type:        "array",
+					Description: "Components the agent currently has",
+					Items: &Property{
+						Type: "object",
+						Properties: map[string]Property{
+							"id":       {Type: "string", Description: "Component ID"},
+							"quantity": {Type: "integer", Description: "Quantity available"},
+						},
+						Required: []string{"id", "quantity"},
+					},
+				},
+				"station_id": {
+					Type:        "string",
+					Description: "Station ID for market prices (optional, uses MSRP for acquisition costs if not provided)",
+				},
+				"max_acquisition_cost": {
+					Type:        "integer",
+					Description: "Skip recipes whose missing item costs more than this to acquire (optional)",
+				},
+				"pricing_model": pricingModelProperty(),
+				"limit": {
+					Type:        "integer",
+					Description: "Max recommendations to return",
+					Default:     10,
+					Minimum:     &minLimit,
+					Maximum:     &maxLimit,
+				},
+			},
+			Required: []string{"components"},
+		},
+	}
+}
+
+func (s *Server) toolCraftRecommendations(ctx context.Context, args json.RawMessage) (any, error) {
+	var req crafting.CraftRecommendationsRequest
+	if err := json.Unmarshal(args, &req); err != nil {
+		return nil, err
+	}
+	return s.engine.CraftRecommendations(ctx, req)
+}
+
+func listCategoriesTool() ToolDefinition {
+	return ToolDefinition{
+		Name:        "list_categories",
+		Description: "List recipe and skill categories with the number of entries in each. Use this to discover valid category_filter and categories values for craft_query.",
+		InputSchema: JSONSchema{
+			Type:       "object",
+			Properties: map[string]Property{},
+		},
+	}
+}
+
+func batchQueryTool() ToolDefinition {
+	// Every craft_query option applies to all inventories; components come
+	// from each inventory instead.
+	properties := map[string]Property{}
+	for name, prop := range craftQueryTool().InputSchema.Properties {
+		if name != "components" {
+			properties[name] = prop
+		}
+	}
+	properties["inventories"] = Property{
+		Type:        "array",
+		Description: "Labelled inventories to query, e.g. one per character or base (at most 50)",
+		Items: &Property{
+			Type: "object",
+			Properties: map[string]Property{
+				"label": {Type: "string", Description: "Unique name for this inventory"},
+				"components": {
+					Type:        "array",
+					Description: "Components in this inventory",
+					Items: &Property{
+						Type: "object",
+						Properties: map[string]Property{
+							"id":       {Type: "string", Description: "Component ID"},
+							"quantity": {Type: "integer", Description: "Quantity available"},
+						},
+						Required: []string{"id", "quantity"},
+					},
+				},
+			},
+			Required: []string{"label", "components"},
+		},
+	}
+
+	return ToolDefinition{
+		Name:        "batch_query",
+		Description: "Run the same craft_query against several labelled inventories in one call. Accepts every craft_query option and returns one craft_query response per label.",
+		InputSchema: JSONSchema{
+			Type:       "object",
+			Properties: properties,
+			Required:   []string{"inventories"},
+		},
+	}
+}
+
+func (s *Server) toolBatchQuery(ctx context.Context, args json.RawMessage) (any, error) {
+	var req crafting.BatchQueryRequest
+	if err := json.Unmarshal(args, &req); err != nil {
+		return nil, err
+	}
+	return s.engine.BatchQuery(ctx, req)
+}
+
+func capabilityDiffTool() ToolDefinition {
+	inventory := func(description string) Property {
+		return Property{
+			Type:        "array",
+			Description: description,
+			Items: &Property{
+				Type: "object",
+				Properties: map[string]Property{
+					"id":       {Type: "string", Description: "Component ID"},
+					"quantity": {Type: "integer", Description: "Quantity available"},
+				},
+				Required: []string{"id", "quantity"},
+			},
+		}
+	}
+
+	return ToolDefinition{
+		Name:        "capability_diff",
+		Description: "Compare what can be crafted from two inventories, e.g. before and after a trade. Returns recipes gained, lost, and craftable in a different quantity.",
+		InputSchema: JSONSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"before": inventory("Components held now"),
+				"after":  inventory("Components held after the change"),
+			},
+			Required: []string{"before", "after"},
+		},
+	}
+}
+
+func (s *Server) toolCapabilityDiff(ctx context.Context, args json.RawMessage) (any, error) {
+	var req crafting.CapabilityDiffRequest
+	if err := json.Unmarshal(args, &req); err != nil {
+		return nil, err
+	}
+	return s.engine.CapabilityDiff(ctx, req)
+}
+
+func recipesForItemTool() ToolDefinition {
+	return ToolDefinition{
+		Name:        "recipes_for_item",
+		Description: "Find all recipes that produce a specific item, with craft time, output per run and input count, fastest first. The complement of component_uses.",
+		InputSchema: JSONSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"item_id": {
+					Type:        "string",
+					Description: "Item to find production recipes for",
+				},
+			},
+			Required: []string{"item_id"},
+		},
+	}
+}
+
+func (s *Server) toolRecipesForItem(ctx context.Context, args json.RawMessage) (any, error) {
+	var req crafting.RecipesForItemRequest
+	if err := json.Unmarshal(args, &req); err != nil {
+		return nil, err
+	}
+	return s.engine.RecipesForItem(ctx, req)
+}
+
+func shoppingListTool() ToolDefinition {
+	return ToolDefinition{
+		Name:        "shopping_list",
+		Description: "Calculate the combined bill of materials for several recipes at once. Shared intermediates are crafted once for their total demand, so the combined raw materials can be less than the sum of separate BOMs. Also reports what each target needs on its own.",
+		InputSchema: JSONSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"targets": {
+					Type:        "array",
+					Description: "Recipes to build (up to 50); a repeated recipe_id has its quantities added",
+					Items: &Property{
+						Type: "object",
+						Properties: map[string]Property{
+							"recipe_id": {Type: "string", Description: "Recipe ID to build"},
+							"quantity":  {Type: "integer", Description: "How many to craft (default 1)"},
+						},
+						Required: []string{"recipe_id"},
+					},
+				},
+			},
+			Required: []string{"targets"},
+		},
+	}
+}
+
+func (s *Server) toolShoppingList(ctx context.Context, args json.RawMessage) (any, error) {
+	var req crafting.ShoppingListRequest
+	if err := json.Unmarshal(args, &req); err != nil {
+		return nil, err
+	}
+	return s.engine.ShoppingList(ctx, req)
+}
+
+func simulatePlanTool() ToolDefinition {
+	return ToolDefinition{
+		Name:        "simulate_plan",
+		Description: "Check that an ordered list of crafts can be carried out from an inventory. Each step consumes its inputs and adds its outputs, so earlier steps can feed later ones. Reports the first step that would fail and what it is missing, plus the inventory left at the end.",
+		InputSchema: JSONSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"components": {
+					Type:        "array",
+					Description: "Starting inventory",
+					Items: &Property{
+						Type: "object",
+						Properties: map[string]Property{
+							"id":       {Type: "string", Description: "Component ID"},
+							"quantity": {Type: "integer", Description: "Quantity available"},
+						},
+						Required: []string{"id", "quantity"},
+					},
+				},
+				"steps": {
+					Type:        "array",
+					Description: "Crafts to perform, in order (up to 200)",
+					Items: &Property{
+						Type: "object",
+						Properties: map[string]Property{
+							"recipe_id": {Type: "string", Description: "Recipe ID to craft"},
+							"runs":      {Type: "integer", Description: "How many times to run it (default 1)"},
+						},
+						Required: []string{"recipe_id"},
+					},
+				},
+			},
+			Required: []string{"components", "steps"},
+		},
+	}
+}
+
+func (s *Server) toolSimulatePlan(ctx context.Context, args json.RawMessage) (any, error) {
+	var req crafting.SimulatePlanRequest
+	if err := json.Unmarshal(args, &req); err != nil {
+		return nil, err
+	}
+	return s.engine.SimulatePlan(ctx, req)
+}
+
+func compareRecipesTool() ToolDefinition {
+	return ToolDefinition{
+		Name:        "compare_recipes",
+		Description: "Compare two recipes field by field: inputs and outputs added, removed or changed in quantity, plus differences in name, category, craft time, success rate and tags. Useful for tracking what a game update changed in a recipe.",
+		InputSchema: JSONSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"recipe_a": {
+					Type:        "string",
+					Description: "Recipe to compare from, e.g. the old version",
+				},
+				"recipe_b": {
+					Type:        "string",
+					Description: "Recipe to compare to, e.g. the new version",
+				},
+			},
+			Required: []string{"recipe_a", "recipe_b"},
+		},
+	}
+}
+
+func (s *Server) toolCompareRecipes(ctx context.Context, args json.RawMessage) (any, error) {
+	var req crafting.CompareRecipesRequest
+	if err := json.Unmarshal(args, &req); err != nil {
+		return nil, err
+	}
+	return s.engine.CompareRecipes(ctx, req)
+}
+
+func cheapestSourcesTool() ToolDefinition {
+	return ToolDefinition{
+		Name:        "cheapest_sources",
+		Description: "Find the station with the lowest buy price for each of a list of components, to plan a shopping route. Components no station sells are reported as unavailable.",
+		InputSchema: JSONSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"component_ids": {
+					Type:        "array",
+					Description: "Components to find sellers for (up to 100)",
+					Items:       &Property{Type: "string"},
+				},
+			},
+			Required: []string{"component_ids"},
+		},
+	}
+}
+
+func (s *Server) toolCheapestSources(ctx context.Context, args json.RawMessage) (any, error) {
+	var req crafting.CheapestSourcesRequest
+	if err := json.Unmarshal(args, &req); err != nil {
+		return nil, err
+	}
+	return s.engine.CheapestSources(ctx, req)
+}
+
+func findArbitrageTool() ToolDefinition {
+	minLimit := 1.0
+	maxLimit := 100.0
+	return ToolDefinition{
+		Name:        "find_arbitrage",
+		Description: "Find components that can be bought at one station and sold at another for a profit, using 7-day average prices. Opportunities are sorted by net spread per unit, widest first.",
+		InputSchema: JSONSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"fee_pct": {
+					Type:        "number",
+					Description: "Fee charged on the sell price, as a percentage (e.g. 5 for a 5% market tax)",
+					Default:     0,
+				},
+				"min_spread": {
+					Type:        "integer",
+					Description: "Only report opportunities whose profit per unit, after the fee, exceeds this",
+					Default:     0,
+				},
+				"limit": {
+					Type:        "integer",
+					Description: "Maximum opportunities to return",
+					Default:     20,
+					Minimum:     &minLimit,
+					Maximum:     &maxLimit,
+				},
+			},
+		},
+	}
+}
+
+func (s *Server) toolFindArbitrage(ctx context.Context, args json.RawMessage) (any, error) {
+	var req crafting.FindArbitrageRequest
+	if err := json.Unmarshal(args, &req); err != nil {
+		return nil, err
+	}
+	return s.engine.FindArbitrage(ctx, req)
+}
+
+func onePurchaseAwayTool() ToolDefinition {
+	minLimit := 1.0
+	maxLimit := 100.0
+	minPurchases := 1.0
+	maxPurchases := 3.0
+
+	return ToolDefinition{
+		Name:        "one_purchase_away",
+		Description: "Find recipes that are not craftable from your components but would be after buying the missing inputs at a station. Each recipe lists what to buy and the total cost, cheapest first. Recipes needing an item the station does not sell are left out.",
+		InputSchema: JSONSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"components": {
+					Type:        "array",
+					Description: "Components the agent currently has",
+					Items: &Property{
+						Type: "object",
+						Properties: map[string]Property{
+							"id":       {Type: "string", Description: "Component ID"},
+							"quantity": {Type: "integer", Description: "Quantity available"},
+						},
+						Required: []string{"id", "quantity"},
+					},
+				},
+				"station_id": {
+					Type:        "string",
+					Description: "Station to buy the missing inputs at",
+				},
+				"budget": {
+					Type:        "integer",
+					Description: "Skip recipes whose purchase costs more than this (optional)",
+				},
+				"max_purchases": {
+					Type:        "integer",
+					Description: "How many different inputs may be bought",
+					Default:     1,
+					Minimum:     &minPurchases,
+					Maximum:     &maxPurchases,
+				},
+				"pricing_model": pricingModelProperty(),
+				"limit": {
+					Type:        "integer",
+					Description: "Max recipes to return",
+					Default:     10,
+					Minimum:     &minLimit,
+					Maximum:     &maxLimit,
+				},
+			},
+			Required: []string{"components", "station_id"},
+		},
+	}
+}
+
+func (s *Server) toolOnePurchaseAway(ctx context.Context, args json.RawMessage) (any, error) {
+	var req crafting.OnePurchaseAwayRequest
+	if err := json.Unmarshal(args, &req); err != nil {
+		return nil, err
+	}
+	return s.engine.OnePurchaseAway(ctx, req)
+}
+
+func (s *Server) toolListCategories(ctx context.Context, _ json.RawMessage) (any, error) {
+	return s.engine.ListCategories(ctx)
+}
+
+func metricsTool() ToolDefinition {
+	return ToolDefinition{
+		Name:        "metrics",
+		Description: "Report tool call counts by outcome (ok, tool_error, timeout, error) and call latency summaries collected since the server started. Only available when the server collects metrics in memory.",
+		InputSchema: JSONSchema{
+			Type:       "object",
+			Properties: map[string]Property{},
+		},
+	}
+}
+
+func (s *Server) toolMetrics(_ context.Context, _ json.RawMessage) (any, error) {
+	m, ok := s.metrics.(*InMemoryMetrics)
+	if !ok {
+		return nil, fmt.Errorf("metrics are not collected in memory: %w", engine.ErrNotFound)
+	}
+	return m.Snapshot(), nil
+}
@@ -0,0 +1,221 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/rsned/spacemolt-crafting-server/internal/crafting/db"
+	"github.com/rsned/spacemolt-crafting-server/internal/crafting/engine"
+	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
+)
+
+// testServer returns a server backed by an in-memory database seeded with
+// the given recipes.
+func testServer(t *testing.T, recipes ...crafting.Recipe) *Server {
+	t.Helper()
+	return NewServer(engine.New(testDatabase(t, recipes...), nil), nil)
+}
+
+// testDatabase returns an initialized in-memory database seeded with the
+// given recipes.
+func testDatabase(t *testing.T, recipes ...crafting.Recipe) *db.DB {
+	t.Helper()
+
+	database, err := db.Open(":memory:")
+	if err != nil {
+		t.Fatalf("opening database: %v", err)
+	}
+	t.Cleanup(func() { _ = database.Close() })
+
+	if err := db.InitSchema(context.Background(), database.DB); err != nil {
+		t.Fatalf("initializing schema: %v", err)
+	}
+	if len(recipes) > 0 {
+		if err := db.NewRecipeStore(database).BulkInsertRecipes(context.Background(), recipes); err != nil {
+			t.Fatalf("inserting recipes: %v", err)
+		}
+	}
+
+	return database
+}
+
+func TestToolsCallDomainErrors(t *testing.T) {
+	ctx := context.Background()
+	database := testDatabase(t)
+	skills := []crafting.Skill{
+		{ID: "a", Name: "A", Category: "Test", MaxLevel: 1, Prerequisites: []crafting.SkillRequirement{{SkillID: "b", LevelRequired: 1}}},
+		{ID: "b", Name: "B", Category: "Test", MaxLevel: 1, Prerequisites: []crafting.SkillRequirement{{SkillID: "a", LevelRequired: 1}}},
+	}
+	if err := db.NewSkillStore(database).BulkInsertSkills(ctx, skills); err != nil {
+		t.Fatalf("inserting skills: %v", err)
+	}
+	s := NewServer(engine.New(database, nil), nil)
+
+	tests := []struct {
+		name    string
+		params  string
+		wantMsg string
+	}{
+		{
+			name:    "not found",
+			params:  `{"name":"bill_of_materials","arguments":{"recipe_id":"missing"}}`,
+			wantMsg: "recipe not found: missing",
+		},
+		{
+			name:    "invalid input",
+			params:  `{"name":"price_history","arguments":{"component_id":"ore","station_id":"s","since":"yesterday"}}`,
+			wantMsg: `invalid since timestamp: parsing time "yesterday" as "2006-01-02T15:04:05Z07:00": cannot parse "yesterday" as "2006"`,
+		},
+		{
+			name:    "prerequisite cycle",
+			params:  `{"name":"skill_prerequisites","arguments":{"skill_id":"a"}}`,
+			wantMsg: "prerequisite cycle detected: a -> b -> a",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := s.handleToolsCall(ctx, json.RawMessage(tt.params))
+			if err != nil {
+				t.Fatalf("expected a tool result, got RPC error: %v", err)
+			}
+			call, ok := result.(ToolCallResult)
+			if !ok {
+				t.Fatalf("expected ToolCallResult, got %T", result)
+			}
+			if !call.IsError {
+				t.Error("expected IsError to be set")
+			}
+			if len(call.Content) != 1 || call.Content[0].Text != tt.wantMsg {
+				t.Errorf("expected message %q, got %+v", tt.wantMsg, call.Content)
+			}
+		})
+	}
+}
+
+func TestToolsCallStructuredContent(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		clientVersion  string
+		wantVersion    string
+		wantStructured bool
+	}{
+		{"2024-11-05", "2024-11-05", false},
+		{"2025-06-18", "2025-06-18", true},
+		{"2099-01-01", "2025-06-18", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.clientVersion, func(t *testing.T) {
+			s := testServer(t)
+
+			init, err := s.handleInitialize(ctx, json.RawMessage(`{"protocolVersion":"`+tt.clientVersion+`"}`))
+			if err != nil {
+				t.Fatalf("handleInitialize failed: %v", err)
+			}
+			if got := init.(InitializeResult).ProtocolVersion; got != tt.wantVersion {
+				t.Errorf("expected protocol version %s, got %s", tt.wantVersion, got)
+			}
+
+			result, err := s.handleToolsCall(ctx, json.RawMessage(`{"name":"validate_recipes","arguments":{}}`))
+			if err != nil {
+				t.Fatalf("handleToolsCall failed: %v", err)
+			}
+			call := result.(ToolCallResult)
+			if len(call.Content) != 1 || call.Content[0].Type != "text" {
+				t.Errorf("expected a text content block, got %+v", call.Content)
+			}
+			if got := call.StructuredContent != nil; got != tt.wantStructured {
+				t.Errorf("expected structured content %v, got %+v", tt.wantStructured, call.StructuredContent)
+			}
+		})
+	}
+}
+
+func TestToolsCallTimeout(t *testing.T) {
+	ctx := context.Background()
+	s := testServer(t)
+	s.SetToolTimeout(time.Nanosecond)
+
+	result, err := s.handleToolsCall(ctx, json.RawMessage(`{"name":"list_categories","arguments":{}}`))
+	if err != nil {
+		t.Fatalf("expected a tool result, got RPC error: %v", err)
+	}
+	call := result.(ToolCallResult)
+	if !call.IsError {
+		t.Fatal("expected IsError to be set")
+	}
+	if want := "list_categories timed out after 1ns"; len(call.Content) != 1 || call.Content[0].Text != want {
+		t.Errorf("expected message %q, got %+v", want, call.Content)
+	}
+
+	// Disabling the deadline lets the same call succeed.
+	s.SetToolTimeout(0)
+	result, err = s.handleToolsCall(ctx, json.RawMessage(`{"name":"list_categories","arguments":{}}`))
+	if err != nil {
+		t.Fatalf("handleToolsCall: %v", err)
+	}
+	if call := result.(ToolCallResult); call.IsError {
+		t.Errorf("unexpected tool error: %+v", call.Content)
+	}
+}
+
+func TestReadOnlyHidesWriteTools(t *testing.T) {
+	ctx := context.Background()
+	path := t.TempDir() + "/crafting.db"
+
+	rw, err := db.OpenAndInit(ctx, path)
+	if err != nil {
+		t.Fatalf("OpenAndInit: %v", err)
+	}
+	_ = rw.Close()
+
+	ro, err := db.OpenReadOnly(ctx, path)
+	if err != nil {
+		t.Fatalf("OpenReadOnly: %v", err)
+	}
+	t.Cleanup(func() { _ = ro.Close() })
+	s := NewServer(engine.New(ro, nil), nil)
+
+	list, err := s.handleToolsList(ctx, nil)
+	if err != nil {
+		t.Fatalf("handleToolsList: %v", err)
+	}
+	for _, tool := range list.(ToolsListResult).Tools {
+		if writeTools[tool.Name] {
+			t.Errorf("write tool %s listed for a read-only database", tool.Name)
+		}
+	}
+
+	result, err := s.handleToolsCall(ctx, json.RawMessage(`{"name":"set_market_price","arguments":{"component_id":"ore","station_id":"s","sell_price":5}}`))
+	if err != nil {
+		t.Fatalf("expected a tool result, got RPC error: %v", err)
+	}
+	call := result.(ToolCallResult)
+	if want := "set_market_price: database is read-only"; !call.IsError || len(call.Content) != 1 || call.Content[0].Text != want {
+		t.Errorf("expected tool error %q, got %+v", want, call)
+	}
+}
+
+func TestComponentUsesReadsComponentID(t *testing.T) {
+	ctx := context.Background()
+	s := testServer(t)
+
+	result, err := s.handleToolsCall(ctx, json.RawMessage(`{"name":"component_uses","arguments":{"component_id":"ore"}}`))
+	if err != nil {
+		t.Fatalf("handleToolsCall failed: %v", err)
+	}
+	call := result.(ToolCallResult)
+	var resp struct {
+		ItemID string `json:"item_id"`
+	}
+	if call.IsError || len(call.Content) != 1 || json.Unmarshal([]byte(call.Content[0].Text), &resp) != nil {
+		t.Fatalf("expected a JSON result, got %+v", call)
+	}
+	if resp.ItemID != "ore" {
+		t.Errorf("expected component_id to be used as the item, got %q", resp.ItemID)
+	}
+}
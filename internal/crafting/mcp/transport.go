@@ -0,0 +1,122 @@
+package mcp
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Transport selects how messages are framed on the stdio stream.
+type Transport string
+
+// Supported transports.
+const (
+	// TransportNewline frames each message as a single line of JSON.
+	TransportNewline Transport = "newline"
+	// TransportContentLength frames each message with LSP-style
+	// Content-Length headers.
+	TransportContentLength Transport = "content-length"
+)
+
+// ParseTransport validates a transport name from the command line.
+func ParseTransport(name string) (Transport, error) {
+	switch t := Transport(name); t {
+	case TransportNewline, TransportContentLength:
+		return t, nil
+	default:
+		return "", fmt.Errorf("unknown transport %q (expected %q or %q)", name, TransportNewline, TransportContentLength)
+	}
+}
+
+// framer reads and writes whole messages on a stream. WriteMessage is only
+// called by one goroutine at a time.
+type framer interface {
+	ReadMessage() ([]byte, error)
+	WriteMessage(data []byte) error
+}
+
+// newFramer returns the framer for a transport.
+func newFramer(t Transport, r io.Reader, w io.Writer) framer {
+	if t == TransportContentLength {
+		return &contentLengthFramer{reader: bufio.NewReader(r), writer: w}
+	}
+	return &newlineFramer{reader: bufio.NewReader(r), writer: w}
+}
+
+// newlineFramer implements newline-delimited JSON.
+type newlineFramer struct {
+	reader *bufio.Reader
+	writer io.Writer
+}
+
+func (f *newlineFramer) ReadMessage() ([]byte, error) {
+	return f.reader.ReadBytes('\n')
+}
+
+func (f *newlineFramer) WriteMessage(data []byte) error {
+	_, err := f.writer.Write(append(data, '\n'))
+	return err
+}
+
+// maxMessageSize caps the Content-Length a client may declare, so a bogus
+// header cannot make the server allocate an arbitrarily large body.
+const maxMessageSize = 64 << 20
+
+// contentLengthFramer implements LSP-style framing: a block of headers
+// terminated by a blank line, followed by a body of Content-Length bytes.
+type contentLengthFramer struct {
+	reader *bufio.Reader
+	writer io.Writer
+}
+
+func (f *contentLengthFramer) ReadMessage() ([]byte, error) {
+	length := -1
+	for {
+		line, err := f.reader.ReadString('\n')
+		if err != nil {
+			if err == io.EOF && (line != "" || length >= 0) {
+				return nil, io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			if length < 0 {
+				// Tolerate blank lines between messages
+				continue
+			}
+			break
+		}
+
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("malformed header %q", line)
+		}
+		if strings.EqualFold(strings.TrimSpace(name), "Content-Length") {
+			length, err = strconv.Atoi(strings.TrimSpace(value))
+			if err != nil || length < 0 {
+				return nil, fmt.Errorf("invalid Content-Length %q", value)
+			}
+			if length > maxMessageSize {
+				return nil, fmt.Errorf("message of %d bytes exceeds the %d byte limit", length, maxMessageSize)
+			}
+		}
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(f.reader, body); err != nil {
+		return nil, fmt.Errorf("reading message body: %w", err)
+	}
+	return body, nil
+}
+
+func (f *contentLengthFramer) WriteMessage(data []byte) error {
+	if _, err := fmt.Fprintf(f.writer, "Content-Length: %d\r\n\r\n", len(data)); err != nil {
+		return err
+	}
+	_, err := f.writer.Write(data)
+	return err
+}
@@ -0,0 +1,81 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestContentLengthFramer(t *testing.T) {
+	var out bytes.Buffer
+	f := newFramer(TransportContentLength, nil, &out)
+	if err := f.WriteMessage([]byte(`{"id":1}`)); err != nil {
+		t.Fatalf("WriteMessage failed: %v", err)
+	}
+	if want := "Content-Length: 8\r\n\r\n{\"id\":1}"; out.String() != want {
+		t.Errorf("unexpected framing: got %q, want %q", out.String(), want)
+	}
+
+	input := "Content-Length: 8\r\nContent-Type: application/vscode-jsonrpc; charset=utf-8\r\n\r\n{\"id\":1}" +
+		"\r\ncontent-length: 9\r\n\r\n{\"id\":22}"
+	f = newFramer(TransportContentLength, strings.NewReader(input), nil)
+	for _, want := range []string{`{"id":1}`, `{"id":22}`} {
+		msg, err := f.ReadMessage()
+		if err != nil {
+			t.Fatalf("ReadMessage failed: %v", err)
+		}
+		if string(msg) != want {
+			t.Errorf("expected message %s, got %s", want, msg)
+		}
+	}
+	if _, err := f.ReadMessage(); err != io.EOF {
+		t.Errorf("expected io.EOF after last message, got %v", err)
+	}
+
+	f = newFramer(TransportContentLength, strings.NewReader("Content-Length: 20\r\n\r\n{}"), nil)
+	if _, err := f.ReadMessage(); err == nil {
+		t.Error("expected error for truncated body")
+	}
+
+	for _, length := range []string{strconv.Itoa(maxMessageSize + 1), "99999999999999"} {
+		f = newFramer(TransportContentLength, strings.NewReader("Content-Length: "+length+"\r\n\r\n{}"), nil)
+		if _, err := f.ReadMessage(); err == nil {
+			t.Errorf("expected error for Content-Length %s", length)
+		}
+	}
+}
+
+func TestServeContentLength(t *testing.T) {
+	s := NewServer(nil, nil)
+	s.SetTransport(TransportContentLength)
+
+	body := `{"jsonrpc":"2.0","id":1,"method":"initialize"}`
+	input := "Content-Length: " + strconv.Itoa(len(body)) + "\r\n\r\n" + body
+
+	var out bytes.Buffer
+	if err := s.serve(context.Background(), strings.NewReader(input), &out); err != nil {
+		t.Fatalf("serve failed: %v", err)
+	}
+
+	msg, err := newFramer(TransportContentLength, &out, nil).ReadMessage()
+	if err != nil {
+		t.Fatalf("reading response: %v", err)
+	}
+	if !strings.Contains(string(msg), `"protocolVersion"`) {
+		t.Errorf("expected initialize result, got %s", msg)
+	}
+}
+
+func TestParseTransport(t *testing.T) {
+	for _, name := range []string{"newline", "content-length"} {
+		if _, err := ParseTransport(name); err != nil {
+			t.Errorf("ParseTransport(%q) failed: %v", name, err)
+		}
+	}
+	if _, err := ParseTransport("websocket"); err == nil {
+		t.Error("expected error for unknown transport")
+	}
+}
@@ -0,0 +1,122 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+)
+
+// validateArguments checks tool arguments against the tool's input schema:
+// required fields, basic types, numeric bounds and enums. Properties the
+// schema does not describe are left for the tool to ignore.
+func validateArguments(schema JSONSchema, args json.RawMessage) error {
+	obj := map[string]any{}
+	if len(args) > 0 && string(args) != "null" {
+		if err := json.Unmarshal(args, &obj); err != nil {
+			return fmt.Errorf("arguments must be a JSON object: %w", err)
+		}
+	}
+	return validateObject("", schema.Properties, schema.Required, nil, obj)
+}
+
+// validateObject checks an object's required fields and each property, using
+// additional for properties the schema does not name.
+func validateObject(path string, props map[string]Property, required []string, additional *Property, obj map[string]any) error {
+	for _, name := range required {
+		if v, ok := obj[name]; !ok || v == nil {
+			return fmt.Errorf("missing required field %q", joinPath(path, name))
+		}
+	}
+
+	// Check in a stable order so the reported field is deterministic
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		prop, ok := props[name]
+		if !ok {
+			if additional == nil {
+				continue
+			}
+			prop = *additional
+		}
+		if err := validateValue(joinPath(path, name), prop, obj[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateValue checks a single value against its property schema.
+func validateValue(path string, prop Property, value any) error {
+	if value == nil {
+		// Explicit nulls are treated like omitted optional fields
+		return nil
+	}
+
+	switch prop.Type {
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("field %q must be a string", path)
+		}
+		if len(prop.Enum) > 0 && !slices.Contains(prop.Enum, s) {
+			return fmt.Errorf("field %q must be one of %s, got %q", path, strings.Join(prop.Enum, ", "), s)
+		}
+	case "integer", "number":
+		n, ok := value.(float64)
+		if !ok && prop.Type == "integer" {
+			return fmt.Errorf("field %q must be an integer", path)
+		}
+		if !ok {
+			return fmt.Errorf("field %q must be a number", path)
+		}
+		if prop.Type == "integer" && n != math.Trunc(n) {
+			return fmt.Errorf("field %q must be an integer, got %v", path, n)
+		}
+		if prop.Minimum != nil && n < *prop.Minimum {
+			return fmt.Errorf("field %q must be at least %v, got %v", path, *prop.Minimum, n)
+		}
+		if prop.Maximum != nil && n > *prop.Maximum {
+			return fmt.Errorf("field %q must be at most %v, got %v", path, *prop.Maximum, n)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("field %q must be a boolean", path)
+		}
+	case "array":
+		items, ok := value.([]any)
+		if !ok {
+			return fmt.Errorf("field %q must be an array", path)
+		}
+		if prop.Items != nil {
+			for i, item := range items {
+				if err := validateValue(fmt.Sprintf("%s[%d]", path, i), *prop.Items, item); err != nil {
+					return err
+				}
+			}
+		}
+	case "object":
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("field %q must be an object", path)
+		}
+		if err := validateObject(path, prop.Properties, prop.Required, prop.AdditionalProperties, obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// joinPath builds a dotted field path for error messages.
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
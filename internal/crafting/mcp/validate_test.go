@@ -0,0 +1,70 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestValidateArguments(t *testing.T) {
+	tool, ok := findTool("craft_query")
+	if !ok {
+		t.Fatal("craft_query tool not found")
+	}
+
+	tests := []struct {
+		name    string
+		args    string
+		wantErr string
+	}{
+		{"valid", `{"components":[{"id":"ore","quantity":5}],"min_match_ratio":0.5,"optimization_strategy":"USE_INVENTORY_FIRST"}`, ""},
+		{"missing required", `{}`, `missing required field "components"`},
+		{"null arguments", `null`, `missing required field "components"`},
+		{"wrong type", `{"components":"ore"}`, `field "components" must be an array`},
+		{"nested required", `{"components":[{"id":"ore"}]}`, `missing required field "components[0].quantity"`},
+		{"non-integer", `{"components":[{"id":"ore","quantity":1.5}]}`, `field "components[0].quantity" must be an integer`},
+		{"below minimum", `{"components":[],"min_match_ratio":-0.1}`, `field "min_match_ratio" must be at least 0`},
+		{"above maximum", `{"components":[],"min_match_ratio":1.5}`, `field "min_match_ratio" must be at most 1`},
+		{"bad enum", `{"components":[],"optimization_strategy":"FASTEST"}`, `field "optimization_strategy" must be one of`},
+		{"unknown field ignored", `{"components":[],"extra":true}`, ""},
+		{"not an object", `[1,2]`, "arguments must be a JSON object"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateArguments(tool.InputSchema, json.RawMessage(tt.args))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	tool, _ = findTool("skill_prerequisites")
+	err := validateArguments(tool.InputSchema, json.RawMessage(`{"skill_id":"x","skills":{"mining":"high"}}`))
+	if err == nil || !strings.Contains(err.Error(), `field "skills.mining" must be an integer`) {
+		t.Errorf("expected additional property error, got %v", err)
+	}
+}
+
+func TestToolsCallInvalidParams(t *testing.T) {
+	s := NewServer(nil, nil)
+
+	resp := s.handleRequest(context.Background(),
+		[]byte(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"component_uses","arguments":{}}}`))
+	if resp == nil || resp.Error == nil {
+		t.Fatalf("expected error response, got %+v", resp)
+	}
+	if resp.Error.Code != ErrCodeInvalidParams {
+		t.Errorf("expected code %d, got %d", ErrCodeInvalidParams, resp.Error.Code)
+	}
+	if !strings.Contains(resp.Error.Message, "component_id") {
+		t.Errorf("expected message naming component_id, got %q", resp.Error.Message)
+	}
+}
@@ -0,0 +1,42 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+)
+
+// SetDryRun makes every import run against a throwaway copy of the
+// database, so it reports what it would change without changing anything.
+// Dry runs are allowed on read-only databases.
+func (s *Syncer) SetDryRun(dryRun bool) {
+	s.dryRun = dryRun
+}
+
+// dryRunImport runs fn against a scratch copy of the database and discards
+// the copy afterwards. Each store commits its own transactions, so a copy
+// is the only way to see the combined effect of an import and still throw
+// it away. The copy is validated after the import so problems it would
+// introduce are reported alongside the counts.
+func (s *Syncer) dryRunImport(ctx context.Context, fn func(scratch *Syncer) (ImportResult, error)) (ImportResult, error) {
+	scratchDB, cleanup, err := s.db.ScratchCopy(ctx)
+	if err != nil {
+		return ImportResult{}, fmt.Errorf("preparing dry run: %w", err)
+	}
+	defer cleanup()
+
+	scratch := NewSyncer(scratchDB)
+	result, err := fn(scratch)
+	if err != nil {
+		return ImportResult{}, err
+	}
+
+	report, err := scratch.ValidateReferences(ctx)
+	if err != nil {
+		return ImportResult{}, err
+	}
+	result.DryRun = true
+	if report.HasProblems() {
+		result.Validation = report
+	}
+	return result, nil
+}
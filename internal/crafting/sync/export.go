@@ -0,0 +1,127 @@
+package sync
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/rsned/spacemolt-crafting-server/internal/crafting/db"
+	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
+)
+
+// writeJSON writes v to w as indented JSON.
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("writing JSON: %w", err)
+	}
+	return nil
+}
+
+// ExportRecipes writes every recipe to w as a JSON array in the format read
+// by ImportRecipes, ordered by recipe ID.
+func (s *Syncer) ExportRecipes(ctx context.Context, w io.Writer) error {
+	recipes, err := db.NewRecipeStore(s.db).GetAllRecipes(ctx)
+	if err != nil {
+		return fmt.Errorf("loading recipes: %w", err)
+	}
+	slices.SortFunc(recipes, func(a, b crafting.Recipe) int { return cmp.Compare(a.ID, b.ID) })
+
+	exports := make([]RecipeImport, 0, len(recipes))
+	for _, r := range recipes {
+		exports = append(exports, exportRecipe(r))
+	}
+	return writeJSON(w, exports)
+}
+
+// exportRecipe converts domain format to import format.
+func exportRecipe(r crafting.Recipe) RecipeImport {
+	imp := RecipeImport{
+		ID:           r.ID,
+		Name:         r.Name,
+		Description:  r.Description,
+		Category:     r.Category,
+		CraftingTime: r.CraftingTime,
+		SuccessRate:  r.SuccessRate,
+		Tags:         r.Tags,
+	}
+	for _, inp := range r.Inputs {
+		imp.Inputs = append(imp.Inputs, RecipeInputImport{
+			ItemID:       inp.ItemID,
+			Quantity:     inp.Quantity,
+			Alternatives: inp.Alternatives,
+		})
+	}
+	for _, out := range r.Outputs {
+		imp.Outputs = append(imp.Outputs, RecipeOutputImport{
+			ItemID:   out.ItemID,
+			Quantity: out.Quantity,
+		})
+	}
+	return imp
+}
+
+// ExportSkills writes every skill to w as a JSON array in the format read by
+// ImportSkills, ordered by skill ID.
+func (s *Syncer) ExportSkills(ctx context.Context, w io.Writer) error {
+	skills, err := db.NewSkillStore(s.db).GetAllSkills(ctx)
+	if err != nil {
+		return fmt.Errorf("loading skills: %w", err)
+	}
+
+	exports := make([]SkillImport, 0, len(skills))
+	for _, sk := range skills {
+		exports = append(exports, exportSkill(sk))
+	}
+	return writeJSON(w, exports)
+}
+
+// exportSkill converts domain format to import format.
+func exportSkill(sk crafting.Skill) SkillImport {
+	imp := SkillImport{
+		ID:             sk.ID,
+		Name:           sk.Name,
+		Description:    sk.Description,
+		Category:       sk.Category,
+		MaxLevel:       sk.MaxLevel,
+		TrainingSource: sk.TrainingSource,
+		XPPerLevel:     sk.XPPerLevel,
+		BonusPerLevel:  sk.BonusPerLevel,
+		RequiredSkills: sk.RequiredSkills,
+		XPThresholds:   sk.XPThresholds,
+	}
+	for _, p := range sk.Prerequisites {
+		imp.Prerequisites = append(imp.Prerequisites, SkillPrerequisiteImport{
+			SkillID: p.SkillID,
+			Level:   p.LevelRequired,
+		})
+	}
+	return imp
+}
+
+// ExportMarketData writes the recorded price history to w as a JSON array
+// in the flat format read by ImportMarketData. Order books and derived
+// statistics are not exported; importing the output rebuilds the summaries.
+func (s *Syncer) ExportMarketData(ctx context.Context, w io.Writer) error {
+	points, err := db.NewMarketStore(s.db).GetAllMarketData(ctx)
+	if err != nil {
+		return fmt.Errorf("loading market data: %w", err)
+	}
+
+	exports := make([]MarketDataImport, 0, len(points))
+	for _, p := range points {
+		exports = append(exports, MarketDataImport{
+			ItemID:    p.ItemID,
+			StationID: p.StationID,
+			BuyPrice:  p.BuyPrice,
+			SellPrice: p.SellPrice,
+			Volume24h: p.Volume24h,
+			Timestamp: p.Timestamp,
+		})
+	}
+	return writeJSON(w, exports)
+}
@@ -0,0 +1,98 @@
+package sync
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/rsned/spacemolt-crafting-server/internal/crafting/db"
+)
+
+// TestExportRoundTrip verifies that exported data imports into an empty
+// database and exports again unchanged.
+func TestExportRoundTrip(t *testing.T) {
+	ctx := context.Background()
+
+	newSyncer := func() *Syncer {
+		t.Helper()
+		database, err := db.OpenAndInit(ctx, ":memory:")
+		if err != nil {
+			t.Fatalf("OpenAndInit: %v", err)
+		}
+		t.Cleanup(func() { _ = database.Close() })
+		return NewSyncer(database)
+	}
+
+	tests := []struct {
+		name     string
+		input    string
+		doImport func(*Syncer, io.Reader) error
+		export   func(*Syncer, io.Writer) error
+		want     []string
+	}{
+		{
+			name: "recipes",
+			input: `[
+				{"id": "frame", "name": "Frame", "category": "Parts", "crafting_time": 30, "success_rate": 0.9, "tags": ["Structural"],
+				 "inputs": [{"item_id": "steel_bar", "quantity": 2, "alternatives": ["titanium_bar"]}],
+				 "outputs": [{"item_id": "frame", "quantity": 1}]},
+				{"id": "bar", "name": "Bar", "components": [{"id": "ore", "quantity": 3}], "output_item_id": "steel_bar", "output_quantity": 2}
+			]`,
+			doImport: func(s *Syncer, r io.Reader) error { _, err := s.ImportRecipes(ctx, r); return err },
+			export:   func(s *Syncer, w io.Writer) error { return s.ExportRecipes(ctx, w) },
+			want:     []string{`"id": "bar"`, `"item_id": "steel_bar"`, `"alternatives": [`, `"structural"`},
+		},
+		{
+			name: "skills",
+			input: `[
+				{"id": "mining", "name": "Mining", "max_level": 5, "xp_thresholds": [100, 300]},
+				{"id": "deep_mining", "name": "Deep Mining", "prerequisites": [{"skill_id": "mining", "level": 3}]}
+			]`,
+			doImport: func(s *Syncer, r io.Reader) error { _, err := s.ImportSkills(ctx, r); return err },
+			export:   func(s *Syncer, w io.Writer) error { return s.ExportSkills(ctx, w) },
+			want:     []string{`"skill_id": "mining"`, `"xp_thresholds": [`},
+		},
+		{
+			name: "market data",
+			input: `[
+				{"item_id": "ore", "station_id": "alpha", "buy_price": 10, "sell_price": 12, "volume_24h": 500, "timestamp": "2026-01-02T03:04:05Z"},
+				{"component_id": "bar", "station_id": "alpha", "sell_price": 40, "timestamp": "2026-01-02T03:04:05Z"}
+			]`,
+			doImport: func(s *Syncer, r io.Reader) error { _, err := s.ImportMarketData(ctx, r); return err },
+			export:   func(s *Syncer, w io.Writer) error { return s.ExportMarketData(ctx, w) },
+			want:     []string{`"item_id": "bar"`, `"buy_price": 10`, `"timestamp": "2026-01-02T03:04:05Z"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first := newSyncer()
+			if err := tt.doImport(first, strings.NewReader(tt.input)); err != nil {
+				t.Fatalf("import: %v", err)
+			}
+			var exported bytes.Buffer
+			if err := tt.export(first, &exported); err != nil {
+				t.Fatalf("export: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(exported.String(), want) {
+					t.Errorf("export missing %s:\n%s", want, exported.String())
+				}
+			}
+
+			second := newSyncer()
+			if err := tt.doImport(second, bytes.NewReader(exported.Bytes())); err != nil {
+				t.Fatalf("re-import: %v", err)
+			}
+			var again bytes.Buffer
+			if err := tt.export(second, &again); err != nil {
+				t.Fatalf("second export: %v", err)
+			}
+			if again.String() != exported.String() {
+				t.Errorf("round trip changed the export:\nfirst:\n%s\nsecond:\n%s", exported.String(), again.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package sync
+
+import (
+	"context"
+	"log/slog"
+	"reflect"
+)
+
+// ImportResult reports how an import changed the database, counted by
+// record ID.
+type ImportResult struct {
+	Inserted  int `json:"inserted"`  // records that did not exist before
+	Updated   int `json:"updated"`   // existing records whose data changed
+	Unchanged int `json:"unchanged"` // existing records imported as they were
+	Skipped   int `json:"skipped"`   // entries without an ID, or superseded by a later entry with the same ID
+	Removed   int `json:"removed"`   // records deleted because the import replaces the full set
+
+	// DryRun is set when the counts describe an import that was not kept.
+	DryRun bool `json:"dry_run,omitempty"`
+
+	// Validation lists problems the import would leave in the database.
+	// It is only filled in by dry runs, and only when there are problems.
+	Validation *ValidationReport `json:"validation,omitempty"`
+}
+
+// LogValue lets an ImportResult be logged as a group of counts.
+func (r ImportResult) LogValue() slog.Value {
+	attrs := []slog.Attr{
+		slog.Int("inserted", r.Inserted),
+		slog.Int("updated", r.Updated),
+		slog.Int("unchanged", r.Unchanged),
+		slog.Int("skipped", r.Skipped),
+		slog.Int("removed", r.Removed),
+	}
+	if r.DryRun {
+		attrs = append(attrs, slog.Bool("dry_run", true))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// dedupeByID drops entries without an ID and all but the last entry for
+// each repeated ID, which is the one an import ends up storing. It returns
+// the kept entries in input order and the number dropped.
+func dedupeByID[T any](entries []T, id func(T) string) ([]T, int) {
+	last := make(map[string]int, len(entries))
+	for i, e := range entries {
+		if k := id(e); k != "" {
+			last[k] = i
+		}
+	}
+
+	kept := make([]T, 0, len(last))
+	for i, e := range entries {
+		if k := id(e); k != "" && last[k] == i {
+			kept = append(kept, e)
+		}
+	}
+	return kept, len(entries) - len(kept)
+}
+
+// ids returns the ID of each entry.
+func ids[T any](entries []T, id func(T) string) []string {
+	out := make([]string, len(entries))
+	for i, e := range entries {
+		out[i] = id(e)
+	}
+	return out
+}
+
+// countChanges compares records read before and after an import of ids.
+// Records missing from after were removed by the import.
+func countChanges[T any](before, after map[string]T, ids []string) ImportResult {
+	var res ImportResult
+	for _, id := range ids {
+		old, existed := before[id]
+		switch {
+		case !existed:
+			res.Inserted++
+		case reflect.DeepEqual(old, after[id]):
+			res.Unchanged++
+		default:
+			res.Updated++
+		}
+	}
+	for id := range before {
+		if _, ok := after[id]; !ok {
+			res.Removed++
+		}
+	}
+	return res
+}
+
+// snapshot loads records with load and indexes them by ID.
+func snapshot[T any](ctx context.Context, load func(context.Context) ([]T, error), id func(T) string) (map[string]T, error) {
+	records, err := load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]T, len(records))
+	for _, r := range records {
+		m[id(r)] = r
+	}
+	return m, nil
+}
@@ -0,0 +1,108 @@
+package sync
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// jsonInput is import data whose format has been sniffed. It is either one
+// JSON document (an array, a {"items": [...]} envelope or a view_market
+// response) held in doc, or a JSON Lines stream of one record per line that
+// is decoded a record at a time so large files never sit in memory whole.
+type jsonInput struct {
+	doc json.RawMessage
+
+	dec     *json.Decoder
+	pending json.RawMessage // first JSON Lines record, read while sniffing
+	records int
+}
+
+// readJSONInput reads the first JSON value from r to tell the formats
+// apart. An array, or an object with an "items" or "action" key and
+// nothing after it, is a single document; anything else is JSON Lines.
+func readJSONInput(r io.Reader) (*jsonInput, error) {
+	dec := json.NewDecoder(r)
+	var first json.RawMessage
+	if err := dec.Decode(&first); err != nil {
+		return nil, fmt.Errorf("parsing JSON: %w", err)
+	}
+
+	if first[0] == '[' {
+		if dec.More() {
+			return nil, errors.New("parsing JSON: unexpected data after top-level array")
+		}
+		return &jsonInput{doc: first}, nil
+	}
+	if !dec.More() && isDocumentObject(first) {
+		return &jsonInput{doc: first}, nil
+	}
+	return &jsonInput{dec: dec, pending: first}, nil
+}
+
+// isDocumentObject reports whether obj is an {"items": [...]} envelope or a
+// view_market response rather than a single record.
+func isDocumentObject(obj json.RawMessage) bool {
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(obj, &keys); err != nil {
+		return false
+	}
+	_, items := keys["items"]
+	_, action := keys["action"]
+	return items || action
+}
+
+// lines reports whether the input is a JSON Lines stream.
+func (in *jsonInput) lines() bool {
+	return in.dec != nil
+}
+
+// next decodes the next JSON Lines record into v. It returns false once
+// the stream is exhausted.
+func (in *jsonInput) next(v any) (bool, error) {
+	raw := in.pending
+	in.pending = nil
+	if raw == nil {
+		if err := in.dec.Decode(&raw); err == io.EOF {
+			return false, nil
+		} else if err != nil {
+			return false, fmt.Errorf("parsing JSON line %d: %w", in.records+1, err)
+		}
+	}
+	in.records++
+
+	if err := json.Unmarshal(raw, v); err != nil {
+		return false, fmt.Errorf("parsing JSON line %d: %w", in.records, err)
+	}
+	return true, nil
+}
+
+// decodeAll decodes every record in the input, whichever format it is in.
+// Documents may wrap their records in an {"items": [...]} envelope.
+func decodeAll[T any](in *jsonInput) ([]T, error) {
+	if !in.lines() {
+		itemsData, err := unwrapItems(in.doc)
+		if err != nil {
+			return nil, fmt.Errorf("unwrapping items: %w", err)
+		}
+		var out []T
+		if err := json.Unmarshal(itemsData, &out); err != nil {
+			return nil, fmt.Errorf("parsing JSON: %w", err)
+		}
+		return out, nil
+	}
+
+	var out []T
+	for {
+		var v T
+		ok, err := in.next(&v)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return out, nil
+		}
+		out = append(out, v)
+	}
+}
@@ -0,0 +1,86 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rsned/spacemolt-crafting-server/internal/crafting/db"
+)
+
+func TestReadJSONInputDetectsFormat(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		lines bool
+	}{
+		{"array", `[{"id": "a"}, {"id": "b"}]`, false},
+		{"envelope", `{"items": [{"id": "a"}], "total": 1}`, false},
+		{"view_market", `{"action": "view_market", "base": "sol", "items": []}`, false},
+		{"json lines", "{\"id\": \"a\"}\n{\"id\": \"b\"}\n", true},
+		{"single record", `{"id": "a"}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in, err := readJSONInput(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("readJSONInput: %v", err)
+			}
+			if in.lines() != tt.lines {
+				t.Errorf("lines() = %v, want %v", in.lines(), tt.lines)
+			}
+		})
+	}
+}
+
+func TestImportJSONLines(t *testing.T) {
+	ctx := context.Background()
+	database, err := db.OpenAndInit(ctx, ":memory:")
+	if err != nil {
+		t.Fatalf("OpenAndInit: %v", err)
+	}
+	defer func() { _ = database.Close() }()
+	syncer := NewSyncer(database)
+
+	result, err := syncer.ImportItems(ctx, strings.NewReader(
+		"{\"id\": \"ore_iron\", \"name\": \"Iron Ore\"}\n{\"id\": \"ore_copper\", \"name\": \"Copper Ore\"}\n"))
+	if err != nil {
+		t.Fatalf("ImportItems: %v", err)
+	}
+	if result.Inserted != 2 {
+		t.Errorf("Inserted = %d, want 2", result.Inserted)
+	}
+
+	if _, err := syncer.ImportItems(ctx, strings.NewReader("{\"id\": \"ore_tin\"}\n{\"id\": ")); err == nil ||
+		!strings.Contains(err.Error(), "line 2") {
+		t.Errorf("expected an error naming line 2, got %v", err)
+	}
+
+	// Enough records to span several batches.
+	n := marketLinesBatchSize*2 + 10
+	start := time.Now().Add(-24 * time.Hour).UTC()
+	var b strings.Builder
+	for i := range n {
+		fmt.Fprintf(&b, `{"item_id": "ore_iron", "station_id": "station_%d", "buy_price": 5, "timestamp": %q}`+"\n",
+			i%3, start.Add(time.Duration(i)*time.Second).Format(time.RFC3339))
+	}
+	if _, err := syncer.ImportMarketDataWithOptions(ctx, strings.NewReader(b.String()), MarketImportOptions{Incremental: true}); err != nil {
+		t.Fatalf("ImportMarketDataWithOptions: %v", err)
+	}
+
+	var prices, summaries int
+	if err := database.QueryRowContext(ctx, `SELECT COUNT(*) FROM market_prices`).Scan(&prices); err != nil {
+		t.Fatalf("counting prices: %v", err)
+	}
+	if prices != n {
+		t.Errorf("market_prices has %d rows, want %d", prices, n)
+	}
+	if err := database.QueryRowContext(ctx, `SELECT COUNT(DISTINCT station_id) FROM market_price_summary`).Scan(&summaries); err != nil {
+		t.Fatalf("counting summaries: %v", err)
+	}
+	if summaries != 3 {
+		t.Errorf("summaries cover %d stations, want 3", summaries)
+	}
+}
@@ -0,0 +1,89 @@
+package sync
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/rsned/spacemolt-crafting-server/internal/crafting/db"
+)
+
+// ErrInvalidMarketData reports a strict market import that was stopped by
+// rows with impossible values.
+var ErrInvalidMarketData = errors.New("invalid market data")
+
+// RejectedMarketRow is a market row left out of an import, with the reason.
+// Row counts from 1: the array element or JSON Lines line for flat data, or
+// the entry in "items" for a view_market response.
+type RejectedMarketRow struct {
+	Row       int    `json:"row"`
+	ItemID    string `json:"item_id"`
+	StationID string `json:"station_id"`
+	Reason    string `json:"reason"`
+}
+
+// LogValue lets a RejectedMarketRow be logged as a group.
+func (r RejectedMarketRow) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Int("row", r.Row),
+		slog.String("item_id", r.ItemID),
+		slog.String("station_id", r.StationID),
+		slog.String("reason", r.Reason),
+	)
+}
+
+// MarketImportResult reports how many market rows an import wrote and which
+// it rejected.
+type MarketImportResult struct {
+	Imported int                 `json:"imported"`
+	Rejected []RejectedMarketRow `json:"rejected,omitempty"`
+}
+
+// reject records row as rejected. In strict mode it also returns the error
+// that ends the import.
+func (r *MarketImportResult) reject(row RejectedMarketRow, strict bool) error {
+	r.Rejected = append(r.Rejected, row)
+	if strict {
+		return r.strictErr()
+	}
+	return nil
+}
+
+// strictErr returns an ErrInvalidMarketData error describing the rejected
+// rows, or nil when there are none.
+func (r *MarketImportResult) strictErr() error {
+	if len(r.Rejected) == 0 {
+		return nil
+	}
+	first := r.Rejected[0]
+	return fmt.Errorf("%w: %d row(s) rejected, first at row %d (%s at %s): %s",
+		ErrInvalidMarketData, len(r.Rejected), first.Row, first.ItemID, first.StationID, first.Reason)
+}
+
+// marketPointProblem returns why p cannot be imported, or "" if it can.
+// Zero prices mean "no price" and are allowed; a sell price below the buy
+// price is a crossed market that would make every profit figure wrong.
+func marketPointProblem(p db.MarketDataPoint) string {
+	switch {
+	case p.BuyPrice < 0:
+		return fmt.Sprintf("negative buy price %d", p.BuyPrice)
+	case p.SellPrice < 0:
+		return fmt.Sprintf("negative sell price %d", p.SellPrice)
+	case p.Volume24h < 0:
+		return fmt.Sprintf("negative volume %d", p.Volume24h)
+	case p.BuyPrice > 0 && p.SellPrice > 0 && p.SellPrice < p.BuyPrice:
+		return fmt.Sprintf("sell price %d below buy price %d", p.SellPrice, p.BuyPrice)
+	}
+	return ""
+}
+
+// orderProblem returns why an order book entry cannot be imported, or "".
+func orderProblem(side string, price, quantity int) string {
+	switch {
+	case price < 0:
+		return fmt.Sprintf("negative price %d in %s order", price, side)
+	case quantity < 0:
+		return fmt.Sprintf("negative quantity %d in %s order", quantity, side)
+	}
+	return ""
+}
@@ -0,0 +1,92 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rsned/spacemolt-crafting-server/internal/crafting/db"
+)
+
+func TestImportMarketDataRejectsInvalidRows(t *testing.T) {
+	const flat = `[
+		{"item_id": "ore", "station_id": "alpha", "buy_price": 10, "sell_price": 12, "volume_24h": 5},
+		{"item_id": "bar", "station_id": "alpha", "buy_price": -1, "sell_price": 12},
+		{"item_id": "gem", "station_id": "alpha", "buy_price": 0, "sell_price": 30, "volume_24h": -3},
+		{"item_id": "rod", "station_id": "alpha", "buy_price": 20, "sell_price": 15}
+	]`
+	wantRejected := []RejectedMarketRow{
+		{Row: 2, ItemID: "bar", StationID: "alpha", Reason: "negative buy price -1"},
+		{Row: 3, ItemID: "gem", StationID: "alpha", Reason: "negative volume -3"},
+		{Row: 4, ItemID: "rod", StationID: "alpha", Reason: "sell price 15 below buy price 20"},
+	}
+
+	ctx := context.Background()
+	newSyncer := func(t *testing.T) (*Syncer, *db.DB) {
+		t.Helper()
+		database, err := db.OpenAndInit(ctx, ":memory:")
+		if err != nil {
+			t.Fatalf("OpenAndInit: %v", err)
+		}
+		t.Cleanup(func() { _ = database.Close() })
+		return NewSyncer(database), database
+	}
+	countPrices := func(t *testing.T, database *db.DB) int {
+		t.Helper()
+		var n int
+		if err := database.QueryRowContext(ctx, `SELECT COUNT(DISTINCT item_id) FROM market_prices`).Scan(&n); err != nil {
+			t.Fatalf("counting prices: %v", err)
+		}
+		return n
+	}
+
+	t.Run("lenient", func(t *testing.T) {
+		syncer, database := newSyncer(t)
+		result, err := syncer.ImportMarketData(ctx, strings.NewReader(flat))
+		if err != nil {
+			t.Fatalf("ImportMarketData: %v", err)
+		}
+		if result.Imported != 1 || !reflect.DeepEqual(result.Rejected, wantRejected) {
+			t.Errorf("result = %+v, want 1 imported and rejected %+v", result, wantRejected)
+		}
+		if n := countPrices(t, database); n != 1 {
+			t.Errorf("prices stored for %d items, want 1", n)
+		}
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		syncer, database := newSyncer(t)
+		result, err := syncer.ImportMarketDataWithOptions(ctx, strings.NewReader(flat), MarketImportOptions{Strict: true})
+		if !errors.Is(err, ErrInvalidMarketData) {
+			t.Fatalf("error = %v, want ErrInvalidMarketData", err)
+		}
+		if !reflect.DeepEqual(result.Rejected, wantRejected) {
+			t.Errorf("rejected = %+v, want %+v", result.Rejected, wantRejected)
+		}
+		if n := countPrices(t, database); n != 0 {
+			t.Errorf("strict import stored prices for %d items, want 0", n)
+		}
+	})
+
+	t.Run("view_market", func(t *testing.T) {
+		syncer, _ := newSyncer(t)
+		result, err := syncer.ImportMarketData(ctx, strings.NewReader(`{
+			"action": "view_market", "base": "alpha",
+			"items": [
+				{"item_id": "ore", "best_buy": 10, "best_sell": 12,
+				 "buy_orders": [{"price_each": 10, "quantity": 5}], "sell_orders": [{"price_each": 12, "quantity": 3}]},
+				{"item_id": "bar", "best_buy": 0, "best_sell": 40,
+				 "sell_orders": [{"price_each": 40, "quantity": -2}]}
+			]
+		}`))
+		if err != nil {
+			t.Fatalf("ImportMarketData: %v", err)
+		}
+		want := []RejectedMarketRow{{Row: 2, ItemID: "bar", StationID: "alpha", Reason: "negative quantity -2 in sell order"}}
+		if result.Imported != 1 || !reflect.DeepEqual(result.Rejected, want) {
+			t.Errorf("result = %+v, want 1 imported and rejected %+v", result, want)
+		}
+	})
+}
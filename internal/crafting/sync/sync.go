@@ -5,7 +5,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"slices"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/rsned/spacemolt-crafting-server/internal/crafting/db"
@@ -14,7 +18,8 @@ import (
 
 // Syncer handles data synchronization from SpaceMolt.
 type Syncer struct {
-	db *db.DB
+	db     *db.DB
+	dryRun bool
 }
 
 // NewSyncer creates a new Syncer.
@@ -22,6 +27,15 @@ func NewSyncer(database *db.DB) *Syncer {
 	return &Syncer{db: database}
 }
 
+// checkWritable rejects imports and clears against a read-only database
+// before any input is read.
+func (s *Syncer) checkWritable() error {
+	if s.db.ReadOnly() {
+		return db.ErrReadOnly
+	}
+	return nil
+}
+
 // unwrapItems tries to unmarshal data as a {"items": [...]} envelope first,
 // falling back to the raw data as a plain array.
 func unwrapItems(data []byte) (json.RawMessage, error) {
@@ -57,41 +71,43 @@ type ItemImport struct {
 
 // RecipeImport represents the expected format of recipe data from SpaceMolt.
 type RecipeImport struct {
-	ID           string `json:"id"`
-	Name         string `json:"name"`
-	Description  string `json:"description,omitempty"`
-	Category     string `json:"category,omitempty"`
-	CraftingTime int    `json:"crafting_time,omitempty"`
+	ID           string   `json:"id"`
+	Name         string   `json:"name"`
+	Description  string   `json:"description,omitempty"`
+	Category     string   `json:"category,omitempty"`
+	CraftingTime int      `json:"crafting_time,omitempty"`
+	SuccessRate  float64  `json:"success_rate,omitempty"`
+	Tags         []string `json:"tags,omitempty"`
 
 	// Inputs (was components)
-	Inputs []struct {
-		ID       string `json:"id,omitempty"`
-		ItemID   string `json:"item_id,omitempty"`
-		Quantity int    `json:"quantity"`
-	} `json:"inputs,omitempty"`
+	Inputs []RecipeInputImport `json:"inputs,omitempty"`
 
 	// Components (legacy support)
-	Components []struct {
-		ID       string `json:"id,omitempty"`
-		ItemID   string `json:"item_id,omitempty"`
-		Quantity int    `json:"quantity"`
-	} `json:"components,omitempty"`
+	Components []RecipeInputImport `json:"components,omitempty"`
 
 	// Outputs - supports multiple
-	Outputs []struct {
-		ItemID   string `json:"item_id,omitempty"`
-		ID       string `json:"id,omitempty"`
-		Quantity int    `json:"quantity"`
-	} `json:"outputs,omitempty"`
+	Outputs []RecipeOutputImport `json:"outputs,omitempty"`
 
 	// Legacy single output support
-	Output struct {
-		ItemID   string `json:"item_id,omitempty"`
-		ID       string `json:"id,omitempty"`
-		Quantity int    `json:"quantity"`
-	} `json:"output,omitempty"`
-	OutputItemID   string `json:"output_item_id,omitempty"`
-	OutputQuantity int    `json:"output_quantity,omitempty"`
+	Output         RecipeOutputImport `json:"output,omitzero"`
+	OutputItemID   string             `json:"output_item_id,omitempty"`
+	OutputQuantity int                `json:"output_quantity,omitempty"`
+}
+
+// RecipeInputImport is one recipe input. Alternatives lists interchangeable
+// items accepted in place of the input.
+type RecipeInputImport struct {
+	ID           string   `json:"id,omitempty"`
+	ItemID       string   `json:"item_id,omitempty"`
+	Quantity     int      `json:"quantity"`
+	Alternatives []string `json:"alternatives,omitempty"`
+}
+
+// RecipeOutputImport is one recipe output.
+type RecipeOutputImport struct {
+	ItemID   string `json:"item_id,omitempty"`
+	ID       string `json:"id,omitempty"`
+	Quantity int    `json:"quantity"`
 }
 
 // SkillImport represents the expected format of skill data from SpaceMolt.
@@ -106,37 +122,55 @@ type SkillImport struct {
 	BonusPerLevel  json.RawMessage `json:"bonus_per_level,omitempty"`
 	RequiredSkills json.RawMessage `json:"required_skills,omitempty"`
 
-	Prerequisites []struct {
-		SkillID string `json:"skill_id,omitempty"`
-		ID      string `json:"id,omitempty"`
-		Level   int    `json:"level,omitempty"`
-	} `json:"prerequisites,omitempty"`
+	Prerequisites []SkillPrerequisiteImport `json:"prerequisites,omitempty"`
 
-	// XP thresholds per level
-	Levels []struct {
-		Level      int `json:"level"`
-		XPRequired int `json:"xp_required,omitempty"`
-		XP         int `json:"xp,omitempty"`
-	} `json:"levels,omitempty"`
+	// Cumulative XP thresholds per level (total XP from level 0)
+	Levels []SkillLevelImport `json:"levels,omitempty"`
 
 	XPThresholds []int `json:"xp_thresholds,omitempty"`
 }
 
+// SkillPrerequisiteImport is a skill level required before training a skill.
+type SkillPrerequisiteImport struct {
+	SkillID string `json:"skill_id,omitempty"`
+	ID      string `json:"id,omitempty"`
+	Level   int    `json:"level,omitempty"`
+}
+
+// SkillLevelImport is the cumulative XP needed to reach a skill level.
+type SkillLevelImport struct {
+	Level      int `json:"level"`
+	XPRequired int `json:"xp_required,omitempty"`
+	XP         int `json:"xp,omitempty"`
+}
+
 // ImportItemsFromFile imports items from a JSON file.
-func (s *Syncer) ImportItemsFromFile(ctx context.Context, path string) error {
-	data, err := os.ReadFile(path)
+func (s *Syncer) ImportItemsFromFile(ctx context.Context, path string) (ImportResult, error) {
+	f, err := os.Open(path)
 	if err != nil {
-		return fmt.Errorf("reading file: %w", err)
+		return ImportResult{}, fmt.Errorf("opening file: %w", err)
 	}
+	defer func() { _ = f.Close() }()
 
-	itemsData, err := unwrapItems(data)
-	if err != nil {
-		return fmt.Errorf("unwrapping items: %w", err)
+	return s.ImportItems(ctx, f)
+}
+
+// ImportItems imports items from JSON read from r.
+func (s *Syncer) ImportItems(ctx context.Context, r io.Reader) (ImportResult, error) {
+	if s.dryRun {
+		return s.dryRunImport(ctx, func(scratch *Syncer) (ImportResult, error) { return scratch.ImportItems(ctx, r) })
+	}
+	if err := s.checkWritable(); err != nil {
+		return ImportResult{}, err
 	}
 
-	var imports []ItemImport
-	if err := json.Unmarshal(itemsData, &imports); err != nil {
-		return fmt.Errorf("parsing JSON: %w", err)
+	in, err := readJSONInput(r)
+	if err != nil {
+		return ImportResult{}, err
+	}
+	imports, err := decodeAll[ItemImport](in)
+	if err != nil {
+		return ImportResult{}, err
 	}
 
 	items := make([]crafting.Item, 0, len(imports))
@@ -146,9 +180,6 @@ func (s *Syncer) ImportItemsFromFile(ctx context.Context, path string) error {
 		if id == "" {
 			id = imp.TypeID
 		}
-		if id == "" {
-			continue // Still no id, skip this entry
-		}
 
 		// Use type as fallback for empty category, default to "module"
 		category := imp.Category
@@ -171,37 +202,62 @@ func (s *Syncer) ImportItemsFromFile(ctx context.Context, path string) error {
 			Tradeable:   imp.Tradeable,
 		})
 	}
+	itemID := func(it crafting.Item) string { return it.ID }
+	items, skipped := dedupeByID(items, itemID)
 
 	itemStore := db.NewItemStore(s.db)
+	before, err := snapshot(ctx, itemStore.GetAllItems, itemID)
+	if err != nil {
+		return ImportResult{}, err
+	}
 	if err := itemStore.BulkInsertItems(ctx, items); err != nil {
-		return fmt.Errorf("inserting items: %w", err)
+		return ImportResult{}, fmt.Errorf("inserting items: %w", err)
 	}
+	after, err := snapshot(ctx, itemStore.GetAllItems, itemID)
+	if err != nil {
+		return ImportResult{}, err
+	}
+	result := countChanges(before, after, ids(items, itemID))
+	result.Skipped = skipped
 
 	if err := s.db.SetSyncMetadata(ctx, "items_last_sync", time.Now().Format(time.RFC3339)); err != nil {
-		return err
+		return result, err
 	}
 	if err := s.db.SetSyncMetadata(ctx, "items_count", fmt.Sprintf("%d", len(items))); err != nil {
-		return err
+		return result, err
 	}
 
-	return nil
+	return result, nil
 }
 
 // ImportRecipesFromFile imports recipes from a JSON file.
-func (s *Syncer) ImportRecipesFromFile(ctx context.Context, path string) error {
-	data, err := os.ReadFile(path)
+func (s *Syncer) ImportRecipesFromFile(ctx context.Context, path string) (ImportResult, error) {
+	f, err := os.Open(path)
 	if err != nil {
-		return fmt.Errorf("reading file: %w", err)
+		return ImportResult{}, fmt.Errorf("opening file: %w", err)
 	}
+	defer func() { _ = f.Close() }()
 
-	itemsData, err := unwrapItems(data)
-	if err != nil {
-		return fmt.Errorf("unwrapping items: %w", err)
+	return s.ImportRecipes(ctx, f)
+}
+
+// ImportRecipes imports recipes from JSON read from r. The import replaces
+// the full recipe set: recipes not in it are removed.
+func (s *Syncer) ImportRecipes(ctx context.Context, r io.Reader) (ImportResult, error) {
+	if s.dryRun {
+		return s.dryRunImport(ctx, func(scratch *Syncer) (ImportResult, error) { return scratch.ImportRecipes(ctx, r) })
+	}
+	if err := s.checkWritable(); err != nil {
+		return ImportResult{}, err
 	}
 
-	var imports []RecipeImport
-	if err := json.Unmarshal(itemsData, &imports); err != nil {
-		return fmt.Errorf("parsing JSON: %w", err)
+	in, err := readJSONInput(r)
+	if err != nil {
+		return ImportResult{}, err
+	}
+	imports, err := decodeAll[RecipeImport](in)
+	if err != nil {
+		return ImportResult{}, err
 	}
 
 	recipes := make([]crafting.Recipe, 0, len(imports))
@@ -209,38 +265,62 @@ func (s *Syncer) ImportRecipesFromFile(ctx context.Context, path string) error {
 		recipe := transformRecipe(imp)
 		recipes = append(recipes, recipe)
 	}
+	recipeID := func(r crafting.Recipe) string { return r.ID }
+	recipes, skipped := dedupeByID(recipes, recipeID)
 
 	recipeStore := db.NewRecipeStore(s.db)
+	before, err := snapshot(ctx, recipeStore.GetAllRecipes, recipeID)
+	if err != nil {
+		return ImportResult{}, err
+	}
 	if err := recipeStore.BulkInsertRecipes(ctx, recipes); err != nil {
-		return fmt.Errorf("inserting recipes: %w", err)
+		return ImportResult{}, fmt.Errorf("inserting recipes: %w", err)
 	}
+	after, err := snapshot(ctx, recipeStore.GetAllRecipes, recipeID)
+	if err != nil {
+		return ImportResult{}, err
+	}
+	result := countChanges(before, after, ids(recipes, recipeID))
+	result.Skipped = skipped
 
 	// Update sync metadata
 	if err := s.db.SetSyncMetadata(ctx, "recipes_last_sync", time.Now().Format(time.RFC3339)); err != nil {
-		return err
+		return result, err
 	}
 	if err := s.db.SetSyncMetadata(ctx, "recipes_count", fmt.Sprintf("%d", len(recipes))); err != nil {
-		return err
+		return result, err
 	}
 
-	return nil
+	return result, nil
 }
 
 // ImportSkillsFromFile imports skills from a JSON file.
-func (s *Syncer) ImportSkillsFromFile(ctx context.Context, path string) error {
-	data, err := os.ReadFile(path)
+func (s *Syncer) ImportSkillsFromFile(ctx context.Context, path string) (ImportResult, error) {
+	f, err := os.Open(path)
 	if err != nil {
-		return fmt.Errorf("reading file: %w", err)
+		return ImportResult{}, fmt.Errorf("opening file: %w", err)
 	}
+	defer func() { _ = f.Close() }()
 
-	itemsData, err := unwrapItems(data)
-	if err != nil {
-		return fmt.Errorf("unwrapping items: %w", err)
+	return s.ImportSkills(ctx, f)
+}
+
+// ImportSkills imports skills from JSON read from r.
+func (s *Syncer) ImportSkills(ctx context.Context, r io.Reader) (ImportResult, error) {
+	if s.dryRun {
+		return s.dryRunImport(ctx, func(scratch *Syncer) (ImportResult, error) { return scratch.ImportSkills(ctx, r) })
+	}
+	if err := s.checkWritable(); err != nil {
+		return ImportResult{}, err
 	}
 
-	var imports []SkillImport
-	if err := json.Unmarshal(itemsData, &imports); err != nil {
-		return fmt.Errorf("parsing JSON: %w", err)
+	in, err := readJSONInput(r)
+	if err != nil {
+		return ImportResult{}, err
+	}
+	imports, err := decodeAll[SkillImport](in)
+	if err != nil {
+		return ImportResult{}, err
 	}
 
 	skills := make([]crafting.Skill, 0, len(imports))
@@ -248,23 +328,116 @@ func (s *Syncer) ImportSkillsFromFile(ctx context.Context, path string) error {
 		skill := transformSkill(imp)
 		skills = append(skills, skill)
 	}
+	skillID := func(sk crafting.Skill) string { return sk.ID }
+	skills, skipped := dedupeByID(skills, skillID)
 
 	skillStore := db.NewSkillStore(s.db)
+	before, err := snapshot(ctx, skillStore.GetAllSkills, skillID)
+	if err != nil {
+		return ImportResult{}, err
+	}
 	if err := skillStore.BulkInsertSkills(ctx, skills); err != nil {
-		return fmt.Errorf("inserting skills: %w", err)
+		return ImportResult{}, fmt.Errorf("inserting skills: %w", err)
+	}
+	after, err := snapshot(ctx, skillStore.GetAllSkills, skillID)
+	if err != nil {
+		return ImportResult{}, err
 	}
+	result := countChanges(before, after, ids(skills, skillID))
+	result.Skipped = skipped
 
 	// Update sync metadata
 	if err := s.db.SetSyncMetadata(ctx, "skills_last_sync", time.Now().Format(time.RFC3339)); err != nil {
-		return err
+		return result, err
 	}
 	if err := s.db.SetSyncMetadata(ctx, "skills_count", fmt.Sprintf("%d", len(skills))); err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// AcquisitionImport represents one non-market source for an item, e.g.
+// {"item_id": "ore_iron", "method": "mine", "detail": "Sol asteroid belt"}.
+type AcquisitionImport struct {
+	ItemID      string `json:"item_id,omitempty"`
+	ComponentID string `json:"component_id,omitempty"` // Fallback for ItemID
+	Method      string `json:"method"`
+	Detail      string `json:"detail,omitempty"`
+}
+
+// ImportAcquisitionSourcesFromFile imports acquisition sources from a JSON file.
+func (s *Syncer) ImportAcquisitionSourcesFromFile(ctx context.Context, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("opening file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	return s.ImportAcquisitionSources(ctx, f)
+}
+
+// ImportAcquisitionSources imports acquisition sources from JSON read from r,
+// replacing any previously imported sources.
+func (s *Syncer) ImportAcquisitionSources(ctx context.Context, r io.Reader) error {
+	if s.dryRun {
+		_, err := s.dryRunImport(ctx, func(scratch *Syncer) (ImportResult, error) {
+			return ImportResult{}, scratch.ImportAcquisitionSources(ctx, r)
+		})
+		return err
+	}
+	if err := s.checkWritable(); err != nil {
+		return err
+	}
+
+	in, err := readJSONInput(r)
+	if err != nil {
+		return err
+	}
+	imports, err := decodeAll[AcquisitionImport](in)
+	if err != nil {
+		return err
+	}
+
+	sources := make([]db.AcquisitionSource, 0, len(imports))
+	for i, imp := range imports {
+		src := transformAcquisition(imp)
+		if src.ItemID == "" || src.Method == "" {
+			return fmt.Errorf("acquisition source %d: item_id and method are required", i)
+		}
+		sources = append(sources, src)
+	}
+
+	acquisitionStore := db.NewItemAcquisitionStore(s.db)
+	if err := acquisitionStore.ReplaceSources(ctx, sources); err != nil {
+		return fmt.Errorf("inserting acquisition sources: %w", err)
+	}
+
+	// Update sync metadata
+	if err := s.db.SetSyncMetadata(ctx, "acquisition_last_sync", time.Now().Format(time.RFC3339)); err != nil {
+		return err
+	}
+	if err := s.db.SetSyncMetadata(ctx, "acquisition_count", fmt.Sprintf("%d", len(sources))); err != nil {
 		return err
 	}
 
 	return nil
 }
 
+// transformAcquisition converts import format to domain format. Methods are
+// lower-cased so "Mine" and "mine" collapse to the same source.
+func transformAcquisition(imp AcquisitionImport) db.AcquisitionSource {
+	itemID := imp.ItemID
+	if itemID == "" {
+		itemID = imp.ComponentID
+	}
+	return db.AcquisitionSource{
+		ItemID: strings.TrimSpace(itemID),
+		Method: strings.ToLower(strings.TrimSpace(imp.Method)),
+		Detail: strings.TrimSpace(imp.Detail),
+	}
+}
+
 // transformRecipe converts import format to domain format.
 func transformRecipe(imp RecipeImport) crafting.Recipe {
 	recipe := crafting.Recipe{
@@ -273,6 +446,8 @@ func transformRecipe(imp RecipeImport) crafting.Recipe {
 		Description:  imp.Description,
 		Category:     imp.Category,
 		CraftingTime: imp.CraftingTime,
+		SuccessRate:  imp.SuccessRate,
+		Tags:         imp.Tags,
 	}
 
 	// Handle inputs - try both "inputs" and "components" fields
@@ -290,8 +465,9 @@ func transformRecipe(imp RecipeImport) crafting.Recipe {
 			continue
 		}
 		recipe.Inputs = append(recipe.Inputs, crafting.RecipeInput{
-			ItemID:   itemID,
-			Quantity: inp.Quantity,
+			ItemID:       itemID,
+			Quantity:     positiveQuantity(inp.Quantity),
+			Alternatives: normalizeAlternatives(itemID, inp.Alternatives),
 		})
 	}
 
@@ -307,7 +483,7 @@ func transformRecipe(imp RecipeImport) crafting.Recipe {
 			}
 			recipe.Outputs = append(recipe.Outputs, crafting.RecipeOutput{
 				ItemID:   itemID,
-				Quantity: out.Quantity,
+				Quantity: positiveQuantity(out.Quantity),
 			})
 		}
 	} else {
@@ -326,14 +502,10 @@ func transformRecipe(imp RecipeImport) crafting.Recipe {
 			outputQuantity = imp.OutputQuantity
 		}
 
-		if outputQuantity == 0 {
-			outputQuantity = 1
-		}
-
 		if outputItemID != "" {
 			recipe.Outputs = append(recipe.Outputs, crafting.RecipeOutput{
 				ItemID:   outputItemID,
-				Quantity: outputQuantity,
+				Quantity: positiveQuantity(outputQuantity),
 			})
 		}
 	}
@@ -341,6 +513,29 @@ func transformRecipe(imp RecipeImport) crafting.Recipe {
 	return recipe
 }
 
+// normalizeAlternatives trims alternative item IDs and drops blanks,
+// duplicates and the input item itself. It returns nil when none remain.
+func normalizeAlternatives(itemID string, alternatives []string) []string {
+	var out []string
+	for _, alt := range alternatives {
+		alt = strings.TrimSpace(alt)
+		if alt == "" || alt == itemID || slices.Contains(out, alt) {
+			continue
+		}
+		out = append(out, alt)
+	}
+	return out
+}
+
+// positiveQuantity treats a missing, zero or negative recipe quantity as 1,
+// since the engine divides by these values.
+func positiveQuantity(q int) int {
+	if q <= 0 {
+		return 1
+	}
+	return q
+}
+
 // transformSkill converts import format to domain format.
 func transformSkill(imp SkillImport) crafting.Skill {
 	skill := crafting.Skill{
@@ -390,7 +585,10 @@ func transformSkill(imp SkillImport) crafting.Skill {
 	if len(imp.XPThresholds) > 0 {
 		skill.XPThresholds = imp.XPThresholds
 	} else if len(imp.Levels) > 0 {
-		for _, lvl := range imp.Levels {
+		// Thresholds are stored by position, so put levels in order first.
+		levels := slices.Clone(imp.Levels)
+		sort.SliceStable(levels, func(i, j int) bool { return levels[i].Level < levels[j].Level })
+		for _, lvl := range levels {
 			xp := lvl.XPRequired
 			if xp == 0 {
 				xp = lvl.XP
@@ -437,162 +635,443 @@ type viewMarketResponse struct {
 	} `json:"items"`
 }
 
+// MarketDataImport is one observation in the flat market data format.
+type MarketDataImport struct {
+	ComponentID string    `json:"component_id,omitempty"` // legacy support
+	ItemID      string    `json:"item_id"`                // new field
+	StationID   string    `json:"station_id"`
+	BuyPrice    int       `json:"buy_price"`
+	SellPrice   int       `json:"sell_price"`
+	Volume24h   int       `json:"volume_24h,omitempty"`
+	Timestamp   time.Time `json:"timestamp,omitzero"`
+}
+
 // ImportMarketDataFromFile imports market data from a JSON file.
+func (s *Syncer) ImportMarketDataFromFile(ctx context.Context, path string) (MarketImportResult, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return MarketImportResult{}, fmt.Errorf("opening file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	return s.ImportMarketData(ctx, f)
+}
+
+// MarketImportOptions controls how market data imports are applied.
+type MarketImportOptions struct {
+	// Incremental refreshes price summaries only for the item/station pairs
+	// touched by the import instead of rescanning the whole price history.
+	Incremental bool
+
+	// Trend controls how price trends are derived when summaries are
+	// refreshed. The zero value uses db.DefaultTrendConfig.
+	Trend db.TrendConfig
+
+	// Strict fails the import with ErrInvalidMarketData when any row has a
+	// negative price or volume, or a sell price below its buy price.
+	// Otherwise such rows are skipped and listed in the result. A strict
+	// JSON Lines import stops at the first bad row; batches written before
+	// it are kept.
+	Strict bool
+}
+
+// ImportMarketData imports market data from JSON read from r.
 // Supports both the view_market API format (nested order books) and
 // the legacy flat array format.
-func (s *Syncer) ImportMarketDataFromFile(ctx context.Context, path string) error {
-	data, err := os.ReadFile(path)
+func (s *Syncer) ImportMarketData(ctx context.Context, r io.Reader) (MarketImportResult, error) {
+	return s.ImportMarketDataWithOptions(ctx, r, MarketImportOptions{})
+}
+
+// ImportMarketDataWithOptions imports market data from JSON read from r
+// using the given options. Rows with impossible values are rejected as
+// described by MarketImportOptions.Strict.
+func (s *Syncer) ImportMarketDataWithOptions(ctx context.Context, r io.Reader, opts MarketImportOptions) (MarketImportResult, error) {
+	if s.dryRun {
+		var result MarketImportResult
+		_, err := s.dryRunImport(ctx, func(scratch *Syncer) (ImportResult, error) {
+			var err error
+			result, err = scratch.ImportMarketDataWithOptions(ctx, r, opts)
+			return ImportResult{}, err
+		})
+		return result, err
+	}
+	if err := s.checkWritable(); err != nil {
+		return MarketImportResult{}, err
+	}
+
+	in, err := readJSONInput(r)
 	if err != nil {
-		return fmt.Errorf("reading file: %w", err)
+		return MarketImportResult{}, err
+	}
+	if in.lines() {
+		return s.importMarketLines(ctx, in, opts)
 	}
 
 	// Try view_market format first (has "action" field)
 	var viewMarket viewMarketResponse
-	if err := json.Unmarshal(data, &viewMarket); err == nil && viewMarket.Action == "view_market" {
-		return s.importViewMarketData(ctx, viewMarket)
+	if err := json.Unmarshal(in.doc, &viewMarket); err == nil && viewMarket.Action == "view_market" {
+		return s.importViewMarketData(ctx, viewMarket, opts)
 	}
 
 	// Fall back to legacy flat array format
-	var imports []struct {
-		ComponentID string    `json:"component_id"` // legacy support
-		ItemID      string    `json:"item_id"`      // new field
-		StationID   string    `json:"station_id"`
-		BuyPrice    int       `json:"buy_price"`
-		SellPrice   int       `json:"sell_price"`
-		Volume24h   int       `json:"volume_24h,omitempty"`
-		Timestamp   time.Time `json:"timestamp,omitempty"`
-	}
+	var imports []MarketDataImport
 
-	if err := json.Unmarshal(data, &imports); err != nil {
-		return fmt.Errorf("parsing JSON: %w", err)
+	if err := json.Unmarshal(in.doc, &imports); err != nil {
+		return MarketImportResult{}, fmt.Errorf("parsing JSON: %w", err)
 	}
 
 	marketStore := db.NewMarketStore(s.db)
 
+	var result MarketImportResult
 	points := make([]db.MarketDataPoint, 0, len(imports))
-	for _, imp := range imports {
-		ts := imp.Timestamp
-		if ts.IsZero() {
-			ts = time.Now()
+	for i, imp := range imports {
+		p := transformMarketData(imp)
+		if problem := marketPointProblem(p); problem != "" {
+			result.Rejected = append(result.Rejected, RejectedMarketRow{Row: i + 1, ItemID: p.ItemID, StationID: p.StationID, Reason: problem})
+			continue
 		}
-
-		itemID := imp.ItemID
-		if itemID == "" {
-			itemID = imp.ComponentID // fallback to legacy field
+		points = append(points, p)
+	}
+	if opts.Strict {
+		if err := result.strictErr(); err != nil {
+			return result, err
 		}
-
-		points = append(points, db.MarketDataPoint{
-			ItemID:    itemID,
-			StationID: imp.StationID,
-			BuyPrice:  imp.BuyPrice,
-			SellPrice: imp.SellPrice,
-			Volume24h: imp.Volume24h,
-			Timestamp: ts,
-		})
 	}
 
 	if err := marketStore.ImportMarketData(ctx, points); err != nil {
-		return fmt.Errorf("importing market data: %w", err)
+		return result, fmt.Errorf("importing market data: %w", err)
 	}
+	result.Imported = len(points)
 
 	// Refresh summaries
-	if err := marketStore.RefreshPriceSummaries(ctx); err != nil {
-		return fmt.Errorf("refreshing summaries: %w", err)
+	if err := refreshSummaries(ctx, marketStore, marketsOf(points), opts); err != nil {
+		return result, fmt.Errorf("refreshing summaries: %w", err)
 	}
 
 	// Update metadata
 	if err := s.db.SetSyncMetadata(ctx, "market_last_sync", time.Now().Format(time.RFC3339)); err != nil {
-		return err
+		return result, err
 	}
 
-	return nil
+	return result, nil
 }
 
-// importViewMarketData imports market data from the view_market API format
-// into both the order book and legacy market_prices tables.
-func (s *Syncer) importViewMarketData(ctx context.Context, viewMarket viewMarketResponse) error {
-	stationID := viewMarket.Base
-	batchID := fmt.Sprintf("import_%s", time.Now().Format("20060102_150405"))
-	recordedAt := time.Now().Format(time.RFC3339)
+// marketLinesBatchSize is how many JSON Lines market records are written
+// per transaction, which bounds memory use for very large dumps.
+const marketLinesBatchSize = 5000
 
+// importMarketLines imports flat market records from a JSON Lines stream,
+// writing them in batches. Only the set of markets touched is kept across
+// batches, for refreshing their summaries at the end.
+func (s *Syncer) importMarketLines(ctx context.Context, in *jsonInput, opts MarketImportOptions) (MarketImportResult, error) {
 	marketStore := db.NewMarketStore(s.db)
 
-	// Import individual orders into market_order_book
-	totalOrders := 0
-	for _, item := range viewMarket.Items {
-		for _, order := range item.BuyOrders {
-			if err := s.db.InsertOrderBookEntry(ctx, batchID, item.ItemID, stationID, "buy", order.PriceEach, order.Quantity, order.Source, recordedAt); err != nil {
-				return fmt.Errorf("inserting buy order for %s: %w", item.ItemID, err)
-			}
-			totalOrders++
+	var result MarketImportResult
+	touched := make(map[db.ItemStation]bool)
+	batch := make([]db.MarketDataPoint, 0, marketLinesBatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := marketStore.ImportMarketData(ctx, batch); err != nil {
+			return fmt.Errorf("importing market data: %w", err)
 		}
+		result.Imported += len(batch)
+		batch = batch[:0]
+		return nil
+	}
 
-		for _, order := range item.SellOrders {
-			if err := s.db.InsertOrderBookEntry(ctx, batchID, item.ItemID, stationID, "sell", order.PriceEach, order.Quantity, order.Source, recordedAt); err != nil {
-				return fmt.Errorf("inserting sell order for %s: %w", item.ItemID, err)
+	for {
+		var imp MarketDataImport
+		ok, err := in.next(&imp)
+		if err != nil {
+			return result, err
+		}
+		if !ok {
+			break
+		}
+		p := transformMarketData(imp)
+		if problem := marketPointProblem(p); problem != "" {
+			row := RejectedMarketRow{Row: in.records, ItemID: p.ItemID, StationID: p.StationID, Reason: problem}
+			if err := result.reject(row, opts.Strict); err != nil {
+				return result, err
+			}
+			continue
+		}
+		touched[db.ItemStation{ItemID: p.ItemID, StationID: p.StationID}] = true
+		batch = append(batch, p)
+		if len(batch) == marketLinesBatchSize {
+			if err := flush(); err != nil {
+				return result, err
 			}
-			totalOrders++
 		}
 	}
+	if err := flush(); err != nil {
+		return result, err
+	}
 
-	// Also import summary prices into legacy market_prices for compatibility
+	markets := make([]db.ItemStation, 0, len(touched))
+	for m := range touched {
+		markets = append(markets, m)
+	}
+	if err := refreshSummaries(ctx, marketStore, markets, opts); err != nil {
+		return result, fmt.Errorf("refreshing summaries: %w", err)
+	}
+
+	if err := s.db.SetSyncMetadata(ctx, "market_last_sync", time.Now().Format(time.RFC3339)); err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// transformMarketData converts a flat market record to domain format.
+// Records without a timestamp are stamped with the current time.
+func transformMarketData(imp MarketDataImport) db.MarketDataPoint {
+	ts := imp.Timestamp
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+
+	itemID := imp.ItemID
+	if itemID == "" {
+		itemID = imp.ComponentID // fallback to legacy field
+	}
+
+	return db.MarketDataPoint{
+		ItemID:    itemID,
+		StationID: imp.StationID,
+		BuyPrice:  imp.BuyPrice,
+		SellPrice: imp.SellPrice,
+		Volume24h: imp.Volume24h,
+		Timestamp: ts,
+	}
+}
+
+// importViewMarketData imports market data from the view_market API format
+// into both the order book and legacy market_prices tables. An item with a
+// bad order or best price is rejected as a whole.
+func (s *Syncer) importViewMarketData(ctx context.Context, viewMarket viewMarketResponse, opts MarketImportOptions) (MarketImportResult, error) {
+	stationID := viewMarket.Base
+	batchID := fmt.Sprintf("import_%s", time.Now().Format("20060102_150405"))
+	recordedAt := time.Now().Format(time.RFC3339)
+
+	marketStore := db.NewMarketStore(s.db)
+
+	// Build summary prices for legacy market_prices, checking each item
+	var result MarketImportResult
+	items := viewMarket.Items[:0:0]
 	points := make([]db.MarketDataPoint, 0, len(viewMarket.Items))
-	for _, item := range viewMarket.Items {
+	for i, item := range viewMarket.Items {
 		sellVolume := 0
+		problem := ""
 		for _, o := range item.SellOrders {
 			sellVolume += o.Quantity
+			if problem == "" {
+				problem = orderProblem("sell", o.PriceEach, o.Quantity)
+			}
 		}
 		buyVolume := 0
 		for _, o := range item.BuyOrders {
 			buyVolume += o.Quantity
+			if problem == "" {
+				problem = orderProblem("buy", o.PriceEach, o.Quantity)
+			}
 		}
 
-		points = append(points, db.MarketDataPoint{
+		p := db.MarketDataPoint{
 			ItemID:    item.ItemID,
 			StationID: stationID,
 			BuyPrice:  item.BestBuy,
 			SellPrice: item.BestSell,
 			Volume24h: sellVolume + buyVolume,
 			Timestamp: time.Now(),
-		})
+		}
+		if problem == "" {
+			problem = marketPointProblem(p)
+		}
+		if problem != "" {
+			result.Rejected = append(result.Rejected, RejectedMarketRow{Row: i + 1, ItemID: item.ItemID, StationID: stationID, Reason: problem})
+			continue
+		}
+		items = append(items, item)
+		points = append(points, p)
+	}
+	if opts.Strict {
+		if err := result.strictErr(); err != nil {
+			return result, err
+		}
 	}
 
+	// Import individual orders into market_order_book
+	totalOrders := 0
+	for _, item := range items {
+		for _, order := range item.BuyOrders {
+			if err := s.db.InsertOrderBookEntry(ctx, batchID, item.ItemID, stationID, "buy", order.PriceEach, order.Quantity, order.Source, recordedAt); err != nil {
+				return result, fmt.Errorf("inserting buy order for %s: %w", item.ItemID, err)
+			}
+			totalOrders++
+		}
+
+		for _, order := range item.SellOrders {
+			if err := s.db.InsertOrderBookEntry(ctx, batchID, item.ItemID, stationID, "sell", order.PriceEach, order.Quantity, order.Source, recordedAt); err != nil {
+				return result, fmt.Errorf("inserting sell order for %s: %w", item.ItemID, err)
+			}
+			totalOrders++
+		}
+	}
+
+	// Also import summary prices into legacy market_prices for compatibility
 	if err := marketStore.ImportMarketData(ctx, points); err != nil {
-		return fmt.Errorf("importing summary market data: %w", err)
+		return result, fmt.Errorf("importing summary market data: %w", err)
 	}
+	result.Imported = len(points)
 
 	// Recalculate stats from order book
-	for _, item := range viewMarket.Items {
+	for _, item := range items {
 		if err := marketStore.RecalculatePriceStats(ctx, item.ItemID, stationID); err != nil {
-			return fmt.Errorf("recalculating stats for %s: %w", item.ItemID, err)
+			return result, fmt.Errorf("recalculating stats for %s: %w", item.ItemID, err)
 		}
 	}
 
 	// Refresh summaries
-	if err := marketStore.RefreshPriceSummaries(ctx); err != nil {
-		return fmt.Errorf("refreshing summaries: %w", err)
+	if err := refreshSummaries(ctx, marketStore, marketsOf(points), opts); err != nil {
+		return result, fmt.Errorf("refreshing summaries: %w", err)
 	}
 
 	// Update metadata
 	if err := s.db.SetSyncMetadata(ctx, "market_last_sync", time.Now().Format(time.RFC3339)); err != nil {
-		return err
+		return result, err
 	}
 	if err := s.db.SetSyncMetadata(ctx, "market_station", stationID); err != nil {
-		return err
+		return result, err
 	}
 	if err := s.db.SetSyncMetadata(ctx, "market_orders_count", fmt.Sprintf("%d", totalOrders)); err != nil {
-		return err
+		return result, err
 	}
 
-	return nil
+	return result, nil
+}
+
+// refreshSummaries rebuilds price summaries after an import, either for the
+// whole table or only for the markets touched by the import.
+func refreshSummaries(ctx context.Context, marketStore *db.MarketStore, markets []db.ItemStation, opts MarketImportOptions) error {
+	if !opts.Incremental {
+		return marketStore.RefreshPriceSummaries(ctx, opts.Trend)
+	}
+	return marketStore.RefreshPriceSummariesFor(ctx, markets, opts.Trend)
+}
+
+// marketsOf returns the item/station pair of each point.
+func marketsOf(points []db.MarketDataPoint) []db.ItemStation {
+	pairs := make([]db.ItemStation, 0, len(points))
+	for _, p := range points {
+		pairs = append(pairs, db.ItemStation{ItemID: p.ItemID, StationID: p.StationID})
+	}
+	return pairs
+}
+
+// MissingSkillReference identifies a skill that requires another skill which
+// does not exist in the database.
+type MissingSkillReference struct {
+	SkillID        string `json:"skill_id"`
+	MissingSkillID string `json:"missing_skill_id"`
+	LevelRequired  int    `json:"level_required"`
+}
+
+// NonIncreasingThreshold identifies a skill level whose cumulative XP
+// threshold does not exceed the previous level's.
+type NonIncreasingThreshold struct {
+	SkillID    string `json:"skill_id"`
+	Level      int    `json:"level"`
+	XPRequired int    `json:"xp_required"`
+	PreviousXP int    `json:"previous_xp"`
+}
+
+// ValidationReport lists problems found in the imported data.
+type ValidationReport struct {
+	MissingSkillReferences  []MissingSkillReference  `json:"missing_skill_references"`
+	NonIncreasingThresholds []NonIncreasingThreshold `json:"non_increasing_thresholds"`
+}
+
+// HasProblems reports whether the validation found anything to fix.
+func (r *ValidationReport) HasProblems() bool {
+	return len(r.MissingSkillReferences) > 0 || len(r.NonIncreasingThresholds) > 0
+}
+
+// ValidateReferences checks that skill references in the imported data point
+// to real skills and that every skill's XP thresholds are cumulative (strictly
+// increasing by level). It should be run after both recipes and skills have
+// been imported.
+//
+// Recipe-level skill requirements were removed in v0.226.0 (see migration 008),
+// so the only skill references left to check are skill prerequisites.
+func (s *Syncer) ValidateReferences(ctx context.Context) (*ValidationReport, error) {
+	skillStore := db.NewSkillStore(s.db)
+	dangling, err := skillStore.FindDanglingPrerequisites(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("checking skill prerequisites: %w", err)
+	}
+
+	thresholds, err := skillStore.FindNonIncreasingThresholds(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("checking XP thresholds: %w", err)
+	}
+
+	report := &ValidationReport{
+		MissingSkillReferences:  make([]MissingSkillReference, 0, len(dangling)),
+		NonIncreasingThresholds: make([]NonIncreasingThreshold, 0, len(thresholds)),
+	}
+	for _, d := range dangling {
+		report.MissingSkillReferences = append(report.MissingSkillReferences, MissingSkillReference{
+			SkillID:        d.SkillID,
+			MissingSkillID: d.MissingSkillID,
+			LevelRequired:  d.LevelRequired,
+		})
+	}
+	for _, t := range thresholds {
+		report.NonIncreasingThresholds = append(report.NonIncreasingThresholds, NonIncreasingThreshold{
+			SkillID:    t.SkillID,
+			Level:      t.Level,
+			XPRequired: t.XPRequired,
+			PreviousXP: t.PreviousXP,
+		})
+	}
+
+	return report, nil
+}
+
+// DeleteRecipe removes one recipe and its inputs, outputs and illegal
+// status, for content that was removed from the game. It reports whether
+// the recipe existed.
+func (s *Syncer) DeleteRecipe(ctx context.Context, id string) (bool, error) {
+	if err := s.checkWritable(); err != nil {
+		return false, err
+	}
+	return db.NewRecipeStore(s.db).DeleteRecipe(ctx, id)
+}
+
+// DeleteSkill removes one skill with its levels and prerequisites. It
+// reports whether the skill existed. Skills that required it are left as
+// they are, so ValidateReferences will flag them.
+func (s *Syncer) DeleteSkill(ctx context.Context, id string) (bool, error) {
+	if err := s.checkWritable(); err != nil {
+		return false, err
+	}
+	return db.NewSkillStore(s.db).DeleteSkill(ctx, id)
 }
 
 // ClearAll removes all data from the database.
 func (s *Syncer) ClearAll(ctx context.Context) error {
+	if err := s.checkWritable(); err != nil {
+		return err
+	}
+
 	itemStore := db.NewItemStore(s.db)
 	recipeStore := db.NewRecipeStore(s.db)
 	skillStore := db.NewSkillStore(s.db)
 	marketStore := db.NewMarketStore(s.db)
+	acquisitionStore := db.NewItemAcquisitionStore(s.db)
 
 	if err := itemStore.ClearItems(ctx); err != nil {
 		return err
@@ -606,6 +1085,9 @@ func (s *Syncer) ClearAll(ctx context.Context) error {
 	if err := marketStore.ClearMarketData(ctx); err != nil {
 		return err
 	}
+	if err := acquisitionStore.ClearSources(ctx); err != nil {
+		return err
+	}
 
 	return nil
 }
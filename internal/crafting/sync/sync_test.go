@@ -0,0 +1,238 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rsned/spacemolt-crafting-server/internal/crafting/db"
+	"github.com/rsned/spacemolt-crafting-server/pkg/crafting"
+)
+
+func TestTransformRecipeNormalizesQuantities(t *testing.T) {
+	var imp RecipeImport
+	err := json.Unmarshal([]byte(`{
+		"id": "bad_recipe",
+		"name": "Bad Recipe",
+		"inputs": [
+			{"item_id": "ore", "quantity": 3},
+			{"item_id": "catalyst", "quantity": 0},
+			{"item_id": "flux", "quantity": -2}
+		],
+		"outputs": [{"item_id": "plate", "quantity": 0}]
+	}`), &imp)
+	if err != nil {
+		t.Fatalf("parsing import: %v", err)
+	}
+
+	got := transformRecipe(imp)
+	want := crafting.Recipe{
+		ID:   "bad_recipe",
+		Name: "Bad Recipe",
+		Inputs: []crafting.RecipeInput{
+			{ItemID: "ore", Quantity: 3},
+			{ItemID: "catalyst", Quantity: 1},
+			{ItemID: "flux", Quantity: 1},
+		},
+		Outputs: []crafting.RecipeOutput{{ItemID: "plate", Quantity: 1}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected recipe:\n got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestTransformRecipeAlternatives(t *testing.T) {
+	var imp RecipeImport
+	err := json.Unmarshal([]byte(`{
+		"id": "frame",
+		"name": "Frame",
+		"inputs": [
+			{"item_id": "steel_bar", "quantity": 2, "alternatives": [" titanium_bar", "steel_bar", "", "titanium_bar", "copper_bar"]},
+			{"item_id": "bolt", "quantity": 1}
+		],
+		"outputs": [{"item_id": "frame", "quantity": 1}]
+	}`), &imp)
+	if err != nil {
+		t.Fatalf("parsing import: %v", err)
+	}
+
+	got := transformRecipe(imp).Inputs
+	want := []crafting.RecipeInput{
+		{ItemID: "steel_bar", Quantity: 2, Alternatives: []string{"titanium_bar", "copper_bar"}},
+		{ItemID: "bolt", Quantity: 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected inputs:\n got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestTransformSkillOrdersLevels(t *testing.T) {
+	var imp SkillImport
+	err := json.Unmarshal([]byte(`{
+		"id": "mining",
+		"name": "Mining",
+		"levels": [
+			{"level": 3, "xp_required": 3000},
+			{"level": 1, "xp_required": 500},
+			{"level": 2, "xp": 1500}
+		]
+	}`), &imp)
+	if err != nil {
+		t.Fatalf("parsing import: %v", err)
+	}
+
+	got := transformSkill(imp).XPThresholds
+	want := []int{500, 1500, 3000}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("XPThresholds = %v, want %v", got, want)
+	}
+}
+
+func TestReadOnlyRejectsImports(t *testing.T) {
+	ctx := context.Background()
+	path := t.TempDir() + "/crafting.db"
+
+	rw, err := db.OpenAndInit(ctx, path)
+	if err != nil {
+		t.Fatalf("OpenAndInit: %v", err)
+	}
+	if _, err := NewSyncer(rw).ImportItems(ctx, strings.NewReader(`[{"id": "ore_iron", "name": "Iron Ore"}]`)); err != nil {
+		t.Fatalf("importing items: %v", err)
+	}
+	_ = rw.Close()
+
+	ro, err := db.OpenReadOnly(ctx, path)
+	if err != nil {
+		t.Fatalf("OpenReadOnly: %v", err)
+	}
+	defer func() { _ = ro.Close() }()
+
+	syncer := NewSyncer(ro)
+	if _, err := syncer.ImportItems(ctx, strings.NewReader(`[{"id": "ore_copper", "name": "Copper Ore"}]`)); !errors.Is(err, db.ErrReadOnly) {
+		t.Errorf("ImportItems error = %v, want ErrReadOnly", err)
+	}
+	if err := syncer.ClearAll(ctx); !errors.Is(err, db.ErrReadOnly) {
+		t.Errorf("ClearAll error = %v, want ErrReadOnly", err)
+	}
+
+	item, err := db.NewItemStore(ro).GetItem(ctx, "ore_iron")
+	if err != nil || item == nil {
+		t.Errorf("expected previously imported item to be readable, got %v, %v", item, err)
+	}
+}
+
+func TestImportResultCounts(t *testing.T) {
+	ctx := context.Background()
+	database, err := db.OpenAndInit(ctx, ":memory:")
+	if err != nil {
+		t.Fatalf("OpenAndInit: %v", err)
+	}
+	defer func() { _ = database.Close() }()
+	syncer := NewSyncer(database)
+
+	importRecipes := func(data string) ImportResult {
+		t.Helper()
+		result, err := syncer.ImportRecipes(ctx, strings.NewReader(data))
+		if err != nil {
+			t.Fatalf("ImportRecipes: %v", err)
+		}
+		return result
+	}
+
+	got := importRecipes(`[
+		{"id": "plate", "name": "Plate", "inputs": [{"item_id": "ore", "quantity": 2}], "outputs": [{"item_id": "plate", "quantity": 1}]},
+		{"id": "wire", "name": "Wire", "inputs": [{"item_id": "ore", "quantity": 1}], "outputs": [{"item_id": "wire", "quantity": 2}]}
+	]`)
+	if want := (ImportResult{Inserted: 2}); got != want {
+		t.Errorf("first import = %+v, want %+v", got, want)
+	}
+
+	// plate unchanged, wire changed, bolt new; the first bolt and the
+	// entry without an ID are skipped.
+	got = importRecipes(`[
+		{"id": "plate", "name": "Plate", "inputs": [{"item_id": "ore", "quantity": 2}], "outputs": [{"item_id": "plate", "quantity": 1}]},
+		{"id": "wire", "name": "Wire", "inputs": [{"item_id": "ore", "quantity": 3}], "outputs": [{"item_id": "wire", "quantity": 2}]},
+		{"id": "bolt", "name": "Old Bolt"},
+		{"id": "bolt", "name": "Bolt", "outputs": [{"item_id": "bolt", "quantity": 4}]},
+		{"name": "Nameless"}
+	]`)
+	if want := (ImportResult{Inserted: 1, Updated: 1, Unchanged: 1, Skipped: 2}); got != want {
+		t.Errorf("second import = %+v, want %+v", got, want)
+	}
+
+	got = importRecipes(`[{"id": "plate", "name": "Plate", "inputs": [{"item_id": "ore", "quantity": 2}], "outputs": [{"item_id": "plate", "quantity": 1}]}]`)
+	if want := (ImportResult{Unchanged: 1, Removed: 2}); got != want {
+		t.Errorf("third import = %+v, want %+v", got, want)
+	}
+
+	// Re-importing a skill replaces its prerequisites rather than adding to them.
+	skills := `[{"id": "mining", "name": "Mining", "prerequisites": [{"skill_id": "basics", "level": 2}], "xp_thresholds": [100, 300]}]`
+	for i, want := range []ImportResult{{Inserted: 1}, {Unchanged: 1}} {
+		got, err := syncer.ImportSkills(ctx, strings.NewReader(skills))
+		if err != nil {
+			t.Fatalf("ImportSkills: %v", err)
+		}
+		if got != want {
+			t.Errorf("skill import %d = %+v, want %+v", i+1, got, want)
+		}
+	}
+	got, err = syncer.ImportSkills(ctx, strings.NewReader(`[{"id": "mining", "name": "Mining", "prerequisites": [{"skill_id": "geology", "level": 1}]}]`))
+	if err != nil {
+		t.Fatalf("ImportSkills: %v", err)
+	}
+	if want := (ImportResult{Updated: 1}); got != want {
+		t.Errorf("changed skill import = %+v, want %+v", got, want)
+	}
+	skill, err := db.NewSkillStore(database).GetSkill(ctx, "mining")
+	if err != nil {
+		t.Fatalf("GetSkill: %v", err)
+	}
+	wantPrereqs := []crafting.SkillRequirement{{SkillID: "geology", LevelRequired: 1}}
+	if !reflect.DeepEqual(skill.Prerequisites, wantPrereqs) || len(skill.XPThresholds) != 0 {
+		t.Errorf("expected only the new prerequisite and no levels, got %+v and %v", skill.Prerequisites, skill.XPThresholds)
+	}
+}
+
+func TestDryRunLeavesDatabaseUntouched(t *testing.T) {
+	ctx := context.Background()
+	database, err := db.OpenAndInit(ctx, ":memory:")
+	if err != nil {
+		t.Fatalf("OpenAndInit: %v", err)
+	}
+	defer func() { _ = database.Close() }()
+	syncer := NewSyncer(database)
+
+	if _, err := syncer.ImportSkills(ctx, strings.NewReader(`[{"id": "mining", "name": "Mining", "xp_thresholds": [100, 300]}]`)); err != nil {
+		t.Fatalf("ImportSkills: %v", err)
+	}
+
+	syncer.SetDryRun(true)
+	got, err := syncer.ImportSkills(ctx, strings.NewReader(`[
+		{"id": "mining", "name": "Mining", "xp_thresholds": [100, 300]},
+		{"id": "refining", "name": "Refining", "prerequisites": [{"skill_id": "smelting", "level": 2}]}
+	]`))
+	if err != nil {
+		t.Fatalf("dry-run ImportSkills: %v", err)
+	}
+	if got.Inserted != 1 || got.Unchanged != 1 || !got.DryRun {
+		t.Errorf("dry-run result = %+v, want 1 inserted, 1 unchanged, DryRun set", got)
+	}
+	wantMissing := []MissingSkillReference{{SkillID: "refining", MissingSkillID: "smelting", LevelRequired: 2}}
+	if got.Validation == nil || !reflect.DeepEqual(got.Validation.MissingSkillReferences, wantMissing) {
+		t.Errorf("dry-run validation = %+v, want missing reference %+v", got.Validation, wantMissing)
+	}
+
+	skill, err := db.NewSkillStore(database).GetSkill(ctx, "refining")
+	if err != nil {
+		t.Fatalf("GetSkill: %v", err)
+	}
+	if skill != nil {
+		t.Errorf("dry run wrote skill %+v", skill)
+	}
+	if last, _ := database.GetSyncMetadata(ctx, "skills_count"); last != "1" {
+		t.Errorf("skills_count = %q after dry run, want 1", last)
+	}
+}
@@ -0,0 +1,54 @@
+// Package version reports the build version of the crafting server.
+package version
+
+import "runtime/debug"
+
+// Version is the release version. It is set at build time with:
+//
+//	go build -ldflags "-X github.com/rsned/spacemolt-crafting-server/internal/version.Version=v1.2.3"
+var Version = "dev"
+
+// shortRevisionLen is the number of commit hash characters to report.
+const shortRevisionLen = 7
+
+// Revision returns the short VCS revision the go tool embedded in the
+// binary, with a "-dirty" suffix for modified trees, or "" if unavailable.
+func Revision() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	return revisionFrom(info.Settings)
+}
+
+// revisionFrom extracts the short revision from build settings.
+func revisionFrom(settings []debug.BuildSetting) string {
+	var revision string
+	var modified bool
+	for _, s := range settings {
+		switch s.Key {
+		case "vcs.revision":
+			revision = s.Value
+		case "vcs.modified":
+			modified = s.Value == "true"
+		}
+	}
+	if revision == "" {
+		return ""
+	}
+	if len(revision) > shortRevisionLen {
+		revision = revision[:shortRevisionLen]
+	}
+	if modified {
+		revision += "-dirty"
+	}
+	return revision
+}
+
+// String returns the version with its revision, e.g. "v1.2.3 (abc1234)".
+func String() string {
+	if rev := Revision(); rev != "" {
+		return Version + " (" + rev + ")"
+	}
+	return Version
+}
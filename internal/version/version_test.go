@@ -0,0 +1,34 @@
+package version
+
+import (
+	"runtime/debug"
+	"testing"
+)
+
+func TestRevisionFrom(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings []debug.BuildSetting
+		want     string
+	}{
+		{"no vcs info", nil, ""},
+		{
+			"clean tree",
+			[]debug.BuildSetting{{Key: "vcs.revision", Value: "0123456789abcdef"}, {Key: "vcs.modified", Value: "false"}},
+			"0123456",
+		},
+		{
+			"modified tree",
+			[]debug.BuildSetting{{Key: "vcs.revision", Value: "0123456789abcdef"}, {Key: "vcs.modified", Value: "true"}},
+			"0123456-dirty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := revisionFrom(tt.settings); got != tt.want {
+				t.Errorf("expected revision %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -1,7 +1,10 @@
 // Package crafting contains the core types for the crafting query server.
 package crafting
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"time"
+)
 
 // ============================================
 // ITEM TYPES
@@ -34,17 +37,19 @@ type Component struct {
 type OptimizationStrategy string
 
 const (
-	StrategyMaximizeProfit      OptimizationStrategy = "MAXIMIZE_PROFIT"
-	StrategyMaximizeVolume      OptimizationStrategy = "MAXIMIZE_VOLUME"
-	StrategyOptimizeCraftPath   OptimizationStrategy = "OPTIMIZE_CRAFT_PATH"
-	StrategyUseInventoryFirst   OptimizationStrategy = "USE_INVENTORY_FIRST"
-	StrategyMinimizeAcquisition OptimizationStrategy = "MINIMIZE_ACQUISITION"
+	StrategyMaximizeProfit        OptimizationStrategy = "MAXIMIZE_PROFIT"
+	StrategyMaximizeProfitPerHour OptimizationStrategy = "MAXIMIZE_PROFIT_PER_HOUR"
+	StrategyMaximizeVolume        OptimizationStrategy = "MAXIMIZE_VOLUME"
+	StrategyOptimizeCraftPath     OptimizationStrategy = "OPTIMIZE_CRAFT_PATH"
+	StrategyUseInventoryFirst     OptimizationStrategy = "USE_INVENTORY_FIRST"
+	StrategyMinimizeAcquisition   OptimizationStrategy = "MINIMIZE_ACQUISITION"
 )
 
 // ValidStrategies returns all valid optimization strategies.
 func ValidStrategies() []OptimizationStrategy {
 	return []OptimizationStrategy{
 		StrategyMaximizeProfit,
+		StrategyMaximizeProfitPerHour,
 		StrategyMaximizeVolume,
 		StrategyOptimizeCraftPath,
 		StrategyUseInventoryFirst,
@@ -62,6 +67,42 @@ func (s OptimizationStrategy) IsValid() bool {
 	return false
 }
 
+// PricingModel controls which market price is used in profit analysis.
+type PricingModel string
+
+const (
+	// PricingAverage uses the representative market price (default).
+	PricingAverage PricingModel = "AVERAGE"
+	// PricingPessimistic buys inputs at the highest and sells outputs at the
+	// lowest observed price.
+	PricingPessimistic PricingModel = "PESSIMISTIC"
+	// PricingOptimistic buys inputs at the lowest and sells outputs at the
+	// highest observed price.
+	PricingOptimistic PricingModel = "OPTIMISTIC"
+)
+
+// IsValid checks if the pricing model is a known model.
+func (m PricingModel) IsValid() bool {
+	switch m {
+	case PricingAverage, PricingPessimistic, PricingOptimistic:
+		return true
+	}
+	return false
+}
+
+// ProfitFilter holds optional profit thresholds shared by the tools that
+// produce profit analysis. A nil threshold is not applied; thresholds only
+// take effect when a station is supplied.
+type ProfitFilter struct {
+	MinProfitMarginPct *float64 `json:"min_profit_margin_pct,omitempty"`
+	MinProfitPerUnit   *int     `json:"min_profit_per_unit,omitempty"`
+}
+
+// IsSet reports whether any threshold is set.
+func (f ProfitFilter) IsSet() bool {
+	return f.MinProfitMarginPct != nil || f.MinProfitPerUnit != nil
+}
+
 // ============================================
 // RECIPE TYPES
 // ============================================
@@ -76,12 +117,25 @@ type Recipe struct {
 	Inputs        []RecipeInput  `json:"inputs"`
 	Outputs       []RecipeOutput `json:"outputs"`
 	IllegalStatus *IllegalStatus `json:"illegal_status,omitempty"`
+
+	// SuccessRate is the chance (0 < rate <= 1) that one craft run produces
+	// its outputs. A failed run still consumes its inputs. Zero means the
+	// rate is unknown and is treated as 1.0.
+	SuccessRate float64 `json:"success_rate,omitempty"`
+
+	// Tags are lower-case attributes such as "tradeable" that classify the
+	// recipe beyond its single Category.
+	Tags []string `json:"tags,omitempty"`
 }
 
 // RecipeInput represents a required input item for a recipe.
 type RecipeInput struct {
 	ItemID   string `json:"item_id"`
 	Quantity int    `json:"quantity"`
+
+	// Alternatives lists interchangeable items (e.g. any tier-2 metal) that
+	// can be used in place of ItemID in the same quantity.
+	Alternatives []string `json:"alternatives,omitempty"`
 }
 
 // RecipeOutput represents what a recipe produces.
@@ -108,6 +162,10 @@ type SkillRequirement struct {
 }
 
 // Skill represents a skill in the progression tree.
+//
+// XPThresholds[i] is the cumulative XP needed to reach level i+1 from level
+// 0, so the XP to train from level a to level b is
+// XPThresholds[b-1] - XPThresholds[a-1], with level 0 counting as 0 XP.
 type Skill struct {
 	ID             string             `json:"id"`
 	Name           string             `json:"name"`
@@ -122,17 +180,25 @@ type Skill struct {
 	XPThresholds   []int              `json:"xp_thresholds"`
 }
 
+// AgentSkillState maps skill IDs to the agent's current level in each skill.
+// Skills that are absent are treated as level 0.
+type AgentSkillState map[string]int
+
 // ============================================
 // MARKET TYPES
 // ============================================
 
 // ProfitAnalysis contains market-based profit calculations for a recipe.
 type ProfitAnalysis struct {
-	OutputSellPrice      int     `json:"output_sell_price"`
-	InputCost            int     `json:"input_cost"`
-	ProfitPerUnit        int     `json:"profit_per_unit"`
-	ProfitMarginPct      float64 `json:"profit_margin_pct"`
-	TotalPotentialProfit int     `json:"total_potential_profit,omitempty"`
+	StationID            string          `json:"station_id,omitempty"` // Station the prices come from
+	OutputSellPrice      int             `json:"output_sell_price"`
+	InputCost            int             `json:"input_cost"`
+	InputCostBreakdown   []ComponentCost `json:"input_cost_breakdown,omitempty"` // One line per input; sums to InputCost
+	ProfitPerUnit        int             `json:"profit_per_unit"`
+	ProfitMarginPct      float64         `json:"profit_margin_pct"`
+	TotalPotentialProfit int             `json:"total_potential_profit,omitempty"`
+	ProfitPerHour        float64         `json:"profit_per_hour,omitempty"` // 0 when craft time is unknown
+	PricingModel         string          `json:"pricing_model,omitempty"`
 
 	// NEW fields from Phase 3: Enhanced Market Data
 	MSRP               int    `json:"msrp,omitempty"`
@@ -140,9 +206,37 @@ type ProfitAnalysis struct {
 	PricingMethod      string `json:"pricing_method,omitempty"`      // "volume_weighted", "second_price", "median", "msrp_only"
 	SampleCount        int    `json:"sample_count,omitempty"`        // Number of orders used in calculation
 
+	// DataComplete is false when some recipe items have no market data at
+	// the station; those are priced at MSRP and listed in MissingPrices.
+	DataComplete  bool     `json:"data_complete"`
+	MissingPrices []string `json:"missing_prices,omitempty"`
+
+	// PriceAsOf is when the oldest market price used was last updated, so
+	// DataAgeHours reflects the stalest number behind the profit figure.
+	// StaleWarning is set when that age exceeds the server's threshold.
+	PriceAsOf    time.Time `json:"price_as_of"`
+	DataAgeHours int       `json:"data_age_hours"`
+	StaleWarning string    `json:"stale_warning,omitempty"`
+
 	// Legacy field - renamed for clarity
 	TotalVolume24h     int    `json:"total_volume_24h,omitempty"`    // Total trading volume in last 24h
 	PriceTrend         string `json:"price_trend,omitempty"`
+
+	// Risk-adjusted figures for recipes that can fail. OutputSellPrice and
+	// ProfitPerUnit assume every run succeeds; these discount the output by
+	// the recipe's success rate and equal them when it is 1.0.
+	SuccessRate           float64 `json:"success_rate"`
+	ExpectedOutputValue   int     `json:"expected_output_value"`
+	ExpectedProfitPerUnit int     `json:"expected_profit_per_unit"`
+}
+
+// ComponentCost is one input's share of a recipe's input cost.
+type ComponentCost struct {
+	ItemID      string `json:"item_id"`
+	Quantity    int    `json:"quantity"`
+	UnitPrice   int    `json:"unit_price"`
+	LineCost    int    `json:"line_cost"`
+	PriceSource string `json:"price_source"` // "market" or "msrp" when the station has no buy data
 }
 
 // MarketPriceSummary contains aggregated price data for an item.
@@ -154,6 +248,13 @@ type MarketPriceSummary struct {
 	MinPrice7d  int     `json:"min_price_7d"`
 	MaxPrice7d  int     `json:"max_price_7d"`
 	PriceTrend  string  `json:"price_trend"`
+	Median7d    float64 `json:"median_7d"`
+	Volatility  float64 `json:"volatility"` // Coefficient of variation (stddev / mean)
+
+	// PriceAsOf is the newest observation behind the summary.
+	PriceAsOf    time.Time `json:"price_as_of"`
+	DataAgeHours int       `json:"data_age_hours"`
+	StaleWarning string    `json:"stale_warning,omitempty"`
 }
 
 // ============================================
@@ -162,9 +263,25 @@ type MarketPriceSummary struct {
 
 // CraftableMatch represents a recipe the agent can craft right now.
 type CraftableMatch struct {
-	Recipe           Recipe          `json:"recipe"`
-	CanCraftQuantity int             `json:"can_craft_quantity"`
-	ProfitAnalysis   *ProfitAnalysis `json:"profit_analysis,omitempty"`
+	Recipe           Recipe              `json:"recipe"`
+	CanCraftQuantity int                 `json:"can_craft_quantity"` // Number of runs
+	ProfitAnalysis   *ProfitAnalysis     `json:"profit_analysis,omitempty"`
+	Substitutions    []InputSubstitution `json:"substitutions,omitempty"`
+
+	// OutputQuantityPossible is how many of the primary output those runs
+	// yield: CanCraftQuantity times the output's batch size.
+	OutputQuantityPossible int `json:"output_quantity_possible"`
+
+	// TotalCraftTimeSec is how long all CanCraftQuantity runs take, or 0
+	// when the recipe's crafting time is unknown.
+	TotalCraftTimeSec int `json:"total_craft_time_sec,omitempty"`
+}
+
+// InputSubstitution records an alternative item matched from inventory in
+// place of the item a recipe lists for one of its inputs.
+type InputSubstitution struct {
+	ItemID       string `json:"item_id"`       // Item the recipe lists
+	SubstituteID string `json:"substitute_id"` // Alternative used instead
 }
 
 // PartialComponentMatch represents a recipe where the agent has some components.
@@ -172,8 +289,30 @@ type PartialComponentMatch struct {
 	Recipe         Recipe          `json:"recipe"`
 	InputsHave     []RecipeInput   `json:"inputs_have"`
 	InputsMissing  []RecipeInput   `json:"inputs_missing"`
-	MatchRatio     float64         `json:"match_ratio"`
+	MatchRatio     float64         `json:"match_ratio"` // Fraction of inputs held in full quantity for one craft
 	ProfitAnalysis *ProfitAnalysis `json:"profit_analysis,omitempty"`
+
+	Substitutions []InputSubstitution `json:"substitutions,omitempty"`
+
+	// QuantityWeightedRatio is units held over units needed for one craft,
+	// summed across inputs with each input capped at its requirement.
+	QuantityWeightedRatio float64 `json:"quantity_weighted_ratio"`
+
+	// AcquisitionCost prices the missing inputs when a budget is given.
+	AcquisitionCost *AcquisitionCost `json:"acquisition_cost,omitempty"`
+}
+
+// AcquisitionCost is the market cost of buying the inputs a partial match
+// is missing, checked against the caller's budget. Each missing input is
+// priced at the cheaper of its item and any alternatives with a buy price.
+// A match with unpriced inputs is never within budget, since its full cost
+// is unknown.
+type AcquisitionCost struct {
+	StationID     string   `json:"station_id"`
+	TotalCost     int      `json:"total_cost"`
+	Budget        int      `json:"budget"`
+	WithinBudget  bool     `json:"within_budget"`
+	UnpricedItems []string `json:"unpriced_items,omitempty"`
 }
 
 // CraftStep represents a single step in a crafting path.
@@ -201,6 +340,7 @@ type MaterialRequirement struct {
 	QuantityHave       int           `json:"quantity_have"`
 	QuantityToAcquire  int           `json:"quantity_to_acquire"`
 	AcquisitionMethods []string      `json:"acquisition_methods,omitempty"`
+	RecommendedMethod  string        `json:"recommended_method,omitempty"`
 	IsCraftable        bool          `json:"is_craftable"`
 	CraftRecipeID      string        `json:"craft_recipe_id,omitempty"`
 	CraftIllegalStatus *IllegalStatus `json:"craft_illegal_status,omitempty"`
@@ -218,8 +358,43 @@ type CraftQueryRequest struct {
 	MinMatchRatio      float64              `json:"min_match_ratio"`
 	Strategy           OptimizationStrategy `json:"optimization_strategy"`
 	StationID          string               `json:"station_id,omitempty"`
-	CategoryFilter     string               `json:"category_filter,omitempty"`
+	CategoryFilter     string               `json:"category_filter,omitempty"` // Merged into Categories
+	Categories         []string             `json:"categories,omitempty"`
+	ExcludeCategories  []string             `json:"exclude_categories,omitempty"`
+	ExcludeRecipeIDs   []string             `json:"exclude_recipe_ids,omitempty"`
 	Limit              int                  `json:"limit"`
+	PricingModel       PricingModel         `json:"pricing_model,omitempty"`
+
+	// UseQuantityWeightedRatio applies MinMatchRatio to the quantity-weighted
+	// ratio instead of the fraction of fully satisfied inputs.
+	UseQuantityWeightedRatio bool `json:"use_quantity_weighted_ratio,omitempty"`
+
+	// OnlyCraftable returns only fully craftable recipes, skipping partial
+	// match work entirely. It overrides IncludePartial.
+	OnlyCraftable bool `json:"only_craftable,omitempty"`
+
+	// Tags keeps only recipes carrying any of the listed tags, or all of
+	// them when RequireAllTags is set.
+	Tags           []string `json:"tags,omitempty"`
+	RequireAllTags bool     `json:"require_all_tags,omitempty"`
+
+	// ProfitFilter drops craftable and partial matches below the thresholds.
+	ProfitFilter
+
+	// Budget, when positive, prices the inputs each partial match is
+	// missing at StationID and flags whether they can be bought for this
+	// much. ExcludeOverBudget drops partial matches that cannot.
+	Budget            int  `json:"budget,omitempty"`
+	ExcludeOverBudget bool `json:"exclude_over_budget,omitempty"`
+
+	// AutoStation prices each recipe at its most profitable station when
+	// StationID is empty.
+	AutoStation bool `json:"auto_station,omitempty"`
+
+	// PrefixMatching lets a component ID ending in "*", such as
+	// "ore_iron_*", stand for every input item beginning with the rest of
+	// it; those items share its quantity. IDs are matched exactly otherwise.
+	PrefixMatching bool `json:"prefix_matching,omitempty"`
 }
 
 // CraftQueryResponse is the output for the craft_query tool.
@@ -229,6 +404,57 @@ type CraftQueryResponse struct {
 	QueryStats        QueryStats              `json:"query_stats"`
 }
 
+// BatchQueryRequest is the input for the batch_query tool. It runs the same
+// craft_query options against several inventories; the embedded request's
+// Components are ignored in favour of each inventory's.
+type BatchQueryRequest struct {
+	Inventories []InventorySnapshot `json:"inventories"`
+	CraftQueryRequest
+}
+
+// InventorySnapshot is one labelled inventory, such as a character or base.
+type InventorySnapshot struct {
+	Label      string      `json:"label"`
+	Components []Component `json:"components"`
+}
+
+// BatchQueryResponse is the output for the batch_query tool, with one
+// result per inventory in request order.
+type BatchQueryResponse struct {
+	Results []BatchQueryResult `json:"results"`
+}
+
+// BatchQueryResult is the craft_query response for one labelled inventory.
+type BatchQueryResult struct {
+	Label    string              `json:"label"`
+	Response *CraftQueryResponse `json:"response"`
+}
+
+// CapabilityDiffRequest is the input for the capability_diff tool.
+type CapabilityDiffRequest struct {
+	Before []Component `json:"before"`
+	After  []Component `json:"after"`
+}
+
+// CapabilityDiffResponse is the output for the capability_diff tool. Each
+// list is sorted by recipe ID.
+type CapabilityDiffResponse struct {
+	Gained    []CapabilityChange `json:"gained"`    // Craftable after but not before
+	Lost      []CapabilityChange `json:"lost"`      // Craftable before but not after
+	Changed   []CapabilityChange `json:"changed"`   // Craftable in both, in different quantities
+	Unchanged int                `json:"unchanged"` // Craftable in both, in the same quantity
+}
+
+// CapabilityChange describes how many times a recipe can be crafted before
+// and after an inventory change.
+type CapabilityChange struct {
+	RecipeID       string `json:"recipe_id"`
+	RecipeName     string `json:"recipe_name"`
+	Category       string `json:"category,omitempty"`
+	BeforeQuantity int    `json:"before_quantity"`
+	AfterQuantity  int    `json:"after_quantity"`
+}
+
 // QueryStats contains metadata about a query execution.
 type QueryStats struct {
 	TotalRecipesChecked int    `json:"total_recipes_checked"`
@@ -239,10 +465,11 @@ type QueryStats struct {
 
 // CraftPathRequest is the input for the craft_path_to tool.
 type CraftPathRequest struct {
-	TargetRecipeID   string      `json:"target_recipe_id"`
-	TargetQuantity   int         `json:"target_quantity"`
-	CurrentInventory []Component `json:"current_inventory"`
-	StationID        string      `json:"station_id,omitempty"`
+	TargetRecipeID   string               `json:"target_recipe_id"`
+	TargetQuantity   int                  `json:"target_quantity"`
+	CurrentInventory []Component          `json:"current_inventory"`
+	StationID        string               `json:"station_id,omitempty"`
+	Strategy         OptimizationStrategy `json:"optimization_strategy,omitempty"`
 }
 
 // CraftPathResponse is the output for the craft_path_to tool.
@@ -272,19 +499,36 @@ type CraftPathSummary struct {
 
 // RecipeLookupRequest is the input for the recipe_lookup tool.
 type RecipeLookupRequest struct {
-	RecipeID  string `json:"recipe_id,omitempty"`
-	Search    string `json:"search,omitempty"`
-	StationID string `json:"station_id,omitempty"`
+	RecipeID   string   `json:"recipe_id,omitempty"`
+	Search     string   `json:"search,omitempty"`
+	StationID  string   `json:"station_id,omitempty"`
+	StationIDs []string `json:"station_ids,omitempty"` // Optional: compare profit across stations
+	PricingModel PricingModel `json:"pricing_model,omitempty"`
+
+	// AutoStation prices the recipe at its most profitable station when
+	// StationID is empty.
+	AutoStation bool `json:"auto_station,omitempty"`
+
+	// ProfitFilter drops station_profits entries below the thresholds.
+	ProfitFilter
 }
 
 // RecipeLookupResponse is the output for the recipe_lookup tool.
 type RecipeLookupResponse struct {
 	Recipe         *Recipe           `json:"recipe,omitempty"`
 	ProfitAnalysis *ProfitAnalysis   `json:"profit_analysis,omitempty"`
+	StationProfits []StationProfit   `json:"station_profits,omitempty"`
+	BestStationID  string            `json:"best_station_id,omitempty"`
 	UsedInRecipes  []string          `json:"used_in_recipes,omitempty"`
 	SearchResults  []RecipeSearchHit `json:"search_results,omitempty"`
 }
 
+// StationProfit is the profit analysis for a recipe at one station.
+type StationProfit struct {
+	StationID      string          `json:"station_id"`
+	ProfitAnalysis *ProfitAnalysis `json:"profit_analysis"`
+}
+
 // RecipeSearchHit is a lightweight recipe match for search results.
 type RecipeSearchHit struct {
 	RecipeID string `json:"recipe_id"`
@@ -292,11 +536,167 @@ type RecipeSearchHit struct {
 	Category string `json:"category"`
 }
 
+// ToolCallEstimate is returned in place of a tool's result when tools/call is
+// made with validate set. It confirms the arguments are valid and previews
+// the cost of the call.
+type ToolCallEstimate struct {
+	Tool             string `json:"tool"`
+	Valid            bool   `json:"valid"`
+	RecipesTotal     int    `json:"recipes_total"`
+	RecipesToScan    int    `json:"recipes_to_scan"`
+	EstimatedResults *int   `json:"estimated_results,omitempty"` // Upper bound; omitted when unknown
+	CraftSteps       int    `json:"craft_steps,omitempty"`       // bill_of_materials only
+}
+
+// ListCategoriesResponse is the output for the list_categories tool.
+type ListCategoriesResponse struct {
+	RecipeCategories []CategoryInfo `json:"recipe_categories"`
+	SkillCategories  []CategoryInfo `json:"skill_categories"`
+}
+
+// CategoryInfo describes a recipe or skill category.
+type CategoryInfo struct {
+	Category     string `json:"category"`
+	Count        int    `json:"count"`
+	PriorityTier int    `json:"priority_tier,omitempty"` // Recipe categories only, 1 (highest) to 6
+}
+
+// ListStationsRequest is the input for the list_stations tool.
+type ListStationsRequest struct {
+	ComponentID string `json:"component_id,omitempty"` // Optional: only stations pricing this item
+}
+
+// ListStationsResponse is the output for the list_stations tool.
+type ListStationsResponse struct {
+	Stations []StationInfo `json:"stations"`
+}
+
+// StationInfo describes a station that has market data.
+type StationInfo struct {
+	StationID   string `json:"station_id"`
+	Name        string `json:"name,omitempty"`
+	Empire      string `json:"empire,omitempty"`
+	ItemsPriced int    `json:"items_priced"`
+}
+
+// CheapestSourcesRequest is the input for the cheapest_sources tool.
+type CheapestSourcesRequest struct {
+	ComponentIDs []string `json:"component_ids"`
+}
+
+// CheapestSourcesResponse is the output for the cheapest_sources tool.
+// Sources follows the order of the request; components no station sells
+// are listed in Unavailable instead.
+type CheapestSourcesResponse struct {
+	Sources     []CheapestSource `json:"sources"`
+	Unavailable []string         `json:"unavailable,omitempty"`
+}
+
+// CheapestSource is the station with the lowest buy price for a component.
+type CheapestSource struct {
+	ComponentID string `json:"component_id"`
+	StationID   string `json:"station_id"`
+	StationName string `json:"station_name,omitempty"`
+	BuyPrice    int    `json:"buy_price"` // 7-day average
+}
+
+// FindArbitrageRequest is the input for the find_arbitrage tool.
+type FindArbitrageRequest struct {
+	// FeePct is charged on the sell price, e.g. a market tax, before the
+	// spread is worked out.
+	FeePct float64 `json:"fee_pct,omitempty"`
+
+	// MinSpread is the net profit per unit an opportunity must exceed.
+	MinSpread int `json:"min_spread,omitempty"`
+
+	Limit int `json:"limit,omitempty"`
+}
+
+// FindArbitrageResponse is the output for the find_arbitrage tool,
+// sorted by net spread, widest first.
+type FindArbitrageResponse struct {
+	Opportunities []ArbitrageOpportunity `json:"opportunities"`
+}
+
+// ArbitrageOpportunity is a component that can be bought at one station and
+// sold at another for a profit.
+type ArbitrageOpportunity struct {
+	ComponentID   string  `json:"component_id"`
+	BuyStationID  string  `json:"buy_station_id"`
+	BuyPrice      int     `json:"buy_price"`
+	SellStationID string  `json:"sell_station_id"`
+	SellPrice     int     `json:"sell_price"`
+	Fee           int     `json:"fee,omitempty"`
+	Spread        int     `json:"spread"`     // Per unit, after the fee
+	SpreadPct     float64 `json:"spread_pct"` // Spread as a percentage of BuyPrice
+}
+
+// PriceHistoryRequest is the input for the price_history tool.
+type PriceHistoryRequest struct {
+	ComponentID string `json:"component_id"`
+	StationID   string `json:"station_id"`
+	PriceType   string `json:"price_type,omitempty"` // "buy" or "sell" (default "sell")
+	Since       string `json:"since,omitempty"`      // RFC3339 timestamp; empty for all history
+	Limit       int    `json:"limit,omitempty"`
+}
+
+// PriceHistoryResponse is the output for the price_history tool.
+type PriceHistoryResponse struct {
+	ComponentID string       `json:"component_id"`
+	StationID   string       `json:"station_id"`
+	PriceType   string       `json:"price_type"`
+	Points      []PricePoint `json:"points"`
+}
+
+// PricePoint is a single recorded price, listed newest first.
+type PricePoint struct {
+	Price      int       `json:"price"`
+	Volume24h  int       `json:"volume_24h"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// SetMarketPriceRequest is the input for the set_market_price tool.
+type SetMarketPriceRequest struct {
+	ComponentID string `json:"component_id"`
+	StationID   string `json:"station_id"`
+	BuyPrice    int    `json:"buy_price,omitempty"`
+	SellPrice   int    `json:"sell_price,omitempty"`
+	Volume24h   int    `json:"volume_24h,omitempty"`
+	ObservedAt  string `json:"observed_at,omitempty"` // RFC3339 timestamp; defaults to now
+}
+
+// SetMarketPriceResponse is the output for the set_market_price tool. It
+// carries the refreshed 7-day summaries for the market that was updated.
+type SetMarketPriceResponse struct {
+	ComponentID string              `json:"component_id"`
+	StationID   string              `json:"station_id"`
+	RecordedAt  time.Time           `json:"recorded_at"`
+	BuySummary  *MarketPriceSummary `json:"buy_summary,omitempty"`
+	SellSummary *MarketPriceSummary `json:"sell_summary,omitempty"`
+}
+
 // ComponentUsesRequest is the input for the component_uses tool.
 type ComponentUsesRequest struct {
-	ItemID    string               `json:"item_id"`
-	StationID string               `json:"station_id,omitempty"`
-	Strategy  OptimizationStrategy `json:"optimization_strategy"`
+	ItemID       string               `json:"component_id"`
+	StationID    string               `json:"station_id,omitempty"`
+	Strategy     OptimizationStrategy `json:"optimization_strategy"`
+	PricingModel PricingModel         `json:"pricing_model,omitempty"`
+
+	// Components is the agent's inventory. When set, each use reports
+	// whether the other inputs are already held. The looked-up item is
+	// assumed to be held unless it is listed here.
+	Components []Component `json:"components,omitempty"`
+
+	// AutoStation prices each use at its most profitable station when
+	// StationID is empty.
+	AutoStation bool `json:"auto_station,omitempty"`
+
+	// AvailableQuantity is how much of the component the agent has. When
+	// set, each use reports how many runs that amount alone would cover.
+	AvailableQuantity int `json:"available_quantity,omitempty"`
+
+	// ProfitFilter drops uses below the thresholds.
+	ProfitFilter
 }
 
 // ComponentUsesResponse is the output for the component_uses tool.
@@ -313,6 +713,39 @@ type ComponentUseInfo struct {
 	Recipe           Recipe          `json:"recipe"`
 	QuantityPerCraft int             `json:"quantity_per_craft"`
 	ProfitAnalysis   *ProfitAnalysis `json:"profit_analysis,omitempty"`
+
+	// Set only when the request includes components.
+	ReadyToCraft  bool          `json:"ready_to_craft,omitempty"`
+	InputsMissing []RecipeInput `json:"inputs_missing,omitempty"`
+
+	// MaxCraftsFromComponent is AvailableQuantity / QuantityPerCraft: the
+	// runs the looked-up component covers, ignoring the other inputs. Set
+	// only when the request includes available_quantity.
+	MaxCraftsFromComponent int `json:"max_crafts_from_component,omitempty"`
+}
+
+// RecipesForItemRequest is the input for the recipes_for_item tool.
+type RecipesForItemRequest struct {
+	ItemID string `json:"item_id"`
+}
+
+// RecipesForItemResponse is the output for the recipes_for_item tool.
+type RecipesForItemResponse struct {
+	ItemID       string           `json:"item_id"`
+	ItemName     string           `json:"item_name,omitempty"`
+	ProducedBy   []ItemRecipeInfo `json:"produced_by"`
+	TotalRecipes int              `json:"total_recipes"`
+}
+
+// ItemRecipeInfo summarises one recipe that produces an item.
+type ItemRecipeInfo struct {
+	RecipeID       string         `json:"recipe_id"`
+	RecipeName     string         `json:"recipe_name"`
+	Category       string         `json:"category,omitempty"`
+	CraftingTime   int            `json:"crafting_time"`
+	OutputQuantity int            `json:"output_quantity"` // Units of the item per run
+	InputCount     int            `json:"input_count"`     // Distinct input items per run
+	IllegalStatus  *IllegalStatus `json:"illegal_status,omitempty"`
 }
 
 // RecipeMarketProfit represents a single recipe's market profitability.
@@ -343,8 +776,20 @@ type RecipeMarketProfitabilityResponse struct {
 
 // BillOfMaterialsRequest is the input for the bill_of_materials tool.
 type BillOfMaterialsRequest struct {
-	RecipeID string `json:"recipe_id"`
-	Quantity int    `json:"quantity"`
+	RecipeID         string      `json:"recipe_id"`
+	Quantity         int         `json:"quantity"`
+	StationID        string      `json:"station_id,omitempty"`        // Optional: enables cost analysis
+	MaxCraftable     bool        `json:"max_craftable,omitempty"`     // Solve for the largest quantity CurrentInventory supports
+	CurrentInventory []Component `json:"current_inventory,omitempty"` // Raw materials on hand, used with MaxCraftable
+
+	// BuyInstead lists craftable items to buy rather than craft. They are
+	// reported as raw materials and their recipes are not expanded.
+	BuyInstead []string `json:"buy_instead,omitempty"`
+
+	// MaxDepth limits the plan to that many crafting levels, the target
+	// recipe being level 1. Craftable items below the cutoff are reported
+	// as raw materials with Truncated set. Zero means no limit.
+	MaxDepth int `json:"max_depth,omitempty"`
 }
 
 // BillOfMaterialsResponse is the output for the bill_of_materials tool.
@@ -352,27 +797,66 @@ type BillOfMaterialsResponse struct {
 	RecipeID       string            `json:"recipe_id"`
 	RecipeName     string            `json:"recipe_name"`
 	OutputItemID   string            `json:"output_item_id"`
+	OutputItemName string            `json:"output_item_name"`
 	Quantity       int               `json:"quantity"`
 	RawMaterials   []BOMItem         `json:"raw_materials"`
 	Intermediates  []BOMIntermediate `json:"intermediates"`
 	CraftSteps     []BOMCraftStep    `json:"craft_steps"`
 	TotalCraftTime int               `json:"total_craft_time_sec"`
+	CostAnalysis   *BOMCostAnalysis  `json:"cost_analysis,omitempty"`
+
+	// LimitingMaterials lists the raw materials that run out first in
+	// max_craftable mode.
+	LimitingMaterials []string `json:"limiting_materials,omitempty"`
+
+	// Byproducts lists secondary recipe outputs left over after they have
+	// been credited against the plan's own demand for those items.
+	Byproducts []BOMItem `json:"byproducts,omitempty"`
+
+	// ExpectedRawMaterials and ExpectedCraftTime repeat RawMaterials and
+	// TotalCraftTime with every step's runs scaled up to cover failed
+	// crafts. They are only set when some step has a success rate below 1.
+	ExpectedRawMaterials []BOMItem `json:"expected_raw_materials,omitempty"`
+	ExpectedCraftTime    int       `json:"expected_craft_time_sec,omitempty"`
+}
+
+// BOMCostAnalysis prices a full bill of materials at a station. Only raw
+// materials are purchased, so intermediates never add to the cost.
+type BOMCostAnalysis struct {
+	StationID       string   `json:"station_id"`
+	RawMaterialCost int      `json:"raw_material_cost"`
+	OutputValue     int      `json:"output_value"`
+	NetProfit       int      `json:"net_profit"`
+	ProfitMarginPct float64  `json:"profit_margin_pct"`
+	UnpricedItems   []string `json:"unpriced_items,omitempty"` // Items with no market price at the station
+
+	// Cost and profit of the expected raw materials, allowing for failed
+	// crafts. Equal to the nominal figures when every step always succeeds.
+	ExpectedRawMaterialCost int `json:"expected_raw_material_cost"`
+	ExpectedNetProfit       int `json:"expected_net_profit"`
 }
 
 // BOMItem represents a raw material requirement.
 type BOMItem struct {
-	ItemID   string `json:"item_id"`
-	Quantity int    `json:"quantity"`
+	ItemID    string `json:"item_id"`
+	ItemName  string `json:"item_name"`
+	Quantity  int    `json:"quantity"`
+	Truncated bool   `json:"truncated,omitempty"` // Craftable, but cut off by max_depth
 }
 
 // BOMIntermediate represents an intermediate crafted item in the dependency tree.
 type BOMIntermediate struct {
 	ItemID        string `json:"item_id"`
+	ItemName      string `json:"item_name"`
 	RecipeID      string `json:"recipe_id"`
 	RecipeName    string `json:"recipe_name"`
 	CraftRuns     int    `json:"craft_runs"`
 	TotalProduced int    `json:"total_produced"`
-	TotalNeeded   int    `json:"total_needed"`
+	TotalNeeded   int    `json:"total_needed"` // After crediting byproducts from other steps
+
+	// Surplus is TotalProduced less TotalNeeded: output left over because
+	// runs are whole and a run can yield more than one.
+	Surplus int `json:"surplus,omitempty"`
 }
 
 // BOMCraftStep represents a single crafting operation in the build order.
@@ -383,4 +867,254 @@ type BOMCraftStep struct {
 	CraftRuns    int    `json:"craft_runs"`
 	OutputItemID string `json:"output_item_id"`
 	OutputPerRun int    `json:"output_per_run"`
+
+	// SuccessRate and ExpectedCraftRuns are set when the recipe can fail.
+	// ExpectedCraftRuns is CraftRuns divided by the rate, rounded up.
+	SuccessRate       float64 `json:"success_rate,omitempty"`
+	ExpectedCraftRuns int     `json:"expected_craft_runs,omitempty"`
+}
+
+// ShoppingListRequest is the input for the shopping_list tool.
+type ShoppingListRequest struct {
+	Targets []ShoppingListTarget `json:"targets"`
+}
+
+// ShoppingListTarget is one recipe to build and how many of its primary
+// output to make.
+type ShoppingListTarget struct {
+	RecipeID string `json:"recipe_id"`
+	Quantity int    `json:"quantity"`
+}
+
+// ShoppingListResponse is the output for the shopping_list tool. The
+// combined plan shares intermediates between targets, so its raw materials
+// can be less than the sum of the per-target figures.
+type ShoppingListResponse struct {
+	Targets        []ShoppingListTargetResult `json:"targets"`
+	RawMaterials   []BOMItem                  `json:"raw_materials"`
+	Intermediates  []BOMIntermediate          `json:"intermediates"`
+	CraftSteps     []BOMCraftStep             `json:"craft_steps"`
+	TotalCraftTime int                        `json:"total_craft_time_sec"`
+	Byproducts     []BOMItem                  `json:"byproducts,omitempty"`
+}
+
+// ShoppingListTargetResult is what one target would need if built alone.
+type ShoppingListTargetResult struct {
+	RecipeID       string    `json:"recipe_id"`
+	RecipeName     string    `json:"recipe_name"`
+	OutputItemID   string    `json:"output_item_id"`
+	OutputItemName string    `json:"output_item_name"`
+	Quantity       int       `json:"quantity"`
+	RawMaterials   []BOMItem `json:"raw_materials"`
+	TotalCraftTime int       `json:"total_craft_time_sec"`
+}
+
+// CompareRecipesRequest is the input for the compare_recipes tool.
+type CompareRecipesRequest struct {
+	RecipeA string `json:"recipe_a"`
+	RecipeB string `json:"recipe_b"`
+}
+
+// CompareRecipesResponse is the output for the compare_recipes tool. Each
+// difference reads as a change from recipe A to recipe B.
+type CompareRecipesResponse struct {
+	RecipeA     string         `json:"recipe_a"`
+	RecipeB     string         `json:"recipe_b"`
+	Identical   bool           `json:"identical"` // Same apart from ID
+	Fields      []FieldChange  `json:"fields,omitempty"`
+	Inputs      RecipeItemDiff `json:"inputs"`
+	Outputs     RecipeItemDiff `json:"outputs"`
+	TagsAdded   []string       `json:"tags_added,omitempty"`
+	TagsRemoved []string       `json:"tags_removed,omitempty"`
+}
+
+// FieldChange is a scalar recipe field that differs, formatted as text.
+type FieldChange struct {
+	Field string `json:"field"` // JSON name of the Recipe field
+	A     string `json:"a"`
+	B     string `json:"b"`
+}
+
+// RecipeItemDiff lists the items only in B, only in A, and in both with a
+// different quantity or set of alternatives. Each list is sorted by item.
+type RecipeItemDiff struct {
+	Added   []RecipeItemChange `json:"added"`
+	Removed []RecipeItemChange `json:"removed"`
+	Changed []RecipeItemChange `json:"changed"`
+}
+
+// Empty reports whether the diff lists no differences.
+func (d RecipeItemDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// RecipeItemChange is one item's quantity in each recipe, zero where it is
+// absent. Alternatives are only given for inputs whose alternatives differ.
+type RecipeItemChange struct {
+	ItemID        string   `json:"item_id"`
+	QuantityA     int      `json:"quantity_a"`
+	QuantityB     int      `json:"quantity_b"`
+	AlternativesA []string `json:"alternatives_a,omitempty"`
+	AlternativesB []string `json:"alternatives_b,omitempty"`
+}
+
+// SimulatePlanRequest is the input for the simulate_plan tool: a starting
+// inventory and the crafts to perform on it, in order.
+type SimulatePlanRequest struct {
+	Components []Component `json:"components"`
+	Steps      []PlanStep  `json:"steps"`
+}
+
+// PlanStep is one craft in a plan: a recipe run some number of times.
+type PlanStep struct {
+	RecipeID string `json:"recipe_id"`
+	Runs     int    `json:"runs"` // Default 1
+}
+
+// SimulatePlanResponse is the output for the simulate_plan tool. Steps
+// lists the steps that were carried out; when one cannot be, FailedStep
+// says why and EndingInventory is the inventory just before it.
+type SimulatePlanResponse struct {
+	Executable      bool             `json:"executable"`
+	Steps           []PlanStepResult `json:"steps"`
+	FailedStep      *PlanStepFailure `json:"failed_step,omitempty"`
+	EndingInventory []Component      `json:"ending_inventory"` // Sorted by ID; empty stacks omitted
+}
+
+// PlanStepResult is what one completed step took from and added to the
+// inventory.
+type PlanStepResult struct {
+	Step     int           `json:"step"` // 1-based position in the plan
+	RecipeID string        `json:"recipe_id"`
+	Runs     int           `json:"runs"`
+	Consumed []RecipeInput `json:"consumed"`
+	Produced []Component   `json:"produced"`
+}
+
+// PlanStepFailure is the first step whose inputs were not all held when it
+// was reached.
+type PlanStepFailure struct {
+	Step     int           `json:"step"`
+	RecipeID string        `json:"recipe_id"`
+	Runs     int           `json:"runs"`
+	Missing  []RecipeInput `json:"missing"` // Shortfall per input, after alternatives
+}
+
+// CraftRecommendationsRequest is the input for the craft_recommendations tool.
+type CraftRecommendationsRequest struct {
+	Components         []Component  `json:"components"`
+	StationID          string       `json:"station_id,omitempty"`
+	MaxAcquisitionCost int          `json:"max_acquisition_cost,omitempty"` // 0 means no limit
+	PricingModel       PricingModel `json:"pricing_model,omitempty"`
+	Limit              int          `json:"limit,omitempty"`
+}
+
+// CraftRecommendationsResponse is the output for the craft_recommendations tool.
+type CraftRecommendationsResponse struct {
+	Recommendations []CraftRecommendation `json:"recommendations"`
+}
+
+// Recommendation reasons.
+const (
+	ReasonCraftableNow       = "craftable_now"
+	ReasonOneAcquisitionAway = "one_acquisition_away"
+)
+
+// CraftRecommendation is one suggested next craft.
+type CraftRecommendation struct {
+	RecipeID        string       `json:"recipe_id"`
+	RecipeName      string       `json:"recipe_name"`
+	Category        string       `json:"category,omitempty"`
+	Reason          string       `json:"reason"`
+	MissingInput    *RecipeInput `json:"missing_input,omitempty"`
+	AcquisitionCost int          `json:"acquisition_cost,omitempty"`
+	EstimatedProfit int          `json:"estimated_profit"` // Profit per craft; 0 without market data
+	Effort          int          `json:"effort"`           // Number of items to acquire first
+	Score           float64      `json:"score"`
+}
+
+// OnePurchaseAwayRequest is the input for the one_purchase_away tool.
+type OnePurchaseAwayRequest struct {
+	Components []Component `json:"components"`
+	StationID  string      `json:"station_id"`
+	Budget     int         `json:"budget,omitempty"` // 0 means no limit
+
+	// MaxPurchases is how many different inputs may be bought; default 1.
+	MaxPurchases int          `json:"max_purchases,omitempty"`
+	PricingModel PricingModel `json:"pricing_model,omitempty"`
+	Limit        int          `json:"limit,omitempty"`
+}
+
+// OnePurchaseAwayResponse is the output for the one_purchase_away tool,
+// cheapest purchase first.
+type OnePurchaseAwayResponse struct {
+	StationID string             `json:"station_id"`
+	Recipes   []OnePurchaseMatch `json:"recipes"`
+}
+
+// OnePurchaseMatch is a recipe that becomes fully craftable once its
+// missing inputs are bought.
+type OnePurchaseMatch struct {
+	RecipeID       string          `json:"recipe_id"`
+	RecipeName     string          `json:"recipe_name"`
+	Category       string          `json:"category,omitempty"`
+	Purchases      []Purchase      `json:"purchases"`
+	TotalCost      int             `json:"total_cost"`
+	ProfitAnalysis *ProfitAnalysis `json:"profit_analysis,omitempty"`
+}
+
+// Purchase is an item to buy at a station. ItemID may be an alternative
+// for the recipe input when it is the cheaper one to buy.
+type Purchase struct {
+	ItemID    string `json:"item_id"`
+	Quantity  int    `json:"quantity"`
+	UnitPrice int    `json:"unit_price"`
+	Cost      int    `json:"cost"`
+}
+
+// SkillPrerequisitesRequest is the input for the skill_prerequisites tool.
+type SkillPrerequisitesRequest struct {
+	SkillID string          `json:"skill_id"`
+	Skills  AgentSkillState `json:"skills,omitempty"`
+}
+
+// SkillPrerequisitesResponse is the output for the skill_prerequisites tool.
+type SkillPrerequisitesResponse struct {
+	SkillID          string                  `json:"skill_id"`
+	SkillName        string                  `json:"skill_name"`
+	Trainable        bool                    `json:"trainable"`
+	Prerequisites    []SkillPrerequisiteStep `json:"prerequisites"`
+	TotalXPRemaining int                     `json:"total_xp_remaining"` // sum of the known xp_remaining values
+}
+
+// SkillPrerequisiteStep is one skill in a prerequisite chain, listed in the
+// order it should be trained (deepest prerequisites first).
+type SkillPrerequisiteStep struct {
+	SkillID       string `json:"skill_id"`
+	SkillName     string `json:"skill_name"`
+	CurrentLevel  int    `json:"current_level"`
+	RequiredLevel int    `json:"required_level"`
+	Met           bool   `json:"met"`
+	XPRemaining   *int   `json:"xp_remaining,omitempty"` // total XP from current to required level; omitted when a threshold is missing
+}
+
+// ValidateRecipesResponse is the output for the validate_recipes tool.
+type ValidateRecipesResponse struct {
+	RecipesChecked         int                     `json:"recipes_checked"`
+	Cycles                 []RecipeCycle           `json:"cycles"`
+	SelfReferencingRecipes []SelfReferencingRecipe `json:"self_referencing_recipes"`
+}
+
+// RecipeCycle is a loop in the recipe dependency graph. ItemIDs starts and
+// ends with the same item; RecipeIDs[i] is the recipe that produces
+// ItemIDs[i] and consumes ItemIDs[i+1].
+type RecipeCycle struct {
+	ItemIDs   []string `json:"item_ids"`
+	RecipeIDs []string `json:"recipe_ids"`
+}
+
+// SelfReferencingRecipe is a recipe that consumes its own output item.
+type SelfReferencingRecipe struct {
+	RecipeID string `json:"recipe_id"`
+	ItemID   string `json:"item_id"`
 }